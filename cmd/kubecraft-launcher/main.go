@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/launcher"
+	"github.com/baighasan/kubecraft/internal/world"
+)
+
+func main() {
+	// "kubecraft-launcher ready" is the readiness probe of servers with a Bedrock port
+	if len(os.Args) > 1 && os.Args[1] == "ready" {
+		if err := launcher.Ready(os.Getenv); err != nil {
+			fmt.Printf("not ready: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "kubecraft-launcher stop" is the container's preStop hook
+	if len(os.Args) > 1 && os.Args[1] == "stop" {
+		if err := launcher.Stop(os.Getenv); err != nil {
+			fmt.Printf("failed to stop server: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "kubecraft-launcher backup" is the scheduled backup job, verify and restore run
+	// from the same job template on demand
+	if len(os.Args) > 1 && slices.Contains([]string{"backup", "verify", "restore"}, os.Args[1]) {
+		cfg, err := launcher.BackupConfigFromEnv(os.Getenv)
+		if err != nil {
+			fmt.Printf("invalid backup configuration: %s\n", err)
+			os.Exit(1)
+		}
+		if err := runBackupTask(cfg, os.Args[1], os.Args[2:]); err != nil {
+			fmt.Printf("backup task %s failed: %s\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "kubecraft-launcher world prune" is run by kubecraft server world prune in
+	// maintenance mode
+	if len(os.Args) > 1 && os.Args[1] == "world" {
+		if err := runWorldTask(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "world task failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "kubecraft-launcher properties set" is run by kubecraft server resource-pack set,
+	// with the properties as a JSON object on stdin
+	if len(os.Args) > 1 && os.Args[1] == "properties" {
+		if err := runPropertiesTask(os.Args[2:], os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "properties task failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "kubecraft-launcher archive list|push|pull" is run by kubecraft server archive,
+	// with a short-lived archive token on stdin
+	if len(os.Args) > 1 && os.Args[1] == "archive" {
+		if err := runArchiveTask(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "archive task failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Set by kubecraft server backup and restore while they work on the world
+	if os.Getenv("MAINTENANCE") == "true" {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+		if err := launcher.Maintenance(launcher.MaintenanceAddr, stop); err != nil {
+			fmt.Printf("failed to run maintenance mode: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := launcher.ConfigFromEnv(os.Getenv)
+	if err != nil {
+		fmt.Printf("invalid launcher configuration: %s\n", err)
+		os.Exit(1)
+	}
+
+	installer := launcher.NewInstaller()
+	if err := launcher.Prepare(cfg, installer); err != nil {
+		fmt.Printf("failed to prepare server: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := launcher.InstallModpack(cfg, installer.HTTPClient); err != nil {
+		fmt.Printf("failed to install modpack: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := launcher.InstallPlugins(cfg, installer.HTTPClient); err != nil {
+		fmt.Printf("failed to install plugins: %s\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.Bedrock {
+		if err := launcher.InstallBedrockPlugins(cfg, launcher.NewGeyserClient()); err != nil {
+			fmt.Printf("failed to install bedrock plugins: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Only returns on failure
+	if err := launcher.Exec(cfg); err != nil {
+		fmt.Printf("failed to start server: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func runBackupTask(cfg *launcher.BackupConfig, task string, args []string) error {
+	switch task {
+	case "verify":
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		return launcher.VerifyBackup(cfg, name)
+	case "restore":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: kubecraft-launcher restore <snapshot>")
+		}
+		return launcher.RestoreBackup(cfg, args[0])
+	default:
+		return launcher.Backup(cfg, time.Now())
+	}
+}
+
+func runWorldTask(args []string) error {
+	if len(args) == 0 || args[0] != "prune" {
+		return fmt.Errorf("usage: kubecraft-launcher world prune --inhabited-below <ticks> [--keep-radius <blocks>] [--apply]")
+	}
+
+	var opts world.PruneOptions
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	flags.Int64Var(&opts.InhabitedBelow, "inhabited-below", 0, "Remove chunks players spent fewer ticks in")
+	flags.IntVar(&opts.KeepRadius, "keep-radius", 0, "Keep chunks within this many blocks of the spawn")
+	flags.BoolVar(&opts.Apply, "apply", false, "Remove the chunks instead of only reporting them")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if opts.InhabitedBelow <= 0 {
+		return fmt.Errorf("--inhabited-below must be positive")
+	}
+
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = launcher.DefaultDataDir
+	}
+	return launcher.PruneWorld(dataDir, opts, os.Stdout)
+}
+
+func runPropertiesTask(args []string, stdin io.Reader) error {
+	if len(args) != 1 || args[0] != "set" {
+		return fmt.Errorf("usage: kubecraft-launcher properties set < properties.json")
+	}
+
+	var props map[string]string
+	if err := json.NewDecoder(stdin).Decode(&props); err != nil {
+		return fmt.Errorf("reading properties: %w", err)
+	}
+
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = launcher.DefaultDataDir
+	}
+	return launcher.SetProperties(filepath.Join(dataDir, "server.properties"), props)
+}
+
+func runArchiveTask(args []string, stdin io.Reader, stdout io.Writer) error {
+	usage := fmt.Errorf("usage: kubecraft-launcher archive list|push|pull --service <url> --server <name> [--name <archive>] [--version <version>] < token")
+	if len(args) == 0 || !slices.Contains([]string{"list", "push", "pull"}, args[0]) {
+		return usage
+	}
+
+	client := &launcher.ArchiveClient{HTTPClient: &http.Client{}}
+	var name, version string
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.StringVar(&client.Service, "service", "", "Base URL of the registration service's archive routes")
+	flags.StringVar(&client.Server, "server", "", "Server the archives belong to")
+	flags.StringVar(&name, "name", "", "Archive to push or pull")
+	flags.StringVar(&version, "version", "", "Minecraft version of the world being pushed")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if client.Service == "" || client.Server == "" || (args[0] != "list" && name == "") || (args[0] == "push" && version == "") {
+		return usage
+	}
+
+	token, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("reading token: %w", err)
+	}
+	client.Token = strings.TrimSpace(string(token))
+	if client.Token == "" {
+		return fmt.Errorf("no archive token on stdin")
+	}
+
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = launcher.DefaultDataDir
+	}
+
+	var result any
+	switch args[0] {
+	case "list":
+		result, err = client.List()
+	case "push":
+		result, err = client.Push(dataDir, name, version, time.Now())
+	case "pull":
+		result, err = client.Pull(dataDir, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(stdout).Encode(result)
+}
@@ -7,7 +7,11 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/baighasan/kubecraft/internal/archive"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/idle"
 	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/proxy"
 	"github.com/baighasan/kubecraft/internal/registration"
 )
 
@@ -24,6 +28,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Give users registered before a change the Role and quota new features need
+	if err := k8sClient.ReconcileUserRBAC(context.Background()); err != nil {
+		fmt.Printf("warning: %s\n", err)
+	}
+
+	// Archive storage is optional, without it the archive routes answer 501
+	archiveConfig, err := archive.S3ConfigFromEnv(os.Getenv)
+	if err != nil {
+		fmt.Printf("invalid archive configuration: %s\n", err)
+		os.Exit(1)
+	}
+	var archiveStore archive.Store
+	archiveKeep := archive.DefaultKeep
+	if archiveConfig != nil {
+		archiveStore, err = archive.NewS3Store(context.Background(), archiveConfig)
+		if err != nil {
+			fmt.Printf("failed to connect to archive storage: %s\n", err)
+			os.Exit(1)
+		}
+		archiveKeep = archiveConfig.Keep
+		fmt.Printf("Archiving worlds to bucket %s at %s\n", archiveConfig.Bucket, archiveConfig.Endpoint)
+	}
+
+	// Stop servers that were left running without players
+	go idle.New(k8sClient, proxy.PlayersOnline).Run(context.Background())
+
+	// Archive routes are only reachable from inside the cluster, server pods call them
+	// with short-lived archive tokens
+	archiveMux := http.NewServeMux()
+	archiveMux.Handle("/archives/", archive.NewHandler(archiveStore, k8sClient.AuthenticateArchiveToken, archiveKeep))
+	go func() {
+		fmt.Printf("Starting archive server on port %d\n", config.ArchivePort)
+		err := http.ListenAndServe(fmt.Sprintf(":%d", config.ArchivePort), archiveMux)
+		fmt.Printf("error starting archive server: %s\n", err)
+		os.Exit(1)
+	}()
+
 	// Set up routes
 	http.HandleFunc("/health", healthHandler)
 	http.HandleFunc("/register", registration.NewRegistrationHandler(k8sClient))
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/proxy"
+)
+
+func main() {
+	// The NodePorts of sleeping servers are routed to this pod's IP, from the downward API
+	podIP := os.Getenv("POD_IP")
+	if podIP == "" {
+		fmt.Printf("POD_IP is not set\n")
+		os.Exit(1)
+	}
+
+	k8sClient, err := k8s.NewInClusterClient()
+	if err != nil {
+		fmt.Printf("failed to create k8s client: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Hand the NodePorts back to the servers when the pod is stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p := proxy.New(k8sClient, podIP)
+
+	// Route <server>.<user>.<domain> on the Minecraft port when a domain is configured
+	if domain := os.Getenv(config.GatewayDomainEnv); domain != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.GatewayPort))
+		if err != nil {
+			fmt.Printf("failed to listen on gateway port: %s\n", err)
+			os.Exit(1)
+		}
+		go func() {
+			<-ctx.Done()
+			listener.Close()
+		}()
+
+		fmt.Printf("Starting gateway for *.%s on port %d\n", domain, config.GatewayPort)
+		go p.ServeGateway(listener, domain)
+	}
+
+	fmt.Printf("Starting wake proxy on %s\n", podIP)
+	if err := p.Run(ctx); err != nil {
+		fmt.Printf("wake proxy failed: %s\n", err)
+		os.Exit(1)
+	}
+}
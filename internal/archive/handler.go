@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+const (
+	// VersionHeader carries the Minecraft version of an archive being uploaded
+	VersionHeader = "X-Minecraft-Version"
+
+	// presignExpiry is how long a pod has to start an upload or download
+	presignExpiry = 15 * time.Minute
+)
+
+// validName matches server names and archive names, so keys can't leave the user's prefix
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*$`)
+
+// Authenticator returns the username a bearer token belongs to
+type Authenticator func(ctx context.Context, token string) (string, error)
+
+// ErrorResponse is the body of a failed archive request
+type ErrorResponse struct {
+	Status  string `json:"status"` // Always "error"
+	Message string `json:"message"`
+}
+
+type handler struct {
+	store        Store
+	authenticate Authenticator
+	keep         int
+}
+
+// NewHandler serves the archives in store under /archives/<server>, each user under
+// their own prefix. Archives aren't passed through: an upload or download answers
+// with a presigned request the caller sends to the store itself, and a completed
+// upload is verified before only the newest keep archives of the server are kept.
+// A nil store answers every request with 501, for clusters without archive storage.
+func NewHandler(store Store, authenticate Authenticator, keep int) http.Handler {
+	h := &handler{store: store, authenticate: authenticate, keep: keep}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /archives/{server}", h.authenticated(h.list))
+	mux.HandleFunc("POST /archives/{server}/{name}/upload", h.authenticated(h.upload))
+	mux.HandleFunc("POST /archives/{server}/{name}/complete", h.authenticated(h.complete))
+	mux.HandleFunc("GET /archives/{server}/{name}", h.authenticated(h.download))
+
+	return mux
+}
+
+// authenticated resolves the user and validates the path before calling next with the
+// user's key prefix for the server
+func (h *handler) authenticated(next func(w http.ResponseWriter, r *http.Request, prefix string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.store == nil {
+			sendError(w, http.StatusNotImplemented, "archive storage is not configured on this cluster")
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			sendError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		username, err := h.authenticate(r.Context(), token)
+		if err != nil {
+			sendError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		server := r.PathValue("server")
+		if !validName.MatchString(server) {
+			sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid server name %q", server))
+			return
+		}
+		if name := r.PathValue("name"); name != "" && (!validName.MatchString(name) || !strings.HasSuffix(name, backup.ArchiveExt)) {
+			sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid archive name %q", name))
+			return
+		}
+
+		next(w, r, username+"/"+server+"/")
+	}
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request, prefix string) {
+	objects, err := h.store.List(r.Context(), prefix)
+	if err != nil {
+		sendError(w, http.StatusBadGateway, fmt.Sprintf("failed to list archives: %v", err))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, objects)
+}
+
+func (h *handler) upload(w http.ResponseWriter, r *http.Request, prefix string) {
+	version := r.Header.Get(VersionHeader)
+	if version == "" {
+		sendError(w, http.StatusBadRequest, fmt.Sprintf("missing %s header", VersionHeader))
+		return
+	}
+
+	req, err := h.store.PresignPut(r.Context(), prefix+r.PathValue("name"), version, presignExpiry)
+	if err != nil {
+		sendError(w, http.StatusBadGateway, fmt.Sprintf("failed to prepare upload: %v", err))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, req)
+}
+
+// complete checks an uploaded archive, which the store took from the pod unseen, and
+// prunes the server's older archives once it is known to be good
+func (h *handler) complete(w http.ResponseWriter, r *http.Request, prefix string) {
+	key := prefix + r.PathValue("name")
+	body, _, err := h.store.Get(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		sendError(w, http.StatusNotFound, fmt.Sprintf("archive %s not found", r.PathValue("name")))
+		return
+	}
+	if err != nil {
+		sendError(w, http.StatusBadGateway, fmt.Sprintf("failed to read archive: %v", err))
+		return
+	}
+	_, err = backup.Verify(body)
+	body.Close()
+	if err != nil {
+		if deleteErr := h.store.Delete(r.Context(), key); deleteErr != nil {
+			fmt.Printf("failed to delete invalid archive %s: %v\n", key, deleteErr)
+		}
+		sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid archive: %v", err))
+		return
+	}
+
+	if err := h.prune(r.Context(), prefix); err != nil {
+		fmt.Printf("failed to prune archives under %s: %v\n", prefix, err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *handler) download(w http.ResponseWriter, r *http.Request, prefix string) {
+	req, err := h.store.PresignGet(r.Context(), prefix+r.PathValue("name"), presignExpiry)
+	if errors.Is(err, ErrNotFound) {
+		sendError(w, http.StatusNotFound, fmt.Sprintf("archive %s not found", r.PathValue("name")))
+		return
+	}
+	if err != nil {
+		sendError(w, http.StatusBadGateway, fmt.Sprintf("failed to prepare download: %v", err))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, req)
+}
+
+// prune deletes all but the newest keep archives under prefix
+func (h *handler) prune(ctx context.Context, prefix string) error {
+	objects, err := h.store.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if len(objects) <= h.keep {
+		return nil
+	}
+
+	// Archive names embed their UTC timestamp, so they sort by age
+	slices.SortFunc(objects, func(a, b Object) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, object := range objects[:len(objects)-h.keep] {
+		if err := h.store.Delete(ctx, prefix+object.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sendError(w http.ResponseWriter, statusCode int, message string) {
+	sendJSON(w, statusCode, ErrorResponse{Status: "error", Message: message})
+}
+
+func sendJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Printf("failed to encode JSON response: %v\n", err)
+	}
+}
@@ -0,0 +1,308 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+// memStore keeps archives in memory, its presigned requests go to a test server
+// standing in for the bucket
+type memStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	versions map[string]string
+	bucket   *httptest.Server
+}
+
+func newMemStore(t *testing.T) *memStore {
+	t.Helper()
+
+	m := &memStore{objects: map[string][]byte{}, versions: map[string]string{}}
+	m.bucket = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		switch r.Method {
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			m.mu.Lock()
+			m.objects[key] = data
+			m.versions[key] = r.Header.Get("X-Amz-Meta-Minecraft-Version")
+			m.mu.Unlock()
+		case http.MethodGet:
+			m.mu.Lock()
+			data, ok := m.objects[key]
+			m.mu.Unlock()
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(m.bucket.Close)
+
+	return m
+}
+
+func (m *memStore) PresignPut(ctx context.Context, key string, minecraftVersion string, expires time.Duration) (*PresignedRequest, error) {
+	return &PresignedRequest{
+		Method: http.MethodPut,
+		URL:    m.bucket.URL + "/" + key,
+		Header: map[string]string{"X-Amz-Meta-Minecraft-Version": minecraftVersion},
+	}, nil
+}
+
+func (m *memStore) PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return nil, ErrNotFound
+	}
+	return &PresignedRequest{Method: http.MethodGet, URL: m.bucket.URL + "/" + key}, nil
+}
+
+func (m *memStore) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	object := &Object{Name: key[strings.LastIndex(key, "/")+1:], Size: int64(len(data)), MinecraftVersion: m.versions[key]}
+	return io.NopCloser(bytes.NewReader(data)), object, nil
+}
+
+func (m *memStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []Object
+	for _, key := range slices.Sorted(maps.Keys(m.objects)) {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			objects = append(objects, Object{Name: name, Size: int64(len(m.objects[key])), MinecraftVersion: m.versions[key]})
+		}
+	}
+	return objects, nil
+}
+
+func (m *memStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// fakeAuth accepts "<username>-token" for any username
+func fakeAuth(ctx context.Context, token string) (string, error) {
+	username, ok := strings.CutSuffix(token, "-token")
+	if !ok {
+		return "", errors.New("invalid token")
+	}
+	return username, nil
+}
+
+// testArchive writes a backup of a one-file world
+func testArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var world bytes.Buffer
+	tw := tar.NewWriter(&world)
+	tw.WriteHeader(&tar.Header{Name: "world/level.dat", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("level"))
+	tw.Close()
+
+	var out bytes.Buffer
+	if _, err := backup.Write(&out, &world, backup.Manifest{Server: "myserver", MinecraftVersion: "1.21.11", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("backup.Write() error = %v", err)
+	}
+	return out.Bytes()
+}
+
+func request(t *testing.T, h http.Handler, method string, path string, token string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set(VersionHeader, "1.21.11")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// sendPresigned sends a presigned request from the handler, as the launcher does
+func sendPresigned(t *testing.T, rec *httptest.ResponseRecorder, body []byte) *http.Response {
+	t.Helper()
+
+	var presigned PresignedRequest
+	if err := json.NewDecoder(rec.Body).Decode(&presigned); err != nil {
+		t.Fatalf("failed to decode presigned request: %v", err)
+	}
+	req, err := http.NewRequest(presigned.Method, presigned.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("invalid presigned request: %v", err)
+	}
+	for k, v := range presigned.Header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("presigned request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// push uploads an archive through the handler like the launcher and returns the status
+// of completing it
+func push(t *testing.T, h http.Handler, name string, token string, archive []byte) int {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/archives/myserver/"+name+"/upload", token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	sendPresigned(t, rec, archive)
+
+	return request(t, h, http.MethodPost, "/archives/myserver/"+name+"/complete", token, nil).Code
+}
+
+func TestHandler_PushThenPull(t *testing.T) {
+	store := newMemStore(t)
+	h := NewHandler(store, fakeAuth, DefaultKeep)
+	archive := testArchive(t)
+
+	if status := push(t, h, "myserver-20260101-040000.tar.zst", "alice-token", archive); status != http.StatusCreated {
+		t.Fatalf("complete status = %d, want 201", status)
+	}
+	key := "alice/myserver/myserver-20260101-040000.tar.zst"
+	if _, ok := store.objects[key]; !ok {
+		t.Fatalf("stored keys = %v, want %s", slices.Collect(maps.Keys(store.objects)), key)
+	}
+	if store.versions[key] != "1.21.11" {
+		t.Errorf("stored version = %q, want 1.21.11", store.versions[key])
+	}
+
+	rec := request(t, h, http.MethodGet, "/archives/myserver/myserver-20260101-040000.tar.zst", "alice-token", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pull status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	data, err := io.ReadAll(sendPresigned(t, rec, nil).Body)
+	if err != nil || !bytes.Equal(data, archive) {
+		t.Errorf("pulled archive differs from the pushed one (%v)", err)
+	}
+}
+
+func TestHandler_UsersAreIsolated(t *testing.T) {
+	store := newMemStore(t)
+	h := NewHandler(store, fakeAuth, DefaultKeep)
+
+	push(t, h, "myserver-20260101-040000.tar.zst", "alice-token", testArchive(t))
+
+	rec := request(t, h, http.MethodGet, "/archives/myserver", "bob-token", nil)
+	var objects []Object
+	if err := json.NewDecoder(rec.Body).Decode(&objects); err != nil {
+		t.Fatalf("failed to decode list: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("bob sees %v, want none of alice's archives", objects)
+	}
+
+	rec = request(t, h, http.MethodGet, "/archives/myserver/myserver-20260101-040000.tar.zst", "bob-token", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("bob pulling alice's archive: status = %d, want 404", rec.Code)
+	}
+	rec = request(t, h, http.MethodPost, "/archives/myserver/myserver-20260101-040000.tar.zst/complete", "bob-token", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("bob completing alice's archive: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_RejectsCorruptArchive(t *testing.T) {
+	store := newMemStore(t)
+	h := NewHandler(store, fakeAuth, DefaultKeep)
+
+	archive := testArchive(t)
+	truncated := archive[:len(archive)/2]
+
+	if status := push(t, h, "myserver-20260101-040000.tar.zst", "alice-token", truncated); status != http.StatusBadRequest {
+		t.Errorf("complete status = %d, want 400", status)
+	}
+	if len(store.objects) != 0 {
+		t.Error("truncated archive was kept")
+	}
+}
+
+func TestHandler_PrunesOldArchives(t *testing.T) {
+	store := newMemStore(t)
+	h := NewHandler(store, fakeAuth, 2)
+	archive := testArchive(t)
+
+	for day := 1; day <= 3; day++ {
+		name := fmt.Sprintf("myserver-2026010%d-040000.tar.zst", day)
+		if status := push(t, h, name, "alice-token", archive); status != http.StatusCreated {
+			t.Fatalf("complete status = %d, want 201", status)
+		}
+	}
+
+	want := []string{"alice/myserver/myserver-20260102-040000.tar.zst", "alice/myserver/myserver-20260103-040000.tar.zst"}
+	if got := slices.Sorted(maps.Keys(store.objects)); !slices.Equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+}
+
+func TestHandler_RejectsBadRequests(t *testing.T) {
+	h := NewHandler(newMemStore(t), fakeAuth, DefaultKeep)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"no token", http.MethodGet, "/archives/myserver", "", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "/archives/myserver", "garbage", http.StatusUnauthorized},
+		{"uppercase server", http.MethodGet, "/archives/MyServer", "alice-token", http.StatusBadRequest},
+		{"not an archive", http.MethodGet, "/archives/myserver/notes.txt", "alice-token", http.StatusBadRequest},
+		{"hidden name", http.MethodPost, "/archives/myserver/..tar.zst/upload", "alice-token", http.StatusBadRequest},
+		{"missing archive", http.MethodGet, "/archives/myserver/myserver-20260101-040000.tar.zst", "alice-token", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := request(t, h, tt.method, tt.path, tt.token, nil); rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestHandler_NotConfigured(t *testing.T) {
+	h := NewHandler(nil, fakeAuth, DefaultKeep)
+
+	rec := request(t, h, http.MethodGet, "/archives/myserver", "alice-token", nil)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
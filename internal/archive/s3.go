@@ -0,0 +1,186 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	DefaultKeep = 5
+
+	// versionMetadata is the object metadata key the Minecraft version is kept under
+	versionMetadata = "Minecraft-Version"
+)
+
+// S3Config locates the bucket archives are kept in, read from the env variables set by
+// the control-plane chart
+type S3Config struct {
+	Endpoint  string // host[:port], without scheme
+	Bucket    string
+	Region    string
+	UseSSL    bool
+	AccessKey string
+	SecretKey string
+	Keep      int // Archives kept per server
+}
+
+// S3ConfigFromEnv builds an S3Config from env variables. It returns nil if no archive
+// storage is configured.
+func S3ConfigFromEnv(getenv func(string) string) (*S3Config, error) {
+	cfg := &S3Config{
+		Endpoint:  getenv("ARCHIVE_ENDPOINT"),
+		Bucket:    getenv("ARCHIVE_BUCKET"),
+		Region:    getenv("ARCHIVE_REGION"),
+		UseSSL:    getenv("ARCHIVE_USE_SSL") != "false",
+		AccessKey: getenv("ARCHIVE_ACCESS_KEY"),
+		SecretKey: getenv("ARCHIVE_SECRET_KEY"),
+		Keep:      DefaultKeep,
+	}
+
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+	if strings.Contains(cfg.Endpoint, "://") {
+		return nil, fmt.Errorf("ARCHIVE_ENDPOINT must be host[:port] without a scheme, use ARCHIVE_USE_SSL instead")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("ARCHIVE_BUCKET is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("ARCHIVE_ACCESS_KEY and ARCHIVE_SECRET_KEY are required")
+	}
+
+	if v := getenv("ARCHIVE_KEEP"); v != "" {
+		keep, err := strconv.Atoi(v)
+		if err != nil || keep < 1 {
+			return nil, fmt.Errorf("ARCHIVE_KEEP must be a positive number")
+		}
+		cfg.Keep = keep
+	}
+
+	return cfg, nil
+}
+
+type s3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store returns a Store backed by an S3-compatible bucket, which must already exist
+func NewS3Store(ctx context.Context, cfg *S3Config) (Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", cfg.Bucket)
+	}
+
+	return &s3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *s3Store) PresignPut(ctx context.Context, key string, minecraftVersion string, expires time.Duration) (*PresignedRequest, error) {
+	// Signed along with the URL, so the uploader can't record another version
+	header := map[string]string{
+		"Content-Type":                  "application/zstd",
+		"X-Amz-Meta-" + versionMetadata: minecraftVersion,
+	}
+	signed := http.Header{}
+	for k, v := range header {
+		signed.Set(k, v)
+	}
+
+	u, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, key, expires, nil, signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload of %s: %w", key, err)
+	}
+
+	return &PresignedRequest{Method: http.MethodPut, URL: u.String(), Header: header}, nil
+}
+
+func (s *s3Store) PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expires, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign download of %s: %w", key, err)
+	}
+
+	return &PresignedRequest{Method: http.MethodGet, URL: u.String()}, nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	body, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	object := &Object{
+		Name:             key[strings.LastIndex(key, "/")+1:],
+		Size:             info.Size,
+		LastModified:     info.LastModified,
+		MinecraftVersion: info.UserMetadata[versionMetadata],
+	}
+	return body, object, nil
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, info.Err)
+		}
+		// Listings leave out user metadata, a server keeps only a few archives
+		stat, err := s.client.StatObject(ctx, s.bucket, info.Key, minio.StatObjectOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", info.Key, err)
+		}
+		objects = append(objects, Object{
+			Name:             strings.TrimPrefix(info.Key, prefix),
+			Size:             info.Size,
+			LastModified:     info.LastModified,
+			MinecraftVersion: stat.UserMetadata[versionMetadata],
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+//go:build integration
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newTestS3Store connects to the MinIO started by make test-archive, in a fresh bucket
+func newTestS3Store(t *testing.T) Store {
+	t.Helper()
+
+	cfg, err := S3ConfigFromEnv(os.Getenv)
+	if err != nil {
+		t.Fatalf("S3ConfigFromEnv() error = %v", err)
+	}
+	if cfg == nil {
+		t.Skip("ARCHIVE_ENDPOINT not set, run make test-archive")
+	}
+
+	ctx := context.Background()
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create S3 client: %v", err)
+	}
+	if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{}); err != nil {
+		if exists, _ := client.BucketExists(ctx, cfg.Bucket); !exists {
+			t.Fatalf("failed to create bucket: %v", err)
+		}
+	}
+
+	store, err := NewS3Store(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+	t.Cleanup(func() {
+		objects, _ := store.List(ctx, "")
+		for _, o := range objects {
+			store.Delete(ctx, o.Name)
+		}
+	})
+
+	return store
+}
+
+// sendTo sends body with a presigned request and returns the response status
+func sendTo(t *testing.T, presigned *PresignedRequest, body []byte, header map[string]string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(presigned.Method, presigned.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("invalid presigned request: %v", err)
+	}
+	for k, v := range presigned.Header {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("presigned request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestS3Store_PresignGetListDelete(t *testing.T) {
+	store := newTestS3Store(t)
+	ctx := context.Background()
+	archive := testArchive(t)
+
+	key := "alice/myserver/myserver-20260101-040000.tar.zst"
+	if _, err := store.PresignGet(ctx, key, time.Minute); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PresignGet() before upload error = %v, want ErrNotFound", err)
+	}
+
+	put, err := store.PresignPut(ctx, key, "1.21.11", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut() error = %v", err)
+	}
+	if status := sendTo(t, put, archive, nil); status != http.StatusOK {
+		t.Fatalf("presigned upload status = %d, want 200", status)
+	}
+
+	body, object, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || !bytes.Equal(data, archive) {
+		t.Errorf("Get() returned %d bytes (%v), want the uploaded archive", len(data), err)
+	}
+	if object.MinecraftVersion != "1.21.11" || object.Size != int64(len(archive)) {
+		t.Errorf("object = %+v, want %d bytes of 1.21.11", object, len(archive))
+	}
+
+	get, err := store.PresignGet(ctx, key, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if status := sendTo(t, get, nil, nil); status != http.StatusOK {
+		t.Errorf("presigned download status = %d, want 200", status)
+	}
+
+	objects, err := store.List(ctx, "alice/myserver/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].Name != "myserver-20260101-040000.tar.zst" || objects[0].MinecraftVersion != "1.21.11" {
+		t.Errorf("List() = %+v, want the archive by name and version", objects)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3Store_PresignedUploadOnlyTakesSignedVersion(t *testing.T) {
+	store := newTestS3Store(t)
+	ctx := context.Background()
+
+	key := "alice/myserver/myserver-20260101-040000.tar.zst"
+	put, err := store.PresignPut(ctx, key, "1.21.11", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut() error = %v", err)
+	}
+	if status := sendTo(t, put, testArchive(t), map[string]string{"X-Amz-Meta-Minecraft-Version": "1.20.1"}); status != http.StatusForbidden {
+		t.Errorf("upload with another version status = %d, want 403", status)
+	}
+
+	objects, err := store.List(ctx, "alice/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("List() = %+v after a rejected upload, want nothing", objects)
+	}
+}
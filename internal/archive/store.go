@@ -0,0 +1,44 @@
+// Package archive keeps users' world archives in S3-compatible object storage, off the
+// cluster node. The registration service hands server pods presigned URLs of single
+// archives, so that storage credentials never leave kubecraft-system and each user only
+// reaches their own prefix, while the data goes straight between the pod and the bucket.
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by a Store for a missing archive
+var ErrNotFound = errors.New("archive not found")
+
+// Object describes a stored archive
+type Object struct {
+	Name             string    `json:"name"`
+	Size             int64     `json:"size"`
+	LastModified     time.Time `json:"lastModified"`
+	MinecraftVersion string    `json:"minecraftVersion,omitempty"`
+}
+
+// PresignedRequest lets whoever holds it read or write one object until it expires,
+// without credentials of its own. Header has to be sent along as is.
+type PresignedRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// Store reads and writes archives by key
+type Store interface {
+	// PresignPut returns a request uploading an archive to key, recording the Minecraft
+	// version the world was saved with
+	PresignPut(ctx context.Context, key string, minecraftVersion string, expires time.Duration) (*PresignedRequest, error)
+	// PresignGet returns a request downloading key, or ErrNotFound
+	PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// List returns the archives under prefix, with names relative to it
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+}
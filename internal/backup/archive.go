@@ -0,0 +1,240 @@
+// Package backup reads and writes world backup archives.
+//
+// An archive is a zstd-compressed tar holding the world directories as they are
+// under /data, followed by a manifest.json entry describing the backup.
+package backup
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	ManifestName  = "manifest.json"
+	FormatVersion = 1
+)
+
+// Manifest describes a backup archive
+type Manifest struct {
+	Format           int       `json:"format"`
+	Server           string    `json:"server"`
+	MinecraftVersion string    `json:"minecraftVersion"`
+	CreatedAt        time.Time `json:"createdAt"`
+	Files            int       `json:"files"`
+	Size             int64     `json:"size"`     // Uncompressed bytes of world data
+	Checksum         string    `json:"checksum"` // sha256 over every file's path and content
+}
+
+// Write compresses the world entries of worldTar into an archive on w and appends
+// the manifest. Files, Size and Checksum are filled in from the data written.
+func Write(w io.Writer, worldTar io.Reader, m Manifest) (*Manifest, error) {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("creating zstd writer: %w", err)
+	}
+	tw := tar.NewWriter(zw)
+
+	sum := newChecksum()
+	tr := tar.NewReader(worldTar)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading world data: %w", err)
+		}
+		if err := checkWorldPath(hdr.Name); err != nil {
+			return nil, err
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", hdr.Name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		sum.file(hdr.Name, hdr.Size)
+		n, err := io.Copy(io.MultiWriter(tw, sum), tr)
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", hdr.Name, err)
+		}
+		m.Files++
+		m.Size += n
+	}
+
+	if m.Files == 0 {
+		return nil, fmt.Errorf("no world files found")
+	}
+
+	m.Format = FormatVersion
+	m.Checksum = sum.String()
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	hdr := &tar.Header{
+		Name:     ManifestName,
+		Mode:     0644,
+		Size:     int64(len(manifest)),
+		ModTime:  m.CreatedAt,
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("finishing archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("finishing archive: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Verify reads a whole archive, checks every file against the manifest checksum
+// and returns the manifest
+func Verify(r io.Reader) (*Manifest, error) {
+	return read(r, nil)
+}
+
+// ExtractWorld verifies an archive while copying its world entries, without the
+// manifest, as a plain tar to w. On error w may have received partial data.
+func ExtractWorld(r io.Reader, w io.Writer) (*Manifest, error) {
+	tw := tar.NewWriter(w)
+
+	m, err := read(r, tw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("writing world data: %w", err)
+	}
+
+	return m, nil
+}
+
+func read(r io.Reader, tw *tar.Writer) (*Manifest, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer zr.Close()
+
+	var manifest *Manifest
+	sum := newChecksum()
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+
+		if hdr.Name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("reading manifest: %w", err)
+			}
+			continue
+		}
+		if manifest != nil {
+			return nil, fmt.Errorf("archive has data after the manifest")
+		}
+		if err := checkWorldPath(hdr.Name); err != nil {
+			return nil, err
+		}
+
+		out := io.Writer(io.Discard)
+		if tw != nil {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return nil, fmt.Errorf("writing %s: %w", hdr.Name, err)
+			}
+			out = tw
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		sum.file(hdr.Name, hdr.Size)
+		if _, err := io.Copy(io.MultiWriter(out, sum), tr); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", hdr.Name, err)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no %s, it is incomplete or not a kubecraft backup", ManifestName)
+	}
+	if manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than this kubecraft supports (%d)", manifest.Format, FormatVersion)
+	}
+	if sum.String() != manifest.Checksum {
+		return nil, fmt.Errorf("archive is corrupt: checksum %s does not match manifest %s", sum.String(), manifest.Checksum)
+	}
+
+	return manifest, nil
+}
+
+// checkWorldPath only lets world directories into an archive, so a restore can't
+// write outside them
+func checkWorldPath(name string) error {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || !strings.HasPrefix(clean, "world") {
+		return fmt.Errorf("unexpected path %q in world data", name)
+	}
+
+	return nil
+}
+
+// MajorVersion returns the major part of a Minecraft version, e.g. 1.21 for 1.21.11
+func MajorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+// checksum hashes file paths and contents in archive order
+type checksum struct {
+	h hash.Hash
+}
+
+func newChecksum() *checksum {
+	return &checksum{h: sha256.New()}
+}
+
+func (c *checksum) file(name string, size int64) {
+	c.h.Write([]byte(path.Clean(name) + "\x00" + strconv.FormatInt(size, 10) + "\x00"))
+}
+
+func (c *checksum) Write(p []byte) (int, error) {
+	return c.h.Write(p)
+}
+
+func (c *checksum) String() string {
+	return "sha256:" + hex.EncodeToString(c.h.Sum(nil))
+}
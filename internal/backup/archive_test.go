@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// worldTar builds a plain tar of the given files, as tar in the pod would stream them
+func worldTar(t *testing.T, files map[string]string, order ...string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range order {
+		content := files[name]
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(name, "/") {
+			hdr = &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			tw.Write([]byte(content))
+		}
+	}
+	tw.Close()
+
+	return &buf
+}
+
+// testArchive writes a backup of a small world
+func testArchive(t *testing.T) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"world/level.dat":        "level",
+		"world/region/r.0.0.mca": strings.Repeat("chunk", 1000),
+		"world_nether/level.dat": "nether",
+	}
+	in := worldTar(t, files, "world/", "world/level.dat", "world/region/", "world/region/r.0.0.mca", "world_nether/", "world_nether/level.dat")
+
+	var out bytes.Buffer
+	_, err := Write(&out, in, Manifest{Server: "myserver", MinecraftVersion: "1.21.11", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	return out.Bytes()
+}
+
+func TestWrite_ThenVerify(t *testing.T) {
+	archive := testArchive(t)
+
+	m, err := Verify(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if m.Server != "myserver" || m.MinecraftVersion != "1.21.11" {
+		t.Errorf("manifest = %+v, want server myserver version 1.21.11", m)
+	}
+	if m.Files != 3 {
+		t.Errorf("manifest Files = %d, want 3", m.Files)
+	}
+	if !strings.HasPrefix(m.Checksum, "sha256:") {
+		t.Errorf("manifest Checksum = %q, want a sha256", m.Checksum)
+	}
+}
+
+func TestExtractWorld_LeavesOutManifest(t *testing.T) {
+	archive := testArchive(t)
+
+	var out bytes.Buffer
+	if _, err := ExtractWorld(bytes.NewReader(archive), &out); err != nil {
+		t.Fatalf("ExtractWorld() error = %v", err)
+	}
+
+	tr := tar.NewReader(&out)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read extracted tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "world/level.dat" {
+			content, _ := io.ReadAll(tr)
+			if string(content) != "level" {
+				t.Errorf("world/level.dat = %q, want %q", content, "level")
+			}
+		}
+	}
+
+	if len(names) != 6 {
+		t.Errorf("extracted entries = %v, want the 6 world entries", names)
+	}
+	for _, name := range names {
+		if name == ManifestName {
+			t.Error("extracted world contains the manifest")
+		}
+	}
+}
+
+func TestVerify_DetectsCorruption(t *testing.T) {
+	// Rebuild an archive whose manifest does not match its data
+	files := map[string]string{"world/level.dat": "level"}
+	var good bytes.Buffer
+	if _, err := Write(&good, worldTar(t, files, "world/level.dat"), Manifest{Server: "a"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	m, _ := Verify(bytes.NewReader(good.Bytes()))
+
+	files["world/level.dat"] = "LEVEL"
+	var bad bytes.Buffer
+	if _, err := Write(&bad, worldTar(t, files, "world/level.dat"), Manifest{Server: "a"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	other, _ := Verify(bytes.NewReader(bad.Bytes()))
+
+	if m.Checksum == other.Checksum {
+		t.Error("different world contents produced the same checksum")
+	}
+}
+
+func TestVerify_RejectsMissingManifest(t *testing.T) {
+	if _, err := Verify(strings.NewReader("not an archive")); err == nil {
+		t.Error("Verify() expected error for garbage input, got nil")
+	}
+}
+
+func TestWrite_RejectsPathsOutsideWorld(t *testing.T) {
+	files := map[string]string{"../etc/passwd": "x"}
+
+	_, err := Write(io.Discard, worldTar(t, files, "../etc/passwd"), Manifest{})
+	if err == nil {
+		t.Error("Write() expected error for a path outside the world, got nil")
+	}
+}
+
+func TestWrite_RejectsEmptyWorld(t *testing.T) {
+	if _, err := Write(io.Discard, worldTar(t, nil), Manifest{}); err == nil {
+		t.Error("Write() expected error for an empty world, got nil")
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.21.11": "1.21",
+		"1.21":    "1.21",
+		"1.8.9":   "1.8",
+		"latest":  "latest",
+	}
+
+	for version, want := range tests {
+		if got := MajorVersion(version); got != want {
+			t.Errorf("MajorVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
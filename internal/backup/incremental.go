@@ -0,0 +1,482 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	IndexExt                 = ".index.json"
+	IncrementalFormatVersion = 1
+
+	blobDir   = "chunks"
+	tmpPrefix = ".tmp-"
+)
+
+// Index describes an incremental snapshot. File contents live in a content-addressed
+// blob store shared by all snapshots of a repository, with region files split into
+// their chunks, so a snapshot only stores the chunks that changed since earlier ones.
+type Index struct {
+	Format           int          `json:"format"`
+	Server           string       `json:"server"`
+	MinecraftVersion string       `json:"minecraftVersion"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	Files            int          `json:"files"`
+	Size             int64        `json:"size"`  // Bytes of world data
+	Added            int64        `json:"added"` // Bytes of new blobs this snapshot stored
+	Entries          []IndexEntry `json:"entries"`
+}
+
+// IndexEntry is a file or directory of a snapshot
+type IndexEntry struct {
+	Path     string      `json:"path"`
+	Mode     fs.FileMode `json:"mode"` // Permissions, plus fs.ModeDir for directories
+	Size     int64       `json:"size,omitempty"`
+	Segments string      `json:"segments,omitempty"` // Blob listing the file's segments, shared while it is unchanged
+}
+
+// segment is a piece of a file stored as one blob
+type segment struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// Repository stores incremental snapshots in a directory: one index file per snapshot
+// and the blobs they reference under chunks/, named by their sha256.
+// Only one snapshot or prune may run on a repository at a time.
+type Repository struct {
+	dir string
+}
+
+// IndexName returns the file name of an incremental snapshot of serverName taken at t
+func IndexName(serverName string, t time.Time) string {
+	return serverName + "-" + t.UTC().Format(snapshotTimeFormat) + IndexExt
+}
+
+// OpenRepository opens the repository in dir, creating it if needed
+func OpenRepository(dir string) (*Repository, error) {
+	if err := os.MkdirAll(filepath.Join(dir, blobDir), 0755); err != nil {
+		return nil, fmt.Errorf("creating repository: %w", err)
+	}
+
+	return &Repository{dir: dir}, nil
+}
+
+// Snapshot stores the world directories under dataDir as a new snapshot described by
+// idx, named after its Server and CreatedAt. Files, Size, Added and Entries are filled
+// in from the data stored. Each file is read whole, like TarWorld does.
+func (r *Repository) Snapshot(dataDir string, idx Index) (*Index, error) {
+	idx.Format = IncrementalFormatVersion
+	idx.Entries = nil
+
+	err := walkWorld(dataDir, func(p string, d fs.DirEntry) error {
+		return r.addFile(&idx, dataDir, p, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if idx.Files == 0 {
+		return nil, fmt.Errorf("no world files found")
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return nil, fmt.Errorf("encoding index: %w", err)
+	}
+	// Written last, so a snapshot only exists once every blob it references does
+	if err := writeFileAtomic(filepath.Join(r.dir, IndexName(idx.Server, idx.CreatedAt)), data); err != nil {
+		return nil, fmt.Errorf("writing index: %w", err)
+	}
+
+	return &idx, nil
+}
+
+func (r *Repository) addFile(idx *Index, dataDir string, p string, d fs.DirEntry) error {
+	rel, err := filepath.Rel(dataDir, p)
+	if err != nil {
+		return err
+	}
+	name := filepath.ToSlash(rel)
+
+	info, err := d.Info()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // Removed by the server since it was listed
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case d.IsDir():
+		idx.Entries = append(idx.Entries, IndexEntry{Path: name, Mode: fs.ModeDir | info.Mode().Perm()})
+		return nil
+	case d.Type().IsRegular():
+	default:
+		// Symlinks and the like are not part of a world
+		return nil
+	}
+
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sizes := []int64{int64(len(data))}
+	if isRegionFile(name) {
+		sizes = regionSegments(data)
+	}
+
+	segments := make([]segment, 0, len(sizes))
+	var offset int64
+	for _, size := range sizes {
+		hash, added, err := r.putBlob(data[offset : offset+size])
+		if err != nil {
+			return fmt.Errorf("storing %s: %w", name, err)
+		}
+		idx.Added += added
+		segments = append(segments, segment{Hash: hash, Size: size})
+		offset += size
+	}
+
+	list, err := json.Marshal(segments)
+	if err != nil {
+		return fmt.Errorf("encoding segments of %s: %w", name, err)
+	}
+	listHash, added, err := r.putBlob(list)
+	if err != nil {
+		return fmt.Errorf("storing %s: %w", name, err)
+	}
+	idx.Added += added
+
+	idx.Entries = append(idx.Entries, IndexEntry{
+		Path:     name,
+		Mode:     info.Mode().Perm(),
+		Size:     int64(len(data)),
+		Segments: listHash,
+	})
+	idx.Files++
+	idx.Size += int64(len(data))
+
+	return nil
+}
+
+// Restore rebuilds the world of a snapshot under target, byte for byte. Every blob is
+// checked against its hash as it is read, so a damaged repository fails the restore.
+func (r *Repository) Restore(name string, target string) (*Index, error) {
+	idx, err := r.ReadIndex(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range idx.Entries {
+		if err := checkWorldPath(entry.Path); err != nil {
+			return nil, err
+		}
+		dst := filepath.Join(target, filepath.FromSlash(entry.Path))
+
+		if entry.Mode.IsDir() {
+			if err := os.MkdirAll(dst, entry.Mode.Perm()); err != nil {
+				return nil, fmt.Errorf("restoring %s: %w", entry.Path, err)
+			}
+			continue
+		}
+
+		if err := r.restoreFile(entry, dst); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", entry.Path, err)
+		}
+	}
+
+	return idx, nil
+}
+
+func (r *Repository) restoreFile(entry IndexEntry, dst string) error {
+	segments, err := r.readSegments(entry.Segments)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, entry.Mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	for _, s := range segments {
+		data, err := r.readBlob(s.Hash)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	return f.Close()
+}
+
+// Verify checks every blob a snapshot references against its hash and returns how
+// many were checked. The error names the files that could not be restored.
+func (r *Repository) Verify(name string) (int, error) {
+	idx, err := r.ReadIndex(name)
+	if err != nil {
+		return 0, err
+	}
+
+	checked := map[string]error{}
+	check := func(hash string) error {
+		if err, ok := checked[hash]; ok {
+			return err
+		}
+		_, err := r.readBlob(hash)
+		checked[hash] = err
+		return err
+	}
+
+	var damaged []string
+	for _, entry := range idx.Entries {
+		if entry.Mode.IsDir() {
+			continue
+		}
+		segments, err := r.readSegments(entry.Segments)
+		checked[entry.Segments] = err
+		if err != nil {
+			damaged = append(damaged, entry.Path)
+			continue
+		}
+		for _, s := range segments {
+			if err := check(s.Hash); err != nil {
+				damaged = append(damaged, entry.Path)
+				break
+			}
+		}
+	}
+
+	if len(damaged) > 0 {
+		shown := damaged[:min(len(damaged), 5)]
+		msg := strings.Join(shown, ", ")
+		if len(damaged) > len(shown) {
+			msg += fmt.Sprintf(" and %d more", len(damaged)-len(shown))
+		}
+		return len(checked), fmt.Errorf("%d of %d files damaged: %s", len(damaged), idx.Files, msg)
+	}
+
+	return len(checked), nil
+}
+
+// ReadIndex reads the index of a snapshot
+func (r *Repository) ReadIndex(name string) (*Index, error) {
+	if !strings.HasSuffix(name, IndexExt) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("%s is not an incremental snapshot", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %s not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("reading index %s: %w", name, err)
+	}
+	if idx.Format > IncrementalFormatVersion {
+		return nil, fmt.Errorf("snapshot format %d is newer than this kubecraft supports (%d)", idx.Format, IncrementalFormatVersion)
+	}
+
+	return &idx, nil
+}
+
+// List returns the snapshots of serverName, oldest first
+func (r *Repository) List(serverName string) ([]Snapshot, error) {
+	snapshots, err := listSnapshots(r.dir, serverName, IndexExt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range snapshots {
+		idx, err := r.ReadIndex(s.Name)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[i].Size = idx.Size
+		snapshots[i].Added = idx.Added
+	}
+
+	return snapshots, nil
+}
+
+// Prune deletes all but the newest keep snapshots of serverName, then the blobs no
+// remaining snapshot references, and returns the snapshots left
+func (r *Repository) Prune(serverName string, keep int) ([]Snapshot, error) {
+	snapshots, err := r.List(serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) > keep {
+		expired := snapshots[:len(snapshots)-keep]
+		for _, s := range expired {
+			err := os.Remove(filepath.Join(r.dir, s.Name))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("removing %s: %w", s.Name, err)
+			}
+		}
+		snapshots = snapshots[len(expired):]
+	}
+
+	if err := r.collectGarbage(); err != nil {
+		return nil, fmt.Errorf("removing unused chunks: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// collectGarbage removes blobs not referenced by any index, and files left behind by
+// interrupted writes
+func (r *Repository) collectGarbage() error {
+	indexes, err := filepath.Glob(filepath.Join(r.dir, "*"+IndexExt))
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, p := range indexes {
+		idx, err := r.ReadIndex(filepath.Base(p))
+		if err != nil {
+			return err
+		}
+		for _, entry := range idx.Entries {
+			if entry.Mode.IsDir() || used[entry.Segments] {
+				continue
+			}
+			used[entry.Segments] = true
+
+			segments, err := r.readSegments(entry.Segments)
+			if err != nil {
+				// Only the unreadable list itself is kept, its pieces go unless another
+				// snapshot uses them. Verify reports the snapshot as damaged.
+				continue
+			}
+			for _, s := range segments {
+				used[s.Hash] = true
+			}
+		}
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(r.dir, tmpPrefix+"*"))
+	if err != nil {
+		return err
+	}
+	for _, p := range leftovers {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	return filepath.WalkDir(filepath.Join(r.dir, blobDir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || used[d.Name()] {
+			return nil
+		}
+		return os.Remove(p)
+	})
+}
+
+func (r *Repository) blobPath(hash string) string {
+	return filepath.Join(r.dir, blobDir, hash[:2], hash)
+}
+
+// putBlob stores data unless a blob with the same content exists, and returns its hash
+// and the bytes newly stored
+func (r *Repository) putBlob(data []byte) (string, int64, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	p := r.blobPath(hash)
+	if _, err := os.Stat(p); err == nil {
+		return hash, 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", 0, err
+	}
+	if err := writeFileAtomic(p, data); err != nil {
+		return "", 0, err
+	}
+
+	return hash, int64(len(data)), nil
+}
+
+// readBlob reads a blob and checks it against its hash
+func (r *Repository) readBlob(hash string) ([]byte, error) {
+	if len(hash) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid chunk hash %q", hash)
+	}
+
+	data, err := os.ReadFile(r.blobPath(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("chunk %s is missing", hash[:12])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("chunk %s is damaged", hash[:12])
+	}
+
+	return data, nil
+}
+
+func (r *Repository) readSegments(hash string) ([]segment, error) {
+	data, err := r.readBlob(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []segment
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, fmt.Errorf("reading chunk list %s: %w", hash[:12], err)
+	}
+
+	return segments, nil
+}
+
+// writeFileAtomic writes data next to p and renames it into place, so p is never
+// seen half-written
+func writeFileAtomic(p string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(p), tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), p)
+}
@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWorld writes a world with one region file of three chunks under dataDir
+func testWorld(t *testing.T, dataDir string) map[int][]byte {
+	t.Helper()
+
+	chunks := map[int][]byte{0: randomChunk(1, 6000), 1: randomChunk(2, 6000), 2: randomChunk(3, 6000)}
+	writeFiles(t, dataDir, map[string]string{
+		"world/level.dat":          "level",
+		"world/region/r.0.0.mca":   string(regionFile(chunks, 0, 1, 2)),
+		"world_nether/DIM-1/x.txt": "nether",
+	})
+	os.MkdirAll(filepath.Join(dataDir, "world", "datapacks"), 0755)
+
+	return chunks
+}
+
+// sameTree fails unless both directories hold the same files with the same contents
+func sameTree(t *testing.T, want string, got string) {
+	t.Helper()
+
+	filepath.WalkDir(want, func(p string, d fs.DirEntry, err error) error {
+		rel, _ := filepath.Rel(want, p)
+		info, statErr := os.Stat(filepath.Join(got, rel))
+		if statErr != nil {
+			t.Errorf("%s missing after restore", rel)
+			return nil
+		}
+		if d.IsDir() {
+			if !info.IsDir() {
+				t.Errorf("%s is not a directory after restore", rel)
+			}
+			return nil
+		}
+		wantData, _ := os.ReadFile(p)
+		gotData, _ := os.ReadFile(filepath.Join(got, rel))
+		if !bytes.Equal(wantData, gotData) {
+			t.Errorf("%s differs after restore", rel)
+		}
+		return nil
+	})
+}
+
+func TestRepository_SecondSnapshotOnlyStoresChanges(t *testing.T) {
+	dataDir, repoDir := t.TempDir(), t.TempDir()
+	chunks := testWorld(t, dataDir)
+	repo, err := OpenRepository(repoDir)
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+
+	day := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	first, err := repo.Snapshot(dataDir, Index{Server: "myserver", MinecraftVersion: "1.21.11", CreatedAt: day})
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if first.Files != 3 || first.Added < first.Size {
+		t.Errorf("first snapshot = %d files, %d of %d bytes added, want 3 files stored in full", first.Files, first.Added, first.Size)
+	}
+
+	// The server rewrites one chunk in place
+	chunks[1] = randomChunk(4, 6000)
+	writeFiles(t, dataDir, map[string]string{"world/region/r.0.0.mca": string(regionFile(chunks, 0, 1, 2))})
+
+	second, err := repo.Snapshot(dataDir, Index{Server: "myserver", MinecraftVersion: "1.21.11", CreatedAt: day.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	// The changed chunk's sectors, the header and the region's new chunk list
+	if max := int64(2*sectorSize + regionHeaderSize + 1024); second.Added > max {
+		t.Errorf("second snapshot added %d bytes, want at most %d for one changed chunk", second.Added, max)
+	}
+
+	firstWorld := t.TempDir()
+	if _, err := repo.Restore(IndexName("myserver", day), firstWorld); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	restored, _ := os.ReadFile(filepath.Join(firstWorld, "world", "region", "r.0.0.mca"))
+	if bytes.Contains(restored, chunks[1]) {
+		t.Error("first snapshot restored with the chunk changed after it")
+	}
+
+	secondWorld := t.TempDir()
+	if _, err := repo.Restore(IndexName("myserver", day.AddDate(0, 0, 1)), secondWorld); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	sameTree(t, dataDir, secondWorld)
+}
+
+func TestRepository_VerifyFindsDamagedChunk(t *testing.T) {
+	dataDir, repoDir := t.TempDir(), t.TempDir()
+	chunks := testWorld(t, dataDir)
+	repo, _ := OpenRepository(repoDir)
+
+	day := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	if _, err := repo.Snapshot(dataDir, Index{Server: "myserver", CreatedAt: day}); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	name := IndexName("myserver", day)
+
+	checked, err := repo.Verify(name)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if checked < 3 {
+		t.Errorf("Verify() checked %d chunks, want every chunk", checked)
+	}
+
+	// Flip a byte in the blob of chunk 2
+	padded := make([]byte, 2*sectorSize)
+	copy(padded, chunks[2])
+	hash, _, _ := repo.putBlob(padded)
+	blob := repo.blobPath(hash)
+	data, _ := os.ReadFile(blob)
+	data[100] ^= 0xff
+	os.WriteFile(blob, data, 0644)
+
+	_, err = repo.Verify(name)
+	if err == nil || !strings.Contains(err.Error(), "world/region/r.0.0.mca") {
+		t.Errorf("Verify() error = %v, want the damaged region file named", err)
+	}
+	if _, err := repo.Restore(name, t.TempDir()); err == nil {
+		t.Error("Restore() of a damaged snapshot succeeded")
+	}
+}
+
+func TestRepository_PruneRemovesUnusedChunks(t *testing.T) {
+	dataDir, repoDir := t.TempDir(), t.TempDir()
+	chunks := testWorld(t, dataDir)
+	repo, _ := OpenRepository(repoDir)
+
+	day := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	repo.Snapshot(dataDir, Index{Server: "myserver", CreatedAt: day})
+	old := make([]byte, 2*sectorSize)
+	copy(old, chunks[1])
+
+	chunks[1] = randomChunk(4, 6000)
+	writeFiles(t, dataDir, map[string]string{"world/region/r.0.0.mca": string(regionFile(chunks, 0, 1, 2))})
+	repo.Snapshot(dataDir, Index{Server: "myserver", CreatedAt: day.AddDate(0, 0, 1)})
+	os.WriteFile(filepath.Join(repoDir, tmpPrefix+"interrupted"), []byte("x"), 0644)
+
+	kept, err := repo.Prune("myserver", 1)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(kept) != 1 || kept[0].Name != IndexName("myserver", day.AddDate(0, 0, 1)) {
+		t.Fatalf("Prune() kept %v, want the newest snapshot", kept)
+	}
+	if kept[0].Size == 0 || kept[0].Added == 0 {
+		t.Errorf("kept snapshot = %+v, want its size and added bytes", kept[0])
+	}
+
+	sum := sha256.Sum256(old)
+	if _, err := repo.readBlob(hex.EncodeToString(sum[:])); err == nil {
+		t.Error("chunk only the pruned snapshot used is still stored")
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, tmpPrefix+"interrupted")); !os.IsNotExist(err) {
+		t.Error("leftover temp file not removed")
+	}
+	if _, err := repo.Verify(kept[0].Name); err != nil {
+		t.Errorf("Verify() after Prune() error = %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package backup
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+)
+
+// Region files (.mca) start with a header of 1024 chunk locations and 1024 timestamps.
+// Each location is a 3-byte sector offset and a 1-byte sector count.
+const (
+	sectorSize       = 4096
+	regionChunks     = 1024
+	regionHeaderSize = 2 * sectorSize
+)
+
+func isRegionFile(name string) bool {
+	return strings.HasSuffix(name, ".mca")
+}
+
+// regionSegments splits a region file into the sizes of its header, each chunk's sectors
+// and the unused sectors between them, in file order. Chunks the server didn't touch
+// keep their bytes, so their segments hash the same between backups.
+// Anything that doesn't parse as a region file is returned as one segment.
+func regionSegments(data []byte) []int64 {
+	whole := []int64{int64(len(data))}
+	if len(data) < regionHeaderSize {
+		return whole
+	}
+
+	type span struct{ start, end int }
+	var chunks []span
+	for i := range regionChunks {
+		location := binary.BigEndian.Uint32(data[i*4:])
+		start, count := int(location>>8)*sectorSize, int(location&0xff)*sectorSize
+		if count == 0 {
+			continue // Chunk not generated
+		}
+		if start < regionHeaderSize || start+count > len(data) {
+			return whole
+		}
+		chunks = append(chunks, span{start, start + count})
+	}
+	slices.SortFunc(chunks, func(a, b span) int {
+		return a.start - b.start
+	})
+
+	sizes := []int64{regionHeaderSize}
+	pos := regionHeaderSize
+	for _, c := range chunks {
+		if c.start < pos {
+			return whole // Overlapping chunks, the file is damaged
+		}
+		if c.start > pos {
+			sizes = append(sizes, int64(c.start-pos))
+		}
+		sizes = append(sizes, int64(c.end-c.start))
+		pos = c.end
+	}
+	if pos < len(data) {
+		sizes = append(sizes, int64(len(data)-pos))
+	}
+
+	return sizes
+}
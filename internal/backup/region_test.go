@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// regionFile builds a region file with the given chunks, each stored in its own sectors
+// in the order given, with an unused sector before the last one
+func regionFile(chunks map[int][]byte, order ...int) []byte {
+	data := make([]byte, regionHeaderSize)
+	for i, index := range order {
+		if i == len(order)-1 {
+			data = append(data, bytes.Repeat([]byte{0xee}, sectorSize)...) // Stale data of a moved chunk
+		}
+		chunk := chunks[index]
+		sectors := (len(chunk) + sectorSize - 1) / sectorSize
+		location := uint32(len(data)/sectorSize)<<8 | uint32(sectors)
+		binary.BigEndian.PutUint32(data[index*4:], location)
+		binary.BigEndian.PutUint32(data[sectorSize+index*4:], uint32(1700000000+i))
+
+		padded := make([]byte, sectors*sectorSize)
+		copy(padded, chunk)
+		data = append(data, padded...)
+	}
+	return data
+}
+
+// randomChunk returns incompressible chunk data of n bytes
+func randomChunk(seed uint64, n int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	chunk := make([]byte, n)
+	for i := range chunk {
+		chunk[i] = byte(r.Uint32())
+	}
+	return chunk
+}
+
+func TestRegionSegments_SplitsPerChunk(t *testing.T) {
+	chunks := map[int][]byte{0: randomChunk(1, 5000), 31: randomChunk(2, 100), 1023: randomChunk(3, 9000)}
+	data := regionFile(chunks, 31, 0, 1023)
+
+	sizes := regionSegments(data)
+
+	// Header, chunk 31, chunk 0, the stale sector, chunk 1023
+	want := []int64{regionHeaderSize, sectorSize, 2 * sectorSize, sectorSize, 3 * sectorSize}
+	if !slices.Equal(sizes, want) {
+		t.Errorf("regionSegments() = %v, want %v", sizes, want)
+	}
+}
+
+func TestRegionSegments_WholeFileWhenDamaged(t *testing.T) {
+	data := regionFile(map[int][]byte{0: randomChunk(1, 5000), 1: randomChunk(2, 5000)}, 0, 1)
+	// Point chunk 1 into the middle of chunk 0
+	binary.BigEndian.PutUint32(data[4:], uint32(3)<<8|1)
+
+	tests := map[string][]byte{
+		"overlapping chunks":  data,
+		"truncated file":      data[:len(data)-100],
+		"shorter than header": data[:100],
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if sizes := regionSegments(data); !slices.Equal(sizes, []int64{int64(len(data))}) {
+				t.Errorf("regionSegments() = %v, want the whole file", sizes)
+			}
+		})
+	}
+}
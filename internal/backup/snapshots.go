@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ArchiveExt         = ".tar.zst"
+	snapshotTimeFormat = "20060102-150405"
+)
+
+// Snapshot is a backup kept in a backup directory by scheduled backups, either an
+// archive or an incremental snapshot
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Added     int64     `json:"added,omitempty"` // Only for incremental snapshots
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SnapshotName returns the file name of a backup of serverName taken at t
+func SnapshotName(serverName string, t time.Time) string {
+	return serverName + "-" + t.UTC().Format(snapshotTimeFormat) + ArchiveExt
+}
+
+// ListSnapshots returns the backups of serverName in dir, oldest first.
+// Unfinished archives and other files are left out.
+func ListSnapshots(dir string, serverName string) ([]Snapshot, error) {
+	return listSnapshots(dir, serverName, ArchiveExt)
+}
+
+// listSnapshots returns the files of serverName in dir named like snapshots with ext
+func listSnapshots(dir string, serverName string, ext string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading backup directory: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), serverName+"-")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ext)
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(snapshotTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", entry.Name(), err)
+		}
+		snapshots = append(snapshots, Snapshot{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// Prune deletes all but the newest keep backups of serverName in dir and returns the rest
+func Prune(dir string, serverName string, keep int) ([]Snapshot, error) {
+	snapshots, err := ListSnapshots(dir, serverName)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) <= keep {
+		return snapshots, nil
+	}
+
+	expired := snapshots[:len(snapshots)-keep]
+	for _, s := range expired {
+		err := os.Remove(filepath.Join(dir, s.Name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing %s: %w", s.Name, err)
+		}
+	}
+
+	return snapshots[len(expired):], nil
+}
@@ -0,0 +1,62 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListSnapshots_SortsAndSkipsOthers(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	writeFiles(t, dir, map[string]string{
+		SnapshotName("myserver", day.AddDate(0, 0, 1)):              "b",
+		SnapshotName("myserver", day):                               "a",
+		SnapshotName("myserver", day.AddDate(0, 0, 2)) + ".partial": "unfinished",
+		SnapshotName("other", day):                                  "other server",
+		"notes.txt":                                                 "x",
+	})
+
+	snapshots, err := ListSnapshots(dir, "myserver")
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+
+	if len(snapshots) != 2 {
+		t.Fatalf("ListSnapshots() = %v, want 2 snapshots", snapshots)
+	}
+	if !snapshots[0].CreatedAt.Equal(day) || snapshots[0].Size != 1 {
+		t.Errorf("oldest snapshot = %+v, want 1 byte from %s", snapshots[0], day)
+	}
+}
+
+func TestPrune_KeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	var names []string
+	for i := range 5 {
+		name := SnapshotName("myserver", day.AddDate(0, 0, i))
+		names = append(names, name)
+		writeFiles(t, dir, map[string]string{name: "x"})
+	}
+
+	kept, err := Prune(dir, "myserver", 2)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	var keptNames []string
+	for _, s := range kept {
+		keptNames = append(keptNames, s.Name)
+	}
+	if !slices.Equal(keptNames, names[3:]) {
+		t.Errorf("kept = %v, want %v", keptNames, names[3:])
+	}
+	for _, name := range names[:3] {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s not pruned", name)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// TarWorld writes the world directories under dataDir (world, world_nether, ...) to w
+// as a plain tar, in the form Write expects. Each file is read whole before its header
+// is written, so a file the server rewrites meanwhile still goes in consistent.
+func TarWorld(dataDir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := walkWorld(dataDir, func(p string, d fs.DirEntry) error {
+		return addWorldFile(tw, dataDir, p, d)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// UntarWorld unpacks world directories in the form TarWorld writes them into dir, e.g.
+// the output of ExtractWorld. Entries outside the world directories are refused.
+func UntarWorld(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading world data: %w", err)
+		}
+		if err := checkWorldPath(hdr.Name); err != nil {
+			return err
+		}
+
+		p := filepath.Join(dir, filepath.FromSlash(path.Clean(hdr.Name)))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(p, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := untarFile(tr, p, hdr.FileInfo().Mode().Perm()); err != nil {
+				return fmt.Errorf("unpacking %s: %w", hdr.Name, err)
+			}
+		}
+	}
+}
+
+func untarFile(r io.Reader, p string, perm fs.FileMode) error {
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// walkWorld calls fn for every file and directory in the world directories under dataDir
+func walkWorld(dataDir string, fn func(p string, d fs.DirEntry) error) error {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("reading data directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "world") {
+			continue
+		}
+
+		err := filepath.WalkDir(filepath.Join(dataDir, entry.Name()), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return fn(p, d)
+		})
+		if err != nil {
+			return fmt.Errorf("archiving %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func addWorldFile(tw *tar.Writer, dataDir string, p string, d fs.DirEntry) error {
+	rel, err := filepath.Rel(dataDir, p)
+	if err != nil {
+		return err
+	}
+	name := filepath.ToSlash(rel)
+
+	info, err := d.Info()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // Removed by the server since it was listed
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case d.IsDir():
+		return tw.WriteHeader(&tar.Header{
+			Name:     name + "/",
+			Mode:     int64(info.Mode().Perm()),
+			ModTime:  info.ModTime(),
+			Typeflag: tar.TypeDir,
+		})
+	case d.Type().IsRegular():
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     int64(info.Mode().Perm()),
+			Size:     int64(len(data)),
+			ModTime:  info.ModTime(),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	default:
+		// Symlinks and the like are not part of a world
+		return nil
+	}
+}
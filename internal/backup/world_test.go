@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeFiles creates files with the given contents under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestTarWorld_OnlyWorldDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"world/level.dat":          "level",
+		"world/region/r.0.0.mca":   "chunks",
+		"world_nether/DIM-1/x.mca": "nether",
+		"server.properties":        "motd=hi",
+		"plugins/thing.jar":        "jar",
+	})
+	// A file named like a world directory is not one
+	writeFiles(t, dir, map[string]string{"world.txt": "notes"})
+
+	var buf bytes.Buffer
+	if err := TarWorld(dir, &buf); err != nil {
+		t.Fatalf("TarWorld() error = %v", err)
+	}
+
+	var files []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %v", err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			files = append(files, hdr.Name)
+		}
+	}
+
+	want := []string{"world/level.dat", "world/region/r.0.0.mca", "world_nether/DIM-1/x.mca"}
+	if !slices.Equal(files, want) {
+		t.Errorf("archived files = %v, want %v", files, want)
+	}
+}
+
+func TestTarWorld_FeedsWrite(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"world/level.dat": "level"})
+
+	var world, archive bytes.Buffer
+	if err := TarWorld(dir, &world); err != nil {
+		t.Fatalf("TarWorld() error = %v", err)
+	}
+	m, err := Write(&archive, &world, Manifest{Server: "myserver", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if m.Files != 1 {
+		t.Errorf("manifest Files = %d, want 1", m.Files)
+	}
+}
+
+func TestUntarWorld_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"world/level.dat":          "level",
+		"world/region/r.0.0.mca":   "chunks",
+		"world_nether/DIM-1/x.mca": "nether",
+	}
+	writeFiles(t, src, files)
+
+	var world bytes.Buffer
+	if err := TarWorld(src, &world); err != nil {
+		t.Fatalf("TarWorld() error = %v", err)
+	}
+	dst := t.TempDir()
+	if err := UntarWorld(&world, dst); err != nil {
+		t.Fatalf("UntarWorld() error = %v", err)
+	}
+
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q (%v), want %q", name, data, err, content)
+		}
+	}
+}
+
+func TestUntarWorld_RefusesOtherPaths(t *testing.T) {
+	for _, name := range []string{"../world/level.dat", "plugins/thing.jar", "/world/level.dat"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+		tw.Write([]byte("x"))
+		tw.Close()
+
+		if err := UntarWorld(&buf, t.TempDir()); err == nil {
+			t.Errorf("UntarWorld(%s) expected error, got nil", name)
+		}
+	}
+}
@@ -37,13 +37,17 @@ var registerCmd = &cobra.Command{
 }
 
 func registerUser(username string) error {
+	return registerUserAtURL(username, ServiceURL("/register"))
+}
+
+// ServiceURL returns the URL of path on the registration service
+func ServiceURL(path string) string {
 	host, _, err := net.SplitHostPort(config.ClusterEndpoint)
 	if err != nil {
 		// No port in endpoint, use as-is
 		host = config.ClusterEndpoint
 	}
-	url := fmt.Sprintf("http://%s:%d/register", host, config.RegistrationServicePort)
-	return registerUserAtURL(username, url)
+	return fmt.Sprintf("http://%s:%d%s", host, config.RegistrationServicePort, path)
 }
 
 func registerUserAtURL(username string, url string) error {
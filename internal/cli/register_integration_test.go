@@ -4,6 +4,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -65,7 +66,7 @@ func TestRegisterIntegration_EndToEnd(t *testing.T) {
 	}
 
 	// Verify namespace was created in K8s
-	exists, err := client.NamespaceExists(username)
+	exists, err := client.NamespaceExists(context.Background(), username)
 	if err != nil {
 		t.Fatalf("NamespaceExists() error = %v", err)
 	}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/baighasan/kubecraft/internal/config"
 	"github.com/baighasan/kubecraft/internal/k8s"
@@ -54,7 +57,12 @@ func init() {
 }
 
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	// Cancel in-flight cluster calls on Ctrl-C, commands read it from cmd.Context()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := RootCmd.ExecuteContext(ctx); err != nil {
+		stop()
 		fmt.Fprintf(os.Stderr, "Oops. An error while executing Kubecraft '%s'\n", err)
 		os.Exit(1)
 	}
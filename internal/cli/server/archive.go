@@ -0,0 +1,263 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// The archive scripts run the launcher in the server pod with a short-lived archive
+// token on stdin. It asks the registration service, inside the cluster, for presigned
+// requests and moves the world straight between the pod and the bucket, writing its
+// result to stdout as JSON.
+const (
+	archiveListScript = "/usr/local/bin/kubecraft-launcher archive list --service %s --server %s"
+	archivePushScript = "/usr/local/bin/kubecraft-launcher archive push --service %s --server %s --name %s --version %s"
+	archivePullScript = "/usr/local/bin/kubecraft-launcher archive pull --service %s --server %s --name %s"
+)
+
+// archiveObject is an archive as listed by the launcher
+type archiveObject struct {
+	Name             string    `json:"name"`
+	Size             int64     `json:"size"`
+	LastModified     time.Time `json:"lastModified"`
+	MinecraftVersion string    `json:"minecraftVersion"`
+}
+
+var (
+	archivePullForce bool
+	archivePullWait  waitOptions
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Keep server worlds in the cluster's off-node archive storage",
+	Long:  "Moves worlds between servers and the S3-compatible storage configured for the cluster. The server's pod transfers the world itself, the registration service only hands it presigned URLs. Each user only sees their own archives.",
+}
+
+var archivePushCmd = &cobra.Command{
+	Use:   "push <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Archive a server's world",
+	Long:  "Saves the world and has the server's pod upload it straight into archive storage. A stopped server is started in maintenance mode for it. Only the newest archives of each server are kept.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeArchivePush(cmd.Context(), serverName)
+	},
+}
+
+var archivePullCmd = &cobra.Command{
+	Use:   "pull <server-name> [archive]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Replace a server's world with an archived one",
+	Long:  "Replaces the world with an archive, the newest one unless named, like kubecraft server restore. The server's pod downloads the archive straight from archive storage and verifies it before swapping it in.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, name := args[0], ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return executeArchivePull(cmd.Context(), serverName, name, archivePullForce, archivePullWait)
+	},
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:   "list <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "List a server's archives",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeArchiveList(cmd.Context(), serverName, os.Stdout)
+	},
+}
+
+func executeArchivePush(ctx context.Context, serverName string) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+
+	release, err := quiesceWorld(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	name := backup.SnapshotName(serverName, time.Now())
+	fmt.Fprintf(os.Stderr, "Archiving %s...\n", serverName)
+	var manifest backup.Manifest
+	script := archiveScript(archivePushScript, serverName, name, version)
+	if err := runArchiveScript(ctx, serverName, script, false, &manifest); err != nil {
+		return fmt.Errorf("could not archive world: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Archived %s (%d files, %s) as %s\n", serverName, manifest.Files, formatBytes(manifest.Size), name)
+	return nil
+}
+
+func executeArchivePull(ctx context.Context, serverName string, name string, force bool, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	archives, err := listArchives(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		return fmt.Errorf("server (%s) has no archives", serverName)
+	}
+	archive := archives[len(archives)-1]
+	if name != "" {
+		i := slices.IndexFunc(archives, func(a archiveObject) bool { return a.Name == name })
+		if i < 0 {
+			return fmt.Errorf("archive %s not found, list them with: kubecraft server archive list %s", name, serverName)
+		}
+		archive = archives[i]
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+	if backup.MajorVersion(archive.MinecraftVersion) != backup.MajorVersion(version) {
+		if !force {
+			return fmt.Errorf("archive is from Minecraft %s but server %s runs %s, use --force to restore it anyway", archive.MinecraftVersion, serverName, version)
+		}
+		fmt.Fprintf(os.Stderr, "Restoring a Minecraft %s world into a %s server\n", archive.MinecraftVersion, version)
+	}
+
+	fmt.Fprintf(os.Stderr, "Archive %s from %s (%s)\n", archive.Name, archive.LastModified.Local().Format("2006-01-02 15:04"), formatBytes(archive.Size))
+	if !confirm(fmt.Sprintf("Replace the world of %s? Its current world is lost", serverName)) {
+		fmt.Fprintln(os.Stderr, "Restore cancelled")
+		return nil
+	}
+
+	return replaceWorld(ctx, serverName, wait, func() error {
+		var manifest backup.Manifest
+		script := archiveScript(archivePullScript, serverName, archive.Name)
+		if err := runArchiveScript(ctx, serverName, script, false, &manifest); err != nil {
+			return fmt.Errorf("could not restore archive %s: %w", archive.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Pulled %s (%d files, %s)\n", archive.Name, manifest.Files, formatBytes(manifest.Size))
+		return nil
+	})
+}
+
+func executeArchiveList(ctx context.Context, serverName string, out io.Writer) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	archives, err := listArchives(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	if len(archives) == 0 {
+		fmt.Fprintf(os.Stderr, "No archives, create one with: kubecraft server archive push %s\n", serverName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "NAME\tSIZE\tVERSION\tAGE\n")
+	// Newest first
+	for _, a := range slices.Backward(archives) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Name, formatBytes(a.Size), a.MinecraftVersion, formatAge(a.LastModified))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// listArchives returns the server's archives, oldest first. A stopped server's are
+// listed from a helper pod.
+func listArchives(ctx context.Context, serverName string) ([]archiveObject, error) {
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("could not check if server is running: %w", err)
+	}
+
+	var archives []archiveObject
+	script := archiveScript(archiveListScript, serverName)
+	if err := runArchiveScript(ctx, serverName, script, !running, &archives); err != nil {
+		return nil, fmt.Errorf("could not list archives: %w", err)
+	}
+
+	// Archive names embed their UTC timestamp, so they sort by age
+	slices.SortFunc(archives, func(a, b archiveObject) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return archives, nil
+}
+
+// archiveScript fills in an archive script for the server, with args quoted
+func archiveScript(format string, serverName string, args ...string) string {
+	quoted := []any{shellQuote(config.ArchiveServiceURL), shellQuote(serverName)}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return fmt.Sprintf(format, quoted...)
+}
+
+// runArchiveScript runs an archive script in the server, or a helper pod, handing it a
+// fresh archive token, and decodes its result into out. The token is only valid for
+// the archive routes and expires after an hour, unlike the one the CLI keeps.
+func runArchiveScript(ctx context.Context, serverName string, script string, inHelper bool, out any) error {
+	token, err := cli.K8sClient.GenerateArchiveToken(ctx, cli.AppConfig.Username)
+	if err != nil {
+		return fmt.Errorf("could not get archive token: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	if inHelper {
+		err = execInHelper(ctx, serverName, script, strings.NewReader(token), &stdout)
+	} else {
+		err = execInServer(ctx, serverName, script, strings.NewReader(token), &stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(stdout.Bytes(), out)
+}
+
+func init() {
+	archivePullCmd.Flags().BoolVar(&archivePullForce, "force", false, "Restore an archive from a different major Minecraft version")
+	addWaitFlags(archivePullCmd, &archivePullWait)
+
+	archiveCmd.AddCommand(archivePushCmd)
+	archiveCmd.AddCommand(archivePullCmd)
+	archiveCmd.AddCommand(archiveListCmd)
+	serverCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/config"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// useArchiveTokens answers the CLI's TokenRequests with archive-token and returns the
+// requests it got
+func useArchiveTokens(t *testing.T, clientset *fake.Clientset) *[]*authv1.TokenRequest {
+	t.Helper()
+
+	var requests []*authv1.TokenRequest
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		requests = append(requests, action.(k8stesting.CreateAction).GetObject().(*authv1.TokenRequest))
+		return true, &authv1.TokenRequest{Status: authv1.TokenRequestStatus{Token: "archive-token"}}, nil
+	})
+
+	return &requests
+}
+
+// archiveListing is the launcher's listing of archives of version, by name
+func archiveListing(t *testing.T, version string, names ...string) string {
+	t.Helper()
+
+	var archives []archiveObject
+	for _, name := range names {
+		archives = append(archives, archiveObject{Name: name, Size: 2048, LastModified: time.Now(), MinecraftVersion: version})
+	}
+	data, err := json.Marshal(archives)
+	if err != nil {
+		t.Fatalf("failed to encode listing: %v", err)
+	}
+	return string(data)
+}
+
+func TestExecuteArchivePush_LauncherUploadsWithArchiveToken(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	requests := useArchiveTokens(t, clientset)
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+	manifest, _ := json.Marshal(backup.Manifest{Server: "myserver", Files: 12, Size: 4096})
+	pushPrefix := fmt.Sprintf("/usr/local/bin/kubecraft-launcher archive push --service '%s' --server 'myserver' --name 'myserver-", config.ArchiveServiceURL)
+	exec := &fakeExec{prefixed: map[string]string{pushPrefix: string(manifest)}}
+	useFakeExec(t, exec)
+
+	if err := executeArchivePush(context.Background(), "myserver"); err != nil {
+		t.Fatalf("executeArchivePush() error = %v", err)
+	}
+
+	if len(exec.scripts) != 1 || !strings.HasPrefix(exec.scripts[0], pushPrefix) || !strings.HasSuffix(exec.scripts[0], "--version '1.21.11'") {
+		t.Fatalf("scripts = %q, want the launcher pushing a 1.21.11 snapshot", exec.scripts)
+	}
+	if token := exec.input[exec.scripts[0]]; token != "archive-token" {
+		t.Errorf("stdin = %q, want the archive token rather than the CLI's", token)
+	}
+	if len(*requests) != 1 || !slices.Equal((*requests)[0].Spec.Audiences, []string{config.ArchiveTokenAudience}) {
+		t.Errorf("token requests = %v, want one for the archive audience", *requests)
+	}
+	if !slices.Equal(session.commands, []string{"save-off", "save-all flush", "save-on"}) {
+		t.Errorf("commands = %v, want saving paused around the push", session.commands)
+	}
+}
+
+func TestExecuteArchivePush_FailedPushReportsLauncherError(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useArchiveTokens(t, clientset)
+	useFakeRcon(t, &fakeRcon{})
+	useFakeExec(t, &fakeExec{err: errors.New("archive storage returned status 403")})
+
+	err := executeArchivePush(context.Background(), "myserver")
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("executeArchivePush() error = %v, want the launcher's error", err)
+	}
+}
+
+func TestExecuteArchivePull_RestoresNewest(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), maintenancePod("myserver"))
+	useArchiveTokens(t, clientset)
+	listScript := archiveScript(archiveListScript, "myserver")
+	pullScript := archiveScript(archivePullScript, "myserver", "myserver-20260102-040000.tar.zst")
+	exec := &fakeExec{output: map[string]string{
+		listScript: archiveListing(t, "1.21.11", "myserver-20260102-040000.tar.zst", "myserver-20260101-040000.tar.zst"),
+		pullScript: `{"files": 12}`,
+	}}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	if err := executeArchivePull(context.Background(), "myserver", "", false, wait); err != nil {
+		t.Fatalf("executeArchivePull() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{listScript, pullScript}) {
+		t.Errorf("scripts = %q, want the newest archive pulled in the pod", exec.scripts)
+	}
+	if exec.input[pullScript] != "archive-token" {
+		t.Errorf("stdin = %q, want the archive token", exec.input[pullScript])
+	}
+	if sts := getStatefulSet(t, clientset, "myserver"); *sts.Spec.Replicas != 1 || hasMaintenanceEnv(sts) {
+		t.Errorf("Replicas = %d, maintenance = %v, want the server started again", *sts.Spec.Replicas, hasMaintenanceEnv(sts))
+	}
+}
+
+func TestExecuteArchivePull_OtherMajorVersionNeedsForce(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), maintenancePod("myserver"))
+	useArchiveTokens(t, clientset)
+	listScript := archiveScript(archiveListScript, "myserver")
+	pullScript := archiveScript(archivePullScript, "myserver", "myserver-20260101-040000.tar.zst")
+	exec := &fakeExec{output: map[string]string{
+		listScript: archiveListing(t, "1.20.4", "myserver-20260101-040000.tar.zst"),
+		pullScript: `{"files": 12}`,
+	}}
+	useFakeExec(t, exec)
+
+	err := executeArchivePull(context.Background(), "myserver", "", false, testWait)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("executeArchivePull() error = %v, want a hint at --force", err)
+	}
+	if !slices.Equal(exec.scripts, []string{listScript}) {
+		t.Errorf("scripts = %q, want only the listing", exec.scripts)
+	}
+
+	answerPrompts(t, "y\n")
+	if err := executeArchivePull(context.Background(), "myserver", "", true, waitOptions{timeout: time.Minute, noWait: true}); err != nil {
+		t.Fatalf("executeArchivePull() with force error = %v", err)
+	}
+}
+
+func TestExecuteArchivePull_MissingArchive(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useArchiveTokens(t, clientset)
+	listScript := archiveScript(archiveListScript, "myserver")
+	exec := &fakeExec{output: map[string]string{listScript: archiveListing(t, "1.21.11", "myserver-20260102-040000.tar.zst")}}
+	useFakeExec(t, exec)
+
+	err := executeArchivePull(context.Background(), "myserver", "myserver-20260101-040000.tar.zst", false, testWait)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("executeArchivePull() error = %v, want not found", err)
+	}
+	if !slices.Equal(exec.scripts, []string{listScript}) {
+		t.Errorf("scripts = %q, want only the listing for a missing archive", exec.scripts)
+	}
+
+	exec.output[listScript] = "[]"
+	if err := executeArchivePull(context.Background(), "myserver", "", false, testWait); err == nil {
+		t.Error("executeArchivePull() expected error without archives, got nil")
+	}
+}
+
+func TestExecuteArchiveList_StoppedServerUsesHelper(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0))
+	useArchiveTokens(t, clientset)
+	listScript := archiveScript(archiveListScript, "myserver")
+	exec := &fakeExec{output: map[string]string{listScript: archiveListing(t, "1.21.11",
+		"myserver-20260101-040000.tar.zst",
+		"myserver-20260103-040000.tar.zst",
+		"myserver-20260102-040000.tar.zst",
+	)}}
+	useFakeExec(t, exec)
+
+	var out bytes.Buffer
+	if err := executeArchiveList(context.Background(), "myserver", &out); err != nil {
+		t.Fatalf("executeArchiveList() error = %v", err)
+	}
+
+	if !slices.Equal(exec.inHelper, []string{listScript}) {
+		t.Errorf("helper scripts = %q, want the listing", exec.inHelper)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want header and 3 archives:\n%s", len(lines), out.String())
+	}
+	for i, want := range []string{"20260103", "20260102", "20260101"} {
+		if !strings.Contains(lines[i+1], want) || !strings.Contains(lines[i+1], "1.21.11") {
+			t.Errorf("line %d = %q, want archive from %s with its version", i+1, lines[i+1], want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// worldTarScript streams the world directories under /data as a tar to stdout
+const worldTarScript = "cd /data && tar -cf - world*"
+
+// execInServer runs a shell script in the server container (swapped for a fake in tests)
+var execInServer = func(ctx context.Context, serverName string, script string, stdin io.Reader, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	err := cli.K8sClient.ExecInServer(ctx, serverName, []string{"sh", "-c", script}, stdin, stdout, &stderr)
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return err
+}
+
+// execInHelper runs a shell script in a short-lived pod with the world of a stopped
+// server mounted read-only at /data, reading stdin if set and writing its output to
+// stdout
+var execInHelper = func(ctx context.Context, serverName string, script string, stdin io.Reader, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	err := cli.K8sClient.ExecInHelper(ctx, serverName, []string{"sh", "-c", script}, stdin, stdout, &stderr)
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return err
+}
+
+var backupOutput string
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Back up a server's world to a local file",
+	Long:  "Saves the world and streams the world directories out of the server into a compressed archive. A stopped server is started in maintenance mode for the copy and stopped again afterwards.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeBackup(cmd.Context(), serverName, backupOutput)
+	},
+}
+
+func executeBackup(ctx context.Context, serverName string, output string) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("%s-%s.tar.zst", serverName, time.Now().Format("20060102-150405"))
+	}
+
+	// Write next to the target and rename, so a failed backup never looks complete
+	partial := output + ".partial"
+	file, err := os.Create(partial)
+	if err != nil {
+		return fmt.Errorf("could not create backup file: %w", err)
+	}
+	defer os.Remove(partial)
+
+	manifest, err := snapshotWorld(ctx, serverName, version, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("could not write backup file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(partial, output); err != nil {
+		return fmt.Errorf("could not write backup file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Backed up %s (%d files, %s) to %s\n", serverName, manifest.Files, formatBytes(manifest.Size), output)
+	return nil
+}
+
+// snapshotWorld writes a backup of the server's world to w
+func snapshotWorld(ctx context.Context, serverName string, version string, w io.Writer) (*backup.Manifest, error) {
+	release, err := quiesceWorld(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	fmt.Fprintf(os.Stderr, "Backing up %s...\n", serverName)
+	return streamBackup(ctx, serverName, w, backup.Manifest{
+		Server:           serverName,
+		MinecraftVersion: version,
+		CreatedAt:        time.Now().UTC(),
+	})
+}
+
+// quiesceWorld makes the server's world safe to copy until release is called. A running
+// server stops saving for the copy, a stopped one is started in maintenance mode and
+// stopped again.
+func quiesceWorld(ctx context.Context, serverName string) (release func(), err error) {
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("could not check if server is running: %w", err)
+	}
+
+	if running {
+		// Keep the server from writing region files while they are copied
+		resume, err := pauseSaving(ctx, serverName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not pause saving (%v), the copy may catch chunks mid-write\n", err)
+			return func() {}, nil
+		}
+		return resume, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Server %s is stopped, starting it in maintenance mode...\n", serverName)
+	release = func() { leaveMaintenance(ctx, serverName, 0) }
+	if err := enterMaintenance(ctx, serverName, config.DefaultReadyTimeout); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// streamBackup tars the world in the server and compresses it into w as it arrives
+func streamBackup(ctx context.Context, serverName string, w io.Writer, m backup.Manifest) (*backup.Manifest, error) {
+	var manifest *backup.Manifest
+	err := streamWorld(ctx, serverName, func(r io.Reader) error {
+		var err error
+		manifest, err = backup.Write(w, r, m)
+		if err != nil {
+			return fmt.Errorf("could not write backup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// streamWorld tars the world directories in the server and passes the tar to read as
+// it arrives. A failed copy is reported before an error from read.
+func streamWorld(ctx context.Context, serverName string, read func(r io.Reader) error) error {
+	pr, pw := io.Pipe()
+	execErr := make(chan error, 1)
+	go func() {
+		err := execInServer(ctx, serverName, worldTarScript, nil, pw)
+		pw.CloseWithError(err)
+		execErr <- err
+	}()
+
+	err := read(pr)
+	if err != nil {
+		// Unblocks the exec, nothing reads its output anymore
+		pr.CloseWithError(err)
+	} else {
+		// tar may pad the archive past the end marker
+		_, _ = io.Copy(io.Discard, pr)
+	}
+	if copyErr := <-execErr; copyErr != nil {
+		return fmt.Errorf("could not copy world from server: %w", copyErr)
+	}
+
+	return err
+}
+
+// pauseSaving flushes the world to disk and turns off autosave until resume is called
+func pauseSaving(ctx context.Context, serverName string) (resume func(), err error) {
+	session, err := connectRcon(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := session.Execute("save-off"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("could not turn off saving: %w", err)
+	}
+
+	resume = func() {
+		if _, err := session.Execute("save-on"); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not turn saving back on (%v), run: kubecraft server exec %s -- save-on\n", err, serverName)
+		}
+		session.Close()
+	}
+
+	fmt.Fprintln(os.Stderr, "Saving world...")
+	if _, err := session.Execute("save-all flush"); err != nil {
+		resume()
+		return nil, fmt.Errorf("could not save world: %w", err)
+	}
+
+	return resume, nil
+}
+
+// enterMaintenance restarts the server as a maintenance pod and waits for it
+func enterMaintenance(ctx context.Context, serverName string, timeout time.Duration) error {
+	err := cli.K8sClient.SetMaintenance(ctx, serverName, true, 1)
+	if err != nil {
+		return fmt.Errorf("could not switch server (%s) to maintenance mode: %w", serverName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = cli.K8sClient.WaitForMaintenance(ctx, serverName, printPhase)
+	if err != nil {
+		return fmt.Errorf("server %s unresponsive: %w", serverName, err)
+	}
+
+	return nil
+}
+
+// leaveMaintenance switches the server back to normal and scales it to replicas.
+// It still runs after Ctrl-C, so the server is not left in maintenance mode.
+func leaveMaintenance(ctx context.Context, serverName string, replicas int32) error {
+	err := cli.K8sClient.SetMaintenance(context.WithoutCancel(ctx), serverName, false, replicas)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Server %s is still in maintenance mode: %v\n", serverName, err)
+		return err
+	}
+
+	return nil
+}
+
+// formatBytes renders a size for humans, e.g. 12.3 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "File to write the backup to (default <server-name>-<timestamp>.tar.zst)")
+
+	serverCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// cronMacros are the shorthand schedules CronJobs accept besides five cron fields
+var cronMacros = []string{"@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly"}
+
+// backupScheduleOptions control the scheduled backups of a server
+type backupScheduleOptions struct {
+	cron        string
+	keep        int
+	incremental bool
+	disable     bool
+}
+
+var backupScheduleOpts backupScheduleOptions
+
+var backupScheduleCmd = &cobra.Command{
+	Use:   "schedule <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Back up a server on a schedule inside the cluster",
+	Long:  "Runs a backup job on a cron schedule that archives the world into a separate backup volume, keeping the newest archives. Incremental backups only store the chunks that changed since the last one.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeBackupSchedule(cmd.Context(), serverName, backupScheduleOpts)
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "List a server's scheduled backups",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeBackupList(cmd.Context(), serverName, os.Stdout)
+	},
+}
+
+func executeBackupSchedule(ctx context.Context, serverName string, opts backupScheduleOptions) error {
+	if !opts.disable {
+		if err := validateCron(opts.cron); err != nil {
+			return err
+		}
+		if opts.keep < 1 || opts.keep > config.MaxBackupKeep {
+			return fmt.Errorf("--keep must be between 1 and %d", config.MaxBackupKeep)
+		}
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	if opts.disable {
+		if err := cli.K8sClient.DisableBackups(ctx, serverName); err != nil {
+			return fmt.Errorf("could not disable backups: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Scheduled backups of %s disabled. Existing backups are kept.\n", serverName)
+		return nil
+	}
+
+	err = cli.K8sClient.ScheduleBackups(ctx, serverName, cli.AppConfig.Username, opts.cron, opts.keep, opts.incremental)
+	if err != nil {
+		return fmt.Errorf("could not schedule backups: %w", err)
+	}
+
+	kind := "backups"
+	if opts.incremental {
+		kind = "incremental backups"
+	}
+	fmt.Fprintf(os.Stderr, "Backing up %s on schedule %q, keeping the newest %d %s\n", serverName, opts.cron, opts.keep, kind)
+	return nil
+}
+
+// validateCron catches obviously malformed schedules before the API server does
+func validateCron(schedule string) error {
+	if slices.Contains(cronMacros, schedule) {
+		return nil
+	}
+	if len(strings.Fields(schedule)) != 5 {
+		return fmt.Errorf("invalid --cron %q: want five fields (minute hour day month weekday) or one of %s", schedule, strings.Join(cronMacros, ", "))
+	}
+
+	return nil
+}
+
+func executeBackupList(ctx context.Context, serverName string, out io.Writer) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	schedule, err := cli.K8sClient.GetBackupSchedule(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get backup schedule: %w", err)
+	}
+	snapshots, err := cli.K8sClient.ListBackups(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not list backups: %w", err)
+	}
+
+	if schedule == nil && len(snapshots) == 0 {
+		fmt.Fprintf(os.Stderr, "No backups scheduled, set them up with: kubecraft server backup schedule %s\n", serverName)
+		return nil
+	}
+
+	if schedule == nil {
+		fmt.Fprintln(os.Stderr, "Scheduled backups are disabled")
+	} else {
+		status := "never run"
+		switch {
+		case schedule.Active:
+			status = "running now"
+		case schedule.LastRun != nil && (schedule.LastSuccess == nil || schedule.LastSuccess.Before(*schedule.LastRun)):
+			status = fmt.Sprintf("last run %s ago failed", formatAge(*schedule.LastRun))
+		case schedule.LastRun != nil:
+			status = fmt.Sprintf("last run %s ago", formatAge(*schedule.LastRun))
+		}
+		kind := ""
+		if schedule.Incremental {
+			kind = " incremental"
+		}
+		fmt.Fprintf(os.Stderr, "Schedule %q, keeping %d%s (%s)\n", schedule.Schedule, schedule.Keep, kind, status)
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Fprintln(os.Stderr, "No backups yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "NAME\tSIZE\tAGE\n")
+	// Newest first
+	for _, s := range slices.Backward(snapshots) {
+		size := formatBytes(s.Size)
+		if strings.HasSuffix(s.Name, backup.IndexExt) {
+			size += fmt.Sprintf(" (+%s new)", formatBytes(s.Added))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, size, formatAge(s.CreatedAt))
+	}
+	w.Flush()
+
+	return nil
+}
+
+func init() {
+	backupScheduleCmd.Flags().StringVar(&backupScheduleOpts.cron, "cron", config.DefaultBackupSchedule, "When to back up, in cron syntax (UTC)")
+	backupScheduleCmd.Flags().IntVar(&backupScheduleOpts.keep, "keep", config.DefaultBackupKeep, "Number of backups to keep, older ones are deleted")
+	backupScheduleCmd.Flags().BoolVar(&backupScheduleOpts.incremental, "incremental", false, "Only store the region chunks that changed since the last backup")
+	backupScheduleCmd.Flags().BoolVar(&backupScheduleOpts.disable, "disable", false, "Stop scheduled backups, keeping the existing ones")
+
+	backupCmd.AddCommand(backupScheduleCmd)
+	backupCmd.AddCommand(backupListCmd)
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// finishedBackupPod returns the pod of a backup job that kept snapshots
+func finishedBackupPod(serverName string, snapshots []backup.Snapshot) *corev1.Pod {
+	message, _ := json.Marshal(snapshots)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serverName + "-backup-1",
+			Namespace: config.NamespacePrefix + fakeUsername,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.BackupLabelValue,
+				"server":              serverName,
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "backup",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: string(message)}},
+			}},
+		},
+	}
+}
+
+func TestExecuteBackupSchedule_CreatesSchedule(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+	opts := backupScheduleOptions{cron: "0 4 * * *", keep: 7}
+	if err := executeBackupSchedule(context.Background(), "myserver", opts); err != nil {
+		t.Fatalf("executeBackupSchedule() error = %v", err)
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(context.Background(), "myserver-backup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("CronJob not created: %v", err)
+	}
+	if cronJob.Labels["user"] != fakeUsername {
+		t.Errorf("CronJob user label = %q, want %q", cronJob.Labels["user"], fakeUsername)
+	}
+
+	opts.disable = true
+	if err := executeBackupSchedule(context.Background(), "myserver", opts); err != nil {
+		t.Fatalf("executeBackupSchedule() with disable error = %v", err)
+	}
+	if _, err := clientset.BatchV1().CronJobs(namespace).Get(context.Background(), "myserver-backup", metav1.GetOptions{}); err == nil {
+		t.Error("CronJob still exists after disabling backups")
+	}
+}
+
+func TestExecuteBackupSchedule_InvalidOptionsTouchNothing(t *testing.T) {
+	tests := map[string]backupScheduleOptions{
+		"too few cron fields": {cron: "0 4 *", keep: 7},
+		"unknown macro":       {cron: "@fortnightly", keep: 7},
+		"keep zero":           {cron: "@daily", keep: 0},
+		"keep too many":       {cron: "@daily", keep: config.MaxBackupKeep + 1},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+			if err := executeBackupSchedule(context.Background(), "myserver", opts); err == nil {
+				t.Fatal("executeBackupSchedule() expected error, got nil")
+			}
+			if actions := clientset.Actions(); len(actions) != 0 {
+				t.Errorf("executeBackupSchedule() made %d API calls for invalid options, want 0", len(actions))
+			}
+		})
+	}
+}
+
+func TestExecuteBackupList_NewestFirst(t *testing.T) {
+	now := time.Now()
+	snapshots := []backup.Snapshot{
+		{Name: "myserver-old.tar.zst", Size: 2048, CreatedAt: now.Add(-48 * time.Hour)},
+		{Name: "myserver-new.tar.zst", Size: 3 << 20, CreatedAt: now.Add(-2 * time.Hour)},
+	}
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), finishedBackupPod("myserver", snapshots))
+
+	var out bytes.Buffer
+	if err := executeBackupList(context.Background(), "myserver", &out); err != nil {
+		t.Fatalf("executeBackupList() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output = %q, want a header and 2 backups", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "myserver-new.tar.zst") || !strings.Contains(lines[1], "3.0 MiB") || !strings.Contains(lines[1], "2h") {
+		t.Errorf("first row = %q, want the newest backup with size and age", lines[1])
+	}
+}
+
+func TestExecuteBackupList_NothingScheduled(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+	var out bytes.Buffer
+	if err := executeBackupList(context.Background(), "myserver", &out); err != nil {
+		t.Fatalf("executeBackupList() error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing on stdout", out.String())
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// backupTaskTimeout bounds the wait for an on-demand verify or restore job
+const backupTaskTimeout = config.BackupJobDeadline * time.Second
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify <server-name> [backup]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Check scheduled backups for damage",
+	Long:  "Runs a job that checks every chunk of an incremental backup, or the checksum of an archive, against the backup volume. Without a backup name all of the server's backups are checked.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, name := args[0], ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return executeBackupVerify(cmd.Context(), serverName, name, os.Stdout)
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <server-name> <backup>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Replace a stopped server's world with an incremental backup",
+	Long:  "Runs a job that rebuilds the world of an incremental backup from the backup volume and swaps it in. Stop the server first.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, name := args[0], args[1]
+		return executeBackupRestore(cmd.Context(), serverName, name)
+	},
+}
+
+func executeBackupVerify(ctx context.Context, serverName string, name string, out io.Writer) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	args := []string{"verify"}
+	if name != "" {
+		args = append(args, name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backupTaskTimeout)
+	defer cancel()
+
+	fmt.Fprintln(os.Stderr, "Verifying backups...")
+	report, err := cli.K8sClient.RunBackupTask(ctx, serverName, args, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, report)
+	return nil
+}
+
+func executeBackupRestore(ctx context.Context, serverName string, name string) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	// The job writes the world directly, the server must not be using it
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check if server is running: %w", err)
+	}
+	if running {
+		return fmt.Errorf("server %s is running, stop it first with: kubecraft server stop %s", serverName, serverName)
+	}
+
+	if !confirm(fmt.Sprintf("Replace the world of %s with %s? Its current world is lost", serverName, name)) {
+		fmt.Fprintln(os.Stderr, "Restore cancelled")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backupTaskTimeout)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "Restoring %s...\n", name)
+	report, err := cli.K8sClient.RunBackupTask(ctx, serverName, []string{"restore", name}, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, report)
+	fmt.Fprintf(os.Stderr, "Start the server with: kubecraft server start %s\n", serverName)
+	return nil
+}
+
+func init() {
+	backupCmd.AddCommand(backupVerifyCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// useFakeBackupTasks schedules incremental backups for serverName and makes every
+// backup task finish at once with message, returning the commands tasks ran
+func useFakeBackupTasks(t *testing.T, clientset *fake.Clientset, serverName string, message string) *[][]string {
+	t.Helper()
+
+	opts := backupScheduleOptions{cron: "@daily", keep: 7, incremental: true}
+	if err := executeBackupSchedule(context.Background(), serverName, opts); err != nil {
+		t.Fatalf("executeBackupSchedule() error = %v", err)
+	}
+
+	var commands [][]string
+	clientset.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		commands = append(commands, job.Spec.Template.Spec.Containers[0].Command)
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name + "-abcde",
+				Namespace: job.Namespace,
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "backup",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}},
+				}},
+			},
+		}
+		return false, nil, clientset.Tracker().Add(pod)
+	})
+
+	return &commands
+}
+
+func TestExecuteBackupSchedule_Incremental(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+	opts := backupScheduleOptions{cron: "@daily", keep: 7, incremental: true}
+	if err := executeBackupSchedule(context.Background(), "myserver", opts); err != nil {
+		t.Fatalf("executeBackupSchedule() error = %v", err)
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(context.Background(), "myserver-backup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("CronJob not created: %v", err)
+	}
+	incremental := false
+	for _, env := range cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env {
+		incremental = incremental || env.Name == "BACKUP_INCREMENTAL" && env.Value == "true"
+	}
+	if !incremental {
+		t.Error("backup job does not take incremental backups")
+	}
+}
+
+func TestExecuteBackupVerify_PrintsReport(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	commands := useFakeBackupTasks(t, clientset, "myserver", "myserver-20260101-040000.index.json: OK, 12 chunks")
+
+	var out bytes.Buffer
+	if err := executeBackupVerify(context.Background(), "myserver", "myserver-20260101-040000.index.json", &out); err != nil {
+		t.Fatalf("executeBackupVerify() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "OK, 12 chunks") {
+		t.Errorf("output = %q, want the task's report", out.String())
+	}
+	if len(*commands) != 1 || strings.Join((*commands)[0][1:], " ") != "verify myserver-20260101-040000.index.json" {
+		t.Errorf("task commands = %q, want one verify of the named backup", *commands)
+	}
+}
+
+func TestExecuteBackupRestore_RunningServerFails(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	commands := useFakeBackupTasks(t, clientset, "myserver", "")
+
+	err := executeBackupRestore(context.Background(), "myserver", "myserver-20260101-040000.index.json")
+	if err == nil || !strings.Contains(err.Error(), "stop it first") {
+		t.Errorf("executeBackupRestore() error = %v, want stop it first", err)
+	}
+	if len(*commands) != 0 {
+		t.Errorf("task commands = %q, want none for a running server", *commands)
+	}
+}
+
+func TestExecuteBackupRestore_StoppedServer(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0))
+	commands := useFakeBackupTasks(t, clientset, "myserver", "Restored myserver-20260101-040000.index.json")
+	answerPrompts(t, "y\n")
+
+	if err := executeBackupRestore(context.Background(), "myserver", "myserver-20260101-040000.index.json"); err != nil {
+		t.Fatalf("executeBackupRestore() error = %v", err)
+	}
+	if len(*commands) != 1 || strings.Join((*commands)[0][1:], " ") != "restore myserver-20260101-040000.index.json" {
+		t.Errorf("task commands = %q, want one restore of the named backup", *commands)
+	}
+}
@@ -0,0 +1,356 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeWorldServer returns the StatefulSet of a server running version, scaled to replicas
+func fakeWorldServer(serverName string, version string, replicas int32) *appsv1.StatefulSet {
+	sts := fakeServerStatefulSet(serverName)
+	sts.Spec.Replicas = &replicas
+	sts.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name: config.CommonLabelValuePod,
+			Env:  []corev1.EnvVar{{Name: "VERSION", Value: version}},
+		},
+	}
+	return sts
+}
+
+// maintenancePod returns the pod of serverName as it runs in maintenance mode
+func maintenancePod(serverName string) *corev1.Pod {
+	pod := readyServerPod(serverName)
+	pod.Spec.Containers = []corev1.Container{
+		{
+			Name: config.CommonLabelValuePod,
+			Env:  []corev1.EnvVar{{Name: "MAINTENANCE", Value: "true"}},
+		},
+	}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: config.CommonLabelValuePod, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+	}
+	return pod
+}
+
+// fakeExec stands in for the server container's shell
+type fakeExec struct {
+	scripts  []string
+	world    map[string]string // Served to worldTarScript and levelScript
+	restored []string          // Entries received by worldUnpackScript
+	output   map[string]string // Written to stdout by any other script
+	prefixed map[string]string // Written to stdout by scripts starting with the key, for timestamped ones
+	input    map[string]string // Read from stdin by any other script
+	inHelper []string          // Scripts run in a helper pod instead of the server
+	err      error
+}
+
+// useFakeExec runs scripts against exec for the duration of the test, whether in
+// the server or a helper pod
+func useFakeExec(t *testing.T, exec *fakeExec) {
+	t.Helper()
+
+	orig, origHelper := execInServer, execInHelper
+	execInServer = func(ctx context.Context, serverName string, script string, stdin io.Reader, stdout io.Writer) error {
+		exec.scripts = append(exec.scripts, script)
+		if exec.err != nil {
+			return exec.err
+		}
+
+		switch script {
+		case worldTarScript:
+			tw := tar.NewWriter(stdout)
+			for _, name := range slices.Sorted(maps.Keys(exec.world)) {
+				content := exec.world[name]
+				tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+				tw.Write([]byte(content))
+			}
+			return tw.Close()
+		case levelScript:
+			_, err := io.WriteString(stdout, exec.world["world/level.dat"])
+			return err
+		case worldUnpackScript:
+			tr := tar.NewReader(stdin)
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				exec.restored = append(exec.restored, hdr.Name)
+			}
+		default:
+			if stdin != nil {
+				data, err := io.ReadAll(stdin)
+				if err != nil {
+					return err
+				}
+				if exec.input == nil {
+					exec.input = map[string]string{}
+				}
+				exec.input[script] = string(data)
+			}
+			if stdout == nil {
+				return nil
+			}
+			out := exec.output[script]
+			for prefix, prefixOut := range exec.prefixed {
+				if strings.HasPrefix(script, prefix) {
+					out = prefixOut
+				}
+			}
+			_, err := io.WriteString(stdout, out)
+			return err
+		}
+	}
+	execInHelper = func(ctx context.Context, serverName string, script string, stdin io.Reader, stdout io.Writer) error {
+		exec.inHelper = append(exec.inHelper, script)
+		return execInServer(ctx, serverName, script, stdin, stdout)
+	}
+	t.Cleanup(func() {
+		execInServer, execInHelper = orig, origHelper
+	})
+}
+
+// writeTestBackup writes a backup of a one-file world made with version to a temp file
+func writeTestBackup(t *testing.T, version string) string {
+	t.Helper()
+
+	var world bytes.Buffer
+	tw := tar.NewWriter(&world)
+	tw.WriteHeader(&tar.Header{Name: "world/level.dat", Mode: 0644, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("level"))
+	tw.Close()
+
+	file := filepath.Join(t.TempDir(), "backup.tar.zst")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("failed to create backup file: %v", err)
+	}
+	defer f.Close()
+
+	m := backup.Manifest{Server: "myserver", MinecraftVersion: version, CreatedAt: time.Now()}
+	if _, err := backup.Write(f, &world, m); err != nil {
+		t.Fatalf("backup.Write() error = %v", err)
+	}
+
+	return file
+}
+
+func getStatefulSet(t *testing.T, clientset *fake.Clientset, serverName string) *appsv1.StatefulSet {
+	t.Helper()
+
+	sts, err := clientset.AppsV1().StatefulSets(config.NamespacePrefix+fakeUsername).Get(context.Background(), serverName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get statefulset: %v", err)
+	}
+	return sts
+}
+
+func hasMaintenanceEnv(sts *appsv1.StatefulSet) bool {
+	return slices.ContainsFunc(sts.Spec.Template.Spec.Containers[0].Env, func(env corev1.EnvVar) bool {
+		return env.Name == "MAINTENANCE"
+	})
+}
+
+func TestExecuteBackup_RunningServerPausesSaving(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+	exec := &fakeExec{world: map[string]string{"world/level.dat": "level", "world_nether/level.dat": "nether"}}
+	useFakeExec(t, exec)
+
+	output := filepath.Join(t.TempDir(), "myserver.tar.zst")
+	if err := executeBackup(context.Background(), "myserver", output); err != nil {
+		t.Fatalf("executeBackup() error = %v", err)
+	}
+
+	want := []string{"save-off", "save-all flush", "save-on"}
+	if !slices.Equal(session.commands, want) {
+		t.Errorf("commands = %v, want %v", session.commands, want)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	defer f.Close()
+	m, err := backup.Verify(f)
+	if err != nil {
+		t.Fatalf("backup.Verify() error = %v", err)
+	}
+	if m.Server != "myserver" || m.MinecraftVersion != "1.21.11" || m.Files != 2 {
+		t.Errorf("manifest = %+v, want 2 files of myserver on 1.21.11", m)
+	}
+
+	if _, err := os.Stat(output + ".partial"); !os.IsNotExist(err) {
+		t.Error("partial backup file left behind")
+	}
+}
+
+func TestExecuteBackup_StoppedServerUsesMaintenanceMode(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	useFakeExec(t, &fakeExec{world: map[string]string{"world/level.dat": "level"}})
+
+	output := filepath.Join(t.TempDir(), "myserver.tar.zst")
+	if err := executeBackup(context.Background(), "myserver", output); err != nil {
+		t.Fatalf("executeBackup() error = %v", err)
+	}
+
+	sts := getStatefulSet(t, clientset, "myserver")
+	if *sts.Spec.Replicas != 0 {
+		t.Errorf("replicas = %d after backup, want 0", *sts.Spec.Replicas)
+	}
+	if hasMaintenanceEnv(sts) {
+		t.Error("server left in maintenance mode")
+	}
+}
+
+func TestExecuteBackup_FailedCopyWritesNoFile(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+	useFakeExec(t, &fakeExec{err: errors.New("pod gone")})
+
+	output := filepath.Join(t.TempDir(), "myserver.tar.zst")
+	if err := executeBackup(context.Background(), "myserver", output); err == nil {
+		t.Fatal("executeBackup() expected error, got nil")
+	}
+
+	for _, file := range []string{output, output + ".partial"} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a failed backup", filepath.Base(file))
+		}
+	}
+	if slices.Index(session.commands, "save-on") < 0 {
+		t.Error("saving not turned back on after a failed backup")
+	}
+}
+
+func TestExecuteBackup_NonexistentServerFails(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeBackup(context.Background(), "ghost", filepath.Join(t.TempDir(), "out")); err == nil {
+		t.Error("executeBackup() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestExecuteRestore_ReplacesWorld(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	file := writeTestBackup(t, "1.21.4")
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	if err := executeRestore(context.Background(), "myserver", file, false, wait); err != nil {
+		t.Fatalf("executeRestore() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{worldUnpackScript, worldSwapScript}) {
+		t.Errorf("scripts = %q, want unpack then swap", exec.scripts)
+	}
+	if !slices.Equal(exec.restored, []string{"world/level.dat"}) {
+		t.Errorf("restored entries = %v, want [world/level.dat]", exec.restored)
+	}
+
+	sts := getStatefulSet(t, clientset, "myserver")
+	if *sts.Spec.Replicas != 1 {
+		t.Errorf("replicas = %d after restore, want 1", *sts.Spec.Replicas)
+	}
+	if hasMaintenanceEnv(sts) {
+		t.Error("server left in maintenance mode")
+	}
+}
+
+func TestExecuteRestore_StoppedServerStaysStopped(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	if err := executeRestore(context.Background(), "myserver", writeTestBackup(t, "1.21.4"), false, testWait); err != nil {
+		t.Fatalf("executeRestore() error = %v", err)
+	}
+
+	if len(exec.restored) != 1 {
+		t.Errorf("restored entries = %v, want the backup restored", exec.restored)
+	}
+	sts := getStatefulSet(t, clientset, "myserver")
+	if *sts.Spec.Replicas != 0 || hasMaintenanceEnv(sts) {
+		t.Errorf("replicas = %d, maintenance = %t, want the server stopped again", *sts.Spec.Replicas, hasMaintenanceEnv(sts))
+	}
+}
+
+func TestExecuteRestore_MajorVersionMismatch(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	file := writeTestBackup(t, "1.20.4")
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+
+	if err := executeRestore(context.Background(), "myserver", file, false, wait); err == nil {
+		t.Fatal("executeRestore() expected error for a 1.20 backup on 1.21, got nil")
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %q, want none without --force", exec.scripts)
+	}
+
+	if err := executeRestore(context.Background(), "myserver", file, true, wait); err != nil {
+		t.Fatalf("executeRestore() with force error = %v", err)
+	}
+	if len(exec.restored) != 1 {
+		t.Errorf("restored entries = %v, want the backup restored with --force", exec.restored)
+	}
+}
+
+func TestExecuteRestore_CorruptBackupTouchesNothing(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useFakeExec(t, &fakeExec{})
+	answerPrompts(t, "y\n")
+
+	file := filepath.Join(t.TempDir(), "broken.tar.zst")
+	os.WriteFile(file, []byte("not a backup"), 0644)
+
+	if err := executeRestore(context.Background(), "myserver", file, true, testWait); err == nil {
+		t.Fatal("executeRestore() expected error for a corrupt backup, got nil")
+	}
+	if hasMaintenanceEnv(getStatefulSet(t, clientset, "myserver")) {
+		t.Error("server switched to maintenance mode for a corrupt backup")
+	}
+}
+
+func TestExecuteRestore_DeclinedTouchesNothing(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "n\n")
+
+	if err := executeRestore(context.Background(), "myserver", writeTestBackup(t, "1.21.11"), false, testWait); err != nil {
+		t.Fatalf("executeRestore() error = %v", err)
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %q, want none when declined", exec.scripts)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var consoleCmd = &cobra.Command{
+	Use:   "console <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Open an interactive console on a Minecraft server",
+	Long:  "Opens an RCON console to the server. Type server commands (op, whitelist add, gamerule, ...) and exit or Ctrl-D to leave.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			return executeConsoleTerminal(cmd.Context(), serverName)
+		}
+		return executeConsole(cmd.Context(), serverName, os.Stdin, os.Stdout)
+	},
+}
+
+// executeConsole runs commands read line by line from in, e.g. piped from a file
+func executeConsole(ctx context.Context, serverName string, in io.Reader, out io.Writer) error {
+	session, err := openRcon(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if done := runConsoleLine(session, scanner.Text(), out); done {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// executeConsoleTerminal runs an interactive console with line editing and history
+func executeConsoleTerminal(ctx context.Context, serverName string) error {
+	session, err := openRcon(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("could not set up terminal: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, serverName+"> ")
+
+	history := loadConsoleHistory()
+	terminal.History = history
+	defer history.save()
+
+	fmt.Fprintf(terminal, "Connected to %s. Type exit or press Ctrl-D to leave.\n", serverName)
+	for {
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read input: %w", err)
+		}
+
+		if done := runConsoleLine(session, line, terminal); done {
+			return nil
+		}
+	}
+}
+
+// runConsoleLine runs one console line and reports whether the user asked to leave
+func runConsoleLine(session rconSession, line string, out io.Writer) bool {
+	command := strings.TrimPrefix(strings.TrimSpace(line), "/")
+	switch command {
+	case "":
+		return false
+	case "exit", "quit":
+		return true
+	}
+
+	output, err := session.Execute(command)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return false
+	}
+	if output != "" {
+		fmt.Fprintln(out, stripFormatting(output))
+	}
+
+	return false
+}
+
+// consoleHistory is the console command history, kept in ~/.kubecraft between sessions
+type consoleHistory struct {
+	path    string
+	entries []string // Oldest first
+}
+
+func loadConsoleHistory() *consoleHistory {
+	h := &consoleHistory{}
+
+	path, err := config.GetConsoleHistoryPath()
+	if err != nil {
+		return h // History is a convenience, work without it
+	}
+	h.path = path
+	h.load()
+
+	return h
+}
+
+func (h *consoleHistory) load() {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		h.Add(line)
+	}
+}
+
+func (h *consoleHistory) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > config.ConsoleHistorySize {
+		h.entries = h.entries[len(h.entries)-config.ConsoleHistorySize:]
+	}
+}
+
+func (h *consoleHistory) Len() int {
+	return len(h.entries)
+}
+
+// At returns the idx-th most recent entry
+func (h *consoleHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+func (h *consoleHistory) save() {
+	if h.path == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+}
+
+func init() {
+	serverCmd.AddCommand(consoleCmd)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeServerStatefulSet returns the StatefulSet of an existing server
+func fakeServerStatefulSet(serverName string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: serverName, Namespace: config.NamespacePrefix + fakeUsername},
+	}
+}
+
+func TestExecuteExec_RunsCommand(t *testing.T) {
+	useFakeCluster(t, fakeServerStatefulSet("myserver"))
+	session := &fakeRcon{output: func(string) string { return "§aAdded Steve to the whitelist" }}
+	useFakeRcon(t, session)
+
+	var out bytes.Buffer
+	if err := executeExec(context.Background(), "myserver", "/whitelist add Steve", &out); err != nil {
+		t.Fatalf("executeExec() error = %v", err)
+	}
+
+	if !slices.Equal(session.commands, []string{"whitelist add Steve"}) {
+		t.Errorf("commands = %v, want [whitelist add Steve]", session.commands)
+	}
+	if strings.TrimSpace(out.String()) != "Added Steve to the whitelist" {
+		t.Errorf("output = %q, want formatting codes stripped", out.String())
+	}
+	if !session.closed {
+		t.Error("executeExec() did not close the session")
+	}
+}
+
+func TestExecuteExec_NonexistentServerFails(t *testing.T) {
+	useFakeCluster(t)
+	useFakeRcon(t, &fakeRcon{})
+
+	if err := executeExec(context.Background(), "ghost", "list", &bytes.Buffer{}); err == nil {
+		t.Error("executeExec() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestExecuteExec_EmptyCommandFails(t *testing.T) {
+	useFakeCluster(t, fakeServerStatefulSet("myserver"))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+
+	if err := executeExec(context.Background(), "myserver", " / ", &bytes.Buffer{}); err == nil {
+		t.Error("executeExec() expected error for an empty command, got nil")
+	}
+	if len(session.commands) != 0 {
+		t.Errorf("commands = %v, want none", session.commands)
+	}
+}
+
+func TestExecuteConsole_RunsLinesUntilExit(t *testing.T) {
+	useFakeCluster(t, fakeServerStatefulSet("myserver"))
+	session := &fakeRcon{output: func(cmd string) string { return "ok " + cmd }}
+	useFakeRcon(t, session)
+
+	input := "op Steve\n\ngamerule keepInventory true\nexit\nlist\n"
+	var out bytes.Buffer
+	if err := executeConsole(context.Background(), "myserver", strings.NewReader(input), &out); err != nil {
+		t.Fatalf("executeConsole() error = %v", err)
+	}
+
+	want := []string{"op Steve", "gamerule keepInventory true"}
+	if !slices.Equal(session.commands, want) {
+		t.Errorf("commands = %v, want %v", session.commands, want)
+	}
+	if !strings.Contains(out.String(), "ok op Steve") {
+		t.Errorf("output = %q, want command output", out.String())
+	}
+}
+
+func TestConsoleHistory_KeepsRecentUniqueEntries(t *testing.T) {
+	h := &consoleHistory{path: filepath.Join(t.TempDir(), "console_history")}
+	for i := 0; i < config.ConsoleHistorySize+10; i++ {
+		h.Add("say " + strings.Repeat("x", i%3))
+	}
+	h.Add("list")
+	h.Add("list")
+
+	if h.Len() > config.ConsoleHistorySize {
+		t.Errorf("Len() = %d, want at most %d", h.Len(), config.ConsoleHistorySize)
+	}
+	if h.At(0) != "list" || h.At(1) == "list" {
+		t.Errorf("At(0), At(1) = %q, %q, want a single most recent list", h.At(0), h.At(1))
+	}
+
+	h.save()
+	loaded := &consoleHistory{path: h.path}
+	loaded.load()
+	if loaded.Len() != h.Len() || loaded.At(0) != "list" {
+		t.Errorf("saved history has %d entries (latest %q), want %d (latest list)", loaded.Len(), loaded.At(0), h.Len())
+	}
+}
@@ -1,15 +1,40 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 	"unicode"
 
 	"github.com/baighasan/kubecraft/internal/cli"
 	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/world"
 	"github.com/spf13/cobra"
 )
 
+// versionPattern matches release versions such as 1.21 or 1.21.11
+var versionPattern = regexp.MustCompile(`^1\.\d{1,2}(\.\d{1,2})?$`)
+
+var (
+	createSpec    = k8s.DefaultServerSpec()
+	createExpose  exposeOptions
+	createWorld   string
+	createModpack string
+	createWait    waitOptions
+)
+
+// exposeOptions decides how players reach a new server
+type exposeOptions struct {
+	gateway bool // By hostname through the gateway instead of a nodeport
+	bedrock bool // Also for Bedrock Edition players, on a UDP nodeport
+}
+
 var createCmd = &cobra.Command{
 	Use:   "create <server-name>",
 	Args:  cobra.ExactArgs(1),
@@ -17,18 +42,72 @@ var createCmd = &cobra.Command{
 	Long:  "I'll think of this later",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
-		return executeCreate(serverName)
+		if createModpack != "" && (cmd.Flags().Changed("type") || cmd.Flags().Changed("version")) {
+			return fmt.Errorf("the modpack picks the server type and version, leave out --type and --version")
+		}
+		return executeCreate(cmd.Context(), serverName, createSpec, createExpose, createWorld, createModpack, createWait)
 	},
 }
 
-func executeCreate(serverName string) error {
+// executeCreate creates the server, reachable as expose asks. With worldSource the world
+// in that zip archive or directory is uploaded before the server first starts, with
+// modpackSource the Modrinth pack, which also sets the server type and version.
+func executeCreate(ctx context.Context, serverName string, spec k8s.ServerSpec, expose exposeOptions, worldSource string, modpackSource string, wait waitOptions) error {
 	// Validate server name
 	if err := ValidateServerName(serverName); err != nil {
 		return fmt.Errorf("invalid server name: %w", err)
 	}
 
+	// The pack decides what the server runs
+	if modpackSource != "" {
+		pack, err := openModpack(modpackSource)
+		if err != nil {
+			return err
+		}
+		spec.Type, spec.LoaderVersion, _ = pack.Loader()
+		spec.Version = pack.Minecraft()
+		spec.Modpack = pack.Title()
+		pack.Close()
+
+		// The launcher installs the pack on start, it has to be uploaded first
+		spec.Maintenance = true
+	}
+
+	// Validate server options
+	if err := ValidateServerSpec(spec); err != nil {
+		return fmt.Errorf("invalid server options: %w", err)
+	}
+	if expose.gateway && config.GatewayDomain == "" {
+		return fmt.Errorf("this cluster has no gateway, leave out --gateway to use a port")
+	}
+	if expose.gateway && expose.bedrock {
+		return fmt.Errorf("bedrock players connect over UDP, which the gateway cannot route, leave out --gateway")
+	}
+	if err := checkExposeType(expose, spec.Type); err != nil {
+		return err
+	}
+
+	// Check the world before anything is created
+	var imported *world.Import
+	if worldSource != "" {
+		var err error
+		imported, err = openWorld(worldSource, spec.Version)
+		if err != nil {
+			return err
+		}
+		defer imported.Close()
+
+		// Keep the server from generating a world of its own before the upload
+		spec.Maintenance = true
+	}
+
+	// Uploading a large world may take longer than the readiness timeout
+	uploadCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, wait.timeout)
+	defer cancel()
+
 	// Check if server already exists
-	serverExists, err := cli.K8sClient.ServerExists(serverName)
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
 	if err != nil {
 		return fmt.Errorf("cannot check server existence: %w", err)
 	}
@@ -38,32 +117,126 @@ func executeCreate(serverName string) error {
 
 	// Run pre-flight checks
 	fmt.Fprintln(os.Stderr, "Checking cluster capacity...")
-	err = cli.K8sClient.CheckNodeCapacity()
+	err = cli.K8sClient.CheckNodeCapacity(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Get available nodeport
-	port, err := cli.K8sClient.AllocateNodePort()
-	if err != nil {
-		return fmt.Errorf("cannot allocate node port: %w", err)
+	// Get available nodeport, servers behind the gateway do without
+	var port, bedrockPort int32
+	if !expose.gateway {
+		port, err = cli.K8sClient.AllocateNodePort(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot allocate node port: %w", err)
+		}
+	}
+	if expose.bedrock {
+		bedrockPort, err = cli.K8sClient.AllocateBedrockNodePort(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot allocate bedrock node port: %w", err)
+		}
 	}
 
 	// Create Minecraft server
 	fmt.Fprintf(os.Stderr, "Creating server %s...\n", serverName)
-	err = cli.K8sClient.CreateServer(serverName, cli.AppConfig.Username, port)
+	err = cli.K8sClient.CreateServer(ctx, serverName, cli.AppConfig.Username, port, bedrockPort, spec)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			offerRollback(serverName)
+		}
 		return fmt.Errorf("cannot create server: %w", err)
 	}
 
+	if spec.Maintenance {
+		err = uploadNewServer(uploadCtx, serverName, imported, modpackSource, wait.timeout)
+		if err != nil {
+			if errors.Is(uploadCtx.Err(), context.Canceled) {
+				offerRollback(serverName)
+			}
+			return err
+		}
+
+		// The readiness wait gets the full timeout after the upload
+		cancel()
+		ctx, cancel = context.WithTimeout(uploadCtx, wait.timeout)
+		defer cancel()
+	}
+
+	if wait.noWait {
+		fmt.Fprintf(os.Stderr, "Server %s is starting and will be available at %s\n", serverName, serverAddress(serverName, port))
+		printBedrockAddress(bedrockPort)
+		return nil
+	}
+
 	// Wait for pod to be ready
 	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
-	err = cli.K8sClient.WaitForReady(serverName)
+	err = cli.K8sClient.WaitForReady(ctx, serverName, printPhase)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			offerRollback(serverName)
+		}
 		return fmt.Errorf("server %s unable to start: %w", serverName, err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Server %s is ready at %s:%d\n", serverName, config.NodeAddress, port)
+	fmt.Fprintf(os.Stderr, "Server %s is ready at %s\n", serverName, serverAddress(serverName, port))
+	printBedrockAddress(bedrockPort)
+
+	return nil
+}
+
+// checkExposeType checks the server type supports how players reach the server. The
+// gateway needs Paper's PROXY protocol support, Bedrock players the Geyser plugin.
+func checkExposeType(expose exposeOptions, serverType string) error {
+	if slices.Contains(config.PaperServerTypes, serverType) {
+		return nil
+	}
+
+	if expose.gateway {
+		return fmt.Errorf("the gateway needs a %s server, %s cannot read its PROXY protocol header", strings.Join(config.PaperServerTypes, " or "), serverType)
+	}
+	if expose.bedrock {
+		return fmt.Errorf("bedrock players need a %s server to run Geyser, not %s", strings.Join(config.PaperServerTypes, " or "), serverType)
+	}
+
+	return nil
+}
+
+func printBedrockAddress(bedrockPort int32) {
+	if bedrockPort != 0 {
+		fmt.Fprintf(os.Stderr, "Bedrock players join at %s\n", bedrockAddress(bedrockPort))
+	}
+}
+
+// uploadNewServer uploads a world, a modpack or both into a server created in
+// maintenance mode and starts it. On failure the server stays in maintenance mode, so
+// it never generates a world of its own or starts without its mods.
+func uploadNewServer(ctx context.Context, serverName string, imported *world.Import, modpackSource string, timeout time.Duration) error {
+	fmt.Fprintln(os.Stderr, "Waiting for server volume...")
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := cli.K8sClient.WaitForMaintenance(waitCtx, serverName, printPhase)
+	if err != nil {
+		return fmt.Errorf("server %s unable to start: %w", serverName, err)
+	}
+
+	if imported != nil {
+		fmt.Fprintln(os.Stderr, "Uploading world...")
+		if err := uploadWorld(ctx, serverName, imported.WriteTar); err != nil {
+			return fmt.Errorf("%w, retry with: kubecraft server import-world %s <zip|dir>", err, serverName)
+		}
+	}
+	if modpackSource != "" {
+		fmt.Fprintln(os.Stderr, "Uploading modpack...")
+		if err := uploadModpack(ctx, serverName, modpackSource); err != nil {
+			return fmt.Errorf("%w, retry with: kubecraft server modpack update %s %s", err, serverName, modpackSource)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting server %s...\n", serverName)
+	if err := leaveMaintenance(ctx, serverName, 1); err != nil {
+		return fmt.Errorf("could not start server (%s): %w", serverName, err)
+	}
 
 	return nil
 }
@@ -86,9 +259,106 @@ func ValidateServerName(name string) error {
 		return fmt.Errorf("server name must start with a lowercase letter")
 	}
 
+	// `backup <server-name>` would run the subcommand instead of backing up the server
+	for _, sub := range backupCmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return fmt.Errorf("server name %q is reserved for kubecraft server backup %s", name, name)
+		}
+	}
+
+	return nil
+}
+
+// offerRollback asks whether to remove the resources of an interrupted create.
+// It runs after the command context is cancelled, so it uses a fresh one.
+func offerRollback(serverName string) {
+	fmt.Fprintln(os.Stderr)
+	if !confirm(fmt.Sprintf("Creation of %s was interrupted. Roll back the partially created server?", serverName)) {
+		fmt.Fprintf(os.Stderr, "Leaving %s in place. Remove it with: kubecraft server delete %s\n", serverName, serverName)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.RollbackTimeout)
+	defer cancel()
+
+	if err := cli.K8sClient.CleanupServer(ctx, serverName); err != nil {
+		fmt.Fprintf(os.Stderr, "Rollback of %s failed: %v\n", serverName, err)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Rolled back server %s\n", serverName)
+}
+
+// ValidateServerSpec checks the server options against the values the server image accepts
+func ValidateServerSpec(spec k8s.ServerSpec) error {
+	if !slices.Contains(config.AllowedServerTypes, spec.Type) {
+		return fmt.Errorf("type must be one of: %s", strings.Join(config.AllowedServerTypes, ", "))
+	}
+
+	if !versionPattern.MatchString(spec.Version) {
+		return fmt.Errorf("version %q must be a Minecraft release such as %s", spec.Version, config.DefaultServerVersion)
+	}
+
+	if !slices.Contains(config.AllowedGameModes, spec.GameMode) {
+		return fmt.Errorf("gamemode must be one of: %s", strings.Join(config.AllowedGameModes, ", "))
+	}
+
+	if !slices.Contains(config.AllowedDifficulties, spec.Difficulty) {
+		return fmt.Errorf("difficulty must be one of: %s", strings.Join(config.AllowedDifficulties, ", "))
+	}
+
+	if !slices.Contains(config.AllowedLevelTypes, spec.LevelType) {
+		return fmt.Errorf("level type must be one of: %s", strings.Join(config.AllowedLevelTypes, ", "))
+	}
+
+	if spec.MaxPlayers < config.MinPlayers || spec.MaxPlayers > config.MaxPlayers {
+		return fmt.Errorf("max players must be between %d and %d", config.MinPlayers, config.MaxPlayers)
+	}
+
+	if len(spec.MOTD) > config.MaxMOTDLength {
+		return fmt.Errorf("motd must be at most %d characters", config.MaxMOTDLength)
+	}
+	if strings.ContainsAny(spec.MOTD, "\r\n") {
+		return fmt.Errorf("motd must be a single line")
+	}
+
+	if len(spec.Seed) > config.MaxSeedLength {
+		return fmt.Errorf("seed must be at most %d characters", config.MaxSeedLength)
+	}
+	if strings.ContainsFunc(spec.Seed, unicode.IsSpace) {
+		return fmt.Errorf("seed must not contain whitespace")
+	}
+
+	// A server has to be up long enough for players to join
+	if spec.IdleTimeout < 0 || (spec.IdleTimeout > 0 && spec.IdleTimeout < config.MinIdleTimeout) {
+		return fmt.Errorf("idle timeout must be 0 (never) or at least %s", formatIdleTimeout(config.MinIdleTimeout))
+	}
+
+	// Hardcore only exists for survival (the game forces hard difficulty itself)
+	if spec.Hardcore && spec.GameMode != "survival" {
+		return fmt.Errorf("hardcore requires --gamemode survival")
+	}
+
 	return nil
 }
 
 func init() {
+	createCmd.Flags().StringVar(&createSpec.Type, "type", createSpec.Type, "Server software ("+strings.Join(config.AllowedServerTypes, "|")+")")
+	createCmd.Flags().StringVar(&createSpec.Version, "version", createSpec.Version, "Minecraft version")
+	createCmd.Flags().StringVar(&createSpec.GameMode, "gamemode", createSpec.GameMode, "Game mode ("+strings.Join(config.AllowedGameModes, "|")+")")
+	createCmd.Flags().StringVar(&createSpec.Difficulty, "difficulty", createSpec.Difficulty, "Difficulty ("+strings.Join(config.AllowedDifficulties, "|")+")")
+	createCmd.Flags().IntVar(&createSpec.MaxPlayers, "max-players", createSpec.MaxPlayers, "Maximum number of players")
+	createCmd.Flags().StringVar(&createSpec.MOTD, "motd", createSpec.MOTD, "Message shown in the server list")
+	createCmd.Flags().StringVar(&createSpec.Seed, "seed", createSpec.Seed, "World seed (random if empty)")
+	createCmd.Flags().StringVar(&createSpec.LevelType, "level-type", createSpec.LevelType, "World type ("+strings.Join(config.AllowedLevelTypes, "|")+")")
+	createCmd.Flags().BoolVar(&createSpec.PVP, "pvp", createSpec.PVP, "Allow players to damage each other")
+	createCmd.Flags().BoolVar(&createSpec.Hardcore, "hardcore", createSpec.Hardcore, "Hardcore mode (survival only)")
+	createCmd.Flags().DurationVar(&createSpec.IdleTimeout, "idle-timeout", createSpec.IdleTimeout, "Stop the server after this long without players, e.g. 30m (0 never)")
+	createCmd.Flags().BoolVar(&createExpose.gateway, "gateway", false, "Join by hostname <server>.<user>.<domain> through the gateway instead of a port")
+	createCmd.Flags().BoolVar(&createExpose.bedrock, "bedrock", false, "Let Bedrock Edition players join too, through Geyser on a UDP port")
+	createCmd.Flags().StringVar(&createWorld, "world", "", "Existing world to start with, as a zip archive or directory")
+	createCmd.Flags().StringVar(&createModpack, "modpack", "", "Modrinth modpack (.mrpack) to install, it sets the type and version")
+	addWaitFlags(createCmd, &createWait)
+
 	serverCmd.AddCommand(createCmd)
 }
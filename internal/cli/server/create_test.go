@@ -1,7 +1,18 @@
 package server
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestValidateServerName_Valid(t *testing.T) {
@@ -68,6 +79,14 @@ func TestValidateServerName_SpecialCharsRejected(t *testing.T) {
 	}
 }
 
+func TestValidateServerName_BackupSubcommandsReserved(t *testing.T) {
+	for _, name := range []string{"schedule", "list", "verify", "restore"} {
+		if err := ValidateServerName(name); err == nil {
+			t.Errorf("ValidateServerName(%q) expected error, got nil", name)
+		}
+	}
+}
+
 func TestValidateServerName_MustStartWithLetter(t *testing.T) {
 	invalidNames := []string{"1server", "123", "9abc"}
 
@@ -80,3 +99,276 @@ func TestValidateServerName_MustStartWithLetter(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateServerSpec_Default(t *testing.T) {
+	if err := ValidateServerSpec(k8s.DefaultServerSpec()); err != nil {
+		t.Errorf("ValidateServerSpec(default) error = %v, want nil", err)
+	}
+}
+
+func TestValidateServerSpec_Valid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *k8s.ServerSpec)
+	}{
+		{"creative", func(s *k8s.ServerSpec) { s.GameMode = "creative" }},
+		{"fabric", func(s *k8s.ServerSpec) { s.Type = "fabric" }},
+		{"older version", func(s *k8s.ServerSpec) { s.Version = "1.20" }},
+		{"hard difficulty", func(s *k8s.ServerSpec) { s.Difficulty = "hard" }},
+		{"flat world", func(s *k8s.ServerSpec) { s.LevelType = "flat" }},
+		{"max players upper bound", func(s *k8s.ServerSpec) { s.MaxPlayers = 20 }},
+		{"numeric seed", func(s *k8s.ServerSpec) { s.Seed = "-4172144997902289642" }},
+		{"empty motd", func(s *k8s.ServerSpec) { s.MOTD = "" }},
+		{"hardcore survival", func(s *k8s.ServerSpec) { s.Hardcore = true; s.Difficulty = "hard" }},
+		{"idle timeout", func(s *k8s.ServerSpec) { s.IdleTimeout = 30 * time.Minute }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := k8s.DefaultServerSpec()
+			tt.modify(&spec)
+			if err := ValidateServerSpec(spec); err != nil {
+				t.Errorf("ValidateServerSpec() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidateServerSpec_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *k8s.ServerSpec)
+	}{
+		{"unknown type", func(s *k8s.ServerSpec) { s.Type = "spigot" }},
+		{"empty version", func(s *k8s.ServerSpec) { s.Version = "" }},
+		{"snapshot version", func(s *k8s.ServerSpec) { s.Version = "24w14a" }},
+		{"latest version", func(s *k8s.ServerSpec) { s.Version = "latest" }},
+		{"unknown gamemode", func(s *k8s.ServerSpec) { s.GameMode = "hardcore" }},
+		{"uppercase gamemode", func(s *k8s.ServerSpec) { s.GameMode = "Creative" }},
+		{"unknown difficulty", func(s *k8s.ServerSpec) { s.Difficulty = "insane" }},
+		{"unknown level type", func(s *k8s.ServerSpec) { s.LevelType = "superflat" }},
+		{"zero players", func(s *k8s.ServerSpec) { s.MaxPlayers = 0 }},
+		{"too many players", func(s *k8s.ServerSpec) { s.MaxPlayers = 21 }},
+		{"multiline motd", func(s *k8s.ServerSpec) { s.MOTD = "line one\nline two" }},
+		{"long motd", func(s *k8s.ServerSpec) { s.MOTD = strings.Repeat("a", 60) }},
+		{"seed with spaces", func(s *k8s.ServerSpec) { s.Seed = "my seed" }},
+		{"long seed", func(s *k8s.ServerSpec) { s.Seed = strings.Repeat("1", 33) }},
+		{"hardcore creative", func(s *k8s.ServerSpec) { s.Hardcore = true; s.GameMode = "creative" }},
+		{"short idle timeout", func(s *k8s.ServerSpec) { s.IdleTimeout = time.Minute }},
+		{"negative idle timeout", func(s *k8s.ServerSpec) { s.IdleTimeout = -time.Hour }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := k8s.DefaultServerSpec()
+			tt.modify(&spec)
+			if err := ValidateServerSpec(spec); err == nil {
+				t.Error("ValidateServerSpec() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestExecuteCreate_CreatesServer(t *testing.T) {
+	clientset := useFakeCluster(t, readyServerPod("myserver"))
+
+	spec := k8s.DefaultServerSpec()
+	spec.GameMode = "creative"
+
+	if err := executeCreate(context.Background(), "myserver", spec, exposeOptions{}, "", "", testWait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	sts, err := clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("StatefulSet not created: %v", err)
+	}
+	if sts.Labels["user"] != fakeUsername {
+		t.Errorf("StatefulSet user label = %q, want %q", sts.Labels["user"], fakeUsername)
+	}
+
+	svc, err := clientset.CoreV1().Services(namespace).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Service not created: %v", err)
+	}
+	if svc.Spec.Ports[0].NodePort != config.McNodePortRangeMin {
+		t.Errorf("NodePort = %d, want %d", svc.Spec.Ports[0].NodePort, config.McNodePortRangeMin)
+	}
+}
+
+func TestExecuteCreate_Gateway(t *testing.T) {
+	clientset := useFakeCluster(t, readyServerPod("myserver"))
+	origDomain := config.GatewayDomain
+	config.GatewayDomain = "mc.example.com"
+	t.Cleanup(func() { config.GatewayDomain = origDomain })
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{gateway: true}, "", "", testWait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	svc, err := clientset.CoreV1().Services(namespace).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Service not created: %v", err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP || svc.Spec.Ports[0].NodePort != 0 {
+		t.Errorf("service = %s on nodeport %d, want ClusterIP without a nodeport", svc.Spec.Type, svc.Spec.Ports[0].NodePort)
+	}
+	if got, want := serverAddress("myserver", 0), "myserver."+fakeUsername+".mc.example.com"; got != want {
+		t.Errorf("serverAddress() = %q, want %q", got, want)
+	}
+}
+
+func TestExecuteCreate_GatewayWithoutDomain(t *testing.T) {
+	clientset := useFakeCluster(t)
+	origDomain := config.GatewayDomain
+	config.GatewayDomain = ""
+	t.Cleanup(func() { config.GatewayDomain = origDomain })
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{gateway: true}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error without a gateway domain, got nil")
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls without a gateway, want 0", len(actions))
+	}
+}
+
+func TestExecuteCreate_Bedrock(t *testing.T) {
+	clientset := useFakeCluster(t, readyServerPod("myserver"))
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{bedrock: true}, "", "", testWait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+
+	svc, err := clientset.CoreV1().Services(config.NamespacePrefix+fakeUsername).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Service not created: %v", err)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[1].NodePort != config.BedrockNodePortRangeMin {
+		t.Errorf("ports = %+v, want a Bedrock port on %d", svc.Spec.Ports, config.BedrockNodePortRangeMin)
+	}
+}
+
+func TestExecuteCreate_BedrockThroughGatewayFails(t *testing.T) {
+	clientset := useFakeCluster(t)
+	origDomain := config.GatewayDomain
+	config.GatewayDomain = "mc.example.com"
+	t.Cleanup(func() { config.GatewayDomain = origDomain })
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{gateway: true, bedrock: true}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error for bedrock through the gateway, got nil")
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls, want 0", len(actions))
+	}
+}
+
+func TestExecuteCreate_BedrockNeedsPaper(t *testing.T) {
+	clientset := useFakeCluster(t)
+
+	spec := k8s.DefaultServerSpec()
+	spec.Type = "forge"
+
+	if err := executeCreate(context.Background(), "myserver", spec, exposeOptions{bedrock: true}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error for bedrock on forge, got nil")
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls, want 0", len(actions))
+	}
+}
+
+func TestExecuteCreate_InvalidSpecTouchesNothing(t *testing.T) {
+	clientset := useFakeCluster(t)
+
+	spec := k8s.DefaultServerSpec()
+	spec.GameMode = "godmode"
+
+	if err := executeCreate(context.Background(), "myserver", spec, exposeOptions{}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error for invalid gamemode, got nil")
+	}
+
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls for an invalid spec, want 0", len(actions))
+	}
+}
+
+func TestExecuteCreate_ExistingServerFails(t *testing.T) {
+	useFakeCluster(t, readyServerPod("myserver"))
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", testWait); err != nil {
+		t.Fatalf("First executeCreate() error = %v", err)
+	}
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", testWait); err == nil {
+		t.Error("Second executeCreate() expected error for existing server, got nil")
+	}
+}
+
+func TestExecuteCreate_NoWaitSkipsReadiness(t *testing.T) {
+	// No ready pod exists, so waiting would time out
+	useFakeCluster(t)
+
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", wait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+}
+
+func TestExecuteCreate_Timeout(t *testing.T) {
+	useFakeCluster(t)
+
+	wait := waitOptions{timeout: 50 * time.Millisecond}
+	err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", wait)
+	if err == nil {
+		t.Fatal("executeCreate() expected timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("executeCreate() error = %q, want a timeout", err)
+	}
+}
+
+func TestExecuteCreate_InterruptOffersRollback(t *testing.T) {
+	clientset := useFakeCluster(t)
+	answerPrompts(t, "y\n")
+
+	// Cancel as soon as the statefulset exists, like Ctrl-C during the readiness wait
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset.PrependReactor("create", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+
+	if err := executeCreate(ctx, "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error after interrupt, got nil")
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	if _, err := clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), "myserver", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("StatefulSet still exists after rollback (err = %v)", err)
+	}
+	if _, err := clientset.CoreV1().Services(namespace).Get(context.Background(), "myserver", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Service still exists after rollback (err = %v)", err)
+	}
+}
+
+func TestExecuteCreate_InterruptKeepsServerWhenDeclined(t *testing.T) {
+	clientset := useFakeCluster(t)
+	answerPrompts(t, "n\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset.PrependReactor("create", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+
+	if err := executeCreate(ctx, "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error after interrupt, got nil")
+	}
+
+	namespace := config.NamespacePrefix + fakeUsername
+	if _, err := clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), "myserver", metav1.GetOptions{}); err != nil {
+		t.Errorf("StatefulSet removed although rollback was declined: %v", err)
+	}
+}
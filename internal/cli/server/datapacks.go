@@ -0,0 +1,383 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+const (
+	datapacksDir = "/data/" + world.DatapacksDir
+
+	// datapacksListScript writes a "<KiB>\t<name>" line per datapack, zips and directories alike
+	datapacksListScript = "cd " + datapacksDir + " 2>/dev/null && du -sk -- * 2>/dev/null || true"
+	// The server loads every zip in the directory, a half-uploaded one must never be seen
+	datapackUploadScript = "mkdir -p " + datapacksDir + " && cd " + datapacksDir + " && cat > .%[1]s.part && mv .%[1]s.part %[1]s"
+	datapackRemoveScript = "cd " + datapacksDir + " && rm -rf -- %s"
+)
+
+var (
+	datapacksAddForce bool
+	datapacksAddWait  waitOptions
+	datapacksRmWait   waitOptions
+)
+
+var datapacksCmd = &cobra.Command{
+	Use:   "datapacks",
+	Short: "Manage the datapacks of a server's world",
+}
+
+var datapacksListCmd = &cobra.Command{
+	Use:   "list <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "List the datapacks in a server's world",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeDatapacksList(cmd.Context(), serverName, os.Stdout)
+	},
+}
+
+var datapacksAddCmd = &cobra.Command{
+	Use:   "add <server-name> <zip|url>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Add a datapack to a server's world",
+	Long:  "Copies a datapack zip, from a file or an http(s) URL, into world/datapacks, replacing a pack of the same name. Its pack.mcmeta has to declare the pack format of the server's Minecraft version. A running server reloads its datapacks, or restarts for packs that change world generation, a stopped one loads it when it next starts.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, source := args[0], args[1]
+		return executeDatapacksAdd(cmd.Context(), serverName, source, datapacksAddForce, datapacksAddWait)
+	},
+}
+
+var datapacksRemoveCmd = &cobra.Command{
+	Use:   "remove <server-name> <name>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Remove a datapack from a server's world",
+	Long:  "Deletes a datapack, as named by datapacks list, from world/datapacks. A running server reloads its datapacks. Structures and biomes a removed pack generated stay in the world.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, name := args[0], args[1]
+		return executeDatapacksRemove(cmd.Context(), serverName, name, datapacksRmWait)
+	},
+}
+
+// datapack is a datapack in a server's world
+type datapack struct {
+	Name string
+	Size int64
+}
+
+func executeDatapacksList(ctx context.Context, serverName string, out io.Writer) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check if server is running: %w", err)
+	}
+
+	var listing bytes.Buffer
+	if running {
+		err = execInServer(ctx, serverName, datapacksListScript, nil, &listing)
+	} else {
+		fmt.Fprintf(os.Stderr, "Server %s is stopped, reading its world through a helper pod...\n", serverName)
+		err = execInHelper(ctx, serverName, datapacksListScript, nil, &listing)
+	}
+	if err != nil {
+		return fmt.Errorf("could not list datapacks: %w", err)
+	}
+
+	packs := parseDatapacks(&listing)
+	if len(packs) == 0 {
+		fmt.Fprintf(os.Stderr, "No datapacks on %s\n", serverName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "NAME\tSIZE\n")
+	for _, p := range packs {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, formatBytes(p.Size))
+	}
+	return w.Flush()
+}
+
+func executeDatapacksAdd(ctx context.Context, serverName string, source string, force bool, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+
+	name, err := packFileName(source)
+	if err != nil {
+		return err
+	}
+	file, cleanup, err := fetchPack(ctx, source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	pack, err := world.OpenPack(file)
+	if err != nil {
+		return fmt.Errorf("invalid datapack %s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "Datapack %s %q (pack format %s)\n", name, pack.Description, formatPackFormats(pack))
+
+	format, known := world.DatapackFormat(version)
+	switch {
+	case !known:
+		fmt.Fprintf(os.Stderr, "Warning: the datapack format of Minecraft %s is unknown, the pack may not load\n", version)
+	case !pack.Supports(format) && !force:
+		return fmt.Errorf("the datapack is for pack format %s but Minecraft %s loads format %d, pass --force to add it anyway", formatPackFormats(pack), version, format)
+	case !pack.Supports(format):
+		fmt.Fprintf(os.Stderr, "Warning: Minecraft %s loads pack format %d, the pack may not work\n", version, format)
+	}
+
+	running, err := editServerFiles(ctx, serverName, wait.timeout, func() error {
+		fmt.Fprintf(os.Stderr, "Uploading %s...\n", name)
+		return uploadDatapack(ctx, serverName, name, file)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !running {
+		fmt.Fprintf(os.Stderr, "Datapack %s added, it loads when %s next starts\n", name, serverName)
+		return nil
+	}
+	if pack.Worldgen {
+		fmt.Fprintf(os.Stderr, "Datapack %s changes world generation, restarting %s to load it...\n", name, serverName)
+		return restartServer(ctx, serverName, wait)
+	}
+
+	return reloadDatapacks(ctx, serverName)
+}
+
+func executeDatapacksRemove(ctx context.Context, serverName string, name string, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	running, err := editServerFiles(ctx, serverName, wait.timeout, func() error {
+		var listing bytes.Buffer
+		if err := execInServer(ctx, serverName, datapacksListScript, nil, &listing); err != nil {
+			return fmt.Errorf("could not list datapacks: %w", err)
+		}
+		if !slices.ContainsFunc(parseDatapacks(&listing), func(p datapack) bool { return p.Name == name }) {
+			return fmt.Errorf("datapack %s is not on %s, see: kubecraft server datapacks list %s", name, serverName, serverName)
+		}
+
+		if err := execInServer(ctx, serverName, fmt.Sprintf(datapackRemoveScript, shellQuote(name)), nil, nil); err != nil {
+			return fmt.Errorf("could not remove datapack: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !running {
+		fmt.Fprintf(os.Stderr, "Datapack %s removed\n", name)
+		return nil
+	}
+
+	return reloadDatapacks(ctx, serverName)
+}
+
+// parseDatapacks reads the output of datapacksListScript
+func parseDatapacks(r io.Reader) []datapack {
+	var packs []datapack
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		size, name, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		kib, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			continue
+		}
+		packs = append(packs, datapack{Name: name, Size: kib * 1024})
+	}
+
+	return packs
+}
+
+func formatPackFormats(pack *world.Pack) string {
+	if pack.MinFormat == pack.MaxFormat {
+		return strconv.Itoa(pack.MinFormat)
+	}
+	return fmt.Sprintf("%d-%d", pack.MinFormat, pack.MaxFormat)
+}
+
+// uploadDatapack copies a datapack zip into the world's datapacks directory
+func uploadDatapack(ctx context.Context, serverName string, name string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("could not open datapack: %w", err)
+	}
+	defer f.Close()
+
+	if err := execInServer(ctx, serverName, fmt.Sprintf(datapackUploadScript, shellQuote(name)), f, nil); err != nil {
+		return fmt.Errorf("could not copy datapack to server: %w", err)
+	}
+
+	return nil
+}
+
+// reloadDatapacks has a running server load its datapacks again, enabling new ones and
+// dropping removed ones
+func reloadDatapacks(ctx context.Context, serverName string) error {
+	session, err := connectRcon(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	fmt.Fprintf(os.Stderr, "Reloading datapacks on %s...\n", serverName)
+	// Namespaced, as plain reload is Bukkit's plugin reload on Paper and Purpur
+	if _, err := session.Execute("minecraft:reload"); err != nil {
+		return fmt.Errorf("could not reload datapacks: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Datapacks reloaded on %s\n", serverName)
+	return nil
+}
+
+// editServerFiles runs edit while the server's files can be written: right away on a
+// running server, in maintenance mode on a stopped one, which is stopped again after.
+// It reports whether the server is running.
+func editServerFiles(ctx context.Context, serverName string, timeout time.Duration, edit func() error) (bool, error) {
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return false, fmt.Errorf("could not check if server is running: %w", err)
+	}
+	if running {
+		return true, edit()
+	}
+
+	fmt.Fprintf(os.Stderr, "Server %s is stopped, starting it in maintenance mode...\n", serverName)
+	if err := enterMaintenance(ctx, serverName, timeout); err != nil {
+		leaveMaintenance(ctx, serverName, 0)
+		return false, err
+	}
+	if err := edit(); err != nil {
+		leaveMaintenance(ctx, serverName, 0)
+		return false, err
+	}
+
+	return false, leaveMaintenance(ctx, serverName, 0)
+}
+
+// packFileName is the zip name a pack given as a file or URL is stored under
+func packFileName(source string) (string, error) {
+	name := filepath.Base(source)
+	if u, ok := packURL(source); ok {
+		name = path.Base(u.Path)
+	}
+
+	if !strings.HasSuffix(strings.ToLower(name), ".zip") || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%s is not a pack zip, the file name has to end in .zip", source)
+	}
+
+	return name, nil
+}
+
+// packURL parses source if it is an http(s) URL rather than a file
+func packURL(source string) (*url.URL, bool) {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// fetchPack returns a local copy of a pack, downloading it if source is a URL. cleanup
+// removes the download.
+func fetchPack(ctx context.Context, source string) (file string, cleanup func(), err error) {
+	if _, ok := packURL(source); !ok {
+		return source, func() {}, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Downloading %s...\n", source)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not build download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not download %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("could not download %s: status %d", source, resp.StatusCode)
+	}
+
+	f, err := os.CreateTemp("", "kubecraft-pack-*.zip")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create download file: %w", err)
+	}
+	cleanup = func() { os.Remove(f.Name()) }
+
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("could not download %s: %w", source, err)
+	}
+
+	return f.Name(), cleanup, nil
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	datapacksAddCmd.Flags().BoolVar(&datapacksAddForce, "force", false, "Add the pack even if it declares another pack format than the server's version")
+	addWaitFlags(datapacksAddCmd, &datapacksAddWait)
+	addWaitFlags(datapacksRemoveCmd, &datapacksRmWait)
+
+	datapacksCmd.AddCommand(datapacksListCmd)
+	datapacksCmd.AddCommand(datapacksAddCmd)
+	datapacksCmd.AddCommand(datapacksRemoveCmd)
+	serverCmd.AddCommand(datapacksCmd)
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// writeTestPack writes a pack zip called name declaring format, with files next to
+// its pack.mcmeta
+func writeTestPack(t *testing.T, name string, format int, files ...string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("failed to create pack: %v", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	w, _ := zw.Create(world.PackMeta)
+	fmt.Fprintf(w, `{"pack": {"pack_format": %d, "description": "Test pack"}}`, format)
+	for _, file := range files {
+		zw.Create(file)
+	}
+
+	return p
+}
+
+// fakeDatapackServer is a 1.21.4 server, running or stopped with the pod it gets in
+// maintenance mode
+func fakeDatapackServer(t *testing.T, exec *fakeExec, running bool) (*fake.Clientset, *fakeRcon) {
+	t.Helper()
+
+	objects := []runtime.Object{fakeWorldServer("myserver", "1.21.4", 1), fakeNodePortService("myserver", 30001)}
+	if !running {
+		objects = []runtime.Object{fakeWorldServer("myserver", "1.21.4", 0), fakeNodePortService("myserver", 30001), maintenancePod("myserver")}
+	}
+	clientset := useFakeCluster(t, objects...)
+	useFakeExec(t, exec)
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+
+	return clientset, session
+}
+
+func TestExecuteDatapacksAdd_RunningServerReloads(t *testing.T) {
+	exec := &fakeExec{}
+	_, session := fakeDatapackServer(t, exec, true)
+
+	pack := writeTestPack(t, "Player's Tweaks.zip", 61, "data/tweaks/function/tick.mcfunction")
+	if err := executeDatapacksAdd(context.Background(), "myserver", pack, false, testWait); err != nil {
+		t.Fatalf("executeDatapacksAdd() error = %v", err)
+	}
+
+	want := []string{fmt.Sprintf(datapackUploadScript, `'Player'\''s Tweaks.zip'`)}
+	if !slices.Equal(exec.scripts, want) {
+		t.Errorf("scripts = %q, want %q", exec.scripts, want)
+	}
+	if !slices.Equal(session.commands, []string{"minecraft:reload"}) {
+		t.Errorf("commands = %v, want a reload", session.commands)
+	}
+}
+
+func TestExecuteDatapacksAdd_WorldgenRestarts(t *testing.T) {
+	exec := &fakeExec{}
+	clientset, session := fakeDatapackServer(t, exec, true)
+
+	pack := writeTestPack(t, "Terralith.zip", 61, "data/terralith/worldgen/biome/desert.json")
+	if err := executeDatapacksAdd(context.Background(), "myserver", pack, false, waitOptions{noWait: true}); err != nil {
+		t.Fatalf("executeDatapacksAdd() error = %v", err)
+	}
+
+	if !slices.Equal(session.commands, []string{"save-all flush"}) {
+		t.Errorf("commands = %v, want the server saved before stopping", session.commands)
+	}
+	if sts := getStatefulSet(t, clientset, "myserver"); *sts.Spec.Replicas != 1 {
+		t.Errorf("Replicas = %d, want the server started again", *sts.Spec.Replicas)
+	}
+}
+
+func TestExecuteDatapacksAdd_StoppedServer(t *testing.T) {
+	exec := &fakeExec{}
+	_, session := fakeDatapackServer(t, exec, false)
+
+	pack := writeTestPack(t, "tweaks.zip", 61)
+	if err := executeDatapacksAdd(context.Background(), "myserver", pack, false, testWait); err != nil {
+		t.Fatalf("executeDatapacksAdd() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{fmt.Sprintf(datapackUploadScript, "'tweaks.zip'")}) {
+		t.Errorf("scripts = %q, want the upload", exec.scripts)
+	}
+	if len(session.commands) != 0 {
+		t.Errorf("commands = %v, want none on a stopped server", session.commands)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(context.Background(), "myserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Status != "stopped" || details.Spec.Maintenance {
+		t.Errorf("Status = %s, Maintenance = %v, want the server stopped again", details.Status, details.Spec.Maintenance)
+	}
+}
+
+func TestExecuteDatapacksAdd_WrongFormat(t *testing.T) {
+	exec := &fakeExec{}
+	fakeDatapackServer(t, exec, true)
+
+	pack := writeTestPack(t, "old.zip", 48)
+	if err := executeDatapacksAdd(context.Background(), "myserver", pack, false, testWait); err == nil {
+		t.Fatal("executeDatapacksAdd() expected error for a 1.21.1 pack on 1.21.4, got nil")
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %q, want nothing uploaded", exec.scripts)
+	}
+
+	if err := executeDatapacksAdd(context.Background(), "myserver", pack, true, testWait); err != nil {
+		t.Fatalf("executeDatapacksAdd() with force error = %v", err)
+	}
+	if len(exec.scripts) != 1 {
+		t.Errorf("scripts = %q, want the upload with force", exec.scripts)
+	}
+}
+
+func TestExecuteDatapacksAdd_Invalid(t *testing.T) {
+	notZip := filepath.Join(t.TempDir(), "tweaks.zip")
+	os.WriteFile(notZip, []byte("not a zip"), 0644)
+
+	for _, source := range []string{notZip, writeTestPack(t, "tweaks.jar", 61), "./missing.zip"} {
+		exec := &fakeExec{}
+		fakeDatapackServer(t, exec, true)
+
+		if err := executeDatapacksAdd(context.Background(), "myserver", source, true, testWait); err == nil {
+			t.Errorf("executeDatapacksAdd(%s) expected error, got nil", source)
+		}
+		if len(exec.scripts) != 0 {
+			t.Errorf("scripts = %q, want nothing uploaded for %s", exec.scripts, source)
+		}
+	}
+}
+
+func TestExecuteDatapacksAdd_FromURL(t *testing.T) {
+	pack, _ := os.ReadFile(writeTestPack(t, "tweaks.zip", 61))
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packs/Tweaks-1.2.zip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(pack)
+	}))
+	defer registry.Close()
+
+	exec := &fakeExec{}
+	fakeDatapackServer(t, exec, true)
+
+	if err := executeDatapacksAdd(context.Background(), "myserver", registry.URL+"/packs/Tweaks-1.2.zip?download=1", false, testWait); err != nil {
+		t.Fatalf("executeDatapacksAdd() error = %v", err)
+	}
+	if !slices.Equal(exec.scripts, []string{fmt.Sprintf(datapackUploadScript, "'Tweaks-1.2.zip'")}) {
+		t.Errorf("scripts = %q, want the pack uploaded under its URL's name", exec.scripts)
+	}
+
+	if err := executeDatapacksAdd(context.Background(), "myserver", registry.URL+"/packs/missing.zip", false, testWait); err == nil {
+		t.Error("executeDatapacksAdd() expected error for a missing download, got nil")
+	}
+}
+
+func TestExecuteDatapacksRemove(t *testing.T) {
+	exec := &fakeExec{output: map[string]string{datapacksListScript: "12\ttweaks.zip\n8\tmy pack\n"}}
+	_, session := fakeDatapackServer(t, exec, true)
+
+	if err := executeDatapacksRemove(context.Background(), "myserver", "my pack", testWait); err != nil {
+		t.Fatalf("executeDatapacksRemove() error = %v", err)
+	}
+	want := []string{datapacksListScript, fmt.Sprintf(datapackRemoveScript, "'my pack'")}
+	if !slices.Equal(exec.scripts, want) {
+		t.Errorf("scripts = %q, want %q", exec.scripts, want)
+	}
+	if !slices.Equal(session.commands, []string{"minecraft:reload"}) {
+		t.Errorf("commands = %v, want a reload", session.commands)
+	}
+
+	exec.scripts = nil
+	if err := executeDatapacksRemove(context.Background(), "myserver", "missing.zip", testWait); err == nil {
+		t.Fatal("executeDatapacksRemove() expected error for a missing pack, got nil")
+	}
+	if !slices.Equal(exec.scripts, []string{datapacksListScript}) {
+		t.Errorf("scripts = %q, want only the listing", exec.scripts)
+	}
+}
+
+func TestExecuteDatapacksList_StoppedServerUsesHelper(t *testing.T) {
+	exec := &fakeExec{output: map[string]string{datapacksListScript: "12\ttweaks.zip\n2048\tTerralith.zip\n"}}
+	fakeDatapackServer(t, exec, false)
+
+	var out bytes.Buffer
+	if err := executeDatapacksList(context.Background(), "myserver", &out); err != nil {
+		t.Fatalf("executeDatapacksList() error = %v", err)
+	}
+
+	if !slices.Equal(exec.inHelper, []string{datapacksListScript}) {
+		t.Errorf("helper scripts = %q, want the listing", exec.inHelper)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "12.0 KiB") || !strings.Contains(lines[2], "2.0 MiB") {
+		t.Errorf("output = %q, want a header and both packs with their size", out.String())
+	}
+}
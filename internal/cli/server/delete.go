@@ -2,6 +2,7 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 
@@ -16,13 +17,13 @@ var deleteCmd = &cobra.Command{
 	Long:  "I'll think of this later",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
-		return executeDelete(serverName)
+		return executeDelete(cmd.Context(), serverName)
 	},
 }
 
-func executeDelete(serverName string) error {
+func executeDelete(ctx context.Context, serverName string) error {
 	// Verify server exists
-	serverExists, err := cli.K8sClient.ServerExists(serverName)
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
 	if err != nil {
 		return fmt.Errorf("could not check if server exists: %v", err)
 	}
@@ -44,7 +45,7 @@ func executeDelete(serverName string) error {
 
 	// Delete the server
 	fmt.Fprintf(os.Stderr, "Deleting server %s...\n", serverName)
-	err = cli.K8sClient.DeleteServer(serverName)
+	err = cli.K8sClient.DeleteServer(ctx, serverName)
 	if err != nil {
 		return fmt.Errorf("could not delete server: %v", err)
 	}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+// levelScript writes the overworld's level.dat to stdout
+const levelScript = "cat /data/world/level.dat"
+
+// ticksPerSecond is the game's fixed tick rate
+const ticksPerSecond = 20
+
+var describeWorld bool
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Show a server's settings and state",
+	Long:  "Shows how a server was created and whether it is running. With --world it also reads the world's level.dat from the server: seed, spawn, time, difficulty and datapacks. A stopped server is started in maintenance mode for that.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeDescribe(cmd.Context(), serverName, describeWorld, os.Stdout)
+	},
+}
+
+func executeDescribe(ctx context.Context, serverName string, showWorld bool, out io.Writer) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not describe server: %w", err)
+	}
+
+	var level *world.Level
+	if showWorld {
+		if level, err = readLevel(ctx, serverName); err != nil {
+			return err
+		}
+	}
+
+	spec := details.Spec
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", details.Name)
+	fmt.Fprintf(w, "Status:\t%s\n", details.Status)
+	fmt.Fprintf(w, "Address:\t%s\n", serverAddress(details.Name, details.NodePort))
+	if details.BedrockNodePort != 0 {
+		fmt.Fprintf(w, "Bedrock:\t%s (UDP)\n", bedrockAddress(details.BedrockNodePort))
+	}
+	fmt.Fprintf(w, "Age:\t%s\n", formatAge(details.Age))
+	fmt.Fprintf(w, "Type:\t%s\n", spec.Type)
+	if spec.LoaderVersion != "" {
+		fmt.Fprintf(w, "Loader:\t%s\n", spec.LoaderVersion)
+	}
+	fmt.Fprintf(w, "Version:\t%s\n", spec.Version)
+	if spec.Modpack != "" {
+		fmt.Fprintf(w, "Modpack:\t%s\n", spec.Modpack)
+	}
+	fmt.Fprintf(w, "Game mode:\t%s\n", spec.GameMode)
+	fmt.Fprintf(w, "Difficulty:\t%s\n", spec.Difficulty)
+	fmt.Fprintf(w, "Max players:\t%d\n", spec.MaxPlayers)
+	fmt.Fprintf(w, "MOTD:\t%s\n", spec.MOTD)
+	fmt.Fprintf(w, "Level type:\t%s\n", spec.LevelType)
+	fmt.Fprintf(w, "PvP:\t%t\n", spec.PVP)
+	fmt.Fprintf(w, "Hardcore:\t%t\n", spec.Hardcore)
+	fmt.Fprintf(w, "Idle timeout:\t%s\n", formatIdleTimeout(spec.IdleTimeout))
+	if !details.IdleStoppedAt.IsZero() {
+		fmt.Fprintf(w, "Idle stop:\t%s ago, no players were online\n", formatAge(details.IdleStoppedAt))
+	}
+	if spec.Maintenance {
+		fmt.Fprintf(w, "Maintenance:\t%t\n", spec.Maintenance)
+	}
+
+	if level != nil {
+		version := level.Version
+		if version == "" {
+			version = "before 1.9"
+		}
+		played := time.Duration(level.GameTime/ticksPerSecond) * time.Second
+
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "World:\t%s\n", level.Name)
+		fmt.Fprintf(w, "Seed:\t%d\n", level.Seed)
+		fmt.Fprintf(w, "Spawn:\t%d, %d, %d\n", level.SpawnX, level.SpawnY, level.SpawnZ)
+		fmt.Fprintf(w, "Game time:\t%d ticks (%s)\n", level.GameTime, played)
+		fmt.Fprintf(w, "Day:\t%d\n", level.Day())
+		fmt.Fprintf(w, "Difficulty:\t%s\n", level.Difficulty)
+		fmt.Fprintf(w, "Saved by:\t%s (data version %d)\n", version, level.DataVersion)
+		fmt.Fprintf(w, "Datapacks:\t%s\n", strings.Join(level.DataPacks, ", "))
+	}
+
+	return w.Flush()
+}
+
+// readLevel reads the level.dat of the server's world. A running server saves first,
+// so the time is current, a stopped one is started in maintenance mode for the read.
+func readLevel(ctx context.Context, serverName string) (*world.Level, error) {
+	release, err := quiesceWorld(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var data bytes.Buffer
+	if err := execInServer(ctx, serverName, levelScript, nil, &data); err != nil {
+		return nil, fmt.Errorf("could not read %s from server: %w", world.LevelFile, err)
+	}
+
+	level, err := world.ReadLevel(&data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read world: %w", err)
+	}
+
+	return level, nil
+}
+
+func init() {
+	describeCmd.Flags().BoolVar(&describeWorld, "world", false, "Also show details of the world from its level.dat")
+
+	serverCmd.AddCommand(describeCmd)
+}
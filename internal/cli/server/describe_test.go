@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/nbt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeNodePortService returns the Service of serverName exposed on nodePort
+func fakeNodePortService(serverName string, nodePort int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: serverName, Namespace: config.NamespacePrefix + fakeUsername},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: config.CommonLabelValuePod, NodePort: nodePort}},
+		},
+	}
+}
+
+// testLevelDat returns a gzipped level.dat of a world on its fourth day
+func testLevelDat(t *testing.T) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	level := nbt.Compound{"Data": nbt.Compound{
+		"LevelName":        "world",
+		"DataVersion":      int32(4671),
+		"Version":          nbt.Compound{"Name": "1.21.11"},
+		"WorldGenSettings": nbt.Compound{"seed": int64(8675309)},
+		"SpawnX":           int32(16),
+		"SpawnY":           int32(70),
+		"SpawnZ":           int32(-32),
+		"Time":             int64(72000),
+		"DayTime":          int64(78000),
+		"Difficulty":       int8(1),
+		"DataPacks":        nbt.Compound{"Enabled": nbt.List{"vanilla", "paper"}},
+	}}
+	if err := nbt.Write(zw, "", level); err != nil {
+		t.Fatalf("nbt.Write() error = %v", err)
+	}
+	zw.Close()
+	return buf.String()
+}
+
+func TestExecuteDescribe_ShowsSettings(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), fakeNodePortService("myserver", 30001))
+
+	var out bytes.Buffer
+	if err := executeDescribe(context.Background(), "myserver", false, &out); err != nil {
+		t.Fatalf("executeDescribe() error = %v", err)
+	}
+
+	for _, want := range []string{"Name:", "myserver", "running", ":30001", "1.21.11"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Seed:") {
+		t.Errorf("output shows world details without --world:\n%s", out.String())
+	}
+}
+
+func TestExecuteDescribe_World(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), fakeNodePortService("myserver", 30001))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+	exec := &fakeExec{world: map[string]string{"world/level.dat": testLevelDat(t)}}
+	useFakeExec(t, exec)
+
+	var out bytes.Buffer
+	if err := executeDescribe(context.Background(), "myserver", true, &out); err != nil {
+		t.Fatalf("executeDescribe() error = %v", err)
+	}
+
+	for _, want := range []string{"8675309", "16, 70, -32", "72000 ticks (1h0m0s)", "Day:", "easy", "data version 4671", "vanilla, paper"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if !regexp.MustCompile(`Day:\s+3\n`).MatchString(out.String()) {
+		t.Errorf("output does not show day 3:\n%s", out.String())
+	}
+	if session.commands[len(session.commands)-1] != "save-on" {
+		t.Errorf("commands = %v, want saving turned back on", session.commands)
+	}
+}
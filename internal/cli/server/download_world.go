@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+var downloadWorldOutput string
+
+var downloadWorldCmd = &cobra.Command{
+	Use:   "download-world <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Download a server's world as a single-player save",
+	Long:  "Saves the world and copies it out of the server into a zip that opens in the Minecraft client: unzip it into the saves folder. The nether and the end, which the server keeps in world_nether and world_the_end, are moved back into the world.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeDownloadWorld(cmd.Context(), serverName, downloadWorldOutput)
+	},
+}
+
+func executeDownloadWorld(ctx context.Context, serverName string, output string) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	if output == "" {
+		output = serverName + ".zip"
+	}
+	// The folder the save unpacks to, and its name in the client's world list
+	folder := strings.TrimSuffix(filepath.Base(output), ".zip")
+
+	// Write next to the target and rename, so a failed download never looks complete
+	partial := output + ".partial"
+	file, err := os.Create(partial)
+	if err != nil {
+		return fmt.Errorf("could not create zip file: %w", err)
+	}
+	defer os.Remove(partial)
+
+	export, err := exportWorld(ctx, serverName, folder, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("could not write zip file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(partial, output); err != nil {
+		return fmt.Errorf("could not write zip file: %w", err)
+	}
+
+	if len(export.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %s, a single-player save only holds one world\n", strings.Join(export.Skipped, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "Downloaded %s (%d files, %s) to %s, unzip it into your Minecraft saves folder\n", serverName, export.Files, formatBytes(export.Size), output)
+	return nil
+}
+
+// exportWorld copies the server's world into a single-player zip on w
+func exportWorld(ctx context.Context, serverName string, folder string, w io.Writer) (*world.Export, error) {
+	release, err := quiesceWorld(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	fmt.Fprintf(os.Stderr, "Downloading world of %s...\n", serverName)
+	var export *world.Export
+	err = streamWorld(ctx, serverName, func(r io.Reader) error {
+		var err error
+		export, err = world.ExportZip(r, w, folder)
+		if err != nil {
+			return fmt.Errorf("could not write zip: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func init() {
+	downloadWorldCmd.Flags().StringVarP(&downloadWorldOutput, "output", "o", "", "File to write the world to (default <server-name>.zip)")
+
+	serverCmd.AddCommand(downloadWorldCmd)
+}
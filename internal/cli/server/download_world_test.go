@@ -0,0 +1,63 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExecuteDownloadWorld_SinglePlayerZip(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+	useFakeExec(t, &fakeExec{world: map[string]string{
+		"world/level.dat":                     "level",
+		"world_nether/level.dat":              "nether level",
+		"world_nether/DIM-1/region/r.0.0.mca": "nether",
+		"world_the_end/DIM1/region/r.0.0.mca": "end",
+	}})
+
+	output := filepath.Join(t.TempDir(), "Our Build.zip")
+	if err := executeDownloadWorld(context.Background(), "myserver", output); err != nil {
+		t.Fatalf("executeDownloadWorld() error = %v", err)
+	}
+
+	if want := []string{"save-off", "save-all flush", "save-on"}; !slices.Equal(session.commands, want) {
+		t.Errorf("commands = %v, want %v", session.commands, want)
+	}
+
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatalf("zip not written: %v", err)
+	}
+	defer zr.Close()
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"Our Build/DIM-1/region/r.0.0.mca", "Our Build/DIM1/region/r.0.0.mca", "Our Build/level.dat"}
+	if slices.Sort(names); !slices.Equal(names, want) {
+		t.Errorf("zip entries = %v, want %v", names, want)
+	}
+}
+
+func TestExecuteDownloadWorld_FailedCopyWritesNoFile(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	useFakeExec(t, &fakeExec{err: errors.New("pod gone")})
+
+	output := filepath.Join(t.TempDir(), "myserver.zip")
+	if err := executeDownloadWorld(context.Background(), "myserver", output); err == nil {
+		t.Fatal("executeDownloadWorld() expected error, got nil")
+	}
+
+	for _, file := range []string{output, output + ".partial"} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a failed download", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <server-name> -- <command>",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Run a command on a Minecraft server",
+	Long:  "Runs a single server command over RCON, e.g. kubecraft server exec myserver -- whitelist add Steve",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeExec(cmd.Context(), serverName, strings.Join(args[1:], " "), os.Stdout)
+	},
+}
+
+func executeExec(ctx context.Context, serverName string, command string, out io.Writer) error {
+	// Commands typed in chat start with a slash, RCON wants them without
+	command = strings.TrimPrefix(strings.TrimSpace(command), "/")
+	if command == "" {
+		return fmt.Errorf("no command given")
+	}
+
+	session, err := openRcon(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	output, err := session.Execute(command)
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+
+	if output != "" {
+		fmt.Fprintln(out, stripFormatting(output))
+	}
+
+	return nil
+}
+
+func init() {
+	serverCmd.AddCommand(execCmd)
+}
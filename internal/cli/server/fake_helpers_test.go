@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const fakeUsername = "alice"
+
+// useFakeCluster points the CLI at a fake clientset for the duration of the test
+func useFakeCluster(t *testing.T, objects ...runtime.Object) *fake.Clientset {
+	t.Helper()
+
+	clientset := fake.NewClientset(objects...)
+
+	origClient, origConfig := cli.K8sClient, cli.AppConfig
+	cli.K8sClient = k8s.NewClientWithInterface(clientset, config.NamespacePrefix+fakeUsername)
+	cli.AppConfig = &config.Config{Username: fakeUsername, Token: "fake-token"}
+	t.Cleanup(func() {
+		cli.K8sClient, cli.AppConfig = origClient, origConfig
+	})
+
+	return clientset
+}
+
+// readyServerPod returns the pod a StatefulSet would run for serverName, already Ready
+func readyServerPod(serverName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serverName + "-0",
+			Namespace: config.NamespacePrefix + fakeUsername,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.CommonLabelValuePod,
+				"server":              serverName,
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+// testWait waits long enough for a fake cluster, which is always ready immediately
+var testWait = waitOptions{timeout: time.Minute}
+
+// answerPrompts feeds input to confirmation prompts for the duration of the test
+func answerPrompts(t *testing.T, input string) {
+	t.Helper()
+
+	orig := confirmInput
+	confirmInput = strings.NewReader(input)
+	t.Cleanup(func() {
+		confirmInput = orig
+	})
+}
+
+// fakeRcon records the commands sent to a server console
+type fakeRcon struct {
+	commands []string
+	output   func(command string) string
+	closed   bool
+}
+
+func (f *fakeRcon) Execute(command string) (string, error) {
+	f.commands = append(f.commands, command)
+	if f.output == nil {
+		return "", nil
+	}
+	return f.output(command), nil
+}
+
+func (f *fakeRcon) Close() error {
+	f.closed = true
+	return nil
+}
+
+// useFakeRcon answers console connections with session for the duration of the test
+func useFakeRcon(t *testing.T, session *fakeRcon) {
+	t.Helper()
+
+	orig := connectRcon
+	connectRcon = func(ctx context.Context, serverName string) (rconSession, error) {
+		return session, nil
+	}
+	t.Cleanup(func() {
+		connectRcon = orig
+	})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var idleTimeoutCmd = &cobra.Command{
+	Use:   "idle-timeout <server-name> <duration>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Stop a server automatically when no players are online",
+	Long:  "Sets how long a running server may go without players before it is stopped, e.g. 30m or 2h, freeing its memory for other servers. 0 turns it off. The player count is checked every minute with a server list ping, and describe shows when a server was stopped for being idle.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		timeout, err := time.ParseDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid idle timeout %q, e.g. 30m or 2h", args[1])
+		}
+		return executeIdleTimeout(cmd.Context(), serverName, timeout)
+	},
+}
+
+func executeIdleTimeout(ctx context.Context, serverName string, timeout time.Duration) error {
+	if timeout < 0 || (timeout > 0 && timeout < config.MinIdleTimeout) {
+		return fmt.Errorf("idle timeout must be 0 (never) or at least %s", formatIdleTimeout(config.MinIdleTimeout))
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	err = cli.K8sClient.SetIdleTimeout(ctx, serverName, timeout)
+	if err != nil {
+		return fmt.Errorf("could not set idle timeout: %w", err)
+	}
+
+	if timeout == 0 {
+		fmt.Fprintf(os.Stderr, "Server %s will keep running without players\n", serverName)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Server %s will stop after %s without players\n", serverName, formatIdleTimeout(timeout))
+
+	return nil
+}
+
+// formatIdleTimeout renders a timeout without trailing zero units, e.g. 30m or 1h30m
+func formatIdleTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "off"
+	}
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func init() {
+	serverCmd.AddCommand(idleTimeoutCmd)
+}
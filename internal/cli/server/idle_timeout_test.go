@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+)
+
+func TestExecuteIdleTimeout(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), fakeNodePortService("myserver", 30001))
+
+	if err := executeIdleTimeout(context.Background(), "myserver", 90*time.Minute); err != nil {
+		t.Fatalf("executeIdleTimeout() error = %v", err)
+	}
+	if got := getStatefulSet(t, clientset, "myserver").Annotations[config.IdleTimeoutAnnotation]; got != "1h30m0s" {
+		t.Errorf("annotation = %q, want 1h30m0s", got)
+	}
+
+	var out bytes.Buffer
+	if err := executeDescribe(context.Background(), "myserver", false, &out); err != nil {
+		t.Fatalf("executeDescribe() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Idle timeout:  1h30m\n") {
+		t.Errorf("describe does not show the idle timeout:\n%s", out.String())
+	}
+
+	if err := executeIdleTimeout(context.Background(), "myserver", 0); err != nil {
+		t.Fatalf("executeIdleTimeout(0) error = %v", err)
+	}
+	if _, ok := getStatefulSet(t, clientset, "myserver").Annotations[config.IdleTimeoutAnnotation]; ok {
+		t.Error("idle timeout still set after turning it off")
+	}
+}
+
+func TestExecuteIdleTimeout_TooShort(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+	if err := executeIdleTimeout(context.Background(), "myserver", time.Minute); err == nil {
+		t.Fatal("executeIdleTimeout() expected error for 1m, got nil")
+	}
+	if _, ok := getStatefulSet(t, clientset, "myserver").Annotations[config.IdleTimeoutAnnotation]; ok {
+		t.Error("idle timeout set despite the error")
+	}
+}
+
+func TestExecuteIdleTimeout_Nonexistent(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeIdleTimeout(context.Background(), "ghost", time.Hour); err == nil {
+		t.Error("executeIdleTimeout() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestFormatIdleTimeout(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                "off",
+		5 * time.Minute:  "5m",
+		90 * time.Minute: "1h30m",
+		2 * time.Hour:    "2h",
+		45 * time.Second: "45s",
+	}
+	for d, want := range tests {
+		if got := formatIdleTimeout(d); got != want {
+			t.Errorf("formatIdleTimeout(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+var importWorldWait waitOptions
+
+var importWorldCmd = &cobra.Command{
+	Use:   "import-world <server-name> <zip|dir>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Replace a server's world with a single-player world",
+	Long:  "Stops the server, replaces its world directories with a world from a zip archive or directory and starts it again. Single-player saves are split into the world, world_nether and world_the_end directories the server uses.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, source := args[0], args[1]
+		return executeImportWorld(cmd.Context(), serverName, source, importWorldWait)
+	},
+}
+
+func executeImportWorld(ctx context.Context, serverName string, source string, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+
+	imported, err := openWorld(source, version)
+	if err != nil {
+		return err
+	}
+	defer imported.Close()
+
+	if !confirm(fmt.Sprintf("Replace the world of %s? Its current world is lost", serverName)) {
+		fmt.Fprintln(os.Stderr, "Import cancelled")
+		return nil
+	}
+
+	// Leave the server running or stopped as it was
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check if server is running: %w", err)
+	}
+	replicas := int32(1)
+	if !running {
+		replicas = 0
+	}
+
+	if running {
+		fmt.Fprintf(os.Stderr, "Stopping server %s...\n", serverName)
+	} else {
+		fmt.Fprintf(os.Stderr, "Starting %s in maintenance mode...\n", serverName)
+	}
+	if err := enterMaintenance(ctx, serverName, wait.timeout); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Uploading world...")
+	if err := uploadWorld(ctx, serverName, imported.WriteTar); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	if !running {
+		if err := leaveMaintenance(ctx, serverName, 0); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "World imported, server %s is left stopped\n", serverName)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting server %s...\n", serverName)
+	if err := leaveMaintenance(ctx, serverName, 1); err != nil {
+		return fmt.Errorf("could not start server (%s): %w", serverName, err)
+	}
+
+	if wait.noWait {
+		fmt.Fprintf(os.Stderr, "World imported, server %s is starting\n", serverName)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait.timeout)
+	defer cancel()
+
+	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
+	err = cli.K8sClient.WaitForReady(ctx, serverName, printPhase)
+	if err != nil {
+		return fmt.Errorf("server %s unresponsive: %w", serverName, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "World imported, server %s is ready\n", serverName)
+	return nil
+}
+
+// openWorld finds the world in a zip archive or directory and checks it can be
+// loaded by serverVersion
+func openWorld(source string, serverVersion string) (*world.Import, error) {
+	imported, err := world.OpenImport(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid world %s: %w", source, err)
+	}
+
+	savedWith := imported.Level.Version
+	if savedWith == "" {
+		savedWith = "before 1.9"
+	}
+	fmt.Fprintf(os.Stderr, "World %q from Minecraft %s (%d files, %s)\n", imported.Level.Name, savedWith, imported.Files, formatBytes(imported.Size))
+
+	if imported.Level.Downgrade(serverVersion) {
+		fmt.Fprintf(os.Stderr, "Warning: the world was saved by Minecraft %s, which is newer than the server's %s. Loading it in an older version can lose chunks and items\n", imported.Level.Version, serverVersion)
+	}
+
+	return imported, nil
+}
+
+func init() {
+	addWaitFlags(importWorldCmd, &importWorldWait)
+
+	serverCmd.AddCommand(importWorldCmd)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/nbt"
+	appsv1 "k8s.io/api/apps/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// writeTestWorld writes a single-player save last played in version to a temp dir
+func writeTestWorld(t *testing.T, version string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "MyWorld")
+	for _, sub := range []string{"region", "DIM-1/region"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("failed to create world: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sub, "r.0.0.mca"), []byte("chunks"), 0644); err != nil {
+			t.Fatalf("failed to create world: %v", err)
+		}
+	}
+
+	f, err := os.Create(filepath.Join(dir, "level.dat"))
+	if err != nil {
+		t.Fatalf("failed to create level.dat: %v", err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	defer zw.Close()
+
+	level := nbt.Compound{"Data": nbt.Compound{
+		"LevelName": "MyWorld",
+		"Version":   nbt.Compound{"Name": version},
+	}}
+	if err := nbt.Write(zw, "", level); err != nil {
+		t.Fatalf("failed to write level.dat: %v", err)
+	}
+
+	return dir
+}
+
+var importedWorld = []string{"world/level.dat", "world/region/r.0.0.mca", "world_nether/DIM-1/region/r.0.0.mca"}
+
+func TestExecuteCreate_UploadsWorldBeforeStarting(t *testing.T) {
+	clientset := useFakeCluster(t, maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, writeTestWorld(t, "1.21.4"), "", wait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+
+	var created *appsv1.StatefulSet
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "statefulsets" {
+			created = action.(k8stesting.CreateAction).GetObject().(*appsv1.StatefulSet)
+		}
+	}
+	if created == nil || !hasMaintenanceEnv(created) {
+		t.Error("server was not created in maintenance mode")
+	}
+
+	if got := slices.Sorted(slices.Values(exec.restored)); !slices.Equal(got, importedWorld) {
+		t.Errorf("uploaded entries = %v, want %v", got, importedWorld)
+	}
+	if hasMaintenanceEnv(getStatefulSet(t, clientset, "myserver")) {
+		t.Error("server left in maintenance mode")
+	}
+}
+
+func TestExecuteCreate_InvalidWorldTouchesNothing(t *testing.T) {
+	clientset := useFakeCluster(t)
+
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, t.TempDir(), "", testWait); err == nil {
+		t.Fatal("executeCreate() expected error for a directory without level.dat, got nil")
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls for an invalid world, want 0", len(actions))
+	}
+}
+
+func TestExecuteImportWorld_ReplacesWorld(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	if err := executeImportWorld(context.Background(), "myserver", writeTestWorld(t, "1.21.4"), wait); err != nil {
+		t.Fatalf("executeImportWorld() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{worldUnpackScript, worldSwapScript}) {
+		t.Errorf("scripts = %q, want unpack then swap", exec.scripts)
+	}
+	if got := slices.Sorted(slices.Values(exec.restored)); !slices.Equal(got, importedWorld) {
+		t.Errorf("uploaded entries = %v, want %v", got, importedWorld)
+	}
+	if hasMaintenanceEnv(getStatefulSet(t, clientset, "myserver")) {
+		t.Error("server left in maintenance mode")
+	}
+}
+
+func TestExecuteImportWorld_StoppedServerStaysStopped(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "y\n")
+
+	if err := executeImportWorld(context.Background(), "myserver", writeTestWorld(t, "1.21.4"), testWait); err != nil {
+		t.Fatalf("executeImportWorld() error = %v", err)
+	}
+
+	if len(exec.restored) == 0 {
+		t.Error("world was not uploaded")
+	}
+	sts := getStatefulSet(t, clientset, "myserver")
+	if *sts.Spec.Replicas != 0 || hasMaintenanceEnv(sts) {
+		t.Errorf("replicas = %d, maintenance = %t, want the server stopped again", *sts.Spec.Replicas, hasMaintenanceEnv(sts))
+	}
+}
+
+func TestExecuteImportWorld_DeclinedTouchesNothing(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+	answerPrompts(t, "n\n")
+
+	// A world from a newer version only warns
+	if err := executeImportWorld(context.Background(), "myserver", writeTestWorld(t, "1.22"), testWait); err != nil {
+		t.Fatalf("executeImportWorld() error = %v", err)
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %q, want none when declined", exec.scripts)
+	}
+	if hasMaintenanceEnv(getStatefulSet(t, clientset, "myserver")) {
+		t.Error("server switched to maintenance mode although declined")
+	}
+}
@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"text/tabwriter"
 	"time"
 
 	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -15,12 +17,12 @@ var listCmd = &cobra.Command{
 	Short: "List all Minecraft server",
 	Long:  "I'll think of this later",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return executeList()
+		return executeList(cmd.Context())
 	},
 }
 
-func executeList() error {
-	serverList, err := cli.K8sClient.ListServers()
+func executeList(ctx context.Context) error {
+	serverList, err := cli.K8sClient.ListServers(ctx)
 	if err != nil {
 		return fmt.Errorf("couldn't list servers: %w", err)
 	}
@@ -29,9 +31,9 @@ func executeList() error {
 		fmt.Fprintln(os.Stderr, "No servers found")
 	} else {
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-		fmt.Fprintf(w, "NAME\tSTATUS\tPORT\tAGE\n")
+		fmt.Fprintf(w, "NAME\tSTATUS\tADDRESS\tBEDROCK\tAGE\n")
 		for _, s := range serverList {
-			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", s.Name, s.Status, s.NodePort, formatAge(s.Age))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Status, serverAddress(s.Name, s.NodePort), bedrockAddress(s.BedrockNodePort), formatAge(s.Age))
 		}
 		w.Flush()
 	}
@@ -39,6 +41,28 @@ func executeList() error {
 	return nil
 }
 
+// serverAddress is the address players join a server at: its hostname on the gateway
+// when it has no nodeport, the node's address and its nodeport otherwise
+func serverAddress(serverName string, nodePort int32) string {
+	if nodePort != 0 {
+		return fmt.Sprintf("%s:%d", config.NodeAddress, nodePort)
+	}
+
+	// A CLI built without the cluster's gateway domain cannot tell the hostname
+	if config.GatewayDomain == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s.%s.%s", serverName, cli.AppConfig.Username, config.GatewayDomain)
+}
+
+// bedrockAddress is the address Bedrock players add the server with, "-" without one
+func bedrockAddress(bedrockPort int32) string {
+	if bedrockPort == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d", config.NodeAddress, bedrockPort)
+}
+
 func formatAge(created time.Time) string {
 	d := time.Since(created)
 	if d.Hours() >= 24 {
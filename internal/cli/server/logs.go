@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/spf13/cobra"
+)
+
+// ANSI colours for highlighted log lines
+const (
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorReset  = "\033[0m"
+)
+
+// logLevelPattern matches the level in Paper log lines such as "[12:34:56 WARN]: ..."
+var logLevelPattern = regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2} (INFO|WARN|ERROR|FATAL)\]`)
+
+var logsOpts = k8s.LogOptions{Tail: -1}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Print the logs of a Minecraft server",
+	Long:  "I'll think of this later",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeLogs(cmd.Context(), serverName, logsOpts, os.Stdout, isTerminal(os.Stdout))
+	},
+}
+
+func executeLogs(ctx context.Context, serverName string, opts k8s.LogOptions, out io.Writer, color bool) error {
+	if opts.Tail < -1 {
+		return fmt.Errorf("--tail must be zero or more lines")
+	}
+	if opts.Since < 0 {
+		return fmt.Errorf("--since must be a positive duration")
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	stream, err := cli.K8sClient.StreamLogs(ctx, serverName, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	highlighter := &logHighlighter{}
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Stack traces can have very long lines
+	for scanner.Scan() {
+		line := scanner.Text()
+		if color {
+			line = highlighter.highlight(line)
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	// Ctrl-C ends a followed stream, that is not an error
+	if err := scanner.Err(); err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("log stream interrupted: %w", err)
+	}
+
+	return nil
+}
+
+// logHighlighter colours WARN and ERROR lines. Lines without a level (such as
+// stack traces) keep the colour of the line they belong to.
+type logHighlighter struct {
+	color string
+}
+
+func (h *logHighlighter) highlight(line string) string {
+	if match := logLevelPattern.FindStringSubmatch(line); match != nil {
+		switch match[1] {
+		case "WARN":
+			h.color = colorYellow
+		case "ERROR", "FATAL":
+			h.color = colorRed
+		default:
+			h.color = ""
+		}
+	}
+
+	if h.color == "" {
+		return line
+	}
+
+	return h.color + line + colorReset
+}
+
+// isTerminal reports whether f is an interactive terminal, where colour is wanted.
+// NO_COLOR (https://no-color.org) turns colour off.
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsOpts.Follow, "follow", "f", false, "Keep streaming new log lines")
+	logsCmd.Flags().Int64Var(&logsOpts.Tail, "tail", logsOpts.Tail, "Number of lines to show from the end of the log (-1 for all)")
+	logsCmd.Flags().DurationVar(&logsOpts.Since, "since", 0, "Only show lines newer than this, e.g. 10m")
+	logsCmd.Flags().BoolVar(&logsOpts.Previous, "previous", false, "Show the log of the previous container, e.g. after a crash")
+
+	serverCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExecuteLogs_PrintsLog(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "myserver", Namespace: config.NamespacePrefix + fakeUsername},
+	}
+	useFakeCluster(t, sts, readyServerPod("myserver"))
+
+	var out bytes.Buffer
+	if err := executeLogs(context.Background(), "myserver", k8s.LogOptions{Tail: -1}, &out, false); err != nil {
+		t.Fatalf("executeLogs() error = %v", err)
+	}
+
+	// The fake clientset always serves "fake logs"
+	if strings.TrimSpace(out.String()) != "fake logs" {
+		t.Errorf("executeLogs() printed %q, want %q", out.String(), "fake logs")
+	}
+}
+
+func TestExecuteLogs_NonexistentServerFails(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeLogs(context.Background(), "ghost", k8s.LogOptions{Tail: -1}, &bytes.Buffer{}, false); err == nil {
+		t.Error("executeLogs() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestExecuteLogs_InvalidTail(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeLogs(context.Background(), "myserver", k8s.LogOptions{Tail: -5}, &bytes.Buffer{}, false); err == nil {
+		t.Error("executeLogs() expected error for negative --tail, got nil")
+	}
+}
+
+func TestLogHighlighter(t *testing.T) {
+	lines := []struct {
+		line string
+		want string
+	}{
+		{"[12:00:00 INFO]: Done (3.2s)!", ""},
+		{"[12:00:01 WARN]: Can't keep up!", colorYellow},
+		{"[12:00:02 ERROR]: Could not pass event", colorRed},
+		{"\tat org.bukkit.Server.run(Server.java:10)", colorRed},
+		{"[12:00:03 INFO]: Saved the game", ""},
+		{"Downloading Paper 1.21.11 build 42", ""},
+	}
+
+	h := &logHighlighter{}
+	for _, tt := range lines {
+		got := h.highlight(tt.line)
+
+		want := tt.line
+		if tt.want != "" {
+			want = tt.want + tt.line + colorReset
+		}
+		if got != want {
+			t.Errorf("highlight(%q) = %q, want %q", tt.line, got, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/modpack"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// The launcher installs the pack on start, a half-uploaded one must never be seen
+	modpackUploadScript = "mkdir -p /data/.kubecraft && cat > " + config.ModpackPath + ".part && mv " + config.ModpackPath + ".part " + config.ModpackPath
+	modpackStateScript  = "cat " + config.ModpackStatePath + " 2>/dev/null || true"
+)
+
+var modpackUpdateWait waitOptions
+
+var modpackCmd = &cobra.Command{
+	Use:   "modpack",
+	Short: "Manage a server's Modrinth modpack",
+}
+
+var modpackUpdateCmd = &cobra.Command{
+	Use:   "update <server-name> <mrpack>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Switch a server to another version of its modpack",
+	Long:  "Uploads a Modrinth modpack (.mrpack) to the server and restarts it to install the pack. Mods the previous pack had and the new one dropped are removed, mods added outside the pack are kept.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, source := args[0], args[1]
+		return executeModpackUpdate(cmd.Context(), serverName, source, modpackUpdateWait)
+	},
+}
+
+func executeModpackUpdate(ctx context.Context, serverName string, source string, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server details: %w", err)
+	}
+
+	pack, err := openModpack(source)
+	if err != nil {
+		return err
+	}
+	defer pack.Close()
+
+	serverType, loaderVersion, _ := pack.Loader()
+	if serverType != details.Spec.Type {
+		return fmt.Errorf("the pack runs on %s but %s is a %s server, switch it first with: kubecraft server type %s %s", serverType, serverName, details.Spec.Type, serverName, serverType)
+	}
+	if !versionPattern.MatchString(pack.Minecraft()) {
+		return fmt.Errorf("the pack is for Minecraft %s, only releases such as %s are supported", pack.Minecraft(), config.DefaultServerVersion)
+	}
+
+	replicas := int32(1)
+	if details.Status == "stopped" {
+		replicas = 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting %s in maintenance mode...\n", serverName)
+	if err := enterMaintenance(ctx, serverName, wait.timeout); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	installed, err := readInstalledModpack(ctx, serverName)
+	if err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+	changes := modpack.Diff(installed.Files, pack.ServerFiles())
+	printModpackChanges(installed, pack, details.Spec.Version, changes)
+
+	if len(changes.Removed) > 0 && !confirm(fmt.Sprintf("Remove the %d files the new pack dropped from %s?", len(changes.Removed), serverName)) {
+		fmt.Fprintln(os.Stderr, "Update cancelled")
+		return leaveMaintenance(ctx, serverName, replicas)
+	}
+
+	fmt.Fprintln(os.Stderr, "Uploading modpack...")
+	if err := uploadModpack(ctx, serverName, source); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	err = cli.K8sClient.SetModpack(ctx, serverName, pack.Title(), pack.Minecraft(), loaderVersion)
+	if err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return fmt.Errorf("could not update server (%s): %w", serverName, err)
+	}
+
+	if replicas == 0 {
+		if err := leaveMaintenance(ctx, serverName, 0); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Modpack uploaded, it is installed when %s next starts\n", serverName)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting server %s...\n", serverName)
+	if err := leaveMaintenance(ctx, serverName, 1); err != nil {
+		return fmt.Errorf("could not start server (%s): %w", serverName, err)
+	}
+
+	if wait.noWait {
+		fmt.Fprintf(os.Stderr, "Modpack uploaded, server %s is installing it\n", serverName)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait.timeout)
+	defer cancel()
+
+	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
+	err = cli.K8sClient.WaitForReady(ctx, serverName, printPhase)
+	if err != nil {
+		return fmt.Errorf("server %s unresponsive: %w", serverName, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Modpack updated, server %s is ready\n", serverName)
+	return nil
+}
+
+// openModpack opens a .mrpack and describes it
+func openModpack(source string) (*modpack.Pack, error) {
+	pack, err := modpack.Open(source)
+	if err != nil {
+		return nil, err
+	}
+
+	serverType, loaderVersion, _ := pack.Loader()
+	fmt.Fprintf(os.Stderr, "Modpack %q for Minecraft %s on %s %s (%d server files, %s)\n", pack.Title(), pack.Minecraft(), serverType, loaderVersion, len(pack.ServerFiles()), formatBytes(pack.Size))
+
+	return pack, nil
+}
+
+// readInstalledModpack reads which pack the launcher installed last, empty if none
+func readInstalledModpack(ctx context.Context, serverName string) (modpack.Installed, error) {
+	var installed modpack.Installed
+	var stdout bytes.Buffer
+	if err := execInServer(ctx, serverName, modpackStateScript, nil, &stdout); err != nil {
+		return installed, fmt.Errorf("could not read installed modpack: %w", err)
+	}
+	if stdout.Len() == 0 {
+		return installed, nil
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &installed); err != nil {
+		return installed, fmt.Errorf("could not read installed modpack: %w", err)
+	}
+
+	return installed, nil
+}
+
+func printModpackChanges(installed modpack.Installed, pack *modpack.Pack, version string, changes modpack.Changes) {
+	if installed.Title != "" {
+		fmt.Fprintf(os.Stderr, "Replacing %s with %s\n", installed.Title, pack.Title())
+	}
+	if pack.Minecraft() != version {
+		fmt.Fprintf(os.Stderr, "Minecraft changes from %s to %s\n", version, pack.Minecraft())
+	}
+
+	for _, p := range changes.Added {
+		fmt.Fprintf(os.Stderr, "  + %s\n", p)
+	}
+	for _, p := range changes.Updated {
+		fmt.Fprintf(os.Stderr, "  ~ %s\n", p)
+	}
+	for _, p := range changes.Removed {
+		fmt.Fprintf(os.Stderr, "  - %s\n", p)
+	}
+	if len(changes.Added)+len(changes.Updated)+len(changes.Removed) == 0 {
+		fmt.Fprintln(os.Stderr, "The pack's files are unchanged")
+	}
+}
+
+// uploadModpack copies a .mrpack to where the launcher installs it from. The server
+// must be in maintenance mode.
+func uploadModpack(ctx context.Context, serverName string, source string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("could not open modpack: %w", err)
+	}
+	defer f.Close()
+
+	if err := execInServer(ctx, serverName, modpackUploadScript, f, nil); err != nil {
+		return fmt.Errorf("could not copy modpack to server: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+	addWaitFlags(modpackUpdateCmd, &modpackUpdateWait)
+
+	modpackCmd.AddCommand(modpackUpdateCmd)
+	serverCmd.AddCommand(modpackCmd)
+}
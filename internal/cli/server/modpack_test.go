@@ -0,0 +1,177 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/modpack"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// writeTestModpack writes a fabric 0.16.9 pack for minecraftVersion whose mods have
+// their path as sha512
+func writeTestModpack(t *testing.T, minecraftVersion string, mods ...string) string {
+	t.Helper()
+
+	files := []modpack.File{}
+	for _, p := range mods {
+		files = append(files, testModpackFile(p))
+	}
+
+	p := filepath.Join(t.TempDir(), "pack.mrpack")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("failed to create modpack: %v", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	w, _ := zw.Create(modpack.IndexFile)
+	json.NewEncoder(w).Encode(modpack.Index{
+		FormatVersion: 1,
+		Game:          "minecraft",
+		VersionID:     minecraftVersion,
+		Name:          "Test Pack",
+		Files:         files,
+		Dependencies:  map[string]string{"minecraft": minecraftVersion, "fabric-loader": "0.16.9"},
+	})
+
+	return p
+}
+
+func testModpackFile(p string) modpack.File {
+	return modpack.File{
+		Path:      p,
+		Hashes:    map[string]string{"sha512": p},
+		Downloads: []string{"https://cdn.modrinth.com/data/" + p},
+	}
+}
+
+// fakeModdedServer is a fabric server with an installed modpack of mods
+func fakeModdedServer(t *testing.T, exec *fakeExec, replicas int32, mods ...string) {
+	t.Helper()
+
+	sts := fakeWorldServer("myserver", "1.21.1", replicas)
+	sts.Spec.Template.Spec.Containers[0].Env = append(sts.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "SERVER_TYPE", Value: "fabric"})
+	useFakeCluster(t, sts, fakeNodePortService("myserver", 30001), maintenancePod("myserver"))
+
+	installed := modpack.Installed{Title: "Test Pack 1.21.1"}
+	for _, p := range mods {
+		installed.Files = append(installed.Files, testModpackFile(p))
+	}
+	data, _ := json.Marshal(installed)
+	exec.output = map[string]string{modpackStateScript: string(data)}
+	useFakeExec(t, exec)
+}
+
+func TestExecuteCreate_Modpack(t *testing.T) {
+	clientset := useFakeCluster(t, maintenancePod("myserver"))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+
+	wait := waitOptions{timeout: time.Minute, noWait: true}
+	pack := writeTestModpack(t, "1.21.1", "mods/lithium.jar")
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{}, "", pack, wait); err != nil {
+		t.Fatalf("executeCreate() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{modpackUploadScript}) {
+		t.Errorf("scripts = %q, want the modpack upload", exec.scripts)
+	}
+	if hasMaintenanceEnv(getStatefulSet(t, clientset, "myserver")) {
+		t.Error("server left in maintenance mode")
+	}
+
+	details, err := cli.K8sClient.DescribeServer(context.Background(), "myserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	spec := details.Spec
+	if spec.Type != "fabric" || spec.Version != "1.21.1" || spec.LoaderVersion != "0.16.9" || spec.Modpack != "Test Pack 1.21.1" {
+		t.Errorf("Spec = %+v, want the pack's fabric 0.16.9 on 1.21.1", spec)
+	}
+}
+
+func TestExecuteCreate_ModpackOnGatewayFails(t *testing.T) {
+	clientset := useFakeCluster(t)
+
+	pack := writeTestModpack(t, "1.21.1", "mods/lithium.jar")
+	if err := executeCreate(context.Background(), "myserver", k8s.DefaultServerSpec(), exposeOptions{bedrock: true}, "", pack, testWait); err == nil {
+		t.Fatal("executeCreate() expected error for bedrock on a fabric pack, got nil")
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("executeCreate() made %d API calls, want 0", len(actions))
+	}
+}
+
+func TestExecuteModpackUpdate(t *testing.T) {
+	exec := &fakeExec{}
+	fakeModdedServer(t, exec, 0, "mods/lithium.jar", "mods/sodium.jar")
+	answerPrompts(t, "y\n")
+
+	pack := writeTestModpack(t, "1.21.4", "mods/lithium.jar", "mods/iris.jar")
+	if err := executeModpackUpdate(context.Background(), "myserver", pack, testWait); err != nil {
+		t.Fatalf("executeModpackUpdate() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{modpackStateScript, modpackUploadScript}) {
+		t.Errorf("scripts = %q, want the installed pack read, then the upload", exec.scripts)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(context.Background(), "myserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Spec.Version != "1.21.4" || details.Spec.Modpack != "Test Pack 1.21.4" || details.Spec.Maintenance {
+		t.Errorf("Spec = %+v, want the new pack out of maintenance mode", details.Spec)
+	}
+	if details.Status != "stopped" {
+		t.Errorf("Status = %s, want the stopped server left stopped", details.Status)
+	}
+}
+
+func TestExecuteModpackUpdate_DeclinedKeepsMods(t *testing.T) {
+	exec := &fakeExec{}
+	fakeModdedServer(t, exec, 1, "mods/lithium.jar", "mods/sodium.jar")
+	answerPrompts(t, "n\n")
+
+	pack := writeTestModpack(t, "1.21.4", "mods/lithium.jar")
+	if err := executeModpackUpdate(context.Background(), "myserver", pack, testWait); err != nil {
+		t.Fatalf("executeModpackUpdate() error = %v", err)
+	}
+
+	if slices.Contains(exec.scripts, modpackUploadScript) {
+		t.Error("modpack uploaded although the removal was declined")
+	}
+	details, err := cli.K8sClient.DescribeServer(context.Background(), "myserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Spec.Version != "1.21.1" || details.Spec.Maintenance || details.Status != "running" {
+		t.Errorf("DescribeServer() = %+v, want the server running unchanged", details)
+	}
+}
+
+func TestExecuteModpackUpdate_TypeMismatch(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.1", 1), fakeNodePortService("myserver", 30001))
+	exec := &fakeExec{}
+	useFakeExec(t, exec)
+
+	// Servers without SERVER_TYPE run Paper, which cannot load fabric mods
+	pack := writeTestModpack(t, "1.21.1", "mods/lithium.jar")
+	if err := executeModpackUpdate(context.Background(), "myserver", pack, testWait); err == nil {
+		t.Fatal("executeModpackUpdate() expected error for a fabric pack on paper, got nil")
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %q, want none", exec.scripts)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+// playersScript streams usercache.json and the overworld's stats files as a tar to
+// stdout, whichever of them exist
+const playersScript = "cd /data && ls -d usercache.json world/stats 2>/dev/null | tar -cf - -T -"
+
+// Names --leaderboard takes for the statistics the player list shows
+var leaderboardStats = map[string]string{
+	"playtime": world.StatPlayTime,
+	"deaths":   world.StatDeaths,
+	"walked":   world.StatWalked,
+}
+
+var (
+	playersLeaderboard string
+	playersOutput      string
+)
+
+var playersCmd = &cobra.Command{
+	Use:   "players <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "List a server's players and their statistics",
+	Long:  "Lists every player who has played on the server with when they were last seen, their playtime, deaths and distance walked, read from the world's stats files. --leaderboard ranks players by playtime, deaths, walked or any statistic, e.g. jump or mined/diamond_ore. A stopped server is read through a short-lived helper pod without starting it.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executePlayers(cmd.Context(), serverName, playersLeaderboard, playersOutput, os.Stdout)
+	},
+}
+
+// player is a row of the player list, as -o json writes it
+type player struct {
+	UUID     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"lastSeen"`
+	PlayTime int64     `json:"playTimeTicks"`
+	Deaths   int64     `json:"deaths"`
+	Walked   int64     `json:"walkedCm"`
+	Rank     int       `json:"rank,omitempty"`
+	Value    *int64    `json:"value,omitempty"` // Of the --leaderboard statistic
+
+	stats world.Stats
+}
+
+func executePlayers(ctx context.Context, serverName string, leaderboard string, output string, out io.Writer) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("invalid output format %q, must be table or json", output)
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	players, err := readPlayers(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	if leaderboard != "" {
+		stat := leaderboard
+		if alias, ok := leaderboardStats[leaderboard]; ok {
+			stat = alias
+		}
+		for i := range players {
+			value := players[i].stats.Get(stat)
+			if stat == world.StatPlayTime {
+				value = players[i].PlayTime
+			}
+			players[i].Value = &value
+		}
+		slices.SortStableFunc(players, func(a, b player) int {
+			return cmp.Compare(*b.Value, *a.Value)
+		})
+		for i := range players {
+			players[i].Rank = i + 1
+		}
+	}
+
+	if output == "json" {
+		if players == nil {
+			players = []player{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(players)
+	}
+
+	if len(players) == 0 {
+		fmt.Fprintln(os.Stderr, "No players have joined yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	if leaderboard != "" {
+		fmt.Fprintf(w, "RANK\tNAME\t%s\n", strings.ToUpper(leaderboard))
+		for _, p := range players {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", p.Rank, p.Name, formatStat(leaderboard, *p.Value))
+		}
+		return w.Flush()
+	}
+
+	fmt.Fprintf(w, "NAME\tLAST SEEN\tPLAYTIME\tDEATHS\tWALKED\n")
+	for _, p := range players {
+		fmt.Fprintf(w, "%s\t%s ago\t%s\t%d\t%s\n", p.Name, formatAge(p.LastSeen), formatPlayTime(p.PlayTime), p.Deaths, formatDistance(p.Walked))
+	}
+	return w.Flush()
+}
+
+// readPlayers reads the stats of every player who has joined, most recently seen
+// first. A running server's stats are as of its last autosave.
+func readPlayers(ctx context.Context, serverName string) ([]player, error) {
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("could not check if server is running: %w", err)
+	}
+
+	var files bytes.Buffer
+	if running {
+		err = execInServer(ctx, serverName, playersScript, nil, &files)
+	} else {
+		fmt.Fprintf(os.Stderr, "Server %s is stopped, reading its world through a helper pod...\n", serverName)
+		err = execInHelper(ctx, serverName, playersScript, nil, &files)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read player stats: %w", err)
+	}
+
+	var players []player
+	names := map[string]string{}
+	tr := tar.NewReader(&files)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read player stats: %w", err)
+		}
+
+		if hdr.Name == world.UserCacheFile {
+			if names, err = world.ReadUserCache(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		uuid, ok := world.ParseStatsName(hdr.Name)
+		if !ok || path.Dir(hdr.Name) != path.Join(world.Overworld, world.StatsDir) {
+			continue
+		}
+		stats, err := world.ReadStats(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+
+		// The server writes a player's stats when they leave and on every autosave
+		// while they are online
+		players = append(players, player{
+			UUID:     uuid,
+			LastSeen: hdr.ModTime,
+			PlayTime: stats.PlayTime(),
+			Deaths:   stats.Get(world.StatDeaths),
+			Walked:   stats.Get(world.StatWalked),
+			stats:    stats,
+		})
+	}
+
+	for i := range players {
+		players[i].Name = names[players[i].UUID]
+		if players[i].Name == "" {
+			players[i].Name = players[i].UUID
+		}
+	}
+	slices.SortFunc(players, func(a, b player) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+
+	return players, nil
+}
+
+// formatStat renders a leaderboard value, as time or distance for the statistics
+// that count ticks or centimetres
+func formatStat(stat string, value int64) string {
+	switch {
+	case stat == "playtime" || strings.HasSuffix(stat, "_time"):
+		return formatPlayTime(value)
+	case stat == "walked" || strings.HasSuffix(stat, "_one_cm"):
+		return formatDistance(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// formatPlayTime renders ticks as hours and minutes
+func formatPlayTime(ticks int64) string {
+	d := time.Duration(ticks/ticksPerSecond) * time.Second
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatDistance renders centimetres in blocks (metres), or kilometres from 1000
+func formatDistance(cm int64) string {
+	blocks := cm / 100
+	if blocks >= 1000 {
+		return fmt.Sprintf("%.1f km", float64(blocks)/1000)
+	}
+	return fmt.Sprintf("%d m", blocks)
+}
+
+func init() {
+	playersCmd.Flags().StringVar(&playersLeaderboard, "leaderboard", "", "Rank players by a statistic: playtime, deaths, walked, or e.g. jump, mined/diamond_ore")
+	playersCmd.Flags().StringVarP(&playersOutput, "output", "o", "table", "Output format: table or json")
+
+	serverCmd.AddCommand(playersCmd)
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	steveUUID = "8667ba71-b85a-4004-af54-457a9734eed7"
+	alexUUID  = "ec561538-f3fd-461d-aff5-086b22154bce"
+)
+
+// testPlayerFiles returns a tar of usercache.json and stats files, as playersScript
+// writes it: Steve played 2 hours and was seen 90 minutes ago, Alex played 30 minutes
+// a day ago and Notch, who is missing from the cache, never died
+func testPlayerFiles(t *testing.T) string {
+	t.Helper()
+
+	now := time.Now()
+	files := []struct {
+		name    string
+		content string
+		modTime time.Time
+	}{
+		{"usercache.json", `[{"name": "Steve", "uuid": "` + steveUUID + `"}, {"name": "Alex", "uuid": "` + alexUUID + `"}]`, now},
+		{"world/stats/" + alexUUID + ".json", `{"stats": {"minecraft:custom": {"minecraft:play_time": 36000, "minecraft:deaths": 7, "minecraft:walk_one_cm": 250000, "minecraft:jump": 40}}}`, now.Add(-25 * time.Hour)},
+		{"world/stats/" + steveUUID + ".json", `{"stats": {"minecraft:custom": {"minecraft:play_time": 144000, "minecraft:deaths": 2, "minecraft:walk_one_cm": 50000, "minecraft:jump": 900}}}`, now.Add(-90 * time.Minute)},
+		{"world/stats/069a79f4-44e9-4726-a5be-fca90e38aaf5.json", `{"stats": {"minecraft:custom": {"minecraft:play_time": 1200}}}`, now.Add(-48 * time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "world/stats/", Mode: 0755, Typeflag: tar.TypeDir, ModTime: now})
+	for _, f := range files {
+		tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), ModTime: f.modTime, Typeflag: tar.TypeReg})
+		tw.Write([]byte(f.content))
+	}
+	tw.Close()
+	return buf.String()
+}
+
+func TestExecutePlayers_List(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	exec := &fakeExec{output: map[string]string{playersScript: testPlayerFiles(t)}}
+	useFakeExec(t, exec)
+
+	var out bytes.Buffer
+	if err := executePlayers(context.Background(), "myserver", "", "table", &out); err != nil {
+		t.Fatalf("executePlayers() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("output has %d lines, want a header and 3 players:\n%s", len(lines), out.String())
+	}
+	if !regexp.MustCompile(`^Steve\s+1h ago\s+2h00m\s+2\s+500 m$`).MatchString(lines[1]) {
+		t.Errorf("first row = %q, want Steve seen most recently", lines[1])
+	}
+	if !regexp.MustCompile(`^Alex\s+1d ago\s+0h30m\s+7\s+2.5 km$`).MatchString(lines[2]) {
+		t.Errorf("second row = %q, want Alex", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "069a79f4-44e9-4726-a5be-fca90e38aaf5") {
+		t.Errorf("third row = %q, want the UUID of a player missing from the cache", lines[3])
+	}
+	if len(exec.inHelper) != 0 {
+		t.Errorf("helper scripts = %v, want the running server read directly", exec.inHelper)
+	}
+}
+
+func TestExecutePlayers_StoppedServerUsesHelper(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0))
+	exec := &fakeExec{output: map[string]string{playersScript: testPlayerFiles(t)}}
+	useFakeExec(t, exec)
+
+	if err := executePlayers(context.Background(), "myserver", "", "table", &bytes.Buffer{}); err != nil {
+		t.Fatalf("executePlayers() error = %v", err)
+	}
+
+	if len(exec.inHelper) != 1 || exec.inHelper[0] != playersScript {
+		t.Errorf("helper scripts = %v, want the players read through a helper pod", exec.inHelper)
+	}
+	if sts := getStatefulSet(t, clientset, "myserver"); *sts.Spec.Replicas != 0 || hasMaintenanceEnv(sts) {
+		t.Error("server was started to read its players")
+	}
+}
+
+func TestExecutePlayers_Leaderboard(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useFakeExec(t, &fakeExec{output: map[string]string{playersScript: testPlayerFiles(t)}})
+
+	tests := map[string][]string{
+		"deaths":   {`^1\s+Alex\s+7$`, `^2\s+Steve\s+2$`, `^3\s+069a79f4\S+\s+0$`},
+		"playtime": {`^1\s+Steve\s+2h00m$`, `^2\s+Alex\s+0h30m$`},
+		"jump":     {`^1\s+Steve\s+900$`, `^2\s+Alex\s+40$`},
+	}
+	for stat, rows := range tests {
+		t.Run(stat, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := executePlayers(context.Background(), "myserver", stat, "table", &out); err != nil {
+				t.Fatalf("executePlayers() error = %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if !strings.Contains(lines[0], strings.ToUpper(stat)) {
+				t.Errorf("header = %q, want the statistic", lines[0])
+			}
+			for i, row := range rows {
+				if !regexp.MustCompile(row).MatchString(lines[i+1]) {
+					t.Errorf("row %d = %q, want %s", i+1, lines[i+1], row)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutePlayers_JSON(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useFakeExec(t, &fakeExec{output: map[string]string{playersScript: testPlayerFiles(t)}})
+
+	var out bytes.Buffer
+	if err := executePlayers(context.Background(), "myserver", "walked", "json", &out); err != nil {
+		t.Fatalf("executePlayers() error = %v", err)
+	}
+
+	var players []struct {
+		UUID     string `json:"uuid"`
+		Name     string `json:"name"`
+		PlayTime int64  `json:"playTimeTicks"`
+		Rank     int    `json:"rank"`
+		Value    int64  `json:"value"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &players); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(players) != 3 || players[0].Name != "Alex" || players[0].Rank != 1 || players[0].Value != 250000 {
+		t.Errorf("players = %+v, want Alex first with 250000 cm walked", players)
+	}
+	if players[1].UUID != steveUUID || players[1].PlayTime != 144000 {
+		t.Errorf("second player = %+v, want Steve with 144000 ticks played", players[1])
+	}
+}
+
+func TestExecutePlayers_NoPlayers(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	useFakeExec(t, &fakeExec{})
+
+	var out bytes.Buffer
+	if err := executePlayers(context.Background(), "myserver", "", "json", &out); err != nil {
+		t.Fatalf("executePlayers() error = %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("output = %q, want an empty JSON list", out.String())
+	}
+}
+
+func TestExecutePlayers_InvalidOutput(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+
+	if err := executePlayers(context.Background(), "myserver", "", "yaml", &bytes.Buffer{}); err == nil {
+		t.Error("executePlayers() expected error for yaml output, got nil")
+	}
+}
+
+func TestExecutePlayers_Nonexistent(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executePlayers(context.Background(), "ghost", "", "table", &bytes.Buffer{}); err == nil {
+		t.Error("executePlayers() expected error for nonexistent server, got nil")
+	}
+}
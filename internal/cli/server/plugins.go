@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/plugins"
+	"github.com/spf13/cobra"
+)
+
+// pluginSources returns the registries plugins are resolved on (swapped for a fake in tests)
+var pluginSources = plugins.DefaultSources
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Manage a Paper server's plugins from Modrinth and Hangar",
+	Long:  "Plugins are pinned in a lockfile kept in the server's ConfigMap, the server installs the locked builds (checksums verified) and removes the jars of plugins taken out of it each time it starts. Jars copied into the plugins directory by hand are left alone.",
+}
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "List a server's locked plugins",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executePluginsList(cmd.Context(), serverName, os.Stdout)
+	},
+}
+
+var pluginsAddCmd = &cobra.Command{
+	Use:   "add <server-name> <slug>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Add a plugin, the newest build for the server's version",
+	Long:  "Looks the plugin up on Modrinth, then Hangar, and locks its newest release for the server's type and Minecraft version. Prefix the slug with modrinth: or hangar: to only search one of them.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, slug := args[0], args[1]
+		return executePluginsAdd(cmd.Context(), serverName, slug)
+	},
+}
+
+var pluginsRemoveCmd = &cobra.Command{
+	Use:   "remove <server-name> <slug>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Remove a plugin, its jar is deleted on the next start",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, slug := args[0], args[1]
+		return executePluginsRemove(cmd.Context(), serverName, slug)
+	},
+}
+
+var pluginsUpdateCmd = &cobra.Command{
+	Use:   "update <server-name> [slug]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Update one or all plugins to their newest build",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, slug := args[0], ""
+		if len(args) == 2 {
+			slug = args[1]
+		}
+		return executePluginsUpdate(cmd.Context(), serverName, slug)
+	},
+}
+
+func executePluginsList(ctx context.Context, serverName string, out io.Writer) error {
+	_, lock, err := loadPlugins(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	if len(lock.Plugins) == 0 {
+		fmt.Fprintf(os.Stderr, "No plugins on %s\n", serverName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "SLUG\tVERSION\tSOURCE\tFILE\n")
+	for _, p := range lock.Plugins {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Slug, p.Version, p.Source, p.File)
+	}
+	return w.Flush()
+}
+
+func executePluginsAdd(ctx context.Context, serverName string, slug string) error {
+	details, lock, err := loadPlugins(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	target, err := pluginTarget(details)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Resolving %s for %s %s...\n", slug, target.ServerType, target.Version)
+	p, err := plugins.Resolve(ctx, pluginSources(), slug, target)
+	if err != nil {
+		return fmt.Errorf("could not resolve plugin %s: %w", slug, err)
+	}
+	if existing := lock.Get(p.Slug); existing != nil {
+		return fmt.Errorf("%s %s is already installed, update it with: kubecraft server plugins update %s %s", existing.Slug, existing.Version, serverName, existing.Slug)
+	}
+
+	lock.Set(*p)
+	if err := savePlugins(ctx, details, lock); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Added %s %s from %s\n", p.Slug, p.Version, p.Source)
+	printPluginsApplied(details)
+	return nil
+}
+
+func executePluginsRemove(ctx context.Context, serverName string, slug string) error {
+	details, lock, err := loadPlugins(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	if !lock.Remove(slug) {
+		return fmt.Errorf("plugin %s is not installed on %s", slug, serverName)
+	}
+	if err := savePlugins(ctx, details, lock); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Removed %s\n", slug)
+	printPluginsApplied(details)
+	return nil
+}
+
+// executePluginsUpdate resolves the plugins again on the registry each came from, all of
+// them without a slug
+func executePluginsUpdate(ctx context.Context, serverName string, slug string) error {
+	details, lock, err := loadPlugins(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	target, err := pluginTarget(details)
+	if err != nil {
+		return err
+	}
+
+	locked := lock.Plugins
+	if slug != "" {
+		p := lock.Get(slug)
+		if p == nil {
+			return fmt.Errorf("plugin %s is not installed on %s", slug, serverName)
+		}
+		locked = []plugins.Plugin{*p}
+	}
+	if len(locked) == 0 {
+		fmt.Fprintf(os.Stderr, "No plugins on %s\n", serverName)
+		return nil
+	}
+
+	updated := 0
+	for _, current := range slices.Clone(locked) {
+		p, err := plugins.Resolve(ctx, pluginSources(), current.Source+":"+current.Slug, target)
+		if err != nil {
+			return fmt.Errorf("could not resolve plugin %s: %w", current.Slug, err)
+		}
+		if p.Hash == current.Hash {
+			fmt.Fprintf(os.Stderr, "%s %s is up to date\n", current.Slug, current.Version)
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s -> %s\n", current.Slug, current.Version, p.Version)
+		lock.Set(*p)
+		updated++
+	}
+	if updated == 0 {
+		return nil
+	}
+
+	if err := savePlugins(ctx, details, lock); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Updated %d plugins\n", updated)
+	printPluginsApplied(details)
+	return nil
+}
+
+// loadPlugins reads the server's details and plugin lockfile
+func loadPlugins(ctx context.Context, serverName string) (*k8s.ServerDetails, *plugins.Lock, error) {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return nil, nil, fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(ctx, serverName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get server details: %w", err)
+	}
+
+	data, err := cli.K8sClient.GetPluginLock(ctx, serverName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read plugins: %w", err)
+	}
+	lock, err := plugins.ParseLock(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return details, lock, nil
+}
+
+// pluginTarget is what plugin builds have to run on, only Paper based servers load plugins
+func pluginTarget(details *k8s.ServerDetails) (plugins.Target, error) {
+	if !slices.Contains(config.PaperServerTypes, details.Spec.Type) {
+		return plugins.Target{}, fmt.Errorf("plugins need a %s server, %s is %s", strings.Join(config.PaperServerTypes, " or "), details.Name, details.Spec.Type)
+	}
+
+	return plugins.Target{ServerType: details.Spec.Type, Version: details.Spec.Version}, nil
+}
+
+func savePlugins(ctx context.Context, details *k8s.ServerDetails, lock *plugins.Lock) error {
+	data, err := lock.Marshal()
+	if err != nil {
+		return err
+	}
+	// The launcher refuses a lockfile it cannot parse, so never store one
+	if _, err := plugins.ParseLock(data); err != nil {
+		return err
+	}
+
+	err = cli.K8sClient.SavePluginLock(ctx, details.Name, data)
+	if err != nil {
+		return fmt.Errorf("could not save plugins: %w", err)
+	}
+
+	return nil
+}
+
+func printPluginsApplied(details *k8s.ServerDetails) {
+	if details.Status == "stopped" {
+		fmt.Fprintf(os.Stderr, "Plugins are installed when %s next starts\n", details.Name)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Plugins are installed when %s restarts: kubecraft server stop %s && kubecraft server start %s\n", details.Name, details.Name, details.Name)
+}
+
+func init() {
+	pluginsCmd.AddCommand(pluginsListCmd)
+	pluginsCmd.AddCommand(pluginsAddCmd)
+	pluginsCmd.AddCommand(pluginsRemoveCmd)
+	pluginsCmd.AddCommand(pluginsUpdateCmd)
+	serverCmd.AddCommand(pluginsCmd)
+}
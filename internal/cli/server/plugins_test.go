@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/plugins"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fakeRegistry serves the newest build of each plugin, by slug
+type fakeRegistry struct {
+	name     string
+	versions map[string]string
+	targets  []plugins.Target
+}
+
+func (f *fakeRegistry) Name() string {
+	return f.name
+}
+
+func (f *fakeRegistry) Resolve(ctx context.Context, slug string, target plugins.Target) (*plugins.Plugin, error) {
+	f.targets = append(f.targets, target)
+	version, ok := f.versions[slug]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", slug, plugins.ErrNotFound)
+	}
+
+	return fakePlugin(f.name, slug, version), nil
+}
+
+func fakePlugin(source string, slug string, version string) *plugins.Plugin {
+	file := slug + "-" + version + ".jar"
+	return &plugins.Plugin{
+		Slug:    slug,
+		Source:  source,
+		Version: version,
+		File:    file,
+		URL:     "https://cdn.example.com/" + file,
+		Hash:    fmt.Sprintf("sha512:%x", sha512.Sum512([]byte(file))),
+	}
+}
+
+func useFakeRegistry(t *testing.T, registry *fakeRegistry) {
+	t.Helper()
+
+	orig := pluginSources
+	pluginSources = func() []plugins.Source {
+		return []plugins.Source{registry}
+	}
+	t.Cleanup(func() { pluginSources = orig })
+}
+
+// fakePluginServer is a stopped server of serverType with locked plugins
+func fakePluginServer(t *testing.T, serverType string, locked ...plugins.Plugin) {
+	t.Helper()
+
+	sts := fakeWorldServer("myserver", "1.21.4", 0)
+	sts.Spec.Template.Spec.Containers[0].Env = append(sts.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "SERVER_TYPE", Value: serverType})
+	useFakeCluster(t, sts, fakeNodePortService("myserver", 30001))
+
+	if len(locked) == 0 {
+		return
+	}
+	data, _ := (&plugins.Lock{Plugins: locked}).Marshal()
+	if err := cli.K8sClient.SavePluginLock(context.Background(), "myserver", data); err != nil {
+		t.Fatalf("SavePluginLock() error = %v", err)
+	}
+}
+
+func readPluginLock(t *testing.T) *plugins.Lock {
+	t.Helper()
+
+	data, err := cli.K8sClient.GetPluginLock(context.Background(), "myserver")
+	if err != nil {
+		t.Fatalf("GetPluginLock() error = %v", err)
+	}
+	lock, err := plugins.ParseLock(data)
+	if err != nil {
+		t.Fatalf("ParseLock() error = %v", err)
+	}
+	return lock
+}
+
+func TestExecutePluginsAdd(t *testing.T) {
+	fakePluginServer(t, "purpur")
+	registry := &fakeRegistry{name: "modrinth", versions: map[string]string{"essentialsx": "2.21.0"}}
+	useFakeRegistry(t, registry)
+
+	if err := executePluginsAdd(context.Background(), "myserver", "essentialsx"); err != nil {
+		t.Fatalf("executePluginsAdd() error = %v", err)
+	}
+
+	p := readPluginLock(t).Get("essentialsx")
+	if p == nil || p.Version != "2.21.0" || p.Source != "modrinth" {
+		t.Errorf("locked plugin = %+v, want essentialsx 2.21.0 from modrinth", p)
+	}
+	if want := (plugins.Target{ServerType: "purpur", Version: "1.21.4"}); len(registry.targets) != 1 || registry.targets[0] != want {
+		t.Errorf("resolved for %+v, want %+v", registry.targets, want)
+	}
+
+	if err := executePluginsAdd(context.Background(), "myserver", "essentialsx"); err == nil {
+		t.Error("executePluginsAdd() expected error for an installed plugin, got nil")
+	}
+}
+
+func TestExecutePluginsAdd_Failures(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverType string
+		slug       string
+	}{
+		{name: "not paper", serverType: "fabric", slug: "essentialsx"},
+		{name: "unknown plugin", serverType: "paper", slug: "nope"},
+		{name: "unknown source", serverType: "paper", slug: "curseforge:essentialsx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePluginServer(t, tt.serverType)
+			useFakeRegistry(t, &fakeRegistry{name: "modrinth", versions: map[string]string{"essentialsx": "2.21.0"}})
+
+			if err := executePluginsAdd(context.Background(), "myserver", tt.slug); err == nil {
+				t.Fatal("executePluginsAdd() expected error, got nil")
+			}
+			if data, _ := cli.K8sClient.GetPluginLock(context.Background(), "myserver"); data != nil {
+				t.Errorf("plugin lock saved: %s", data)
+			}
+		})
+	}
+}
+
+func TestExecutePluginsRemove(t *testing.T) {
+	fakePluginServer(t, "paper", *fakePlugin("modrinth", "essentialsx", "2.21.0"), *fakePlugin("hangar", "ViaVersion", "5.2.0"))
+
+	if err := executePluginsRemove(context.Background(), "myserver", "viaversion"); err != nil {
+		t.Fatalf("executePluginsRemove() error = %v", err)
+	}
+	lock := readPluginLock(t)
+	if len(lock.Plugins) != 1 || lock.Plugins[0].Slug != "essentialsx" {
+		t.Errorf("Plugins = %+v, want only essentialsx left", lock.Plugins)
+	}
+
+	if err := executePluginsRemove(context.Background(), "myserver", "viaversion"); err == nil {
+		t.Error("executePluginsRemove() expected error for a plugin not installed, got nil")
+	}
+}
+
+func TestExecutePluginsUpdate(t *testing.T) {
+	fakePluginServer(t, "paper", *fakePlugin("modrinth", "essentialsx", "2.21.0"), *fakePlugin("modrinth", "luckperms", "5.4"))
+	useFakeRegistry(t, &fakeRegistry{name: "modrinth", versions: map[string]string{"essentialsx": "2.21.1", "luckperms": "5.4"}})
+
+	if err := executePluginsUpdate(context.Background(), "myserver", ""); err != nil {
+		t.Fatalf("executePluginsUpdate() error = %v", err)
+	}
+
+	lock := readPluginLock(t)
+	if p := lock.Get("essentialsx"); p == nil || p.Version != "2.21.1" || p.File != "essentialsx-2.21.1.jar" {
+		t.Errorf("essentialsx = %+v, want 2.21.1", p)
+	}
+	if p := lock.Get("luckperms"); p == nil || p.Version != "5.4" {
+		t.Errorf("luckperms = %+v, want 5.4 kept", p)
+	}
+
+	if err := executePluginsUpdate(context.Background(), "myserver", "worldedit"); err == nil {
+		t.Error("executePluginsUpdate() expected error for a plugin not installed, got nil")
+	}
+}
+
+func TestExecutePluginsUpdate_KeepsSource(t *testing.T) {
+	fakePluginServer(t, "paper", *fakePlugin("hangar", "ViaVersion", "5.2.0"))
+	// Also on Modrinth, which is searched first
+	modrinth := &fakeRegistry{name: "modrinth", versions: map[string]string{"ViaVersion": "9.9.9"}}
+	hangar := &fakeRegistry{name: "hangar", versions: map[string]string{"ViaVersion": "5.2.1"}}
+	orig := pluginSources
+	pluginSources = func() []plugins.Source { return []plugins.Source{modrinth, hangar} }
+	t.Cleanup(func() { pluginSources = orig })
+
+	if err := executePluginsUpdate(context.Background(), "myserver", "ViaVersion"); err != nil {
+		t.Fatalf("executePluginsUpdate() error = %v", err)
+	}
+
+	if p := readPluginLock(t).Get("ViaVersion"); p == nil || p.Version != "5.2.1" || p.Source != "hangar" {
+		t.Errorf("ViaVersion = %+v, want 5.2.1 from hangar", p)
+	}
+	if len(modrinth.targets) != 0 {
+		t.Error("update searched modrinth for a hangar plugin")
+	}
+}
+
+func TestExecutePluginsList(t *testing.T) {
+	fakePluginServer(t, "paper", *fakePlugin("modrinth", "essentialsx", "2.21.0"))
+
+	var out bytes.Buffer
+	if err := executePluginsList(context.Background(), "myserver", &out); err != nil {
+		t.Fatalf("executePluginsList() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "SLUG") || strings.Fields(lines[1])[0] != "essentialsx" {
+		t.Errorf("output = %q, want a header and essentialsx", out.String())
+	}
+}
+
+func TestExecutePluginsList_ServerNotFound(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executePluginsList(context.Background(), "missing", &bytes.Buffer{}); err == nil {
+		t.Fatal("executePluginsList() expected error for a missing server, got nil")
+	}
+}
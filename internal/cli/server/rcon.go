@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	"github.com/baighasan/kubecraft/internal/rcon"
+)
+
+// formattingCodes matches Minecraft § colour and style codes in command output
+var formattingCodes = regexp.MustCompile(`§[0-9a-fk-or]`)
+
+// rconSession runs commands on a server
+type rconSession interface {
+	Execute(command string) (string, error)
+	Close() error
+}
+
+// connectRcon opens an RCON session to a running server (swapped for a fake in tests)
+var connectRcon = dialServerRcon
+
+// forwardedRcon is an RCON client talking through a port-forward to the server pod
+type forwardedRcon struct {
+	*rcon.Client
+	forward *k8s.PortForward
+}
+
+func (r *forwardedRcon) Close() error {
+	err := r.Client.Close()
+	r.forward.Close()
+	return err
+}
+
+func dialServerRcon(ctx context.Context, serverName string) (rconSession, error) {
+	password, err := cli.K8sClient.GetRconPassword(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	forward, err := cli.K8sClient.ForwardPort(ctx, serverName, config.RconPort)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := rcon.Dial(fmt.Sprintf("127.0.0.1:%d", forward.LocalPort), password, config.RconTimeout)
+	if err != nil {
+		forward.Close()
+		return nil, fmt.Errorf("could not connect to server console: %w", err)
+	}
+
+	return &forwardedRcon{Client: client, forward: forward}, nil
+}
+
+// openRcon checks the server exists, then connects to its console
+func openRcon(ctx context.Context, serverName string) (rconSession, error) {
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return nil, fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	return connectRcon(ctx, serverName)
+}
+
+// stripFormatting removes colour codes, which RCON output keeps but terminals can't show
+func stripFormatting(output string) string {
+	return formattingCodes.ReplaceAllString(output, "")
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+// propertiesSetScript has the launcher write the properties on stdin to server.properties
+const propertiesSetScript = "/usr/local/bin/kubecraft-launcher properties set"
+
+// resourcePackOptions control where players get a server's resource pack from
+type resourcePackOptions struct {
+	url      string
+	required bool
+}
+
+var (
+	resourcePackOpts resourcePackOptions
+	resourcePackWait waitOptions
+)
+
+var resourcePackCmd = &cobra.Command{
+	Use:   "resource-pack",
+	Short: "Manage the resource pack players get when joining a server",
+}
+
+var resourcePackSetCmd = &cobra.Command{
+	Use:   "set <server-name> <url|file>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Offer a resource pack to players joining a server",
+	Long:  "Sets resource-pack, resource-pack-sha1 and require-resource-pack in server.properties. The pack is downloaded to compute its SHA-1, or read from a local copy with --url pointing at where players download it. A running server restarts to apply it.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, source := args[0], args[1]
+		return executeResourcePackSet(cmd.Context(), serverName, source, resourcePackOpts, resourcePackWait)
+	},
+}
+
+func executeResourcePackSet(ctx context.Context, serverName string, source string, opts resourcePackOptions, wait waitOptions) error {
+	// Players download the pack themselves, so it needs a URL whatever the source
+	packLink := opts.url
+	if _, ok := packURL(source); ok {
+		if packLink != "" {
+			return fmt.Errorf("--url is for a local copy of the pack, %s already is a URL", source)
+		}
+		packLink = source
+	} else if packLink == "" {
+		return fmt.Errorf("players download the resource pack from a URL, pass the one %s is served from with --url", source)
+	}
+	if _, ok := packURL(packLink); !ok {
+		return fmt.Errorf("invalid resource pack URL %s, it must be http or https", packLink)
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	file, cleanup, err := fetchPack(ctx, source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	pack, err := world.OpenPack(file)
+	if err != nil {
+		return fmt.Errorf("invalid resource pack %s: %w", source, err)
+	}
+	sum, err := sha1File(file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Resource pack %q (pack format %s, SHA-1 %s)\n", pack.Description, formatPackFormats(pack), sum)
+
+	props := map[string]string{
+		"resource-pack":         packLink,
+		"resource-pack-sha1":    sum,
+		"require-resource-pack": strconv.FormatBool(opts.required),
+	}
+	running, err := editServerFiles(ctx, serverName, wait.timeout, func() error {
+		return setServerProperties(ctx, serverName, props)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !running {
+		fmt.Fprintf(os.Stderr, "Resource pack set, players get it once %s next starts\n", serverName)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Resource pack set, restarting %s to apply it...\n", serverName)
+	return restartServer(ctx, serverName, wait)
+}
+
+// setServerProperties writes props to the server's server.properties, other keys are kept
+func setServerProperties(ctx context.Context, serverName string, props map[string]string) error {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+
+	if err := execInServer(ctx, serverName, propertiesSetScript, bytes.NewReader(data), nil); err != nil {
+		return fmt.Errorf("could not update server.properties: %w", err)
+	}
+
+	return nil
+}
+
+// sha1File returns the hex SHA-1 of a file, the hash Minecraft checks resource packs with
+func sha1File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("could not open resource pack: %w", err)
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("could not read resource pack: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func init() {
+	resourcePackSetCmd.Flags().StringVar(&resourcePackOpts.url, "url", "", "Where players download the pack from, when given a local copy")
+	resourcePackSetCmd.Flags().BoolVar(&resourcePackOpts.required, "required", false, "Disconnect players who decline the pack")
+	addWaitFlags(resourcePackSetCmd, &resourcePackWait)
+
+	resourcePackCmd.AddCommand(resourcePackSetCmd)
+	serverCmd.AddCommand(resourcePackCmd)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+)
+
+func readSetProperties(t *testing.T, exec *fakeExec) map[string]string {
+	t.Helper()
+
+	var props map[string]string
+	if err := json.Unmarshal([]byte(exec.input[propertiesSetScript]), &props); err != nil {
+		t.Fatalf("properties sent to the launcher: %v", err)
+	}
+	return props
+}
+
+func TestExecuteResourcePackSet_File(t *testing.T) {
+	exec := &fakeExec{}
+	clientset, session := fakeDatapackServer(t, exec, true)
+
+	pack := writeTestPack(t, "pack.zip", 46)
+	data, _ := os.ReadFile(pack)
+	sum := sha1.Sum(data)
+
+	opts := resourcePackOptions{url: "https://cdn.example.com/pack.zip", required: true}
+	if err := executeResourcePackSet(context.Background(), "myserver", pack, opts, waitOptions{noWait: true}); err != nil {
+		t.Fatalf("executeResourcePackSet() error = %v", err)
+	}
+
+	if !slices.Equal(exec.scripts, []string{propertiesSetScript}) {
+		t.Errorf("scripts = %q, want the properties set", exec.scripts)
+	}
+	props := readSetProperties(t, exec)
+	want := map[string]string{
+		"resource-pack":         "https://cdn.example.com/pack.zip",
+		"resource-pack-sha1":    hex.EncodeToString(sum[:]),
+		"require-resource-pack": "true",
+	}
+	for key, value := range want {
+		if props[key] != value {
+			t.Errorf("%s = %q, want %q", key, props[key], value)
+		}
+	}
+
+	// Restarted through stop and start to read server.properties again
+	if !slices.Equal(session.commands, []string{"save-all flush"}) {
+		t.Errorf("commands = %v, want the server saved before stopping", session.commands)
+	}
+	if sts := getStatefulSet(t, clientset, "myserver"); *sts.Spec.Replicas != 1 {
+		t.Errorf("Replicas = %d, want the server started again", *sts.Spec.Replicas)
+	}
+}
+
+func TestExecuteResourcePackSet_URL(t *testing.T) {
+	data, _ := os.ReadFile(writeTestPack(t, "pack.zip", 46))
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer cdn.Close()
+
+	exec := &fakeExec{}
+	_, session := fakeDatapackServer(t, exec, false)
+
+	if err := executeResourcePackSet(context.Background(), "myserver", cdn.URL+"/pack.zip", resourcePackOptions{}, testWait); err != nil {
+		t.Fatalf("executeResourcePackSet() error = %v", err)
+	}
+
+	sum := sha1.Sum(data)
+	props := readSetProperties(t, exec)
+	if props["resource-pack"] != cdn.URL+"/pack.zip" || props["resource-pack-sha1"] != hex.EncodeToString(sum[:]) || props["require-resource-pack"] != "false" {
+		t.Errorf("properties = %v, want the downloaded pack's URL and SHA-1", props)
+	}
+	if len(session.commands) != 0 {
+		t.Errorf("commands = %v, want a stopped server left stopped", session.commands)
+	}
+}
+
+func TestExecuteResourcePackSet_Invalid(t *testing.T) {
+	pack := writeTestPack(t, "pack.zip", 46)
+	notPack := writeTestPack(t, "notpack.zip", 46)
+	os.WriteFile(notPack, []byte("not a zip"), 0644)
+
+	tests := []struct {
+		name   string
+		source string
+		url    string
+	}{
+		{name: "file without url", source: pack},
+		{name: "url and --url", source: "https://cdn.example.com/pack.zip", url: "https://cdn.example.com/other.zip"},
+		{name: "not http", source: pack, url: "ftp://cdn.example.com/pack.zip"},
+		{name: "not a pack", source: notPack, url: "https://cdn.example.com/pack.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeExec{}
+			fakeDatapackServer(t, exec, true)
+
+			err := executeResourcePackSet(context.Background(), "myserver", tt.source, resourcePackOptions{url: tt.url}, testWait)
+			if err == nil {
+				t.Fatal("executeResourcePackSet() expected error, got nil")
+			}
+			if len(exec.scripts) != 0 {
+				t.Errorf("scripts = %q, want server.properties left alone", exec.scripts)
+			}
+		})
+	}
+}
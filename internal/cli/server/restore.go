@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+// Restoring unpacks the backup next to the current world and only swaps it in once it
+// is complete, so a failed restore leaves the old world in place
+const (
+	worldUnpackScript = "rm -rf /data/.restore && mkdir /data/.restore && cd /data/.restore && tar -xf -"
+	worldSwapScript   = "cd /data && rm -rf world* && mv .restore/world* . && rmdir .restore"
+)
+
+var (
+	restoreForce bool
+	restoreWait  waitOptions
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <server-name> <file>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Replace a server's world with a backup",
+	Long:  "Stops the server, replaces its world directories with the ones in a backup made by kubecraft server backup and starts it again.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName, file := args[0], args[1]
+		return executeRestore(cmd.Context(), serverName, file, restoreForce, restoreWait)
+	},
+}
+
+func executeRestore(ctx context.Context, serverName string, file string, force bool, wait waitOptions) error {
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	// Check the whole archive before touching the server
+	fmt.Fprintf(os.Stderr, "Verifying %s...\n", file)
+	manifest, err := verifyBackupFile(file)
+	if err != nil {
+		return err
+	}
+
+	version, err := cli.K8sClient.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not get server version: %w", err)
+	}
+	if backup.MajorVersion(manifest.MinecraftVersion) != backup.MajorVersion(version) {
+		if !force {
+			return fmt.Errorf("backup is from Minecraft %s but server %s runs %s, use --force to restore it anyway", manifest.MinecraftVersion, serverName, version)
+		}
+		fmt.Fprintf(os.Stderr, "Restoring a Minecraft %s world into a %s server\n", manifest.MinecraftVersion, version)
+	}
+
+	fmt.Fprintf(os.Stderr, "Backup of %s from %s (%d files, %s)\n", manifest.Server, manifest.CreatedAt.Local().Format("2006-01-02 15:04"), manifest.Files, formatBytes(manifest.Size))
+	if !confirm(fmt.Sprintf("Replace the world of %s? Its current world is lost", serverName)) {
+		fmt.Fprintln(os.Stderr, "Restore cancelled")
+		return nil
+	}
+
+	return replaceWorld(ctx, serverName, wait, func() error {
+		return streamRestore(ctx, serverName, file)
+	})
+}
+
+// replaceWorld runs restore, which replaces the world, with the server in maintenance
+// mode. A running server is started again afterwards, a stopped one is left stopped.
+func replaceWorld(ctx context.Context, serverName string, wait waitOptions, restore func() error) error {
+	// Leave the server running or stopped as it was
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check if server is running: %w", err)
+	}
+	replicas := int32(1)
+	if !running {
+		replicas = 0
+	}
+
+	if running {
+		fmt.Fprintf(os.Stderr, "Stopping server %s...\n", serverName)
+	} else {
+		fmt.Fprintf(os.Stderr, "Starting %s in maintenance mode...\n", serverName)
+	}
+	if err := enterMaintenance(ctx, serverName, wait.timeout); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Restoring world...")
+	if err := restore(); err != nil {
+		leaveMaintenance(ctx, serverName, replicas)
+		return err
+	}
+
+	if !running {
+		if err := leaveMaintenance(ctx, serverName, 0); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "World restored, server %s is left stopped\n", serverName)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting server %s...\n", serverName)
+	if err := leaveMaintenance(ctx, serverName, 1); err != nil {
+		return fmt.Errorf("could not start server (%s): %w", serverName, err)
+	}
+
+	if wait.noWait {
+		fmt.Fprintf(os.Stderr, "World restored, server %s is starting\n", serverName)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait.timeout)
+	defer cancel()
+
+	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
+	err = cli.K8sClient.WaitForReady(ctx, serverName, printPhase)
+	if err != nil {
+		return fmt.Errorf("server %s unresponsive: %w", serverName, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "World restored, server %s is ready\n", serverName)
+	return nil
+}
+
+func verifyBackupFile(file string) (*backup.Manifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open backup: %w", err)
+	}
+	defer f.Close()
+
+	manifest, err := backup.Verify(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup %s: %w", file, err)
+	}
+
+	return manifest, nil
+}
+
+// streamRestore unpacks the world of a backup file into the server
+func streamRestore(ctx context.Context, serverName string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("could not open backup: %w", err)
+	}
+	defer f.Close()
+
+	return uploadWorld(ctx, serverName, func(w io.Writer) error {
+		_, err := backup.ExtractWorld(f, w)
+		return err
+	})
+}
+
+// uploadWorld unpacks the world directories writeTar produces into the server, which
+// must be in maintenance mode, and swaps them in for the current ones
+func uploadWorld(ctx context.Context, serverName string, writeTar func(w io.Writer) error) error {
+	pr, pw := io.Pipe()
+	writeErr := make(chan error, 1)
+	go func() {
+		err := writeTar(pw)
+		pw.CloseWithError(err)
+		writeErr <- err
+	}()
+
+	err := execInServer(ctx, serverName, worldUnpackScript, pr, nil)
+	if err != nil {
+		// Unblocks the writer, nothing reads its output anymore
+		pr.CloseWithError(err)
+	} else {
+		// tar may stop reading at the first end marker block
+		_, _ = io.Copy(io.Discard, pr)
+	}
+	if writeErr := <-writeErr; writeErr != nil && err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not copy world to server: %w", err)
+	}
+
+	err = execInServer(ctx, serverName, worldSwapScript, nil, nil)
+	if err != nil {
+		return fmt.Errorf("could not replace world: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Restore a backup from a different major Minecraft version")
+	addWaitFlags(restoreCmd, &restoreWait)
+
+	serverCmd.AddCommand(restoreCmd)
+}
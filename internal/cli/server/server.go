@@ -1,10 +1,50 @@
 package server
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
 	"github.com/spf13/cobra"
 )
 
+// confirmInput is where confirmation prompts read answers from (swapped in tests)
+var confirmInput io.Reader = os.Stdin
+
+// waitOptions control how create and start wait for the server to become ready
+type waitOptions struct {
+	timeout time.Duration
+	noWait  bool
+}
+
+func addWaitFlags(cmd *cobra.Command, opts *waitOptions) {
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", config.DefaultReadyTimeout, "Give up if the server is not ready within this time")
+	cmd.Flags().BoolVar(&opts.noWait, "no-wait", false, "Return as soon as the server is scheduled, without waiting for it to be ready")
+}
+
+// printPhase reports startup progress while waiting for a server
+func printPhase(phase string) {
+	fmt.Fprintf(os.Stderr, "  %s...\n", phase)
+}
+
+// confirm asks a yes/no question on stderr, defaulting to no
+func confirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+
+	scanner := bufio.NewScanner(confirmInput)
+	if !scanner.Scan() {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Manage Minecraft servers",
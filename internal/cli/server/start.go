@@ -1,11 +1,11 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/baighasan/kubecraft/internal/cli"
-	"github.com/baighasan/kubecraft/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -16,13 +16,18 @@ var startCmd = &cobra.Command{
 	Long:  "I'll think of this later",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
-		return executeStart(serverName)
+		return executeStart(cmd.Context(), serverName, startWait)
 	},
 }
 
-func executeStart(serverName string) error {
+var startWait waitOptions
+
+func executeStart(ctx context.Context, serverName string, wait waitOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, wait.timeout)
+	defer cancel()
+
 	// Validate server exists
-	serverExists, err := cli.K8sClient.ServerExists(serverName)
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
 	if err != nil {
 		return fmt.Errorf("couldn't check server (%s) existence: %v", serverName, err)
 	}
@@ -32,29 +37,45 @@ func executeStart(serverName string) error {
 
 	// Scale up server (statefulset)
 	fmt.Fprintf(os.Stderr, "Starting server %s...\n", serverName)
-	err = cli.K8sClient.ScaleServer(serverName, 1)
+	err = cli.K8sClient.ScaleServer(ctx, serverName, 1)
 	if err != nil {
 		return fmt.Errorf("could not start server (%s): %v", serverName, err)
 	}
 
-	// Wait for server to become ready
-	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
-	err = cli.K8sClient.WaitForReady(serverName)
+	// Get nodeport
+	serverPort, err := cli.K8sClient.GetNodePort(ctx, serverName)
 	if err != nil {
-		return fmt.Errorf("server %s unresponsive: %v", serverName, err)
+		return fmt.Errorf("couldn't get node port: %v", err)
 	}
+	address := serverAddress(serverName, serverPort)
 
-	// Get nodeport
-	serverPort, err := cli.K8sClient.GetNodePort(serverName)
+	if wait.noWait {
+		fmt.Fprintf(os.Stderr, "Server %s is starting and will be available at %s\n", serverName, address)
+		return nil
+	}
+
+	// Wait for server to become ready
+	fmt.Fprintln(os.Stderr, "Waiting for server to be ready...")
+	err = cli.K8sClient.WaitForReady(ctx, serverName, printPhase)
 	if err != nil {
-		return fmt.Errorf("couldn't get node port: %v", err)
+		return fmt.Errorf("server %s unresponsive: %v", serverName, err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Server %s is ready at %s:%d\n", serverName, config.NodeAddress, serverPort)
+	fmt.Fprintf(os.Stderr, "Server %s is ready at %s\n", serverName, address)
 
 	return nil
 }
 
+// restartServer stops the server the way stop does and starts it again
+func restartServer(ctx context.Context, serverName string, wait waitOptions) error {
+	if err := executeStop(ctx, serverName, stopOptions{}); err != nil {
+		return err
+	}
+
+	return executeStart(ctx, serverName, wait)
+}
+
 func init() {
+	addWaitFlags(startCmd, &startWait)
 	serverCmd.AddCommand(startCmd)
 }
@@ -1,27 +1,49 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
 	"github.com/spf13/cobra"
 )
 
+// countdownWarnings are the remaining times at which players are warned before a stop
+var countdownWarnings = []time.Duration{
+	5 * time.Minute, time.Minute, 30 * time.Second, 10 * time.Second,
+	5 * time.Second, 4 * time.Second, 3 * time.Second, 2 * time.Second, time.Second,
+}
+
+// stopOptions control how a server is shut down
+type stopOptions struct {
+	countdown time.Duration
+	force     bool
+}
+
+var stopOpts stopOptions
+
 var stopCmd = &cobra.Command{
 	Use:   "stop <server-name>",
 	Args:  cobra.ExactArgs(1),
 	Short: "Stop a Minecraft server",
-	Long:  "I'll think of this later",
+	Long:  "Warns online players and saves the world, then scales the server to zero, which shuts it down. Data is preserved.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
-		return executeStop(serverName)
+		return executeStop(cmd.Context(), serverName, stopOpts)
 	},
 }
 
-func executeStop(serverName string) error {
+func executeStop(ctx context.Context, serverName string, opts stopOptions) error {
+	if opts.countdown < 0 {
+		return fmt.Errorf("--countdown must not be negative")
+	}
+
 	// Verify server exists
-	serverExists, err := cli.K8sClient.ServerExists(serverName)
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
 	if err != nil {
 		return fmt.Errorf("could not check server existence: %w", err)
 	}
@@ -29,17 +51,111 @@ func executeStop(serverName string) error {
 		return fmt.Errorf("server (%s) does not exist", serverName)
 	}
 
+	// Warn players and save over RCON first. The server is not stopped over RCON: the
+	// container would exit and be restarted before the scale-down reaches it.
+	if !opts.force {
+		err := shutdownServer(ctx, serverName, opts.countdown)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return fmt.Errorf("stop of server (%s) cancelled: %w", serverName, ctx.Err())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save %s before stopping (%v), stopping it anyway\n", serverName, err)
+		}
+	}
+
 	// Scale down server (statefulset)
 	fmt.Fprintf(os.Stderr, "Stopping server %s...\n", serverName)
-	err = cli.K8sClient.ScaleServer(serverName, 0)
+	err = cli.K8sClient.ScaleServer(ctx, serverName, 0)
 	if err != nil {
 		return fmt.Errorf("could not stop server: %w", err)
 	}
 
+	// The pod's preStop hook stops the server, which saves the world once more
+	waitCtx, cancel := context.WithTimeout(ctx, config.StopTimeout)
+	defer cancel()
+	if err := cli.K8sClient.WaitForServerExit(waitCtx, serverName); err != nil {
+		return fmt.Errorf("server (%s) is scaled down but did not shut down: %w", serverName, err)
+	}
+
 	fmt.Fprintf(os.Stderr, "Server %s stopped. Data is preserved.\n", serverName)
 	return nil
 }
 
+// shutdownServer counts down in chat and saves the world
+func shutdownServer(ctx context.Context, serverName string, countdown time.Duration) error {
+	session, err := connectRcon(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if countdown > 0 {
+		fmt.Fprintf(os.Stderr, "Warning players, stopping in %s...\n", countdown)
+		if err := broadcastCountdown(ctx, session, countdown); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Saving world...")
+	if _, err := session.Execute("save-all flush"); err != nil {
+		return fmt.Errorf("could not save world: %w", err)
+	}
+
+	return nil
+}
+
+// broadcastCountdown announces the stop and warns again at each countdownWarnings mark
+func broadcastCountdown(ctx context.Context, session rconSession, countdown time.Duration) error {
+	remaining := countdown
+	if _, err := session.Execute("say Server stopping in " + formatCountdown(remaining)); err != nil {
+		return fmt.Errorf("could not warn players: %w", err)
+	}
+
+	for _, mark := range countdownWarnings {
+		if mark >= remaining {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(remaining - mark):
+		}
+		remaining = mark
+
+		if _, err := session.Execute("say Server stopping in " + formatCountdown(remaining)); err != nil {
+			return fmt.Errorf("could not warn players: %w", err)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(remaining):
+	}
+
+	return nil
+}
+
+// formatCountdown renders a countdown for chat, e.g. "1 minute" or "10 seconds"
+func formatCountdown(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		if d == time.Minute {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds == 1 {
+		return "1 second"
+	}
+	return fmt.Sprintf("%d seconds", seconds)
+}
+
 func init() {
+	stopCmd.Flags().DurationVar(&stopOpts.countdown, "countdown", config.DefaultStopCountdown, "Warn players this long before stopping (0 to stop right away)")
+	stopCmd.Flags().BoolVar(&stopOpts.force, "force", false, "Scale the server down right away, without a countdown (the pod still saves on shutdown)")
+
 	serverCmd.AddCommand(stopCmd)
 }
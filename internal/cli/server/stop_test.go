@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestExecuteStop_ScalesToZero(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "myserver", Namespace: namespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(1))},
+	}
+	clientset := useFakeCluster(t, sts)
+	useFakeRcon(t, &fakeRcon{})
+
+	if err := executeStop(context.Background(), "myserver", stopOptions{}); err != nil {
+		t.Fatalf("executeStop() error = %v", err)
+	}
+
+	got, err := clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+	if *got.Spec.Replicas != 0 {
+		t.Errorf("Replicas = %d, want 0", *got.Spec.Replicas)
+	}
+}
+
+func TestExecuteStop_SavesBeforeStopping(t *testing.T) {
+	useFakeCluster(t, fakeServerStatefulSet("myserver"))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+
+	if err := executeStop(context.Background(), "myserver", stopOptions{countdown: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("executeStop() error = %v", err)
+	}
+
+	// The preStop hook stops the server once it is scaled down
+	want := []string{"say Server stopping in 1 second", "save-all flush"}
+	if !slices.Equal(session.commands, want) {
+		t.Errorf("commands = %v, want %v", session.commands, want)
+	}
+	if !session.closed {
+		t.Error("executeStop() did not close the console session")
+	}
+}
+
+func TestExecuteStop_ForceSkipsConsole(t *testing.T) {
+	useFakeCluster(t, fakeServerStatefulSet("myserver"))
+	session := &fakeRcon{}
+	useFakeRcon(t, session)
+
+	if err := executeStop(context.Background(), "myserver", stopOptions{force: true}); err != nil {
+		t.Fatalf("executeStop() error = %v", err)
+	}
+	if len(session.commands) != 0 {
+		t.Errorf("commands = %v, want none with --force", session.commands)
+	}
+}
+
+func TestExecuteStop_WithoutConsoleStillStops(t *testing.T) {
+	// No rcon secret exists, so the console cannot be reached
+	clientset := useFakeCluster(t, fakeServerStatefulSet("myserver"))
+
+	if err := executeStop(context.Background(), "myserver", stopOptions{}); err != nil {
+		t.Fatalf("executeStop() error = %v", err)
+	}
+
+	got, err := clientset.AppsV1().StatefulSets(config.NamespacePrefix+fakeUsername).Get(context.Background(), "myserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+	if got.Spec.Replicas == nil || *got.Spec.Replicas != 0 {
+		t.Errorf("Replicas = %v, want 0", got.Spec.Replicas)
+	}
+}
+
+func TestExecuteStop_NonexistentServerFails(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeStop(context.Background(), "ghost", stopOptions{}); err == nil {
+		t.Error("executeStop() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{5 * time.Minute, "5 minutes"},
+		{time.Minute, "1 minute"},
+		{90 * time.Second, "90 seconds"},
+		{10 * time.Second, "10 seconds"},
+		{time.Second, "1 second"},
+		{300 * time.Millisecond, "1 second"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCountdown(tt.d); got != tt.want {
+			t.Errorf("formatCountdown(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
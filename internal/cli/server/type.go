@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var typeForce bool
+
+var typeCmd = &cobra.Command{
+	Use:   "type <server-name> <" + strings.Join(config.AllowedServerTypes, "|") + ">",
+	Args:  cobra.ExactArgs(2),
+	Short: "Switch the server software",
+	Long:  "Switches a server to another server software, installed the next time it starts. A running server restarts right away. Worlds do not always survive the switch: mod loaders leave blocks and items of removed mods behind, and vanilla drops what Paper's plugins stored, so back the server up first and pass --force.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeType(cmd.Context(), serverName, args[1], typeForce)
+	},
+}
+
+func executeType(ctx context.Context, serverName string, serverType string, force bool) error {
+	if !slices.Contains(config.AllowedServerTypes, serverType) {
+		return fmt.Errorf("type must be one of: %s", strings.Join(config.AllowedServerTypes, ", "))
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	details, err := cli.K8sClient.DescribeServer(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not describe server: %w", err)
+	}
+	if details.Spec.Type == serverType {
+		fmt.Fprintf(os.Stderr, "Server %s already runs %s\n", serverName, serverType)
+		return nil
+	}
+
+	expose := exposeOptions{gateway: details.NodePort == 0, bedrock: details.BedrockNodePort != 0}
+	if err := checkExposeType(expose, serverType); err != nil {
+		return err
+	}
+
+	if !force {
+		return fmt.Errorf("switching %s from %s to %s may break its world, back it up with: kubecraft server backup %s, then pass --force", serverName, details.Spec.Type, serverType, serverName)
+	}
+
+	err = cli.K8sClient.SetServerType(ctx, serverName, serverType)
+	if err != nil {
+		return fmt.Errorf("could not set server type: %w", err)
+	}
+
+	if details.Status == "running" {
+		fmt.Fprintf(os.Stderr, "Server %s is restarting as %s\n", serverName, serverType)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Server %s will run %s from its next start\n", serverName, serverType)
+
+	return nil
+}
+
+func init() {
+	typeCmd.Flags().BoolVar(&typeForce, "force", false, "Switch even though the world may not survive it")
+
+	serverCmd.AddCommand(typeCmd)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecuteType(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), fakeNodePortService("myserver", 30001))
+
+	// Paper by default, as for servers created before server types
+	if err := executeType(context.Background(), "myserver", "fabric", false); err == nil {
+		t.Fatal("executeType() expected error without --force, got nil")
+	}
+	if got := describeType(t, "myserver"); got != "paper" {
+		t.Fatalf("type = %q after refused switch, want paper", got)
+	}
+
+	if err := executeType(context.Background(), "myserver", "fabric", true); err != nil {
+		t.Fatalf("executeType(--force) error = %v", err)
+	}
+	if got := describeType(t, "myserver"); got != "fabric" {
+		t.Errorf("type = %q, want fabric", got)
+	}
+
+	// Nothing to switch, so nothing to force
+	if err := executeType(context.Background(), "myserver", "fabric", false); err != nil {
+		t.Errorf("executeType() to the same type error = %v", err)
+	}
+}
+
+func TestExecuteType_GatewayNeedsPaper(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), fakeNodePortService("myserver", 0))
+
+	if err := executeType(context.Background(), "myserver", "vanilla", true); err == nil {
+		t.Error("executeType() expected error for vanilla behind the gateway, got nil")
+	}
+	if err := executeType(context.Background(), "myserver", "purpur", true); err != nil {
+		t.Errorf("executeType() error = %v for purpur behind the gateway", err)
+	}
+}
+
+func TestExecuteType_Invalid(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1), fakeNodePortService("myserver", 30001))
+
+	if err := executeType(context.Background(), "myserver", "spigot", true); err == nil {
+		t.Error("executeType() expected error for unknown type, got nil")
+	}
+	if err := executeType(context.Background(), "ghost", "fabric", true); err == nil {
+		t.Error("executeType() expected error for nonexistent server, got nil")
+	}
+}
+
+func describeType(t *testing.T, serverName string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := executeDescribe(context.Background(), serverName, false, &out); err != nil {
+		t.Fatalf("executeDescribe() error = %v", err)
+	}
+	for line := range strings.Lines(out.String()) {
+		if value, ok := strings.CutPrefix(line, "Type:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	t.Fatalf("describe does not show the type:\n%s", out.String())
+	return ""
+}
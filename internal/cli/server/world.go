@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/cli"
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/world"
+	"github.com/spf13/cobra"
+)
+
+// worldPruneScript runs the launcher's pruner over the world, taking the inhabited time
+// in ticks and the keep radius in blocks. It writes its report to stdout as JSON.
+const worldPruneScript = "/usr/local/bin/kubecraft-launcher world prune --inhabited-below %d --keep-radius %d"
+
+var (
+	pruneInhabitedBelow time.Duration
+	pruneKeepRadius     int
+	pruneDryRun         bool
+)
+
+var worldCmd = &cobra.Command{
+	Use:   "world",
+	Short: "Inspect and clean up a server's world",
+}
+
+var worldPruneCmd = &cobra.Command{
+	Use:   "prune <server-name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Remove chunks players barely visited",
+	Long:  "Removes chunks players spent less than --inhabited-below in, outside --keep-radius blocks of the spawn (of 0,0 in the nether and end), so the game generates them anew when they next load. The server has to be stopped: it is started in maintenance mode, a report of what would be removed is shown and, once confirmed, the region files are rewritten without those chunks.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverName := args[0]
+		return executeWorldPrune(cmd.Context(), serverName, pruneInhabitedBelow, pruneKeepRadius, pruneDryRun, os.Stdout)
+	},
+}
+
+func executeWorldPrune(ctx context.Context, serverName string, inhabitedBelow time.Duration, keepRadius int, dryRun bool, out io.Writer) error {
+	if inhabitedBelow < time.Second/ticksPerSecond {
+		return fmt.Errorf("--inhabited-below must be at least one tick (50ms)")
+	}
+	if keepRadius < 0 {
+		return fmt.Errorf("--keep-radius must not be negative")
+	}
+
+	// Verify server exists
+	serverExists, err := cli.K8sClient.ServerExists(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check server existence: %w", err)
+	}
+	if !serverExists {
+		return fmt.Errorf("server (%s) does not exist", serverName)
+	}
+
+	// The region files are rewritten in place, the server must not be using them
+	running, err := cli.K8sClient.IsServerRunning(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("could not check if server is running: %w", err)
+	}
+	if running {
+		return fmt.Errorf("server %s is running, stop it first with: kubecraft server stop %s", serverName, serverName)
+	}
+
+	fmt.Fprintf(os.Stderr, "Starting %s in maintenance mode...\n", serverName)
+	defer leaveMaintenance(ctx, serverName, 0)
+	if err := enterMaintenance(ctx, serverName, config.DefaultReadyTimeout); err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf(worldPruneScript, int64(inhabitedBelow/(time.Second/ticksPerSecond)), keepRadius)
+	report, err := runPrune(ctx, serverName, script)
+	if err != nil {
+		return err
+	}
+	if err := printPruneReport(out, report); err != nil {
+		return err
+	}
+
+	chunks, freed := 0, int64(0)
+	for _, dim := range report.Dimensions {
+		chunks += dim.Prunable
+		freed += dim.Freed
+	}
+	if chunks == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to prune")
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+
+	prompt := fmt.Sprintf("Remove %d chunks from %s, freeing %s? Consider a backup first with: kubecraft server backup %s", chunks, serverName, formatBytes(freed), serverName)
+	if !confirm(prompt) {
+		fmt.Fprintln(os.Stderr, "Prune cancelled")
+		return nil
+	}
+
+	// The world may have changed since the dry run, report what was actually removed
+	fmt.Fprintln(os.Stderr, "Rewriting region files...")
+	applied, err := runPrune(ctx, serverName, script+" --apply")
+	if err != nil {
+		return err
+	}
+	if err := printPruneReport(out, applied); err != nil {
+		return err
+	}
+
+	chunks, freed = 0, 0
+	for _, dim := range applied.Dimensions {
+		chunks += dim.Prunable
+		freed += dim.Freed
+	}
+	fmt.Fprintf(os.Stderr, "Pruned %d chunks, freed %s\n", chunks, formatBytes(freed))
+	return nil
+}
+
+// runPrune runs the pruner script in the server and decodes its report
+func runPrune(ctx context.Context, serverName string, script string) (*world.PruneReport, error) {
+	var stdout bytes.Buffer
+	if err := execInServer(ctx, serverName, script, nil, &stdout); err != nil {
+		return nil, fmt.Errorf("could not prune world: %w", err)
+	}
+
+	var report world.PruneReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		return nil, fmt.Errorf("could not read prune report: %w", err)
+	}
+
+	return &report, nil
+}
+
+func printPruneReport(out io.Writer, report *world.PruneReport) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIMENSION\tSIZE\tCHUNKS\tPRUNABLE\tFREED")
+	for _, dim := range report.Dimensions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", dim.Name, formatBytes(dim.Size), dim.Chunks, dim.Prunable, formatBytes(dim.Freed))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, dim := range report.Dimensions {
+		if dim.Unreadable > 0 {
+			fmt.Fprintf(os.Stderr, "%d chunks in the %s could not be read and are kept\n", dim.Unreadable, dim.Name)
+		}
+	}
+	for _, name := range report.Damaged {
+		fmt.Fprintf(os.Stderr, "%s is not a valid region file and was left alone\n", name)
+	}
+	return nil
+}
+
+func init() {
+	worldPruneCmd.Flags().DurationVar(&pruneInhabitedBelow, "inhabited-below", 0, "Remove chunks players spent less than this long in, e.g. 5m")
+	worldPruneCmd.Flags().IntVar(&pruneKeepRadius, "keep-radius", 0, "Always keep chunks within this many blocks of the spawn")
+	worldPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only show what would be removed")
+	err := worldPruneCmd.MarkFlagRequired("inhabited-below")
+	if err != nil {
+		panic(err)
+	}
+
+	worldCmd.AddCommand(worldPruneCmd)
+	serverCmd.AddCommand(worldCmd)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/world"
+)
+
+// usePruneReports answers the prune script for 5 minutes and a 2000 block radius
+// with report, and its --apply run with the report marked applied
+func usePruneReports(t *testing.T, report world.PruneReport) *fakeExec {
+	t.Helper()
+
+	script := fmt.Sprintf(worldPruneScript, 6000, 2000)
+	dryRun, _ := json.Marshal(report)
+	report.Applied = true
+	applied, _ := json.Marshal(report)
+
+	exec := &fakeExec{output: map[string]string{
+		script:              string(dryRun),
+		script + " --apply": string(applied),
+	}}
+	useFakeExec(t, exec)
+	return exec
+}
+
+var testPruneReport = world.PruneReport{Dimensions: []world.DimensionReport{
+	{Name: "overworld", Dir: "world", Size: 40 << 20, Chunks: 9000, Prunable: 6000, Freed: 25 << 20},
+	{Name: "nether", Dir: "world_nether/DIM-1", Size: 4 << 20, Chunks: 800, Prunable: 0, Unreadable: 3},
+}}
+
+func TestExecuteWorldPrune_Apply(t *testing.T) {
+	clientset := useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := usePruneReports(t, testPruneReport)
+	answerPrompts(t, "y\n")
+
+	var out bytes.Buffer
+	if err := executeWorldPrune(context.Background(), "myserver", 5*time.Minute, 2000, false, &out); err != nil {
+		t.Fatalf("executeWorldPrune() error = %v", err)
+	}
+
+	for _, want := range []string{"DIMENSION", "overworld", "40.0 MiB", "9000", "6000", "25.0 MiB", "nether"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if len(exec.scripts) != 2 || !strings.HasSuffix(exec.scripts[1], "--apply") {
+		t.Errorf("scripts = %v, want a dry run then --apply", exec.scripts)
+	}
+
+	sts := getStatefulSet(t, clientset, "myserver")
+	if hasMaintenanceEnv(sts) || *sts.Spec.Replicas != 0 {
+		t.Error("server left in maintenance mode or running, want it stopped again")
+	}
+}
+
+func TestExecuteWorldPrune_ReportsAppliedRun(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := usePruneReports(t, testPruneReport)
+	answerPrompts(t, "y\n")
+
+	// A player loaded some of the chunks between the dry run and --apply
+	applied := world.PruneReport{Applied: true, Dimensions: []world.DimensionReport{
+		{Name: "overworld", Dir: "world", Size: 40 << 20, Chunks: 9000, Prunable: 5800, Freed: 24 << 20},
+	}}
+	data, _ := json.Marshal(applied)
+	exec.output[fmt.Sprintf(worldPruneScript, 6000, 2000)+" --apply"] = string(data)
+
+	var out bytes.Buffer
+	if err := executeWorldPrune(context.Background(), "myserver", 5*time.Minute, 2000, false, &out); err != nil {
+		t.Fatalf("executeWorldPrune() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "5800") || !strings.Contains(out.String(), "24.0 MiB") {
+		t.Errorf("output missing the applied run's report:\n%s", out.String())
+	}
+}
+
+func TestExecuteWorldPrune_DryRun(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := usePruneReports(t, testPruneReport)
+
+	var out bytes.Buffer
+	if err := executeWorldPrune(context.Background(), "myserver", 5*time.Minute, 2000, true, &out); err != nil {
+		t.Fatalf("executeWorldPrune() error = %v", err)
+	}
+
+	if len(exec.scripts) != 1 {
+		t.Errorf("scripts = %v, want only the dry run", exec.scripts)
+	}
+	if !strings.Contains(out.String(), "overworld") {
+		t.Errorf("output missing the report:\n%s", out.String())
+	}
+}
+
+func TestExecuteWorldPrune_Cancelled(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0), maintenancePod("myserver"))
+	exec := usePruneReports(t, testPruneReport)
+	answerPrompts(t, "n\n")
+
+	if err := executeWorldPrune(context.Background(), "myserver", 5*time.Minute, 2000, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("executeWorldPrune() error = %v", err)
+	}
+	if len(exec.scripts) != 1 {
+		t.Errorf("scripts = %v, want no --apply run", exec.scripts)
+	}
+}
+
+func TestExecuteWorldPrune_RunningServer(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 1))
+	exec := usePruneReports(t, testPruneReport)
+
+	err := executeWorldPrune(context.Background(), "myserver", 5*time.Minute, 2000, false, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "stop it first") {
+		t.Fatalf("executeWorldPrune() error = %v, want it to ask to stop the server", err)
+	}
+	if len(exec.scripts) != 0 {
+		t.Errorf("scripts = %v, want none", exec.scripts)
+	}
+}
+
+func TestExecuteWorldPrune_InvalidOptions(t *testing.T) {
+	useFakeCluster(t, fakeWorldServer("myserver", "1.21.11", 0))
+
+	if err := executeWorldPrune(context.Background(), "myserver", 0, 2000, true, &bytes.Buffer{}); err == nil {
+		t.Error("executeWorldPrune() expected error for zero --inhabited-below, got nil")
+	}
+	if err := executeWorldPrune(context.Background(), "myserver", time.Minute, -1, true, &bytes.Buffer{}); err == nil {
+		t.Error("executeWorldPrune() expected error for negative --keep-radius, got nil")
+	}
+}
+
+func TestExecuteWorldPrune_Nonexistent(t *testing.T) {
+	useFakeCluster(t)
+
+	if err := executeWorldPrune(context.Background(), "ghost", time.Minute, 0, true, &bytes.Buffer{}); err == nil {
+		t.Error("executeWorldPrune() expected error for nonexistent server, got nil")
+	}
+}
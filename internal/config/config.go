@@ -27,6 +27,16 @@ func GetConfigPath() (string, error) {
 	return configPath, nil
 }
 
+// GetConsoleHistoryPath returns the path to ~/.kubecraft/console_history
+func GetConsoleHistoryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".kubecraft/console_history"), nil
+}
+
 // CheckConfigExists checks if the config file exists
 func CheckConfigExists() (bool, error) {
 	configPath, err := GetConfigPath()
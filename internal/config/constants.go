@@ -15,6 +15,8 @@ const (
 	RegistrationServicePort = 30099 // External NodePort for registration
 	McNodePortRangeMin      = 30000 // Start of Minecraft server NodePort range
 	McNodePortRangeMax      = 30015 // End of range (supports 16 servers)
+	BedrockNodePortRangeMin = 30100 // Start of the UDP NodePort range for Bedrock players
+	BedrockNodePortRangeMax = 30115
 )
 
 // Kubernetes Resources
@@ -34,6 +36,7 @@ const (
 // RBAC Resource Names
 const (
 	UserRoleName                   = "minecraft-manager"
+	UserResourceQuotaName          = "mc-compute-resources"
 	CapacityCheckerClusterRole     = "kc-capacity-checker"
 	CapacityCheckerBinding         = "kc-users-capacity-check"
 	RegistrationClusterRole        = "kc-registration-admin"
@@ -66,6 +69,7 @@ var ReservedUserNames = []string{
 var (
 	ClusterEndpoint = "localhost" // K8s API server address (host:port)
 	NodeAddress     = "localhost" // Public IP/hostname for Minecraft connections
+	GatewayDomain   = ""          // Domain the gateway routes <server>.<user>.<domain> under, empty without a gateway
 	TLSInsecure     = "false"
 )
 
@@ -81,14 +85,138 @@ const (
 	MaxServerNameLength = 16
 	ServerImage         = "hasanbaig786/kubecraft"
 	MinecraftPort       = 25565
+	BedrockPort         = 19132 // UDP, Geyser's listener for Bedrock Edition players
+	RconPort            = 25575
 	ServerStorageSize   = "10Gi"
 	ServerStorageClass  = "local-path"
 	CapacityThreshold   = 4096  // 4GB in MiB — minimum free RAM to allow creation (matches server limit)
 	TotalAvailableRAM   = 14336 // 14GB in MiB — total RAM for workloads (16GB - 2GB system overhead)
 )
 
+// Server Defaults - used when a flag is not passed to `kubecraft server create`
+const (
+	DefaultServerVersion = "1.21.11"
+	DefaultServerType    = "paper"
+	DefaultGameMode      = "survival"
+	DefaultDifficulty    = "easy"
+	DefaultMaxPlayers    = 5
+	DefaultLevelType     = "normal"
+	DefaultMOTD          = "A Kubecraft Server"
+	DefaultPVP           = true
+	MinPlayers           = 1
+	MaxPlayers           = 20 // Keeps a full server within ServerJavaMemory
+	MaxMOTDLength        = 59 // Longest MOTD that fits on one line of the server list
+	MaxSeedLength        = 32
+)
+
+// Allowed server.properties values
+var (
+	AllowedGameModes    = []string{"survival", "creative", "adventure", "spectator"}
+	AllowedDifficulties = []string{"peaceful", "easy", "normal", "hard"}
+	AllowedLevelTypes   = []string{"normal", "flat", "large_biomes", "amplified", "single_biome_surface"}
+)
+
+// Server Types - the server software the launcher installs
+var (
+	AllowedServerTypes = []string{"paper", "purpur", "vanilla", "fabric", "forge", "neoforge"}
+	PaperServerTypes   = []string{"paper", "purpur"} // Read Paper's config and run its plugins, which the gateway and Bedrock need
+)
+
+// Plugins - Paper plugins from Modrinth and Hangar, pinned in a lockfile the launcher installs from on start
+const (
+	PluginLockSuffix    = "-plugins"               // ConfigMap <server>-plugins holds the server's lockfile
+	PluginLockKey       = "kubecraft-plugins.lock" // Key of the lockfile in the ConfigMap
+	PluginLockMountPath = "/etc/kubecraft/plugins" // Where the ConfigMap is mounted in the server container
+)
+
+// Modpacks - Modrinth packs (.mrpack) are uploaded to the server's volume and installed by the launcher
+const (
+	ModpackAnnotation = "kubecraft.io/modpack"            // On the server's StatefulSet, the pack's name and version
+	ModpackPath       = "/data/.kubecraft/modpack.mrpack" // Where the launcher looks for the pack
+	ModpackStatePath  = "/data/.kubecraft/modpack.json"   // Files of the installed pack, written by the launcher
+)
+
 // Readiness Check
 const (
-	MaxAttempts  = 30
-	PollInterval = 5 * time.Second
+	DefaultReadyTimeout = 150 * time.Second // Default for --timeout on create and start
+	PollInterval        = 5 * time.Second
+	RollbackTimeout     = 30 * time.Second // Time allowed to clean up after an interrupted create
+)
+
+// RCON - used by server console, exec and graceful stop
+const (
+	RconSecretSuffix   = "-rcon" // Secret <server>-rcon holds the server's RCON password
+	RconSecretKey      = "password"
+	RconPasswordLength = 24 // Random bytes, hex encoded in the Secret
+	RconTimeout        = 10 * time.Second
+	ConsoleHistorySize = 500
+)
+
+// Graceful Stop
+const (
+	DefaultStopCountdown = 10 * time.Second // Default for --countdown on stop
+	StopTimeout          = 2 * time.Minute  // Time allowed for the server to save and exit
+	ServerGracePeriod    = 120              // Seconds the pod gets to flush the world on eviction (more than launcher.StopTimeout)
+)
+
+// Scheduled Backups - a CronJob per server writing archives to its own PVC
+const (
+	BackupCronJobSuffix    = "-backup"  // CronJob <server>-backup runs the scheduled backups
+	BackupPVCSuffix        = "-backups" // PVC <server>-backups keeps the archives
+	BackupLabelValue       = "minecraft-backup"
+	BackupTaskLabelValue   = "minecraft-backup-task" // On-demand verify and restore jobs
+	BackupStorageSize      = "10Gi"
+	BackupJobCPURequest    = "100m"
+	BackupJobCPULimit      = "500m"
+	BackupJobMemoryRequest = "256Mi"
+	BackupJobMemoryLimit   = "512Mi"
+	BackupJobDeadline      = 3600 // Seconds a backup job may run before it is killed
+	DefaultBackupSchedule  = "0 4 * * *"
+	DefaultBackupKeep      = 7
+	MaxBackupKeep          = 30 // The job lists the kept archives in its 4KB termination message
+	UserPVCLimit           = 2  // Server world + backups
+)
+
+// Helper Pods - short-lived pods reading a stopped server's world PVC
+const (
+	HelperPodSuffix        = "-helper" // Pod <server>-helper-<random> mounts the world read-only
+	HelperLabelValue       = "minecraft-helper"
+	HelperPodCPURequest    = "50m"
+	HelperPodCPULimit      = "250m"
+	HelperPodMemoryRequest = "32Mi"
+	HelperPodMemoryLimit   = "128Mi"
+	HelperPodDeadline      = 600 // Seconds a helper pod lives, should the CLI not delete it
+)
+
+// Archives - the CLI has the launcher in the server pod push and pull worlds, the
+// registration service hands it presigned URLs of the archive bucket
+const (
+	ArchivePort          = 8081 // Cluster-internal port of the archive routes, never a NodePort
+	ArchiveServiceURL    = "http://registration-service-archive.kubecraft-system.svc:8081"
+	ArchiveTokenAudience = "kubecraft-archive" // Archive tokens can't be used against the API server
+	ArchiveTokenExpiry   = 3600                // Seconds an archive token is valid, long enough for one push or pull
+)
+
+// Wake Proxy - answers on a stopped server's NodePort and starts it when a player joins
+const (
+	WakeEndpointSliceSuffix = "-wake" // EndpointSlice <server>-wake routes the NodePort to the proxy
+	WakeProxyManagedBy      = "kubecraft-wake-proxy"
+	WakeLoginTimeout        = 10 * time.Second // Time a client gets to send its handshake
+	WakeStartupEstimate     = 60 * time.Second // Told to players who woke a server
+)
+
+// Gateway - the wake proxy routes <server>.<user>.<domain> on one port to the server's ClusterIP Service
+const (
+	GatewayPort      = 25565
+	GatewayDomainEnv = "GATEWAY_DOMAIN" // Set on the wake proxy to turn the gateway on
+)
+
+// Idle Shutdown - servers with an idle timeout are stopped once no players are online for that long
+const (
+	IdleTimeoutAnnotation = "kubecraft.io/idle-timeout" // On the server's StatefulSet, e.g. 30m0s
+	IdleStoppedAnnotation = "kubecraft.io/idle-stopped" // When the server was last stopped for being idle
+	MinIdleTimeout        = 5 * time.Minute             // Shorter would stop servers before players finish joining
+	IdleCheckInterval     = time.Minute
+	IdleShutdownReason    = "IdleShutdown" // Reason of the Event recorded on the StatefulSet
+	IdleControllerName    = "kubecraft-idle-controller"
 )
@@ -63,6 +63,7 @@ func TestConstants_ResourceNames(t *testing.T) {
 		{"NamespacePrefix", NamespacePrefix, "mc-"},
 		{"SystemNamespace", SystemNamespace, "kubecraft-system"},
 		{"UserRoleName", UserRoleName, "minecraft-manager"},
+		{"UserResourceQuotaName", UserResourceQuotaName, "mc-compute-resources"},
 		{"CapacityCheckerClusterRole", CapacityCheckerClusterRole, "kc-capacity-checker"},
 		{"CapacityCheckerBinding", CapacityCheckerBinding, "kc-users-capacity-check"},
 		{"RegistrationClusterRole", RegistrationClusterRole, "kc-registration-admin"},
@@ -0,0 +1,121 @@
+// Package idle stops servers that had no players online for their idle timeout, so
+// forgotten servers do not keep the node's memory from others.
+package idle
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+)
+
+// pingTimeout bounds each server list ping, a server too busy to answer is not idle
+const pingTimeout = 10 * time.Second
+
+// Cluster is the part of the k8s client the controller uses
+type Cluster interface {
+	ListServerStates(ctx context.Context) ([]k8s.ServerState, error)
+	StopIdleServer(ctx context.Context, state k8s.ServerState, idleFor time.Duration) error
+}
+
+// PingFunc returns the number of players online on the server at addr, which expects
+// the PROXY protocol when proxyProtocol is set
+type PingFunc func(ctx context.Context, addr string, proxyProtocol bool) (int, error)
+
+// Controller polls the player count of every server with an idle timeout
+type Controller struct {
+	cluster Cluster
+	ping    PingFunc
+	now     func() time.Time // Overridden in tests
+
+	// When each server, by namespace/name, was first seen without players. Kept in
+	// memory only, so a restart gives every server its full timeout again.
+	idleSince map[string]time.Time
+}
+
+// New returns a controller that counts players with ping, e.g. proxy.PlayersOnline
+func New(cluster Cluster, ping PingFunc) *Controller {
+	return &Controller{
+		cluster:   cluster,
+		ping:      ping,
+		now:       time.Now,
+		idleSince: make(map[string]time.Time),
+	}
+}
+
+// Run checks the servers every config.IdleCheckInterval until ctx is done
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.IdleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.check(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("failed to check idle servers: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check stops every server that has had no players for its idle timeout
+func (c *Controller) check(ctx context.Context) error {
+	states, err := c.cluster.ListServerStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+	idle := make(map[string]time.Time, len(c.idleSince))
+	for _, state := range states {
+		// Only servers accepting players count down, not stopped, starting or
+		// maintenance-mode ones
+		if state.IdleTimeout <= 0 || !state.Ready() {
+			continue
+		}
+		key := state.Namespace + "/" + state.Name
+
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		online, err := c.ping(pingCtx, net.JoinHostPort(state.PodIP, strconv.Itoa(config.MinecraftPort)), state.Gateway())
+		cancel()
+		if err != nil {
+			fmt.Printf("failed to ping %s: %v\n", key, err)
+			if since, ok := c.idleSince[key]; ok {
+				idle[key] = since
+			}
+			continue
+		}
+		if online > 0 {
+			continue
+		}
+
+		since, ok := c.idleSince[key]
+		if !ok {
+			since = now
+		}
+		idleFor := now.Sub(since)
+		if idleFor < state.IdleTimeout {
+			idle[key] = since
+			continue
+		}
+
+		if err := c.cluster.StopIdleServer(ctx, state, idleFor); err != nil {
+			fmt.Printf("failed to stop idle server %s: %v\n", key, err)
+			idle[key] = since
+			continue
+		}
+		fmt.Printf("Stopped server %s, no players online for %s\n", key, idleFor.Round(time.Second))
+	}
+
+	// Servers with players, stopped or deleted start over
+	c.idleSince = idle
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package idle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/k8s"
+)
+
+type fakeCluster struct {
+	states  []k8s.ServerState
+	stopped map[string]time.Duration
+}
+
+func (c *fakeCluster) ListServerStates(ctx context.Context) ([]k8s.ServerState, error) {
+	return c.states, nil
+}
+
+func (c *fakeCluster) StopIdleServer(ctx context.Context, state k8s.ServerState, idleFor time.Duration) error {
+	c.stopped[state.Name] = idleFor
+	return nil
+}
+
+func runningServer(name string, podIP string, timeout time.Duration) k8s.ServerState {
+	return k8s.ServerState{
+		Namespace:   "mc-alice",
+		Name:        name,
+		Replicas:    1,
+		PodIP:       podIP,
+		IdleTimeout: timeout,
+	}
+}
+
+// newTestController returns a controller whose clock the test moves, and servers
+// with the given players online by pod IP
+func newTestController(cluster *fakeCluster, online map[string]int) (*Controller, *time.Time) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cluster.stopped = map[string]time.Duration{}
+	c := New(cluster, func(ctx context.Context, addr string, proxyProtocol bool) (int, error) {
+		players, ok := online[addr]
+		if !ok {
+			return 0, errors.New("connection refused")
+		}
+		return players, nil
+	})
+	c.now = func() time.Time { return now }
+
+	return c, &now
+}
+
+func TestCheck_StopsServerIdleForTimeout(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{runningServer("quiet", "10.42.0.20", 30*time.Minute)}}
+	online := map[string]int{"10.42.0.20:25565": 0}
+	c, now := newTestController(cluster, online)
+
+	c.check(context.Background())
+	*now = now.Add(29 * time.Minute)
+	c.check(context.Background())
+	if len(cluster.stopped) != 0 {
+		t.Fatalf("stopped = %v after 29m, want the server still running", cluster.stopped)
+	}
+
+	*now = now.Add(time.Minute)
+	c.check(context.Background())
+	if idleFor, ok := cluster.stopped["quiet"]; !ok || idleFor != 30*time.Minute {
+		t.Errorf("stopped = %v, want quiet stopped after 30m idle", cluster.stopped)
+	}
+}
+
+func TestCheck_PlayersResetTheClock(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{runningServer("busy", "10.42.0.20", 30*time.Minute)}}
+	online := map[string]int{"10.42.0.20:25565": 0}
+	c, now := newTestController(cluster, online)
+
+	c.check(context.Background())
+	*now = now.Add(20 * time.Minute)
+	online["10.42.0.20:25565"] = 2
+	c.check(context.Background())
+
+	*now = now.Add(20 * time.Minute)
+	online["10.42.0.20:25565"] = 0
+	c.check(context.Background())
+	*now = now.Add(20 * time.Minute)
+	c.check(context.Background())
+
+	if len(cluster.stopped) != 0 {
+		t.Errorf("stopped = %v, want the server kept, it was idle for 20m since players left", cluster.stopped)
+	}
+}
+
+func TestCheck_SkipsServersWithoutTimeoutOrNotReady(t *testing.T) {
+	starting := runningServer("starting", "", 5*time.Minute)
+	cluster := &fakeCluster{states: []k8s.ServerState{runningServer("forever", "10.42.0.20", 0), starting}}
+	c, now := newTestController(cluster, map[string]int{"10.42.0.20:25565": 0})
+
+	c.check(context.Background())
+	*now = now.Add(time.Hour)
+	c.check(context.Background())
+
+	if len(cluster.stopped) != 0 {
+		t.Errorf("stopped = %v, want servers without a timeout or not ready kept", cluster.stopped)
+	}
+}
+
+func TestCheck_FailedPingIsNotIdle(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{runningServer("lagging", "10.42.0.20", 30*time.Minute)}}
+	online := map[string]int{}
+	c, now := newTestController(cluster, online)
+
+	c.check(context.Background())
+	*now = now.Add(time.Hour)
+	c.check(context.Background())
+
+	if len(cluster.stopped) != 0 {
+		t.Errorf("stopped = %v, want a server that does not answer kept", cluster.stopped)
+	}
+}
@@ -0,0 +1,361 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	backupContainerName = "backup"
+	launcherPath        = "/usr/local/bin/kubecraft-launcher"
+)
+
+// BackupSchedule describes a server's scheduled backups
+type BackupSchedule struct {
+	Schedule    string
+	Keep        int
+	Incremental bool       // Only changed chunks are stored
+	Active      bool       // A backup is running right now
+	LastRun     *time.Time // Nil until the first backup started
+	LastSuccess *time.Time // Nil until a backup succeeded
+}
+
+func backupCronJobName(serverName string) string {
+	return serverName + config.BackupCronJobSuffix
+}
+
+func backupPVCName(serverName string) string {
+	return serverName + config.BackupPVCSuffix
+}
+
+// ScheduleBackups creates or updates the CronJob that backs up the server on schedule
+// (cron syntax), keeping the newest keep archives on the server's backup PVC.
+// Incremental backups store only the chunks that changed instead of full archives.
+func (c *Client) ScheduleBackups(ctx context.Context, serverName string, username string, schedule string, keep int, incremental bool) error {
+	version, err := c.GetServerVersion(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.createBackupPVC(ctx, serverName, username); err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		config.CommonLabelKey: config.CommonLabelValue,
+		"server":              serverName,
+		"user":                username,
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupCronJobName(serverName),
+			Namespace: c.namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			// The last successful job's pod holds the backup list
+			SuccessfulJobsHistoryLimit: ptr.To(int32(1)),
+			FailedJobsHistoryLimit:     ptr.To(int32(1)),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit:          ptr.To(int32(1)),
+					ActiveDeadlineSeconds: ptr.To(int64(config.BackupJobDeadline)),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								config.CommonLabelKey: config.BackupLabelValue,
+								"server":              serverName,
+								"user":                username,
+							},
+						},
+						Spec: backupPodSpec(serverName, version, keep, incremental),
+					},
+				},
+			},
+		},
+	}
+
+	existing, err := c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Get(
+			ctx,
+			cronJob.Name,
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		_, err = c.clientset.
+			BatchV1().
+			CronJobs(c.namespace).
+			Create(
+				ctx,
+				cronJob,
+				metav1.CreateOptions{},
+			)
+		if err != nil {
+			return fmt.Errorf("failed to schedule backups (cronjob): %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get backup schedule (cronjob): %w", err)
+	}
+
+	existing.Labels = cronJob.Labels
+	existing.Spec = cronJob.Spec
+	_, err = c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Update(
+			ctx,
+			existing,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to update backup schedule (cronjob): %w", err)
+	}
+
+	return nil
+}
+
+// backupPodSpec runs the launcher's backup mode with the world mounted read-only
+func backupPodSpec(serverName string, version string, keep int, incremental bool) corev1.PodSpec {
+	return corev1.PodSpec{
+		RestartPolicy:                corev1.RestartPolicyNever,
+		AutomountServiceAccountToken: ptr.To(false),
+		Containers: []corev1.Container{
+			{
+				Name:    backupContainerName,
+				Image:   config.ServerImage,
+				Command: []string{launcherPath, "backup"},
+				Env: []corev1.EnvVar{
+					{Name: "SERVER_NAME", Value: serverName},
+					{Name: "VERSION", Value: version},
+					{Name: "BACKUP_KEEP", Value: strconv.Itoa(keep)},
+					{Name: "BACKUP_INCREMENTAL", Value: strconv.FormatBool(incremental)},
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(config.BackupJobCPURequest),
+						corev1.ResourceMemory: resource.MustParse(config.BackupJobMemoryRequest),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(config.BackupJobCPULimit),
+						corev1.ResourceMemory: resource.MustParse(config.BackupJobMemoryLimit),
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						Name:      "mc",
+						MountPath: "/data",
+						ReadOnly:  true,
+					},
+					{
+						Name:      "backups",
+						MountPath: "/backups",
+					},
+				},
+			},
+		},
+		Volumes: []corev1.Volume{
+			{
+				Name: "mc",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: fmt.Sprintf("mc-%s-0", serverName),
+						ReadOnly:  true,
+					},
+				},
+			},
+			{
+				Name: "backups",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: backupPVCName(serverName),
+					},
+				},
+			},
+		},
+	}
+}
+
+// createBackupPVC creates the PVC scheduled backups are kept on, unless it already exists
+func (c *Client) createBackupPVC(ctx context.Context, serverName string, username string) error {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupPVCName(serverName),
+			Namespace: c.namespace,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.CommonLabelValue,
+				"server":              serverName,
+				"user":                username,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			StorageClassName: ptr.To(config.ServerStorageClass),
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(config.BackupStorageSize),
+				},
+			},
+		},
+	}
+
+	_, err := c.clientset.
+		CoreV1().
+		PersistentVolumeClaims(c.namespace).
+		Create(
+			ctx,
+			pvc,
+			metav1.CreateOptions{},
+		)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backup storage (pvc): %w", err)
+	}
+
+	return nil
+}
+
+// GetBackupSchedule returns the server's backup schedule, or nil if it has none
+func (c *Client) GetBackupSchedule(ctx context.Context, serverName string) (*BackupSchedule, error) {
+	cronJob, err := c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Get(
+			ctx,
+			backupCronJobName(serverName),
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup schedule (cronjob): %w", err)
+	}
+
+	schedule := &BackupSchedule{
+		Schedule: cronJob.Spec.Schedule,
+		Active:   len(cronJob.Status.Active) > 0,
+	}
+	if t := cronJob.Status.LastScheduleTime; t != nil {
+		schedule.LastRun = &t.Time
+	}
+	if t := cronJob.Status.LastSuccessfulTime; t != nil {
+		schedule.LastSuccess = &t.Time
+	}
+	for _, container := range cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			switch env.Name {
+			case "BACKUP_KEEP":
+				schedule.Keep, _ = strconv.Atoi(env.Value)
+			case "BACKUP_INCREMENTAL":
+				schedule.Incremental = env.Value == "true"
+			}
+		}
+	}
+
+	return schedule, nil
+}
+
+// ListBackups returns the server's scheduled backups, oldest first, as reported by the
+// most recent successful backup job. It is empty until a backup has succeeded.
+func (c *Client) ListBackups(ctx context.Context, serverName string) ([]backup.Snapshot, error) {
+	pods, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		List(
+			ctx,
+			metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s=%s,server=%s", config.CommonLabelKey, config.BackupLabelValue, serverName),
+			},
+		)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup jobs (pods): %w", err)
+	}
+
+	// Newest first
+	slices.SortFunc(pods.Items, func(a, b corev1.Pod) int {
+		return b.CreationTimestamp.Compare(a.CreationTimestamp.Time)
+	})
+
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			terminated := cs.State.Terminated
+			if cs.Name != backupContainerName || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+
+			var snapshots []backup.Snapshot
+			if err := json.Unmarshal([]byte(terminated.Message), &snapshots); err != nil {
+				return nil, fmt.Errorf("backup job (%s) reported an unreadable backup list: %w", pod.Name, err)
+			}
+			return snapshots, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// DisableBackups removes the server's backup schedule. Archives already taken are kept.
+func (c *Client) DisableBackups(ctx context.Context, serverName string) error {
+	err := c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Delete(
+			ctx,
+			backupCronJobName(serverName),
+			metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)},
+		)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("server (%s) has no backup schedule", serverName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete backup schedule (cronjob): %w", err)
+	}
+
+	return nil
+}
+
+// deleteBackups removes the server's backup schedule and archives. Servers that never
+// scheduled backups have neither, so missing resources are not an error.
+func (c *Client) deleteBackups(ctx context.Context, serverName string) error {
+	err := c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Delete(
+			ctx,
+			backupCronJobName(serverName),
+			metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)},
+		)
+	if err := ignoreAbsent(err); err != nil {
+		return err
+	}
+
+	err = c.clientset.
+		CoreV1().
+		PersistentVolumeClaims(c.namespace).
+		Delete(
+			ctx,
+			backupPVCName(serverName),
+			metav1.DeleteOptions{},
+		)
+	return ignoreAbsent(err)
+}
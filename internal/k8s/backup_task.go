@@ -0,0 +1,178 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/utils/ptr"
+)
+
+// RunBackupTask runs kubecraft-launcher with args in a one-off job built from the
+// server's backup schedule, so it sees the same volumes, waits for it and returns the
+// message it reported. With writeWorld the world volume is mounted writable, which
+// restores need; the server must be stopped then.
+func (c *Client) RunBackupTask(ctx context.Context, serverName string, args []string, writeWorld bool) (string, error) {
+	cronJob, err := c.clientset.
+		BatchV1().
+		CronJobs(c.namespace).
+		Get(
+			ctx,
+			backupCronJobName(serverName),
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return "", fmt.Errorf("server (%s) has no backup schedule", serverName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get backup schedule (cronjob): %w", err)
+	}
+
+	spec := cronJob.Spec.JobTemplate.Spec.DeepCopy()
+	spec.BackoffLimit = ptr.To(int32(0))
+	// Cleaned up below, unless the CLI goes away first
+	spec.TTLSecondsAfterFinished = ptr.To(int32(600))
+	// Kept apart from scheduled jobs, whose messages list the backups
+	spec.Template.Labels[config.CommonLabelKey] = config.BackupTaskLabelValue
+
+	pod := &spec.Template.Spec
+	for i := range pod.Containers {
+		if pod.Containers[i].Name == backupContainerName {
+			pod.Containers[i].Command = append([]string{launcherPath}, args...)
+			for j := range pod.Containers[i].VolumeMounts {
+				if pod.Containers[i].VolumeMounts[j].Name == "mc" {
+					pod.Containers[i].VolumeMounts[j].ReadOnly = !writeWorld
+				}
+			}
+		}
+	}
+	for i := range pod.Volumes {
+		if pod.Volumes[i].Name == "mc" && pod.Volumes[i].PersistentVolumeClaim != nil {
+			pod.Volumes[i].PersistentVolumeClaim.ReadOnly = !writeWorld
+		}
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%s", cronJob.Name, args[0], utilrand.String(5)),
+			Namespace: c.namespace,
+			Labels:    cronJob.Labels,
+			// Removed with the schedule, e.g. when the server is deleted
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Name:       cronJob.Name,
+				UID:        cronJob.UID,
+			}},
+		},
+		Spec: *spec,
+	}
+
+	job, err = c.clientset.
+		BatchV1().
+		Jobs(c.namespace).
+		Create(
+			ctx,
+			job,
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return "", fmt.Errorf("failed to start backup task (job): %w", err)
+	}
+	defer func() {
+		// Also after Ctrl-C, which stops a task that is still running
+		_ = c.clientset.
+			BatchV1().
+			Jobs(c.namespace).
+			Delete(
+				context.WithoutCancel(ctx),
+				job.Name,
+				metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)},
+			)
+	}()
+
+	message, err := c.waitForTask(ctx, job.Name)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out waiting for backup task %s", job.Name)
+	}
+
+	return message, err
+}
+
+// waitForTask watches the pods of a backup task job until its container has finished
+func (c *Client) waitForTask(ctx context.Context, jobName string) (string, error) {
+	selector := metav1.ListOptions{LabelSelector: "job-name=" + jobName}
+
+	// Check the current state first, a watch only reports changes
+	pods, err := c.clientset.CoreV1().Pods(c.namespace).List(ctx, selector)
+	if err != nil {
+		return "", fmt.Errorf("failed to list backup task pods: %w", err)
+	}
+	for i := range pods.Items {
+		if message, done, err := taskResult(&pods.Items[i]); done {
+			return message, err
+		}
+	}
+
+	podWatch, err := c.clientset.CoreV1().Pods(c.namespace).Watch(ctx, selector)
+	if err != nil {
+		return "", fmt.Errorf("failed to watch backup task pods: %w", err)
+	}
+	defer func() { podWatch.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+
+		case ev, ok := <-podWatch.ResultChan():
+			if !ok {
+				// Watches are closed by the API server periodically, open a new one
+				if podWatch, err = c.clientset.CoreV1().Pods(c.namespace).Watch(ctx, selector); err != nil {
+					return "", fmt.Errorf("failed to watch backup task pods: %w", err)
+				}
+				continue
+			}
+			pod, isPod := ev.Object.(*corev1.Pod)
+			if !isPod || pod.Labels["job-name"] != jobName || ev.Type == watch.Deleted {
+				continue
+			}
+			if message, done, err := taskResult(pod); done {
+				return message, err
+			}
+		}
+	}
+}
+
+// taskResult returns the message of a finished backup task pod
+func taskResult(pod *corev1.Pod) (string, bool, error) {
+	for _, cs := range pod.Status.ContainerStatuses {
+		terminated := cs.State.Terminated
+		if cs.Name != backupContainerName || terminated == nil {
+			continue
+		}
+
+		message := strings.TrimSpace(terminated.Message)
+		if terminated.ExitCode != 0 {
+			if message == "" {
+				message = terminated.Reason
+			}
+			return "", true, fmt.Errorf("backup task failed: %s", message)
+		}
+		return message, true, nil
+	}
+
+	// Killed before the container finished, e.g. past the job's deadline
+	if pod.Status.Phase == corev1.PodFailed {
+		return "", true, fmt.Errorf("backup task failed: %s", pod.Status.Message)
+	}
+
+	return "", false, nil
+}
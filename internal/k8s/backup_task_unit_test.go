@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// finishTasks makes every backup task job finish at once with exitCode and message,
+// and returns the jobs as created
+func finishTasks(t *testing.T, clientset *fake.Clientset, exitCode int32, message string) *[]*batchv1.Job {
+	t.Helper()
+
+	var jobs []*batchv1.Job
+	clientset.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		jobs = append(jobs, job.DeepCopy())
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name + "-abcde",
+				Namespace: job.Namespace,
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: backupContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: exitCode,
+						Message:  message,
+					}},
+				}},
+			},
+		}
+		if err := clientset.Tracker().Add(pod); err != nil {
+			t.Errorf("failed to add task pod: %v", err)
+		}
+		return false, nil, nil
+	})
+
+	return &jobs
+}
+
+func scheduledServer(t *testing.T) (*Client, *fake.Clientset) {
+	t.Helper()
+
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := client.ScheduleBackups(ctx, "testserver", fakeUsername, "0 4 * * *", 7, true); err != nil {
+		t.Fatalf("ScheduleBackups() error = %v", err)
+	}
+
+	return client, clientset
+}
+
+// worldWritable reports whether a task pod can write the world volume
+func worldWritable(pod corev1.PodSpec) bool {
+	for _, volume := range pod.Volumes {
+		if volume.Name == "mc" && volume.PersistentVolumeClaim.ReadOnly {
+			return false
+		}
+	}
+	for _, mount := range pod.Containers[0].VolumeMounts {
+		if mount.Name == "mc" && mount.ReadOnly {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRunBackupTask_VerifyReadsWorldOnly(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := scheduledServer(t)
+	jobs := finishTasks(t, clientset, 0, "testserver-a.index.json: OK, 12 chunks\n")
+
+	report, err := client.RunBackupTask(ctx, "testserver", []string{"verify"}, false)
+	if err != nil {
+		t.Fatalf("RunBackupTask() error = %v", err)
+	}
+	if report != "testserver-a.index.json: OK, 12 chunks" {
+		t.Errorf("RunBackupTask() = %q, want the task's message", report)
+	}
+
+	if len(*jobs) != 1 {
+		t.Fatalf("created %d jobs, want 1", len(*jobs))
+	}
+	job := (*jobs)[0]
+	pod := job.Spec.Template.Spec
+	if !slices.Equal(pod.Containers[0].Command, []string{launcherPath, "verify"}) {
+		t.Errorf("Command = %v, want the launcher verifying", pod.Containers[0].Command)
+	}
+	if worldWritable(pod) {
+		t.Error("verify task can write the world")
+	}
+	if job.Spec.Template.Labels[config.CommonLabelKey] != config.BackupTaskLabelValue {
+		t.Errorf("pod label = %q, want a task label apart from scheduled backups", job.Spec.Template.Labels[config.CommonLabelKey])
+	}
+
+	if _, err := clientset.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("task job still exists (err = %v)", err)
+	}
+}
+
+func TestRunBackupTask_RestoreWritesWorld(t *testing.T) {
+	client, clientset := scheduledServer(t)
+	jobs := finishTasks(t, clientset, 0, "Restored testserver-a.index.json")
+
+	if _, err := client.RunBackupTask(context.Background(), "testserver", []string{"restore", "testserver-a.index.json"}, true); err != nil {
+		t.Fatalf("RunBackupTask() error = %v", err)
+	}
+	if !worldWritable((*jobs)[0].Spec.Template.Spec) {
+		t.Error("restore task cannot write the world")
+	}
+}
+
+func TestRunBackupTask_ReportsFailure(t *testing.T) {
+	client, clientset := scheduledServer(t)
+	finishTasks(t, clientset, 1, "world/region/r.0.0.mca: chunk 3f2a damaged")
+
+	_, err := client.RunBackupTask(context.Background(), "testserver", []string{"verify"}, false)
+	if err == nil || !strings.Contains(err.Error(), "r.0.0.mca") {
+		t.Errorf("RunBackupTask() error = %v, want the task's message", err)
+	}
+}
+
+func TestRunBackupTask_NoSchedule(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	_, err := client.RunBackupTask(context.Background(), "testserver", []string{"verify"}, false)
+	if err == nil || !strings.Contains(err.Error(), "no backup schedule") {
+		t.Errorf("RunBackupTask() error = %v, want no backup schedule", err)
+	}
+}
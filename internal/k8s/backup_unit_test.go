@@ -0,0 +1,153 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// backupJobPod returns a finished backup job pod that reported snapshots
+func backupJobPod(name string, created time.Time, exitCode int32, snapshots []backup.Snapshot) *corev1.Pod {
+	message, _ := json.Marshal(snapshots)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         config.NamespacePrefix + fakeUsername,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				config.CommonLabelKey: config.BackupLabelValue,
+				"server":              "testserver",
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: backupContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: exitCode,
+					Message:  string(message),
+				}},
+			}},
+		},
+	}
+}
+
+func TestScheduleBackups_CreatesCronJobAndPVC(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	namespace := config.NamespacePrefix + fakeUsername
+
+	if err := client.ScheduleBackups(ctx, "testserver", fakeUsername, "0 4 * * *", 7, false); err != nil {
+		t.Fatalf("ScheduleBackups() error = %v", err)
+	}
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, "testserver-backups", metav1.GetOptions{}); err != nil {
+		t.Errorf("backup PVC not created: %v", err)
+	}
+
+	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, "testserver-backup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("CronJob not created: %v", err)
+	}
+	if cronJob.Spec.Schedule != "0 4 * * *" {
+		t.Errorf("Schedule = %q, want %q", cronJob.Spec.Schedule, "0 4 * * *")
+	}
+
+	pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	for _, volume := range pod.Volumes {
+		if volume.PersistentVolumeClaim.ClaimName == "mc-testserver-0" && !volume.PersistentVolumeClaim.ReadOnly {
+			t.Error("world PVC is not mounted read-only")
+		}
+	}
+	if keep, _ := envValue(pod.Containers[0], "BACKUP_KEEP"); keep != "7" {
+		t.Errorf("BACKUP_KEEP = %q, want 7", keep)
+	}
+}
+
+func TestScheduleBackups_UpdatesExistingSchedule(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	if err := client.ScheduleBackups(ctx, "testserver", fakeUsername, "0 4 * * *", 7, false); err != nil {
+		t.Fatalf("ScheduleBackups() error = %v", err)
+	}
+	if err := client.ScheduleBackups(ctx, "testserver", fakeUsername, "0 */6 * * *", 3, true); err != nil {
+		t.Fatalf("second ScheduleBackups() error = %v", err)
+	}
+
+	schedule, err := client.GetBackupSchedule(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("GetBackupSchedule() error = %v", err)
+	}
+	if schedule.Schedule != "0 */6 * * *" || schedule.Keep != 3 || !schedule.Incremental {
+		t.Errorf("GetBackupSchedule() = %+v, want incremental every 6 hours keeping 3", schedule)
+	}
+}
+
+func TestGetBackupSchedule_NoneScheduled(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	schedule, err := client.GetBackupSchedule(context.Background(), "testserver")
+	if err != nil {
+		t.Fatalf("GetBackupSchedule() error = %v", err)
+	}
+	if schedule != nil {
+		t.Errorf("GetBackupSchedule() = %+v, want nil", schedule)
+	}
+}
+
+func TestListBackups_UsesNewestSuccessfulJob(t *testing.T) {
+	now := time.Now()
+	older := []backup.Snapshot{{Name: "testserver-a.tar.zst"}}
+	newer := []backup.Snapshot{{Name: "testserver-a.tar.zst"}, {Name: "testserver-b.tar.zst", Size: 42}}
+	client, _ := newFakeClient(t,
+		backupJobPod("job-1", now.Add(-48*time.Hour), 0, older),
+		backupJobPod("job-2", now.Add(-24*time.Hour), 0, newer),
+		backupJobPod("job-3", now, 1, nil),
+	)
+
+	snapshots, err := client.ListBackups(context.Background(), "testserver")
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[1].Size != 42 {
+		t.Errorf("ListBackups() = %+v, want the list from the newest successful job", snapshots)
+	}
+}
+
+func TestDeleteServer_RemovesBackups(t *testing.T) {
+	ctx := context.Background()
+	namespace := config.NamespacePrefix + fakeUsername
+	// The StatefulSet controller would create the world PVC
+	worldPVC := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "mc-testserver-0", Namespace: namespace}}
+	client, clientset := newFakeClient(t, worldPVC)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := client.ScheduleBackups(ctx, "testserver", fakeUsername, "0 4 * * *", 7, false); err != nil {
+		t.Fatalf("ScheduleBackups() error = %v", err)
+	}
+
+	if err := client.DeleteServer(ctx, "testserver"); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+
+	if _, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, "testserver-backup", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("backup CronJob still exists (err = %v)", err)
+	}
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, "testserver-backups", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("backup PVC still exists (err = %v)", err)
+	}
+}
@@ -9,8 +9,9 @@ import (
 )
 
 type Client struct {
-	clientset *kubernetes.Clientset
-	namespace string
+	clientset  kubernetes.Interface
+	restConfig *rest.Config // Needed for port-forwarding, nil for fake clientsets
+	namespace  string
 }
 
 func NewInClusterClient() (*Client, error) {
@@ -27,8 +28,9 @@ func NewInClusterClient() (*Client, error) {
 	}
 
 	return &Client{
-		clientset: clientset,
-		namespace: "",
+		clientset:  clientset,
+		restConfig: cfg,
+		namespace:  "",
 	}, nil
 }
 
@@ -47,8 +49,9 @@ func NewClientFromToken(token string, endpoint string, username string) (*Client
 	}
 
 	client := &Client{
-		clientset: clientset,
-		namespace: config.NamespacePrefix + username,
+		clientset:  clientset,
+		restConfig: cfg,
+		namespace:  config.NamespacePrefix + username,
 	}
 
 	return client, nil
@@ -63,13 +66,24 @@ func NewClientFromRestConfig(config *rest.Config) (*Client, error) {
 	}
 
 	return &Client{
-		clientset: clientset,
-		namespace: "",
+		clientset:  clientset,
+		restConfig: config,
+		namespace:  "",
 	}, nil
 }
 
+// NewClientWithInterface creates a Client from any clientset implementation.
+// Used by unit tests to run against k8s.io/client-go/kubernetes/fake.
+// An empty namespace leaves the client unscoped (like the registration server's client).
+func NewClientWithInterface(clientset kubernetes.Interface, namespace string) *Client {
+	return &Client{
+		clientset: clientset,
+		namespace: namespace,
+	}
+}
+
 // GetClientset returns the underlying Kubernetes clientset
 // Primarily used for testing and advanced operations
-func (c *Client) GetClientset() *kubernetes.Clientset {
+func (c *Client) GetClientset() kubernetes.Interface {
 	return c.clientset
 }
@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ServerDetails is a server's state along with the settings it was created with
+type ServerDetails struct {
+	ServerInfo
+	Spec ServerSpec
+	// IdleStoppedAt is when the server was stopped for having no players, zero if it
+	// was not
+	IdleStoppedAt time.Time
+}
+
+// DescribeServer returns the state and settings of a server
+func (c *Client) DescribeServer(ctx context.Context, serverName string) (*ServerDetails, error) {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	if len(sts.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("server (%s) has no containers", serverName)
+	}
+
+	nodePort, err := c.GetNodePort(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	bedrockPort, err := c.GetBedrockNodePort(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "running"
+	if sts.Spec.Replicas != nil && *sts.Spec.Replicas == 0 {
+		status = "stopped"
+	}
+
+	details := &ServerDetails{
+		ServerInfo: ServerInfo{
+			Name:            sts.Name,
+			Status:          status,
+			NodePort:        nodePort,
+			BedrockNodePort: bedrockPort,
+			Age:             sts.CreationTimestamp.Time,
+		},
+		Spec: specFromEnv(sts.Spec.Template.Spec.Containers[0].Env),
+	}
+	details.Spec.IdleTimeout = idleTimeout(sts)
+	details.Spec.Modpack = sts.Annotations[config.ModpackAnnotation]
+	if status == "stopped" {
+		details.IdleStoppedAt = idleStoppedAt(sts)
+	}
+
+	return details, nil
+}
+
+// specFromEnv reads back the spec envVars turned into env variables
+func specFromEnv(env []corev1.EnvVar) ServerSpec {
+	var spec ServerSpec
+	for _, e := range env {
+		switch e.Name {
+		case serverTypeEnv:
+			spec.Type = e.Value
+		case "VERSION":
+			spec.Version = e.Value
+		case "GAME_MODE":
+			spec.GameMode = e.Value
+		case "DIFFICULTY":
+			spec.Difficulty = e.Value
+		case "MAX_PLAYERS":
+			spec.MaxPlayers, _ = strconv.Atoi(e.Value)
+		case "MOTD":
+			spec.MOTD = e.Value
+		case "SEED":
+			spec.Seed = e.Value
+		case loaderVersionEnv:
+			spec.LoaderVersion = e.Value
+		case "LEVEL_TYPE":
+			spec.LevelType = e.Value
+		case "PVP":
+			spec.PVP, _ = strconv.ParseBool(e.Value)
+		case "HARDCORE":
+			spec.Hardcore, _ = strconv.ParseBool(e.Value)
+		case maintenanceEnv.Name:
+			spec.Maintenance = e.Value == maintenanceEnv.Value
+		}
+	}
+
+	// Servers created before server types run Paper
+	if spec.Type == "" {
+		spec.Type = config.DefaultServerType
+	}
+
+	return spec
+}
@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDescribeServer_ReadsBackSpec(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFakeClient(t)
+
+	spec := DefaultServerSpec()
+	spec.GameMode = "creative"
+	spec.MaxPlayers = 12
+	spec.Seed = "42"
+	spec.Hardcore = true
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30003, 0, spec); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := client.ScaleServer(ctx, "testserver", 0); err != nil {
+		t.Fatalf("ScaleServer() error = %v", err)
+	}
+
+	details, err := client.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Spec != spec {
+		t.Errorf("Spec = %+v, want %+v", details.Spec, spec)
+	}
+	if details.Status != "stopped" || details.NodePort != 30003 {
+		t.Errorf("DescribeServer() = %+v, want stopped on port 30003", details.ServerInfo)
+	}
+}
+
+func TestDescribeServer_Nonexistent(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	if _, err := client.DescribeServer(context.Background(), "ghost"); err == nil {
+		t.Error("DescribeServer() expected error for nonexistent server, got nil")
+	}
+}
+
+func TestSetServerType(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30003, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	if err := client.SetServerType(ctx, "testserver", "fabric"); err != nil {
+		t.Fatalf("SetServerType() error = %v", err)
+	}
+
+	details, err := client.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	want := DefaultServerSpec()
+	want.Type = "fabric"
+	if details.Spec != want {
+		t.Errorf("Spec = %+v, want %+v", details.Spec, want)
+	}
+}
+
+func TestSpecFromEnv_DefaultsToPaper(t *testing.T) {
+	// Servers created before server types have no SERVER_TYPE
+	spec := specFromEnv([]corev1.EnvVar{{Name: "VERSION", Value: "1.21.4"}})
+
+	if spec.Type != config.DefaultServerType {
+		t.Errorf("Type = %q, want %q", spec.Type, config.DefaultServerType)
+	}
+}
+
+func TestSetModpack(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFakeClient(t)
+
+	spec := DefaultServerSpec()
+	spec.Type = "fabric"
+	spec.LoaderVersion = "0.16.9"
+	spec.Modpack = "Test Pack 1.0.0"
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30003, 0, spec); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	details, err := client.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Spec != spec {
+		t.Errorf("Spec = %+v, want %+v", details.Spec, spec)
+	}
+
+	// A pack leaving the loader version to the launcher unpins it
+	if err := client.SetModpack(ctx, "testserver", "Test Pack 2.0.0", "1.21.4", ""); err != nil {
+		t.Fatalf("SetModpack() error = %v", err)
+	}
+
+	details, err = client.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	want := spec
+	want.Version = "1.21.4"
+	want.LoaderVersion = ""
+	want.Modpack = "Test Pack 2.0.0"
+	if details.Spec != want {
+		t.Errorf("Spec = %+v, want %+v", details.Spec, want)
+	}
+}
@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ExecInServer runs command in the server container, streaming stdin to it and its
+// output to stdout and stderr. Any of the streams may be nil.
+func (c *Client) ExecInServer(ctx context.Context, serverName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	err := c.execInPod(ctx, serverName+"-0", config.CommonLabelValuePod, command, stdin, stdout, stderr)
+	if err != nil {
+		return fmt.Errorf("command in server (%s) failed: %w", serverName, err)
+	}
+
+	return nil
+}
+
+// execInPod runs command in a container of the pod
+func (c *Client) execInPod(ctx context.Context, podName string, container string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if c.restConfig == nil {
+		return fmt.Errorf("exec needs a client created from a cluster config")
+	}
+
+	req := c.clientset.
+		CoreV1().
+		RESTClient().
+		Post().
+		Resource("pods").
+		Namespace(c.namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(
+			&corev1.PodExecOptions{
+				Container: container,
+				Command:   command,
+				Stdin:     stdin != nil,
+				Stdout:    stdout != nil,
+				Stderr:    stderr != nil,
+			},
+			scheme.ParameterCodec,
+		)
+
+	executor, err := remotecommand.NewSPDYExecutor(c.restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to set up exec: %w", err)
+	}
+
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const fakeUsername = "alice"
+
+// newFakeClient creates a Client scoped to the fake user's namespace, backed by a fake clientset
+func newFakeClient(t *testing.T, objects ...runtime.Object) (*Client, *fake.Clientset) {
+	t.Helper()
+
+	clientset := fake.NewClientset(objects...)
+	client := NewClientWithInterface(clientset, config.NamespacePrefix+fakeUsername)
+
+	return client, clientset
+}
+
+// fakeMinecraftPod builds a server pod requesting the given memory
+func fakeMinecraftPod(namespace, name, memory string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.CommonLabelValuePod,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: config.CommonLabelValuePod,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+// fakeServerService builds a kubecraft NodePort service
+func fakeServerService(namespace, name string, nodePort int32) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.CommonLabelValue,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{
+					Name:     config.CommonLabelValuePod,
+					Port:     config.MinecraftPort,
+					NodePort: nodePort,
+				},
+			},
+		},
+	}
+}
+
+// envValue returns the value of an env variable in a container, and whether it is set
+func envValue(container corev1.Container, name string) (string, bool) {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// waitForWatches blocks until watches on all the given resources have been opened
+func waitForWatches(t *testing.T, clientset *fake.Clientset, resources ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		watching := map[string]bool{}
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "watch" {
+				watching[action.GetResource().Resource] = true
+			}
+		}
+		if !slices.ContainsFunc(resources, func(r string) bool { return !watching[r] }) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("never started watching %v", resources)
+}
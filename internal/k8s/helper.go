@@ -0,0 +1,198 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/utils/ptr"
+)
+
+const helperContainerName = "helper"
+
+// ExecInHelper runs command in a short-lived pod that mounts the server's world
+// read-only at /data, so a stopped server's files can be read without starting it.
+// stdin may be nil. The pod is deleted once the command has finished.
+func (c *Client) ExecInHelper(ctx context.Context, serverName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if c.restConfig == nil {
+		return fmt.Errorf("exec needs a client created from a cluster config")
+	}
+
+	podName, err := c.startHelperPod(ctx, serverName)
+	if podName != "" {
+		defer c.deleteHelperPod(ctx, podName)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = c.execInPod(ctx, podName, helperContainerName, command, stdin, stdout, stderr)
+	if err != nil {
+		return fmt.Errorf("command in helper pod (%s) failed: %w", podName, err)
+	}
+
+	return nil
+}
+
+// startHelperPod creates a helper pod for the server and waits until it runs. The
+// pod's name is returned as soon as it exists, so it can be cleaned up on failure.
+func (c *Client) startHelperPod(ctx context.Context, serverName string) (string, error) {
+	pod := helperPod(serverName)
+	pod.Namespace = c.namespace
+
+	pod, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		Create(
+			ctx,
+			pod,
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return "", fmt.Errorf("failed to start helper pod: %w", err)
+	}
+
+	if err := c.waitForPodRunning(ctx, pod.Name); err != nil {
+		return pod.Name, err
+	}
+
+	return pod.Name, nil
+}
+
+// deleteHelperPod removes a helper pod right away, also after Ctrl-C
+func (c *Client) deleteHelperPod(ctx context.Context, podName string) {
+	_ = c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		Delete(
+			context.WithoutCancel(ctx),
+			podName,
+			metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))},
+		)
+}
+
+// helperPod sleeps with the server's world mounted read-only until it is deleted, or
+// its deadline passes
+func helperPod(serverName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s%s-%s", serverName, config.HelperPodSuffix, utilrand.String(5)),
+			Labels: map[string]string{
+				config.CommonLabelKey: config.HelperLabelValue,
+				"server":              serverName,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			AutomountServiceAccountToken:  ptr.To(false),
+			ActiveDeadlineSeconds:         ptr.To(int64(config.HelperPodDeadline)),
+			TerminationGracePeriodSeconds: ptr.To(int64(0)),
+			Containers: []corev1.Container{
+				{
+					Name:    helperContainerName,
+					Image:   config.ServerImage,
+					Command: []string{"sleep", fmt.Sprint(config.HelperPodDeadline)},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(config.HelperPodCPURequest),
+							corev1.ResourceMemory: resource.MustParse(config.HelperPodMemoryRequest),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(config.HelperPodCPULimit),
+							corev1.ResourceMemory: resource.MustParse(config.HelperPodMemoryLimit),
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "mc",
+							MountPath: "/data",
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "mc",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: fmt.Sprintf("mc-%s-0", serverName),
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitForPodRunning watches the pod until its containers have started
+func (c *Client) waitForPodRunning(ctx context.Context, podName string) error {
+	selector := metav1.ListOptions{FieldSelector: "metadata.name=" + podName}
+
+	podWatch, err := c.clientset.CoreV1().Pods(c.namespace).Watch(ctx, selector)
+	if err != nil {
+		return fmt.Errorf("failed to watch helper pod: %w", err)
+	}
+	defer func() { podWatch.Stop() }()
+
+	// Check the current state too, the pod may have started before the watch did
+	pod, err := c.clientset.CoreV1().Pods(c.namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get helper pod: %w", err)
+	}
+	if done, err := helperPodStarted(pod); done {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("helper pod %s did not start: %w", podName, ctx.Err())
+
+		case ev, ok := <-podWatch.ResultChan():
+			if !ok {
+				// Watches are closed by the API server periodically, open a new one
+				if podWatch, err = c.clientset.CoreV1().Pods(c.namespace).Watch(ctx, selector); err != nil {
+					return fmt.Errorf("failed to watch helper pod: %w", err)
+				}
+				continue
+			}
+			pod, isPod := ev.Object.(*corev1.Pod)
+			if !isPod || pod.Name != podName {
+				continue
+			}
+			if ev.Type == watch.Deleted {
+				return fmt.Errorf("helper pod %s was deleted", podName)
+			}
+			if done, err := helperPodStarted(pod); done {
+				return err
+			}
+		}
+	}
+}
+
+// helperPodStarted reports whether the helper pod runs, or never will
+func helperPodStarted(pod *corev1.Pod) (bool, error) {
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		return true, nil
+	case corev1.PodFailed, corev1.PodSucceeded:
+		return true, fmt.Errorf("helper pod %s stopped: %s", pod.Name, pod.Status.Message)
+	}
+
+	// Image pull errors won't fix themselves
+	for _, cs := range pod.Status.ContainerStatuses {
+		if waiting := cs.State.Waiting; waiting != nil && (waiting.Reason == "ErrImagePull" || waiting.Reason == "ImagePullBackOff") {
+			return true, fmt.Errorf("helper pod %s cannot pull its image: %s", pod.Name, waiting.Message)
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// setHelperPhase moves every helper pod to phase once it has been created
+func setHelperPhase(t *testing.T, clientset *fake.Clientset, phase corev1.PodPhase) {
+	t.Helper()
+
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		go func() {
+			waitForWatches(t, clientset, "pods")
+			pod := pod.DeepCopy()
+			pod.Status.Phase = phase
+			if _, err := clientset.CoreV1().Pods(pod.Namespace).UpdateStatus(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+				t.Errorf("failed to update helper pod: %v", err)
+			}
+		}()
+		return false, nil, nil
+	})
+}
+
+func TestHelperPod_MountsWorldReadOnly(t *testing.T) {
+	pod := helperPod("testserver")
+
+	if !strings.HasPrefix(pod.Name, "testserver"+config.HelperPodSuffix+"-") {
+		t.Errorf("Name = %s, want a random suffix after testserver-helper", pod.Name)
+	}
+	if pod.Labels[config.CommonLabelKey] == config.CommonLabelValuePod {
+		t.Error("helper pod is labeled as a server pod, it would be counted against node capacity")
+	}
+	if worldWritable(pod.Spec) {
+		t.Error("helper pod can write the world volume")
+	}
+	if claim := pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName; claim != "mc-testserver-0" {
+		t.Errorf("ClaimName = %s, want mc-testserver-0", claim)
+	}
+	if pod.Spec.ActiveDeadlineSeconds == nil {
+		t.Error("helper pod has no deadline, it could outlive a CLI that went away")
+	}
+}
+
+func TestStartHelperPod_WaitsUntilRunning(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	setHelperPhase(t, clientset, corev1.PodRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name, err := client.startHelperPod(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("startHelperPod() error = %v", err)
+	}
+
+	client.deleteHelperPod(ctx, name)
+	pods, _ := clientset.CoreV1().Pods(client.namespace).List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("%d pods left after deleteHelperPod, want none", len(pods.Items))
+	}
+}
+
+func TestStartHelperPod_Failed(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	setHelperPhase(t, clientset, corev1.PodFailed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name, err := client.startHelperPod(ctx, "testserver")
+	if err == nil {
+		t.Fatal("startHelperPod() expected error for a failed pod, got nil")
+	}
+	if name == "" {
+		t.Error("startHelperPod() returned no name, the pod could not be cleaned up")
+	}
+}
+
+func TestExecInHelper_NeedsClusterConfig(t *testing.T) {
+	client, clientset := newFakeClient(t)
+
+	if err := client.ExecInHelper(context.Background(), "testserver", []string{"true"}, nil, nil, nil); err == nil {
+		t.Fatal("ExecInHelper() expected error without a cluster config, got nil")
+	}
+	pods, _ := clientset.CoreV1().Pods(client.namespace).List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("%d pods created, want none", len(pods.Items))
+	}
+}
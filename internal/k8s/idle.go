@@ -0,0 +1,135 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetIdleTimeout sets how long the server may run without players before it is
+// stopped, 0 to never stop it
+func (c *Client) SetIdleTimeout(ctx context.Context, serverName string, timeout time.Duration) error {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return err
+	}
+
+	setIdleTimeout(&sts.ObjectMeta, timeout)
+
+	_, err = c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Update(
+			ctx,
+			sts,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to update server (statefulset): %w", err)
+	}
+
+	return nil
+}
+
+// StopIdleServer stops a server in any namespace that had no players for idleFor and
+// records why, in an annotation describe shows and in an Event on its StatefulSet
+func (c *Client) StopIdleServer(ctx context.Context, state ServerState, idleFor time.Duration) error {
+	scoped := NewClientWithInterface(c.clientset, state.Namespace)
+	sts, err := scoped.getServerStatefulSet(ctx, state.Name)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	replicas := int32(0)
+	sts.Spec.Replicas = &replicas
+	if sts.Annotations == nil {
+		sts.Annotations = map[string]string{}
+	}
+	sts.Annotations[config.IdleStoppedAnnotation] = now.UTC().Format(time.RFC3339)
+
+	_, err = c.clientset.
+		AppsV1().
+		StatefulSets(state.Namespace).
+		Update(
+			ctx,
+			sts,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to stop idle server (statefulset): %w", err)
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: state.Name + "-",
+			Namespace:    state.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Name:       sts.Name,
+			Namespace:  sts.Namespace,
+			UID:        sts.UID,
+		},
+		Reason:         config.IdleShutdownReason,
+		Message:        fmt.Sprintf("No players online for %s, stopped the server (idle timeout %s)", idleFor.Round(time.Minute), state.IdleTimeout),
+		Type:           corev1.EventTypeNormal,
+		Source:         corev1.EventSource{Component: config.IdleControllerName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	_, err = c.clientset.
+		CoreV1().
+		Events(state.Namespace).
+		Create(
+			ctx,
+			event,
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to record idle shutdown (event): %w", err)
+	}
+
+	return nil
+}
+
+// idleStoppedAt returns when the server was stopped for being idle, zero if it was
+// started or stopped by hand since
+func idleStoppedAt(sts *appsv1.StatefulSet) time.Time {
+	stopped, err := time.Parse(time.RFC3339, sts.Annotations[config.IdleStoppedAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+
+	return stopped
+}
+
+// idleTimeout reads the idle timeout annotation, 0 if unset or invalid
+func idleTimeout(sts *appsv1.StatefulSet) time.Duration {
+	timeout, err := time.ParseDuration(sts.Annotations[config.IdleTimeoutAnnotation])
+	if err != nil {
+		return 0
+	}
+
+	return timeout
+}
+
+func setIdleTimeout(meta *metav1.ObjectMeta, timeout time.Duration) {
+	if timeout <= 0 {
+		delete(meta.Annotations, config.IdleTimeoutAnnotation)
+		return
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[config.IdleTimeoutAnnotation] = timeout.String()
+}
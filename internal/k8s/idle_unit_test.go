@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateServer_IdleTimeoutAnnotation(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+
+	spec := DefaultServerSpec()
+	spec.IdleTimeout = 30 * time.Minute
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, spec); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	sts, _ := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if got := sts.Annotations[config.IdleTimeoutAnnotation]; got != "30m0s" {
+		t.Errorf("annotation = %q, want 30m0s", got)
+	}
+
+	details, err := client.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.Spec.IdleTimeout != 30*time.Minute {
+		t.Errorf("IdleTimeout = %s, want 30m read back", details.Spec.IdleTimeout)
+	}
+}
+
+func TestSetIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	if err := client.SetIdleTimeout(ctx, "testserver", time.Hour); err != nil {
+		t.Fatalf("SetIdleTimeout() error = %v", err)
+	}
+	sts, _ := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if idleTimeout(sts) != time.Hour {
+		t.Errorf("idle timeout = %s, want 1h", idleTimeout(sts))
+	}
+
+	if err := client.SetIdleTimeout(ctx, "testserver", 0); err != nil {
+		t.Fatalf("SetIdleTimeout(0) error = %v", err)
+	}
+	sts, _ = clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if _, ok := sts.Annotations[config.IdleTimeoutAnnotation]; ok {
+		t.Error("annotation still set after disabling the idle timeout")
+	}
+}
+
+func TestStopIdleServer_RecordsEvent(t *testing.T) {
+	ctx := context.Background()
+	user, proxy := wakeFixture(t, 1)
+	if err := user.SetIdleTimeout(ctx, "testserver", 30*time.Minute); err != nil {
+		t.Fatalf("SetIdleTimeout() error = %v", err)
+	}
+	states, _ := proxy.ListServerStates(ctx)
+	if states[0].IdleTimeout != 30*time.Minute {
+		t.Fatalf("IdleTimeout = %s, want 30m", states[0].IdleTimeout)
+	}
+
+	if err := proxy.StopIdleServer(ctx, states[0], 31*time.Minute); err != nil {
+		t.Fatalf("StopIdleServer() error = %v", err)
+	}
+
+	running, _ := user.IsServerRunning(ctx, "testserver")
+	if running {
+		t.Error("server still running after StopIdleServer")
+	}
+	details, err := user.DescribeServer(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("DescribeServer() error = %v", err)
+	}
+	if details.IdleStoppedAt.IsZero() {
+		t.Error("IdleStoppedAt is zero, want the idle shutdown shown")
+	}
+	events, _ := proxy.clientset.CoreV1().Events(user.namespace).List(ctx, metav1.ListOptions{})
+	if len(events.Items) != 1 || events.Items[0].Reason != config.IdleShutdownReason || !strings.Contains(events.Items[0].Message, "No players online for 31m") {
+		t.Errorf("events = %+v, want the idle shutdown recorded", events.Items)
+	}
+
+	// Once started and stopped by hand, the old shutdown no longer explains anything
+	user.ScaleServer(ctx, "testserver", 1)
+	user.ScaleServer(ctx, "testserver", 0)
+	details, _ = user.DescribeServer(ctx, "testserver")
+	if !details.IdleStoppedAt.IsZero() {
+		t.Errorf("IdleStoppedAt = %s after a stop by hand, want zero", details.IdleStoppedAt)
+	}
+}
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogOptions select which part of the server log to stream
+type LogOptions struct {
+	Follow   bool
+	Tail     int64         // Number of lines from the end, negative for all
+	Since    time.Duration // Only lines newer than this, zero for all
+	Previous bool          // Log of the previous (crashed) container
+}
+
+// podLogOptions converts the options into the form the API server expects
+func (o LogOptions) podLogOptions() *corev1.PodLogOptions {
+	opts := &corev1.PodLogOptions{
+		Container: config.CommonLabelValuePod,
+		Follow:    o.Follow,
+		Previous:  o.Previous,
+	}
+
+	if o.Tail >= 0 {
+		opts.TailLines = &o.Tail
+	}
+	if o.Since > 0 {
+		seconds := int64(math.Ceil(o.Since.Seconds()))
+		opts.SinceSeconds = &seconds
+	}
+
+	return opts
+}
+
+// StreamLogs opens the log of the server container. The caller closes the stream;
+// with Follow it stays open until the container stops or ctx is done.
+func (c *Client) StreamLogs(ctx context.Context, serverName string, opts LogOptions) (io.ReadCloser, error) {
+	podName := serverName + "-0"
+
+	// A stopped server has no pod, say so instead of surfacing a bare NotFound
+	_, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		Get(
+			ctx,
+			podName,
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("server (%s) is not running, start it to see its logs", serverName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server pod: %w", err)
+	}
+
+	stream, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		GetLogs(
+			podName,
+			opts.podLogOptions(),
+		).
+		Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream server logs: %w", err)
+	}
+
+	return stream, nil
+}
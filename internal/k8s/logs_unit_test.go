@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestStreamLogs_PassesOptions(t *testing.T) {
+	pod := fakeMinecraftPod(config.NamespacePrefix+fakeUsername, "testserver-0", config.ServerMemoryRequest, corev1.PodRunning)
+	client, clientset := newFakeClient(t, pod)
+
+	opts := LogOptions{Follow: true, Tail: 50, Since: 90 * time.Second, Previous: true}
+	stream, err := client.StreamLogs(context.Background(), "testserver", opts)
+	if err != nil {
+		t.Fatalf("StreamLogs() error = %v", err)
+	}
+	defer stream.Close()
+
+	body, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("failed to read log stream: %v", err)
+	}
+	if len(body) == 0 {
+		t.Error("StreamLogs() returned an empty stream")
+	}
+
+	var got *corev1.PodLogOptions
+	for _, action := range clientset.Actions() {
+		if action.GetSubresource() == "log" {
+			got = action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		}
+	}
+	if got == nil {
+		t.Fatal("StreamLogs() did not request the pod log")
+	}
+
+	if got.Container != config.CommonLabelValuePod {
+		t.Errorf("Container = %q, want %q", got.Container, config.CommonLabelValuePod)
+	}
+	if !got.Follow || !got.Previous {
+		t.Errorf("Follow = %v, Previous = %v, want both true", got.Follow, got.Previous)
+	}
+	if got.TailLines == nil || *got.TailLines != 50 {
+		t.Errorf("TailLines = %v, want 50", got.TailLines)
+	}
+	if got.SinceSeconds == nil || *got.SinceSeconds != 90 {
+		t.Errorf("SinceSeconds = %v, want 90", got.SinceSeconds)
+	}
+}
+
+func TestStreamLogs_AllLinesByDefault(t *testing.T) {
+	opts := LogOptions{Tail: -1}.podLogOptions()
+
+	if opts.TailLines != nil {
+		t.Errorf("TailLines = %d, want unset", *opts.TailLines)
+	}
+	if opts.SinceSeconds != nil {
+		t.Errorf("SinceSeconds = %d, want unset", *opts.SinceSeconds)
+	}
+}
+
+func TestStreamLogs_StoppedServer(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	_, err := client.StreamLogs(context.Background(), "testserver", LogOptions{Tail: -1})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("StreamLogs() error = %v, want a not running error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maintenanceEnv makes the launcher keep the container up without starting the server
+var maintenanceEnv = corev1.EnvVar{Name: "MAINTENANCE", Value: "true"}
+
+// SetMaintenance switches the server in or out of maintenance mode and scales it to replicas.
+// In maintenance mode the pod runs with the world mounted but no Minecraft process,
+// so the world can be read or replaced through ExecInServer.
+func (c *Client) SetMaintenance(ctx context.Context, serverName string, enabled bool, replicas int32) error {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	if len(sts.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("server (%s) has no containers", serverName)
+	}
+
+	container := &sts.Spec.Template.Spec.Containers[0]
+	container.Env = slices.DeleteFunc(container.Env, func(env corev1.EnvVar) bool {
+		return env.Name == maintenanceEnv.Name
+	})
+	if enabled {
+		container.Env = append(container.Env, maintenanceEnv)
+	}
+	sts.Spec.Replicas = &replicas
+
+	_, err = c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Update(
+			ctx,
+			sts,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to update server (statefulset): %w", err)
+	}
+
+	return nil
+}
+
+// WaitForMaintenance blocks until the server pod runs in maintenance mode, or ctx is done
+func (c *Client) WaitForMaintenance(ctx context.Context, serverName string, progress func(phase string)) error {
+	return c.waitForPod(ctx, serverName, "enter maintenance mode", inMaintenance, progress)
+}
+
+// inMaintenance reports whether the pod is a live maintenance-mode pod, not one
+// still left over from before the switch
+func inMaintenance(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil && containerRunning(pod) && maintenanceEnabled(pod)
+}
+
+func maintenanceEnabled(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == config.CommonLabelValuePod && slices.Contains(container.Env, maintenanceEnv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsServerRunning reports whether the server is scaled up
+func (c *Client) IsServerRunning(ctx context.Context, serverName string) (bool, error) {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return false, err
+	}
+
+	return sts.Spec.Replicas == nil || *sts.Spec.Replicas > 0, nil
+}
+
+// GetServerVersion returns the Minecraft version the server is configured to run
+func (c *Client) GetServerVersion(ctx context.Context, serverName string) (string, error) {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == "VERSION" {
+				return env.Value, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("server (%s) has no VERSION set", serverName)
+}
+
+func (c *Client) getServerStatefulSet(ctx context.Context, serverName string) (*appsv1.StatefulSet, error) {
+	sts, err := c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Get(
+			ctx,
+			serverName,
+			metav1.GetOptions{},
+		)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server (statefulset): %w", err)
+	}
+
+	return sts, nil
+}
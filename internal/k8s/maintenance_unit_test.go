@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetMaintenance_TogglesEnvAndScales(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	namespace := config.NamespacePrefix + fakeUsername
+
+	if err := client.SetMaintenance(ctx, "testserver", true, 1); err != nil {
+		t.Fatalf("SetMaintenance(true) error = %v", err)
+	}
+	// Switching twice must not add the variable twice
+	if err := client.SetMaintenance(ctx, "testserver", true, 1); err != nil {
+		t.Fatalf("SetMaintenance(true) error = %v", err)
+	}
+
+	sts, _ := clientset.AppsV1().StatefulSets(namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	count := 0
+	for _, env := range sts.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "MAINTENANCE" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("MAINTENANCE set %d times, want 1", count)
+	}
+	if *sts.Spec.Replicas != 1 {
+		t.Errorf("replicas = %d, want 1", *sts.Spec.Replicas)
+	}
+
+	if err := client.SetMaintenance(ctx, "testserver", false, 0); err != nil {
+		t.Fatalf("SetMaintenance(false) error = %v", err)
+	}
+
+	sts, _ = clientset.AppsV1().StatefulSets(namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if _, ok := envValue(sts.Spec.Template.Spec.Containers[0], "MAINTENANCE"); ok {
+		t.Error("MAINTENANCE still set after leaving maintenance mode")
+	}
+	if _, ok := envValue(sts.Spec.Template.Spec.Containers[0], "VERSION"); !ok {
+		t.Error("VERSION lost while toggling maintenance mode")
+	}
+	if *sts.Spec.Replicas != 0 {
+		t.Errorf("replicas = %d, want 0", *sts.Spec.Replicas)
+	}
+}
+
+func TestWaitForMaintenance_IgnoresNormalPod(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	pod := runningServerPod()
+	client, clientset := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitForMaintenance(ctx, "testserver", nil)
+	}()
+
+	waitForWatches(t, clientset, "pods")
+
+	// The replacement pod comes up in maintenance mode
+	pod = pod.DeepCopy()
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, maintenanceEnv)
+	if _, err := clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("WaitForMaintenance() error = %v, want nil", err)
+	}
+}
+
+func TestWaitForReady_IgnoresMaintenancePod(t *testing.T) {
+	pod := runningServerPod()
+	pod.Spec.Containers[0].Env = []corev1.EnvVar{maintenanceEnv}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	client, _ := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := client.WaitForReady(ctx, "testserver", nil); err == nil {
+		t.Error("WaitForReady() returned for a maintenance pod, want a timeout")
+	}
+}
+
+func TestGetServerVersion(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newFakeClient(t)
+	spec := DefaultServerSpec()
+	spec.Version = "1.20.4"
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, spec); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	version, err := client.GetServerVersion(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("GetServerVersion() error = %v", err)
+	}
+	if version != "1.20.4" {
+		t.Errorf("GetServerVersion() = %q, want 1.20.4", version)
+	}
+
+	running, err := client.IsServerRunning(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("IsServerRunning() error = %v", err)
+	}
+	if !running {
+		t.Error("IsServerRunning() = false for a new server, want true")
+	}
+}
@@ -10,7 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (c *Client) CreateNamespace(username string) error {
+func (c *Client) CreateNamespace(ctx context.Context, username string) error {
 	// Build namespace name
 	nsName := config.NamespacePrefix + username
 
@@ -30,7 +30,7 @@ func (c *Client) CreateNamespace(username string) error {
 		CoreV1().
 		Namespaces().
 		Create(
-			context.TODO(),
+			ctx,
 			ns,
 			metav1.CreateOptions{},
 		)
@@ -46,7 +46,7 @@ func (c *Client) CreateNamespace(username string) error {
 	return nil
 }
 
-func (c *Client) NamespaceExists(username string) (bool, error) {
+func (c *Client) NamespaceExists(ctx context.Context, username string) (bool, error) {
 	// Build namespace name
 	nsName := "mc-" + username
 
@@ -55,7 +55,7 @@ func (c *Client) NamespaceExists(username string) (bool, error) {
 		CoreV1().
 		Namespaces().
 		Get(
-			context.TODO(),
+			ctx,
 			nsName,
 			metav1.GetOptions{},
 		)
@@ -69,14 +69,14 @@ func (c *Client) NamespaceExists(username string) (bool, error) {
 	return true, nil
 }
 
-func (c *Client) DeleteNamespace(username string) error {
+func (c *Client) DeleteNamespace(ctx context.Context, username string) error {
 	nsName := config.NamespacePrefix + username
 
 	err := c.clientset.
 		CoreV1().
 		Namespaces().
 		Delete(
-			context.TODO(),
+			ctx,
 			nsName,
 			metav1.DeleteOptions{},
 		)
@@ -87,13 +87,13 @@ func (c *Client) DeleteNamespace(username string) error {
 	return nil
 }
 
-func (c *Client) CountUserNamespaces() (int, error) {
+func (c *Client) CountUserNamespaces(ctx context.Context) (int, error) {
 	// Get all the existing namespaces as a list
 	nsList, err := c.clientset.
 		CoreV1().
 		Namespaces().
 		List(
-			context.TODO(),
+			ctx,
 			metav1.ListOptions{
 				LabelSelector: config.CommonLabelSelector,
 			},
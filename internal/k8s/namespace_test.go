@@ -15,14 +15,14 @@ func TestCreateNamespace_Success(t *testing.T) {
 	username := UniqueUsername()
 	defer CleanupNamespace(t, client, username)
 
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Verify namespace was created
 	nsName := config.NamespacePrefix + username
-	exists, err := client.NamespaceExists(username)
+	exists, err := client.NamespaceExists(context.Background(), username)
 	if err != nil {
 		t.Fatalf("NamespaceExists() error = %v", err)
 	}
@@ -43,13 +43,13 @@ func TestCreateNamespace_AlreadyExists(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace first time
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() first call error = %v", err)
 	}
 
 	// Try to create again - should fail
-	err = client.CreateNamespace(username)
+	err = client.CreateNamespace(context.Background(), username)
 	if err == nil {
 		t.Fatal("CreateNamespace() expected error for duplicate namespace, got nil")
 	}
@@ -65,7 +65,7 @@ func TestCreateNamespace_LabelsCorrect(t *testing.T) {
 	username := UniqueUsername()
 	defer CleanupNamespace(t, client, username)
 
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
@@ -103,13 +103,13 @@ func TestNamespaceExists_ReturnsTrue(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Check existence
-	exists, err := client.NamespaceExists(username)
+	exists, err := client.NamespaceExists(context.Background(), username)
 	if err != nil {
 		t.Fatalf("NamespaceExists() error = %v", err)
 	}
@@ -124,7 +124,7 @@ func TestNamespaceExists_ReturnsFalse(t *testing.T) {
 	username := UniqueUsername()
 
 	// Don't create namespace - just check if it exists
-	exists, err := client.NamespaceExists(username)
+	exists, err := client.NamespaceExists(context.Background(), username)
 	if err != nil {
 		t.Fatalf("NamespaceExists() error = %v", err)
 	}
@@ -147,21 +147,21 @@ func TestCountUserNamespaces_ReturnsCorrectCount(t *testing.T) {
 	}
 
 	// Get initial count
-	initialCount, err := client.CountUserNamespaces()
+	initialCount, err := client.CountUserNamespaces(context.Background())
 	if err != nil {
 		t.Fatalf("CountUserNamespaces() initial error = %v", err)
 	}
 
 	// Create namespaces
 	for _, username := range usernames {
-		err := client.CreateNamespace(username)
+		err := client.CreateNamespace(context.Background(), username)
 		if err != nil {
 			t.Fatalf("CreateNamespace(%s) error = %v", username, err)
 		}
 	}
 
 	// Count again
-	finalCount, err := client.CountUserNamespaces()
+	finalCount, err := client.CountUserNamespaces(context.Background())
 	if err != nil {
 		t.Fatalf("CountUserNamespaces() final error = %v", err)
 	}
@@ -178,7 +178,7 @@ func TestCountUserNamespaces_IgnoresNonKubecraftNamespaces(t *testing.T) {
 	client := GetTestClient(t)
 
 	// Get initial count
-	initialCount, err := client.CountUserNamespaces()
+	initialCount, err := client.CountUserNamespaces(context.Background())
 	if err != nil {
 		t.Fatalf("CountUserNamespaces() error = %v", err)
 	}
@@ -0,0 +1,162 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// pluginLockVolume names the pod volume holding the server's plugin lockfile
+const pluginLockVolume = "plugins"
+
+func pluginLockName(serverName string) string {
+	return serverName + config.PluginLockSuffix
+}
+
+// mountPluginLock mounts the server's plugin ConfigMap into its container, where the
+// launcher reads the lockfile. The ConfigMap is optional, servers without plugins have
+// none. It reports whether the pod template changed.
+func mountPluginLock(sts *appsv1.StatefulSet) bool {
+	pod := &sts.Spec.Template.Spec
+	if slices.ContainsFunc(pod.Volumes, func(v corev1.Volume) bool { return v.Name == pluginLockVolume }) {
+		return false
+	}
+
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: pluginLockVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: pluginLockName(sts.Name)},
+				Optional:             ptr.To(true),
+			},
+		},
+	})
+	container := &pod.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      pluginLockVolume,
+		MountPath: config.PluginLockMountPath,
+		ReadOnly:  true,
+	})
+
+	return true
+}
+
+// GetPluginLock returns the server's plugin lockfile, empty if it has no plugins
+func (c *Client) GetPluginLock(ctx context.Context, serverName string) ([]byte, error) {
+	cm, err := c.clientset.
+		CoreV1().
+		ConfigMaps(c.namespace).
+		Get(
+			ctx,
+			pluginLockName(serverName),
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin lock (configmap): %w", err)
+	}
+
+	return []byte(cm.Data[config.PluginLockKey]), nil
+}
+
+// SavePluginLock stores the server's plugin lockfile, which the launcher installs from
+// on the next start. Servers created before plugin support get the ConfigMap mounted,
+// which restarts them if running.
+func (c *Client) SavePluginLock(ctx context.Context, serverName string, lock []byte) error {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	if len(sts.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("server (%s) has no containers", serverName)
+	}
+
+	cm, err := c.clientset.
+		CoreV1().
+		ConfigMaps(c.namespace).
+		Get(
+			ctx,
+			pluginLockName(serverName),
+			metav1.GetOptions{},
+		)
+	switch {
+	case errors.IsNotFound(err):
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pluginLockName(serverName),
+				Namespace: c.namespace,
+				Labels: map[string]string{
+					config.CommonLabelKey: config.CommonLabelValue,
+					"server":              serverName,
+					"user":                sts.Labels["user"],
+				},
+			},
+			Data: map[string]string{config.PluginLockKey: string(lock)},
+		}
+		_, err = c.clientset.
+			CoreV1().
+			ConfigMaps(c.namespace).
+			Create(
+				ctx,
+				cm,
+				metav1.CreateOptions{},
+			)
+		if err != nil {
+			return fmt.Errorf("failed to create plugin lock (configmap): %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get plugin lock (configmap): %w", err)
+	default:
+		cm.Data = map[string]string{config.PluginLockKey: string(lock)}
+		_, err = c.clientset.
+			CoreV1().
+			ConfigMaps(c.namespace).
+			Update(
+				ctx,
+				cm,
+				metav1.UpdateOptions{},
+			)
+		if err != nil {
+			return fmt.Errorf("failed to update plugin lock (configmap): %w", err)
+		}
+	}
+
+	if !mountPluginLock(sts) {
+		return nil
+	}
+	_, err = c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Update(
+			ctx,
+			sts,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to update server (statefulset): %w", err)
+	}
+
+	return nil
+}
+
+// deletePluginLock removes the server's plugin ConfigMap, missing if it never had plugins
+func (c *Client) deletePluginLock(ctx context.Context, serverName string) error {
+	err := c.clientset.
+		CoreV1().
+		ConfigMaps(c.namespace).
+		Delete(
+			ctx,
+			pluginLockName(serverName),
+			metav1.DeleteOptions{},
+		)
+	return ignoreAbsent(err)
+}
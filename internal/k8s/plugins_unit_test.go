@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSavePluginLock(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	ctx := context.Background()
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	lock, err := client.GetPluginLock(ctx, "testserver")
+	if err != nil || lock != nil {
+		t.Fatalf("GetPluginLock() = %q, %v, want nothing before any plugin", lock, err)
+	}
+
+	for _, want := range []string{`{"plugins": [1]}`, `{"plugins": [2]}`} {
+		if err := client.SavePluginLock(ctx, "testserver", []byte(want)); err != nil {
+			t.Fatalf("SavePluginLock() error = %v", err)
+		}
+		lock, err := client.GetPluginLock(ctx, "testserver")
+		if err != nil || string(lock) != want {
+			t.Errorf("GetPluginLock() = %q, %v, want %q", lock, err, want)
+		}
+	}
+
+	if err := client.CleanupServer(ctx, "testserver"); err != nil {
+		t.Fatalf("CleanupServer() error = %v", err)
+	}
+	_, err = clientset.CoreV1().ConfigMaps(client.namespace).Get(ctx, "testserver"+config.PluginLockSuffix, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("plugin lock ConfigMap left after CleanupServer(), err = %v", err)
+	}
+}
+
+func TestSavePluginLock_MountsOnOlderServers(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	ctx := context.Background()
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	// A server created before plugin support
+	sts, _ := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	sts.Spec.Template.Spec.Volumes = nil
+	sts.Spec.Template.Spec.Containers[0].VolumeMounts = sts.Spec.Template.Spec.Containers[0].VolumeMounts[:1]
+	clientset.AppsV1().StatefulSets(client.namespace).Update(ctx, sts, metav1.UpdateOptions{})
+
+	if err := client.SavePluginLock(ctx, "testserver", []byte("{}")); err != nil {
+		t.Fatalf("SavePluginLock() error = %v", err)
+	}
+
+	sts, _ = clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	mounts := sts.Spec.Template.Spec.Containers[0].VolumeMounts
+	if len(mounts) != 2 || mounts[1].MountPath != config.PluginLockMountPath {
+		t.Errorf("VolumeMounts = %+v, want the plugin lock at %s", mounts, config.PluginLockMountPath)
+	}
+	volumes := sts.Spec.Template.Spec.Volumes
+	if len(volumes) != 1 || volumes[0].ConfigMap == nil || volumes[0].ConfigMap.Name != "testserver"+config.PluginLockSuffix {
+		t.Errorf("Volumes = %+v, want the plugin ConfigMap", volumes)
+	}
+}
@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward is an open tunnel from a local port to a port on the server pod
+type PortForward struct {
+	LocalPort uint16
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+}
+
+// Close shuts the tunnel down
+func (p *PortForward) Close() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+}
+
+// ForwardPort opens a SPDY port-forward from a random local port on 127.0.0.1 to
+// remotePort on the server pod. The tunnel stays up until Close is called or ctx is done.
+func (c *Client) ForwardPort(ctx context.Context, serverName string, remotePort int) (*PortForward, error) {
+	if c.restConfig == nil {
+		return nil, fmt.Errorf("port-forwarding needs a client created from a cluster config")
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(c.restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+
+	url := c.clientset.
+		CoreV1().
+		RESTClient().
+		Post().
+		Resource("pods").
+		Namespace(c.namespace).
+		Name(serverName + "-0").
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	pf := &PortForward{stopCh: make(chan struct{})}
+	readyCh := make(chan struct{})
+
+	forwarder, err := portforward.NewOnAddresses(
+		dialer,
+		[]string{"127.0.0.1"},
+		[]string{fmt.Sprintf("0:%d", remotePort)},
+		pf.stopCh,
+		readyCh,
+		io.Discard,
+		io.Discard,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up port-forward: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return nil, fmt.Errorf("failed to port-forward to server (%s): %w", serverName, err)
+	case <-ctx.Done():
+		pf.Close()
+		return nil, ctx.Err()
+	}
+
+	// Tie the tunnel to ctx so callers cannot leak it
+	go func() {
+		select {
+		case <-ctx.Done():
+			pf.Close()
+		case <-pf.stopCh:
+		}
+	}()
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		pf.Close()
+		return nil, fmt.Errorf("failed to get forwarded port: %v", err)
+	}
+	pf.LocalPort = ports[0].Local
+
+	return pf, nil
+}
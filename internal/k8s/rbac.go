@@ -4,16 +4,18 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/baighasan/kubecraft/internal/config"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (c *Client) CreateServiceAccount(username string) error {
+func (c *Client) CreateServiceAccount(ctx context.Context, username string) error {
 	// Create service account object
 	sa := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
@@ -31,7 +33,7 @@ func (c *Client) CreateServiceAccount(username string) error {
 		CoreV1().
 		ServiceAccounts(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			sa,
 			metav1.CreateOptions{},
 		)
@@ -42,7 +44,7 @@ func (c *Client) CreateServiceAccount(username string) error {
 	return nil
 }
 
-func (c *Client) CreateRole() error {
+func (c *Client) CreateRole(ctx context.Context) error {
 	// Create role object
 	r := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
@@ -53,28 +55,7 @@ func (c *Client) CreateRole() error {
 				"component": "rbac", // Add to constants later to remove hardcoding
 			},
 		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{""},
-				Resources: []string{"persistentvolumeclaims", "services"},
-				Verbs:     []string{"get", "list", "create", "update", "delete"},
-			},
-			{
-				APIGroups: []string{""},
-				Resources: []string{"pods"},
-				Verbs:     []string{"get", "list"},
-			},
-			{
-				APIGroups: []string{""},
-				Resources: []string{"pods/log"},
-				Verbs:     []string{"get"},
-			},
-			{
-				APIGroups: []string{"apps"},
-				Resources: []string{"statefulsets"},
-				Verbs:     []string{"create", "get", "list", "patch", "update", "delete"},
-			},
-		},
+		Rules: userRoleRules(),
 	}
 
 	// Create role in cluster
@@ -82,7 +63,7 @@ func (c *Client) CreateRole() error {
 		RbacV1().
 		Roles(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			r,
 			metav1.CreateOptions{},
 		)
@@ -93,7 +74,69 @@ func (c *Client) CreateRole() error {
 	return nil
 }
 
-func (c *Client) CreateRoleBinding(username string) error {
+// userRoleRules are the permissions a user's Role grants in their namespace
+func userRoleRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"persistentvolumeclaims", "services"},
+			Verbs:     []string{"get", "list", "create", "update", "delete"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list", "watch", "create", "delete"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"events"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/log"},
+			Verbs:     []string{"get"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods/portforward", "pods/exec"},
+			Verbs:     []string{"get", "create"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"secrets"},
+			Verbs:     []string{"get", "create", "delete"},
+		},
+		{
+			// Short-lived archive tokens the CLI hands to the server pod
+			APIGroups: []string{""},
+			Resources: []string{"serviceaccounts/token"},
+			Verbs:     []string{"create"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"get", "create", "update", "delete"},
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"statefulsets"},
+			Verbs:     []string{"create", "get", "list", "patch", "update", "delete"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"cronjobs"},
+			Verbs:     []string{"create", "get", "list", "update", "delete"},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"create", "delete"},
+		},
+	}
+}
+
+func (c *Client) CreateRoleBinding(ctx context.Context, username string) error {
 	// Create role binding object
 	rb := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -124,7 +167,7 @@ func (c *Client) CreateRoleBinding(username string) error {
 		RbacV1().
 		RoleBindings(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			rb,
 			metav1.CreateOptions{},
 		)
@@ -135,11 +178,11 @@ func (c *Client) CreateRoleBinding(username string) error {
 	return nil
 }
 
-func (c *Client) CreateResourceQuota(username string) error {
+func (c *Client) CreateResourceQuota(ctx context.Context, username string) error {
 	// Create resource quota object
 	rq := &corev1.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "mc-compute-resources", // Add to constants.go later to prevent hardcoding
+			Name:      config.UserResourceQuotaName,
 			Namespace: c.namespace,
 			Labels: map[string]string{
 				"app":  config.CommonLabelValue,
@@ -147,13 +190,7 @@ func (c *Client) CreateResourceQuota(username string) error {
 			},
 		},
 		Spec: corev1.ResourceQuotaSpec{
-			Hard: map[corev1.ResourceName]resource.Quantity{
-				corev1.ResourceRequestsCPU:            resource.MustParse(config.ServerCPURequest),
-				corev1.ResourceRequestsMemory:         resource.MustParse(config.ServerMemoryRequest),
-				corev1.ResourceLimitsCPU:              resource.MustParse(config.ServerCPULimit),
-				corev1.ResourceLimitsMemory:           resource.MustParse(config.ServerMemoryLimit),
-				corev1.ResourcePersistentVolumeClaims: resource.MustParse("1"),
-			},
+			Hard: userQuota(),
 		},
 	}
 
@@ -162,7 +199,7 @@ func (c *Client) CreateResourceQuota(username string) error {
 		CoreV1().
 		ResourceQuotas(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			rq,
 			metav1.CreateOptions{},
 		)
@@ -173,13 +210,150 @@ func (c *Client) CreateResourceQuota(username string) error {
 	return nil
 }
 
-func (c *Client) AddUserToCapacityChecker(username string) error {
+// userQuota is the hard limit of a user's ResourceQuota
+func userQuota() corev1.ResourceList {
+	return corev1.ResourceList{
+		// Room for a server and its scheduled backup job running side by side
+		corev1.ResourceRequestsCPU:            sumQuantities(config.ServerCPURequest, config.BackupJobCPURequest),
+		corev1.ResourceRequestsMemory:         sumQuantities(config.ServerMemoryRequest, config.BackupJobMemoryRequest),
+		corev1.ResourceLimitsCPU:              sumQuantities(config.ServerCPULimit, config.BackupJobCPULimit),
+		corev1.ResourceLimitsMemory:           sumQuantities(config.ServerMemoryLimit, config.BackupJobMemoryLimit),
+		corev1.ResourcePersistentVolumeClaims: *resource.NewQuantity(config.UserPVCLimit, resource.DecimalSI),
+	}
+}
+
+// ReconcileUserRBAC updates the Role and ResourceQuota of every registered user to the
+// current ones. Both are only created at registration, so without this users registered
+// before a feature was added lack the permissions and quota it needs. It should be
+// called at startup.
+func (c *Client) ReconcileUserRBAC(ctx context.Context) error {
+	namespaces, err := c.clientset.
+		CoreV1().
+		Namespaces().
+		List(
+			ctx,
+			metav1.ListOptions{
+				LabelSelector: config.CommonLabelSelector,
+			},
+		)
+	if err != nil {
+		return fmt.Errorf("could not list user namespaces: %w", err)
+	}
+
+	// One broken namespace should not keep the others from being updated
+	var failed []string
+	for _, ns := range namespaces.Items {
+		if ns.Labels["user"] == "" {
+			continue
+		}
+
+		if err := c.reconcileRole(ctx, ns.Name); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", ns.Name, err))
+			continue
+		}
+		if err := c.reconcileResourceQuota(ctx, ns.Name); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", ns.Name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not update user RBAC (%s)", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (c *Client) reconcileRole(ctx context.Context, namespace string) error {
+	role, err := c.clientset.
+		RbacV1().
+		Roles(namespace).
+		Get(
+			ctx,
+			config.UserRoleName,
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil // Registration failed half-way, the namespace is being deleted
+	}
+	if err != nil {
+		return fmt.Errorf("could not get Role: %w", err)
+	}
+
+	rules := userRoleRules()
+	if equality.Semantic.DeepEqual(role.Rules, rules) {
+		return nil
+	}
+	role.Rules = rules
+
+	_, err = c.clientset.
+		RbacV1().
+		Roles(namespace).
+		Update(
+			ctx,
+			role,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("could not update Role: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) reconcileResourceQuota(ctx context.Context, namespace string) error {
+	rq, err := c.clientset.
+		CoreV1().
+		ResourceQuotas(namespace).
+		Get(
+			ctx,
+			config.UserResourceQuotaName,
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get ResourceQuota: %w", err)
+	}
+
+	hard := userQuota()
+	if equality.Semantic.DeepEqual(rq.Spec.Hard, hard) {
+		return nil
+	}
+	rq.Spec.Hard = hard
+
+	_, err = c.clientset.
+		CoreV1().
+		ResourceQuotas(namespace).
+		Update(
+			ctx,
+			rq,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("could not update ResourceQuota: %w", err)
+	}
+
+	return nil
+}
+
+// sumQuantities adds up resource quantities such as "100m" and "1000m"
+func sumQuantities(values ...string) resource.Quantity {
+	var total resource.Quantity
+	for _, v := range values {
+		total.Add(resource.MustParse(v))
+	}
+
+	return total
+}
+
+func (c *Client) AddUserToCapacityChecker(ctx context.Context, username string) error {
 	// Get the cluster role binding from the cluster
 	crb, err := c.clientset.
 		RbacV1().
 		ClusterRoleBindings().
 		Get(
-			context.TODO(),
+			ctx,
 			config.CapacityCheckerBinding,
 			metav1.GetOptions{},
 		)
@@ -208,7 +382,7 @@ func (c *Client) AddUserToCapacityChecker(username string) error {
 		RbacV1().
 		ClusterRoleBindings().
 		Update(
-			context.TODO(),
+			ctx,
 			crb,
 			metav1.UpdateOptions{},
 		)
@@ -219,12 +393,12 @@ func (c *Client) AddUserToCapacityChecker(username string) error {
 	return nil
 }
 
-func (c *Client) RemoveUserFromCapacityChecker(username string) error {
+func (c *Client) RemoveUserFromCapacityChecker(ctx context.Context, username string) error {
 	crb, err := c.clientset.
 		RbacV1().
 		ClusterRoleBindings().
 		Get(
-			context.TODO(),
+			ctx,
 			config.CapacityCheckerBinding,
 			metav1.GetOptions{},
 		)
@@ -246,7 +420,7 @@ func (c *Client) RemoveUserFromCapacityChecker(username string) error {
 		RbacV1().
 		ClusterRoleBindings().
 		Update(
-			context.TODO(),
+			ctx,
 			crb,
 			metav1.UpdateOptions{},
 		)
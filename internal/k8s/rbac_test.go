@@ -18,13 +18,13 @@ func TestCreateServiceAccount_Success(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace first
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Create ServiceAccount
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
@@ -55,13 +55,13 @@ func TestCreateRole_Success(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace first
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Create Role
-	err = client.CreateRole()
+	err = client.CreateRole(context.Background())
 	if err != nil {
 		t.Fatalf("CreateRole() error = %v", err)
 	}
@@ -113,23 +113,23 @@ func TestCreateRoleBinding_Success(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace, ServiceAccount, and Role first
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
 
-	err = client.CreateRole()
+	err = client.CreateRole(context.Background())
 	if err != nil {
 		t.Fatalf("CreateRole() error = %v", err)
 	}
 
 	// Create RoleBinding
-	err = client.CreateRoleBinding(username)
+	err = client.CreateRoleBinding(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateRoleBinding() error = %v", err)
 	}
@@ -169,13 +169,13 @@ func TestCreateResourceQuota_Success(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace first
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Create ResourceQuota
-	err = client.CreateResourceQuota(username)
+	err = client.CreateResourceQuota(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateResourceQuota() error = %v", err)
 	}
@@ -191,14 +191,14 @@ func TestCreateResourceQuota_Success(t *testing.T) {
 		t.Fatalf("Failed to get ResourceQuota: %v", err)
 	}
 
-	// Verify limits match config constants (optimized for Oracle Cloud A1)
+	// Verify limits fit one server plus its backup job (optimized for Oracle Cloud A1)
 	// Use Quantity comparison to handle K8s normalization (e.g., 1000m -> 1)
 	expectedLimits := map[string]string{
-		"requests.cpu":           config.ServerCPURequest,
-		"requests.memory":        config.ServerMemoryRequest,
-		"limits.cpu":             config.ServerCPULimit,
-		"limits.memory":          config.ServerMemoryLimit,
-		"persistentvolumeclaims": "1",
+		"requests.cpu":           "1100m", // ServerCPURequest + BackupJobCPURequest
+		"requests.memory":        "2304Mi",
+		"limits.cpu":             "2000m",
+		"limits.memory":          "4608Mi",
+		"persistentvolumeclaims": "2", // World + backups
 	}
 
 	for resourceName, expectedValue := range expectedLimits {
@@ -225,12 +225,12 @@ func TestAddUserToCapacityChecker_Success(t *testing.T) {
 	RequireSystemRBAC(t, client)
 
 	// Create namespace and ServiceAccount first
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
@@ -247,7 +247,7 @@ func TestAddUserToCapacityChecker_Success(t *testing.T) {
 	initialCount := len(crb.Subjects)
 
 	// Add user to capacity checker
-	err = client.AddUserToCapacityChecker(username)
+	err = client.AddUserToCapacityChecker(context.Background(), username)
 	if err != nil {
 		t.Fatalf("AddUserToCapacityChecker() error = %v", err)
 	}
@@ -292,24 +292,24 @@ func TestAddUserToCapacityChecker_Duplicate(t *testing.T) {
 	RequireSystemRBAC(t, client)
 
 	// Create namespace and ServiceAccount
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
 
 	// Add user first time
-	err = client.AddUserToCapacityChecker(username)
+	err = client.AddUserToCapacityChecker(context.Background(), username)
 	if err != nil {
 		t.Fatalf("AddUserToCapacityChecker() first call error = %v", err)
 	}
 
 	// Try to add again - should fail
-	err = client.AddUserToCapacityChecker(username)
+	err = client.AddUserToCapacityChecker(context.Background(), username)
 	if err == nil {
 		t.Fatal("AddUserToCapacityChecker() expected error for duplicate, got nil")
 	}
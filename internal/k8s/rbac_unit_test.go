@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileUserRBAC_UpdatesOlderUsers(t *testing.T) {
+	ctx := context.Background()
+	namespace := config.NamespacePrefix + fakeUsername
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   namespace,
+		Labels: map[string]string{config.CommonLabelKey: config.CommonLabelValue, "user": fakeUsername},
+	}}
+	// The Role and quota of a user registered before RCON and backups
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: config.UserRoleName, Namespace: namespace},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"apps"},
+			Resources: []string{"statefulsets"},
+			Verbs:     []string{"create", "get", "list", "patch", "update", "delete"},
+		}},
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: config.UserResourceQuotaName, Namespace: namespace},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourcePersistentVolumeClaims: resource.MustParse("1"),
+		}},
+	}
+	client, clientset := newFakeClient(t, ns, role, quota)
+
+	if err := client.ReconcileUserRBAC(ctx); err != nil {
+		t.Fatalf("ReconcileUserRBAC() error = %v", err)
+	}
+
+	role, err := clientset.RbacV1().Roles(namespace).Get(ctx, config.UserRoleName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get Role: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(role.Rules, userRoleRules()) {
+		t.Errorf("Role rules = %v, want the current rules", role.Rules)
+	}
+
+	quota, err = clientset.CoreV1().ResourceQuotas(namespace).Get(ctx, config.UserResourceQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ResourceQuota: %v", err)
+	}
+	if pvcs := quota.Spec.Hard[corev1.ResourcePersistentVolumeClaims]; pvcs.Value() != config.UserPVCLimit {
+		t.Errorf("PVC quota = %s, want %d", pvcs.String(), config.UserPVCLimit)
+	}
+	if _, ok := quota.Spec.Hard[corev1.ResourceRequestsMemory]; !ok {
+		t.Errorf("quota = %v, want memory limited", quota.Spec.Hard)
+	}
+
+	// Up-to-date users are left alone
+	clientset.ClearActions()
+	if err := client.ReconcileUserRBAC(ctx); err != nil {
+		t.Fatalf("ReconcileUserRBAC() error = %v", err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" {
+			t.Errorf("unexpected %s of %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func rconSecretName(serverName string) string {
+	return serverName + config.RconSecretSuffix
+}
+
+// rconEnvVars passes the RCON port and the password from the server's Secret to the container
+func rconEnvVars(serverName string) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "RCON_PORT", Value: strconv.Itoa(config.RconPort)},
+		{
+			Name: "RCON_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: rconSecretName(serverName)},
+					Key:                  config.RconSecretKey,
+				},
+			},
+		},
+	}
+}
+
+// createRconSecret stores a new random RCON password for the server
+func (c *Client) createRconSecret(ctx context.Context, serverName string, username string) error {
+	password := make([]byte, config.RconPasswordLength)
+	if _, err := rand.Read(password); err != nil {
+		return fmt.Errorf("failed to generate rcon password: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rconSecretName(serverName),
+			Namespace: c.namespace,
+			Labels: map[string]string{
+				config.CommonLabelKey: config.CommonLabelValue,
+				"server":              serverName,
+				"user":                username,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			config.RconSecretKey: []byte(hex.EncodeToString(password)),
+		},
+	}
+
+	_, err := c.clientset.
+		CoreV1().
+		Secrets(c.namespace).
+		Create(
+			ctx,
+			secret,
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to create server (rcon secret): %w", err)
+	}
+
+	return nil
+}
+
+// deleteRconSecret removes the server's RCON Secret. Servers created before RCON
+// support have none, so a missing Secret is not an error.
+func (c *Client) deleteRconSecret(ctx context.Context, serverName string) error {
+	err := c.clientset.
+		CoreV1().
+		Secrets(c.namespace).
+		Delete(
+			ctx,
+			rconSecretName(serverName),
+			metav1.DeleteOptions{},
+		)
+	return ignoreAbsent(err)
+}
+
+// GetRconPassword reads the server's RCON password from its Secret
+func (c *Client) GetRconPassword(ctx context.Context, serverName string) (string, error) {
+	secret, err := c.clientset.
+		CoreV1().
+		Secrets(c.namespace).
+		Get(
+			ctx,
+			rconSecretName(serverName),
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return "", fmt.Errorf("server (%s) has no rcon password, it was created before console support", serverName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get rcon password: %w", err)
+	}
+
+	password, ok := secret.Data[config.RconSecretKey]
+	if !ok {
+		return "", fmt.Errorf("rcon secret for server (%s) has no %q key", serverName, config.RconSecretKey)
+	}
+
+	return string(password), nil
+}
@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateServer_CreatesRconSecret(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	ctx := context.Background()
+
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	password, err := client.GetRconPassword(ctx, "testserver")
+	if err != nil {
+		t.Fatalf("GetRconPassword() error = %v", err)
+	}
+	if len(password) != 2*config.RconPasswordLength {
+		t.Errorf("password length = %d, want %d", len(password), 2*config.RconPasswordLength)
+	}
+
+	sts, err := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+
+	var ref string
+	for _, env := range sts.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "RCON_PASSWORD" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			ref = env.ValueFrom.SecretKeyRef.Name
+		}
+	}
+	if ref != "testserver"+config.RconSecretSuffix {
+		t.Errorf("RCON_PASSWORD secret = %q, want %q", ref, "testserver"+config.RconSecretSuffix)
+	}
+}
+
+func TestCreateServer_DifferentPasswordPerServer(t *testing.T) {
+	client, _ := newFakeClient(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"one", "two"} {
+		if err := client.CreateServer(ctx, name, fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+			t.Fatalf("CreateServer(%s) error = %v", name, err)
+		}
+	}
+
+	one, _ := client.GetRconPassword(ctx, "one")
+	two, _ := client.GetRconPassword(ctx, "two")
+	if one == two {
+		t.Error("servers share an rcon password, want one each")
+	}
+}
+
+func TestCleanupServer_RemovesRconSecret(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	ctx := context.Background()
+
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := client.CleanupServer(ctx, "testserver"); err != nil {
+		t.Fatalf("CleanupServer() error = %v", err)
+	}
+
+	_, err := clientset.CoreV1().Secrets(client.namespace).Get(ctx, "testserver"+config.RconSecretSuffix, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("rcon secret still exists after cleanup (err = %v)", err)
+	}
+}
+
+func TestGetRconPassword_MissingSecret(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	if _, err := client.GetRconPassword(context.Background(), "testserver"); err == nil {
+		t.Error("GetRconPassword() expected error for a server without a secret, got nil")
+	}
+}
+
+func TestForwardPort_NeedsRestConfig(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	if _, err := client.ForwardPort(context.Background(), "testserver", config.RconPort); err == nil {
+		t.Error("ForwardPort() expected error for a client without a rest config, got nil")
+	}
+}
@@ -0,0 +1,452 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Startup phases reported while waiting for a server, in the order they happen
+const (
+	PhaseScheduling        = "scheduling"
+	PhasePullingImage      = "pulling image"
+	PhaseStartingContainer = "starting container"
+	PhaseDownloadingServer = "downloading server"
+	PhaseInstallingModpack = "installing modpack"
+	PhaseStartingServer    = "starting server"
+	PhaseLoadingWorld      = "loading world"
+)
+
+var phaseOrder = map[string]int{
+	PhaseScheduling:        0,
+	PhasePullingImage:      1,
+	PhaseStartingContainer: 2,
+	PhaseDownloadingServer: 3,
+	PhaseInstallingModpack: 4,
+	PhaseStartingServer:    5,
+	PhaseLoadingWorld:      6,
+}
+
+// logPhases maps launcher and server log lines to startup phases
+var logPhases = []struct {
+	contains string
+	phase    string
+}{
+	{"Downloading server", PhaseDownloadingServer},
+	{"Installing modpack", PhaseInstallingModpack},
+	{"Starting minecraft server version", PhaseStartingServer},
+	{"Preparing level", PhaseLoadingWorld},
+	{"Preparing start region", PhaseLoadingWorld},
+}
+
+// StartupError is a startup failure that will not fix itself by waiting longer
+type StartupError struct {
+	Reason  string // ImagePullBackOff, CrashLoopBackOff, OOMKilled, InsufficientMemory, UnboundPVC, ...
+	Message string
+}
+
+func (e *StartupError) Error() string {
+	return e.Message
+}
+
+// WaitForReady watches the server pod and its events until the pod is Ready, ctx is done,
+// or the pod hits a failure that will not resolve (see StartupError).
+// Startup phases are passed to progress as they are reached; progress may be nil.
+func (c *Client) WaitForReady(ctx context.Context, serverName string, progress func(phase string)) error {
+	return c.waitForPod(ctx, serverName, "become ready", isServerReady, progress)
+}
+
+// waitForPod watches the server pod like WaitForReady, until done reports true for it
+func (c *Client) waitForPod(ctx context.Context, serverName string, goal string, done func(*corev1.Pod) bool, progress func(phase string)) error {
+	w := &readinessWatcher{
+		client:   c,
+		podName:  serverName + "-0",
+		pvcName:  fmt.Sprintf("mc-%s-0", serverName),
+		done:     done,
+		progress: progress,
+		phase:    -1,
+		started:  time.Now().Truncate(time.Second), // Event timestamps only have seconds
+		logLines: make(chan string),
+	}
+
+	err := w.run(ctx)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		msg := fmt.Sprintf("timed out waiting for server (%s) to %s", serverName, goal)
+		if w.lastProblem != "" {
+			msg += fmt.Sprintf(" (last problem: %s)", w.lastProblem)
+		} else if w.phase >= 0 {
+			msg += fmt.Sprintf(" (stuck %s)", phaseName(w.phase))
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped waiting for server (%s) to %s: %w", serverName, goal, ctx.Err())
+	}
+
+	return err
+}
+
+type readinessWatcher struct {
+	client      *Client
+	podName     string
+	pvcName     string
+	done        func(*corev1.Pod) bool
+	progress    func(string)
+	phase       int
+	started     time.Time
+	podUID      types.UID
+	podCreated  time.Time
+	lastProblem string
+	logsStarted bool
+	logLines    chan string
+}
+
+func (w *readinessWatcher) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Stops the log stream
+
+	// Check the current state first, a watch only reports changes
+	pod, err := w.client.clientset.CoreV1().Pods(w.client.namespace).Get(ctx, w.podName, metav1.GetOptions{})
+	if err == nil {
+		if done, err := w.handlePod(ctx, pod); done || err != nil {
+			return err
+		}
+	} else {
+		w.report(PhaseScheduling)
+	}
+
+	podWatch, err := w.watchPods(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { podWatch.Stop() }()
+
+	eventWatch, err := w.watchEvents(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { eventWatch.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case ev, ok := <-podWatch.ResultChan():
+			if !ok {
+				// Watches are closed by the API server periodically, open a new one
+				if podWatch, err = w.watchPods(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			pod, isPod := ev.Object.(*corev1.Pod)
+			if !isPod || pod.Name != w.podName || ev.Type == watch.Deleted {
+				continue
+			}
+			if done, err := w.handlePod(ctx, pod); done || err != nil {
+				return err
+			}
+
+		case ev, ok := <-eventWatch.ResultChan():
+			if !ok {
+				if eventWatch, err = w.watchEvents(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			event, isEvent := ev.Object.(*corev1.Event)
+			if !isEvent || ev.Type == watch.Deleted {
+				continue
+			}
+			if err := w.handleEvent(event); err != nil {
+				return err
+			}
+
+		case line := <-w.logLines:
+			for _, lp := range logPhases {
+				if strings.Contains(line, lp.contains) {
+					w.report(lp.phase)
+				}
+			}
+		}
+	}
+}
+
+func (w *readinessWatcher) watchPods(ctx context.Context) (watch.Interface, error) {
+	pods, err := w.client.clientset.CoreV1().Pods(w.client.namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: "metadata.name=" + w.podName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch server pod: %w", err)
+	}
+
+	return pods, nil
+}
+
+func (w *readinessWatcher) watchEvents(ctx context.Context) (watch.Interface, error) {
+	events, err := w.client.clientset.CoreV1().Events(w.client.namespace).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch server events: %w", err)
+	}
+
+	return events, nil
+}
+
+// handlePod returns done when the pod reached the goal, or an error when it cannot start
+func (w *readinessWatcher) handlePod(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	w.podUID = pod.UID
+	w.podCreated = pod.CreationTimestamp.Time
+
+	if w.done(pod) {
+		return true, nil
+	}
+
+	if err := diagnosePod(pod); err != nil {
+		return false, err
+	}
+
+	w.report(podPhase(pod))
+
+	// Follow the logs once the container runs to see download and world loading progress
+	if !w.logsStarted && containerRunning(pod) {
+		w.logsStarted = true
+		go w.followLogs(ctx)
+	}
+
+	return false, nil
+}
+
+func (w *readinessWatcher) handleEvent(event *corev1.Event) error {
+	if !w.current(event) {
+		return nil
+	}
+
+	if err := diagnoseEvent(event, w.pvcName); err != nil {
+		return err
+	}
+
+	if event.Reason == "Pulling" {
+		w.report(PhasePullingImage)
+	}
+	if event.Type == corev1.EventTypeWarning {
+		w.lastProblem = event.Message
+	}
+
+	return nil
+}
+
+// current reports whether an event is about this run of the server rather than a pod
+// or volume claim left from an earlier one. The event watch replays everything still
+// stored, which includes the failures of a pod that has since been replaced.
+func (w *readinessWatcher) current(event *corev1.Event) bool {
+	since := w.started
+	if !w.podCreated.IsZero() {
+		since = w.podCreated.Truncate(time.Second)
+	}
+
+	switch event.InvolvedObject.Name {
+	case w.podName:
+		if w.podUID != "" && event.InvolvedObject.UID != "" {
+			return event.InvolvedObject.UID == w.podUID
+		}
+		return !eventTime(event).Before(since)
+	case w.pvcName:
+		return !eventTime(event).Before(since)
+	}
+
+	return false
+}
+
+func (w *readinessWatcher) followLogs(ctx context.Context) {
+	stream, err := w.client.clientset.CoreV1().Pods(w.client.namespace).GetLogs(w.podName, &corev1.PodLogOptions{
+		Container: config.CommonLabelValuePod,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return // Progress is best effort, readiness is still tracked through the pod
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		select {
+		case w.logLines <- scanner.Text():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// report passes a phase to the progress callback, only ever moving forward
+func (w *readinessWatcher) report(phase string) {
+	order := phaseOrder[phase]
+	if order <= w.phase {
+		return
+	}
+
+	w.phase = order
+	if w.progress != nil {
+		w.progress(phase)
+	}
+}
+
+func phaseName(order int) string {
+	for name, o := range phaseOrder {
+		if o == order {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// podPhase works out the startup phase from the pod status. Once the container runs,
+// the launcher's and server's log lines (logPhases) take it further.
+func podPhase(pod *corev1.Pod) string {
+	if pod.Spec.NodeName == "" {
+		return PhaseScheduling
+	}
+	if containerRunning(pod) {
+		return PhaseStartingContainer
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.ImageID == "" && cs.State.Waiting != nil && cs.State.Waiting.Reason == "ContainerCreating" {
+			return PhasePullingImage
+		}
+	}
+
+	return PhaseStartingContainer
+}
+
+// diagnosePod returns a StartupError when the pod status shows a failure that will not resolve
+func diagnosePod(pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodFailed {
+		return &StartupError{Reason: "PodFailed", Message: fmt.Sprintf("server pod failed: %s", pod.Status.Message)}
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+			if err := diagnoseScheduling(cond.Message); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if term := cs.LastTerminationState.Terminated; term != nil && term.Reason == "OOMKilled" {
+			return &StartupError{
+				Reason:  "OOMKilled",
+				Message: fmt.Sprintf("server ran out of memory and was killed (OOMKilled, limit %s)", config.ServerMemoryLimit),
+			}
+		}
+
+		waiting := cs.State.Waiting
+		if waiting == nil {
+			continue
+		}
+
+		switch waiting.Reason {
+		case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+			return &StartupError{
+				Reason:  waiting.Reason,
+				Message: fmt.Sprintf("cannot pull server image %s: %s", cs.Image, waiting.Message),
+			}
+		case "CrashLoopBackOff":
+			msg := fmt.Sprintf("server keeps crashing (%d restarts)", cs.RestartCount)
+			if term := cs.LastTerminationState.Terminated; term != nil {
+				msg += fmt.Sprintf(", last exit code %d", term.ExitCode)
+				if term.Reason != "" && term.Reason != "Error" {
+					msg += fmt.Sprintf(" (%s)", term.Reason)
+				}
+			}
+			return &StartupError{Reason: waiting.Reason, Message: msg}
+		case "CreateContainerConfigError":
+			return &StartupError{
+				Reason:  waiting.Reason,
+				Message: fmt.Sprintf("server container is misconfigured: %s", waiting.Message),
+			}
+		}
+	}
+
+	return nil
+}
+
+// diagnoseEvent returns a StartupError for events that mean the pod will never start
+func diagnoseEvent(event *corev1.Event, pvcName string) error {
+	switch event.Reason {
+	case "FailedScheduling":
+		return diagnoseScheduling(event.Message)
+	case "ProvisioningFailed":
+		return &StartupError{
+			Reason: "UnboundPVC",
+			Message: fmt.Sprintf("could not provision storage for volume claim %s (storage class %s): %s",
+				pvcName, config.ServerStorageClass, event.Message),
+		}
+	}
+
+	return nil
+}
+
+// diagnoseScheduling checks a scheduler message for failures that waiting will not fix.
+// "pod has unbound immediate PersistentVolumeClaims" is not one of them: the scheduler
+// reports it for every new server until its volume is provisioned, and a provisioner
+// that fails says so with a ProvisioningFailed event.
+func diagnoseScheduling(message string) error {
+	if strings.Contains(message, "Insufficient memory") {
+		return &StartupError{
+			Reason:  "InsufficientMemory",
+			Message: fmt.Sprintf("not enough free memory on the node to schedule the server, stop another server and retry (%s)", message),
+		}
+	}
+
+	return nil
+}
+
+// isServerReady ignores maintenance pods, which pass the readiness probe without a server
+func isServerReady(pod *corev1.Pod) bool {
+	return isPodReady(pod) && !maintenanceEnabled(pod)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containerRunning(pod *corev1.Pod) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Running != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,390 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// waitingContainer returns a container status stuck waiting for the given reason
+func waitingContainer(reason, message string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:  config.CommonLabelValuePod,
+		Image: config.ServerImage,
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message},
+		},
+	}
+}
+
+func TestWaitForReady_ReturnsWhenReady(t *testing.T) {
+	pod := fakeMinecraftPod(config.NamespacePrefix+fakeUsername, "testserver-0", config.ServerMemoryRequest, corev1.PodRunning)
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	client, _ := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := client.WaitForReady(ctx, "testserver", nil); err != nil {
+		t.Errorf("WaitForReady() error = %v, want nil", err)
+	}
+}
+
+func TestWaitForReady_WatchesUntilReady(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	pod := fakeMinecraftPod(namespace, "testserver-0", config.ServerMemoryRequest, corev1.PodPending)
+	client, clientset := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var phases []string
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitForReady(ctx, "testserver", func(phase string) {
+			phases = append(phases, phase)
+		})
+	}()
+
+	waitForWatches(t, clientset, "pods", "events")
+
+	pod = pod.DeepCopy()
+	pod.Spec.NodeName = "node"
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if _, err := clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("WaitForReady() error = %v, want nil", err)
+	}
+	if len(phases) != 1 || phases[0] != PhaseScheduling {
+		t.Errorf("WaitForReady() reported phases %v, want [%s]", phases, PhaseScheduling)
+	}
+}
+
+func TestReadinessWatcher_ReportsLauncherPhasesFromLogs(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	pod := fakeMinecraftPod(namespace, "testserver-0", config.ServerMemoryRequest, corev1.PodRunning)
+	pod.Spec.NodeName = "node"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  config.CommonLabelValuePod,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+	client, clientset := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var phases []string
+	w := &readinessWatcher{
+		client:   client,
+		podName:  "testserver-0",
+		pvcName:  "mc-testserver-0",
+		done:     isServerReady,
+		progress: func(phase string) { phases = append(phases, phase) },
+		phase:    -1,
+		started:  time.Now().Truncate(time.Second),
+		logLines: make(chan string),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- w.run(ctx)
+	}()
+
+	waitForWatches(t, clientset, "pods", "events")
+
+	// As the launcher prints them before starting the server
+	for _, line := range []string{
+		"Downloading server (type: fabric, version: 1.21.1)",
+		"Installing modpack Test Pack 1.21.1",
+		"[12:00:01] [Server thread/INFO]: Starting minecraft server version 1.21.1",
+	} {
+		select {
+		case w.logLines <- line:
+		case <-ctx.Done():
+			t.Fatalf("watcher stopped reading log lines: %v", <-done)
+		}
+	}
+
+	pod = pod.DeepCopy()
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	if _, err := clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("run() error = %v, want nil", err)
+	}
+	want := []string{PhaseStartingContainer, PhaseDownloadingServer, PhaseInstallingModpack, PhaseStartingServer}
+	if !slices.Equal(phases, want) {
+		t.Errorf("reported phases %v, want %v", phases, want)
+	}
+}
+
+func TestWaitForReady_FailsFastOnImagePull(t *testing.T) {
+	pod := fakeMinecraftPod(config.NamespacePrefix+fakeUsername, "testserver-0", config.ServerMemoryRequest, corev1.PodPending)
+	pod.Spec.NodeName = "node"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{waitingContainer("ImagePullBackOff", "Back-off pulling image")}
+	client, _ := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := client.WaitForReady(ctx, "testserver", nil)
+
+	var startupErr *StartupError
+	if !errors.As(err, &startupErr) {
+		t.Fatalf("WaitForReady() error = %v, want a StartupError", err)
+	}
+	if startupErr.Reason != "ImagePullBackOff" {
+		t.Errorf("StartupError.Reason = %q, want %q", startupErr.Reason, "ImagePullBackOff")
+	}
+}
+
+func TestWaitForReady_FailsOnSchedulingEvent(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	client, clientset := newFakeClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitForReady(ctx, "testserver", nil)
+	}()
+
+	waitForWatches(t, clientset, "pods", "events")
+
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "testserver-0.1", Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "testserver-0", Namespace: namespace},
+		Reason:         "FailedScheduling",
+		Message:        "0/1 nodes are available: 1 Insufficient memory.",
+		Type:           corev1.EventTypeWarning,
+		LastTimestamp:  metav1.Now(),
+	}
+	if _, err := clientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+
+	var startupErr *StartupError
+	if err := <-done; !errors.As(err, &startupErr) || startupErr.Reason != "InsufficientMemory" {
+		t.Errorf("WaitForReady() error = %v, want InsufficientMemory StartupError", err)
+	}
+}
+
+func TestReadinessWatcher_IgnoresEarlierPodEvents(t *testing.T) {
+	created := time.Now().Truncate(time.Second)
+	oom := func(uid types.UID, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "testserver-0", UID: uid},
+			Reason:         "FailedScheduling",
+			Message:        "0/1 nodes are available: 1 Insufficient memory.",
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	provisioning := func(at time.Time) *corev1.Event {
+		return &corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "mc-testserver-0"},
+			Reason:         "ProvisioningFailed",
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *corev1.Event
+		fails bool
+	}{
+		{"earlier pod", oom("earlier", created.Add(time.Second)), false},
+		{"current pod", oom("current", created.Add(-time.Minute)), true},
+		{"earlier volume claim failure", provisioning(created.Add(-30 * time.Second)), false},
+		{"volume claim failure", provisioning(created), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &readinessWatcher{
+				podName:    "testserver-0",
+				pvcName:    "mc-testserver-0",
+				started:    created.Add(time.Minute),
+				podUID:     "current",
+				podCreated: created,
+			}
+
+			if err := w.handleEvent(tt.event); (err != nil) != tt.fails {
+				t.Errorf("handleEvent() error = %v, want failure %t", err, tt.fails)
+			}
+		})
+	}
+}
+
+func TestWaitForReady_Timeout(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.WaitForReady(ctx, "testserver", nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("WaitForReady() error = %v, want a timeout", err)
+	}
+	if !strings.Contains(err.Error(), PhaseScheduling) {
+		t.Errorf("WaitForReady() error = %q, want it to mention the last phase", err)
+	}
+}
+
+func TestWaitForReady_Cancelled(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.WaitForReady(ctx, "testserver", nil)
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitForReady() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestDiagnosePod(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     corev1.PodStatus
+		wantReason string
+	}{
+		{
+			name:       "healthy pending pod",
+			status:     corev1.PodStatus{Phase: corev1.PodPending},
+			wantReason: "",
+		},
+		{
+			name: "image pull error",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{waitingContainer("ErrImagePull", "not found")},
+			},
+			wantReason: "ErrImagePull",
+		},
+		{
+			name: "crash loop",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					RestartCount: 3,
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+					},
+				}},
+			},
+			wantReason: "CrashLoopBackOff",
+		},
+		{
+			name: "out of memory",
+			status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+					},
+				}},
+			},
+			wantReason: "OOMKilled",
+		},
+		{
+			name: "unschedulable for memory",
+			status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "0/1 nodes are available: 1 Insufficient memory.",
+				}},
+			},
+			wantReason: "InsufficientMemory",
+		},
+		{
+			name: "unbound volume claim",
+			status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "0/1 nodes are available: pod has unbound immediate PersistentVolumeClaims.",
+				}},
+			},
+			wantReason: "", // Until the volume is provisioned
+		},
+		{
+			name:       "failed pod",
+			status:     corev1.PodStatus{Phase: corev1.PodFailed, Message: "evicted"},
+			wantReason: "PodFailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "testserver-0"}, Status: tt.status}
+
+			err := diagnosePod(pod)
+
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Errorf("diagnosePod() = %v, want nil", err)
+				}
+				return
+			}
+
+			var startupErr *StartupError
+			if !errors.As(err, &startupErr) {
+				t.Fatalf("diagnosePod() = %v, want a StartupError", err)
+			}
+			if startupErr.Reason != tt.wantReason {
+				t.Errorf("diagnosePod() reason = %q, want %q", startupErr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestDiagnoseEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		reason     string
+		message    string
+		wantReason string
+	}{
+		{"insufficient memory", "FailedScheduling", "0/1 nodes are available: 1 Insufficient memory.", "InsufficientMemory"},
+		{"transient scheduling", "FailedScheduling", "0/1 nodes are available: 1 node(s) had untolerated taint.", ""},
+		{"provisioning failed", "ProvisioningFailed", "failed to provision volume", "UnboundPVC"},
+		{"normal pull", "Pulling", "Pulling image", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Name: "testserver-0"},
+				Reason:         tt.reason,
+				Message:        tt.message,
+			}
+
+			err := diagnoseEvent(event, "mc-testserver-0")
+
+			var startupErr *StartupError
+			gotReason := ""
+			if errors.As(err, &startupErr) {
+				gotReason = startupErr.Reason
+			}
+			if gotReason != tt.wantReason {
+				t.Errorf("diagnoseEvent() reason = %q, want %q", gotReason, tt.wantReason)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/baighasan/kubecraft/internal/config"
@@ -16,18 +19,103 @@ import (
 )
 
 type ServerInfo struct {
-	Name     string
-	Status   string // "running" or "stopped"
-	NodePort int32
-	Age      time.Time
+	Name            string
+	Status          string // "running" or "stopped"
+	NodePort        int32
+	BedrockNodePort int32 // 0 without Bedrock support
+	Age             time.Time
 }
 
-func (c *Client) CheckNodeCapacity() error {
+// ServerSpec holds the game settings passed to the server container as env variables
+type ServerSpec struct {
+	Type       string // Server software: paper, purpur, vanilla, fabric, forge or neoforge
+	Version    string
+	GameMode   string
+	Difficulty string
+	MaxPlayers int
+	MOTD       string
+	Seed       string // empty means a random seed
+	LevelType  string
+	PVP        bool
+	Hardcore   bool
+	// Maintenance starts the pod without the server, e.g. to upload a world first
+	Maintenance bool
+	// IdleTimeout stops the server after that long without players, 0 never. It is an
+	// annotation on the StatefulSet rather than an env variable.
+	IdleTimeout time.Duration
+	// LoaderVersion pins the fabric, forge or neoforge version, empty for the latest
+	LoaderVersion string
+	// Modpack names the Modrinth pack the server was set up from, an annotation too
+	Modpack string
+}
+
+// DefaultServerSpec returns the spec used when no options are given
+func DefaultServerSpec() ServerSpec {
+	return ServerSpec{
+		Type:       config.DefaultServerType,
+		Version:    config.DefaultServerVersion,
+		GameMode:   config.DefaultGameMode,
+		Difficulty: config.DefaultDifficulty,
+		MaxPlayers: config.DefaultMaxPlayers,
+		MOTD:       config.DefaultMOTD,
+		LevelType:  config.DefaultLevelType,
+		PVP:        config.DefaultPVP,
+	}
+}
+
+// envVars converts the spec into the env variables read by the server image
+func (s ServerSpec) envVars() []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "EULA", Value: "TRUE"},
+		{Name: serverTypeEnv, Value: s.Type},
+		{Name: "VERSION", Value: s.Version},
+		{Name: "GAME_MODE", Value: s.GameMode},
+		{Name: "DIFFICULTY", Value: s.Difficulty},
+		{Name: "MAX_PLAYERS", Value: strconv.Itoa(s.MaxPlayers)},
+		{Name: "MOTD", Value: s.MOTD},
+		{Name: "LEVEL_TYPE", Value: s.LevelType},
+		{Name: "PVP", Value: strconv.FormatBool(s.PVP)},
+		{Name: "HARDCORE", Value: strconv.FormatBool(s.Hardcore)},
+	}
+
+	// Leave the seed unset so the server picks a random one
+	if s.Seed != "" {
+		env = append(env, corev1.EnvVar{Name: "SEED", Value: s.Seed})
+	}
+	if s.LoaderVersion != "" {
+		env = append(env, corev1.EnvVar{Name: loaderVersionEnv, Value: s.LoaderVersion})
+	}
+
+	env = append(env, corev1.EnvVar{Name: "JAVA_MEMORY", Value: config.ServerJavaMemory})
+
+	if s.Maintenance {
+		env = append(env, maintenanceEnv)
+	}
+
+	return env
+}
+
+// serverTypeEnv picks the server software the launcher installs
+const serverTypeEnv = "SERVER_TYPE"
+
+// loaderVersionEnv pins the mod loader the launcher installs, modpacks name theirs
+const loaderVersionEnv = "LOADER_VERSION"
+
+// proxyProtocolEnv makes the launcher switch on the PROXY protocol, for servers behind the gateway
+var proxyProtocolEnv = corev1.EnvVar{Name: "PROXY_PROTOCOL", Value: "true"}
+
+// bedrockEnv makes the launcher install Geyser and Floodgate, for servers with a Bedrock port
+var bedrockEnv = corev1.EnvVar{Name: "BEDROCK", Value: "true"}
+
+// bedrockPortName names the UDP port of the Service and container
+const bedrockPortName = "bedrock"
+
+func (c *Client) CheckNodeCapacity(ctx context.Context) error {
 	pods, err := c.clientset.
 		CoreV1().
 		Pods("").
 		List(
-			context.TODO(),
+			ctx,
 			metav1.ListOptions{
 				LabelSelector: config.CommonLabelKey + "=" + config.CommonLabelValuePod,
 				FieldSelector: "status.phase=Running",
@@ -52,12 +140,22 @@ func (c *Client) CheckNodeCapacity() error {
 	return nil
 }
 
-func (c *Client) AllocateNodePort() (int32, error) {
+func (c *Client) AllocateNodePort(ctx context.Context) (int32, error) {
+	return c.allocateNodePort(ctx, config.McNodePortRangeMin, config.McNodePortRangeMax)
+}
+
+// AllocateBedrockNodePort finds a free NodePort for a server's Bedrock (UDP) listener,
+// from a range of its own so Java servers keep all of theirs
+func (c *Client) AllocateBedrockNodePort(ctx context.Context) (int32, error) {
+	return c.allocateNodePort(ctx, config.BedrockNodePortRangeMin, config.BedrockNodePortRangeMax)
+}
+
+func (c *Client) allocateNodePort(ctx context.Context, rangeMin int32, rangeMax int32) (int32, error) {
 	services, err := c.clientset.
 		CoreV1().
 		Services("").
 		List(
-			context.TODO(),
+			ctx,
 			metav1.ListOptions{
 				LabelSelector: config.CommonLabelSelector,
 			},
@@ -75,17 +173,71 @@ func (c *Client) AllocateNodePort() (int32, error) {
 		}
 	}
 
-	for port := int32(config.McNodePortRangeMin); port <= int32(config.McNodePortRangeMax); port++ {
+	for port := rangeMin; port <= rangeMax; port++ {
 		if !occupiedPorts[port] {
 			return port, nil
 		}
 	}
 
-	return 0, fmt.Errorf("no available ports found in range %d-%d", config.McNodePortRangeMin, config.McNodePortRangeMax)
+	return 0, fmt.Errorf("no available ports found in range %d-%d", rangeMin, rangeMax)
 }
 
-func (c *Client) CreateServer(serverName string, username string, nodePort int32) error {
-	// Define nodeport service
+func (c *Client) CreateServer(ctx context.Context, serverName string, username string, nodePort int32, bedrockNodePort int32, spec ServerSpec) error {
+	// Without a nodeport the server is only reached through the gateway, which tells it
+	// the player's address with the PROXY protocol
+	serviceType := corev1.ServiceTypeNodePort
+	env := append(spec.envVars(), rconEnvVars(serverName)...)
+	if nodePort == 0 {
+		serviceType = corev1.ServiceTypeClusterIP
+		env = append(env, proxyProtocolEnv)
+	}
+
+	// The Java port stays first, the wake proxy and GetNodePort read it from there
+	ports := []corev1.ServicePort{
+		{
+			Name:       config.CommonLabelValuePod,
+			Port:       config.MinecraftPort,
+			TargetPort: intstr.FromInt(config.MinecraftPort),
+			NodePort:   nodePort,
+			Protocol:   corev1.ProtocolTCP,
+		},
+	}
+	containerPorts := []corev1.ContainerPort{
+		{
+			Name:          config.CommonLabelValuePod,
+			ContainerPort: config.MinecraftPort,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	readiness := corev1.ProbeHandler{
+		TCPSocket: &corev1.TCPSocketAction{
+			Port: intstr.FromInt(int(config.MinecraftPort)),
+		},
+	}
+
+	// Bedrock players join through Geyser on a UDP port, which a TCP probe cannot check
+	if bedrockNodePort != 0 {
+		env = append(env, bedrockEnv)
+		ports = append(ports, corev1.ServicePort{
+			Name:       bedrockPortName,
+			Port:       config.BedrockPort,
+			TargetPort: intstr.FromInt(config.BedrockPort),
+			NodePort:   bedrockNodePort,
+			Protocol:   corev1.ProtocolUDP,
+		})
+		containerPorts = append(containerPorts, corev1.ContainerPort{
+			Name:          bedrockPortName,
+			ContainerPort: config.BedrockPort,
+			Protocol:      corev1.ProtocolUDP,
+		})
+		readiness = corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/usr/local/bin/kubecraft-launcher", "ready"},
+			},
+		}
+	}
+
+	// Define service
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serverName,
@@ -95,16 +247,8 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 			},
 		},
 		Spec: corev1.ServiceSpec{
-			Type: corev1.ServiceTypeNodePort,
-			Ports: []corev1.ServicePort{
-				{
-					Name:       config.CommonLabelValuePod,
-					Port:       config.MinecraftPort,
-					TargetPort: intstr.FromInt(config.MinecraftPort),
-					NodePort:   nodePort,
-					Protocol:   corev1.ProtocolTCP,
-				},
-			},
+			Type:  serviceType,
+			Ports: ports,
 			Selector: map[string]string{
 				config.CommonLabelKey: config.CommonLabelValuePod,
 				"server":              serverName,
@@ -113,17 +257,32 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 		},
 	}
 
-	// Create nodeport service
+	// Create service
 	_, err := c.clientset.
 		CoreV1().
 		Services(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			service,
 			metav1.CreateOptions{},
 		)
 	if err != nil {
-		return fmt.Errorf("failed to create server (nodeport service): %w", err)
+		return fmt.Errorf("failed to create server (service): %w", err)
+	}
+
+	// Create rcon password, used by the console and graceful stop
+	err = c.createRconSecret(ctx, serverName, username)
+	if err != nil {
+		_ = c.clientset.
+			CoreV1().
+			Services(c.namespace).
+			Delete(
+				context.WithoutCancel(ctx),
+				serverName,
+				metav1.DeleteOptions{},
+			)
+
+		return err
 	}
 
 	// Define statefulset
@@ -156,39 +315,14 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 					},
 				},
 				Spec: corev1.PodSpec{
+					// Long enough for the preStop hook to save the world
+					TerminationGracePeriodSeconds: ptr.To(int64(config.ServerGracePeriod)),
 					Containers: []corev1.Container{
 						{
 							Name:  config.CommonLabelValuePod,
 							Image: config.ServerImage,
-							Env: []corev1.EnvVar{
-								{
-									Name:  "EULA",
-									Value: "TRUE",
-								},
-								{
-									Name:  "VERSION",
-									Value: "1.21.11",
-								},
-								{
-									Name:  "GAME_MODE",
-									Value: "survival",
-								},
-								{
-									Name:  "MAX_PLAYERS",
-									Value: "5",
-								},
-								{
-									Name:  "JAVA_MEMORY",
-									Value: config.ServerJavaMemory,
-								},
-							},
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          config.CommonLabelValuePod,
-									ContainerPort: config.MinecraftPort,
-									Protocol:      corev1.ProtocolTCP,
-								},
-							},
+							Env:   env,
+							Ports: containerPorts,
 							Resources: corev1.ResourceRequirements{
 								Requests: corev1.ResourceList{
 									corev1.ResourceCPU:    resource.MustParse(config.ServerCPURequest),
@@ -200,13 +334,17 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 								},
 							},
 							ReadinessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									TCPSocket: &corev1.TCPSocketAction{
-										Port: intstr.FromInt(int(config.MinecraftPort)),
-									},
-								},
+								ProbeHandler:        readiness,
 								InitialDelaySeconds: 30,
 								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+							},
+							Lifecycle: &corev1.Lifecycle{
+								PreStop: &corev1.LifecycleHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"/usr/local/bin/kubecraft-launcher", "stop"},
+									},
+								},
 							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -239,25 +377,30 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 		},
 	}
 
+	setIdleTimeout(&sts.ObjectMeta, spec.IdleTimeout)
+	setModpack(&sts.ObjectMeta, spec.Modpack)
+	mountPluginLock(sts)
+
 	// Create statefulset
 	_, err = c.clientset.
 		AppsV1().
 		StatefulSets(c.namespace).
 		Create(
-			context.TODO(),
+			ctx,
 			sts,
 			metav1.CreateOptions{},
 		)
 	if err != nil {
-		// Clean up the orphaned service
+		// Clean up the orphaned service and secret, even if ctx was cancelled (Ctrl-C)
 		_ = c.clientset.
 			CoreV1().
 			Services(c.namespace).
 			Delete(
-				context.TODO(),
+				context.WithoutCancel(ctx),
 				serverName,
 				metav1.DeleteOptions{},
 			)
+		_ = c.deleteRconSecret(context.WithoutCancel(ctx), serverName)
 
 		return fmt.Errorf("failed to create server (statefulset): %w", err)
 	}
@@ -265,13 +408,13 @@ func (c *Client) CreateServer(serverName string, username string, nodePort int32
 	return nil
 }
 
-func (c *Client) DeleteServer(serverName string) error {
+func (c *Client) DeleteServer(ctx context.Context, serverName string) error {
 	// Delete statefulset
 	err := c.clientset.
 		AppsV1().
 		StatefulSets(c.namespace).
 		Delete(
-			context.TODO(),
+			ctx,
 			serverName,
 			metav1.DeleteOptions{},
 		)
@@ -284,7 +427,7 @@ func (c *Client) DeleteServer(serverName string) error {
 		CoreV1().
 		Services(c.namespace).
 		Delete(
-			context.TODO(),
+			ctx,
 			serverName,
 			metav1.DeleteOptions{},
 		)
@@ -298,7 +441,7 @@ func (c *Client) DeleteServer(serverName string) error {
 		CoreV1().
 		PersistentVolumeClaims(c.namespace).
 		Delete(
-			context.TODO(),
+			ctx,
 			pvcName,
 			metav1.DeleteOptions{},
 		)
@@ -306,15 +449,96 @@ func (c *Client) DeleteServer(serverName string) error {
 		return fmt.Errorf("failed to delete pvc (service): %w", err)
 	}
 
+	// Delete rcon secret
+	err = c.deleteRconSecret(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to delete server (rcon secret): %w", err)
+	}
+
+	// Delete plugin lock
+	err = c.deletePluginLock(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to delete server (plugin lock): %w", err)
+	}
+
+	// Delete backup schedule and archives
+	err = c.deleteBackups(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to delete server (backups): %w", err)
+	}
+
+	return nil
+}
+
+// CleanupServer removes whatever exists of a partially created server.
+// Unlike DeleteServer, missing resources are not an error.
+func (c *Client) CleanupServer(ctx context.Context, serverName string) error {
+	err := c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Delete(
+			ctx,
+			serverName,
+			metav1.DeleteOptions{},
+		)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to clean up server (statefulset): %w", err)
+	}
+
+	err = c.clientset.
+		CoreV1().
+		Services(c.namespace).
+		Delete(
+			ctx,
+			serverName,
+			metav1.DeleteOptions{},
+		)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to clean up server (service): %w", err)
+	}
+
+	err = c.clientset.
+		CoreV1().
+		PersistentVolumeClaims(c.namespace).
+		Delete(
+			ctx,
+			fmt.Sprintf("mc-%s-0", serverName),
+			metav1.DeleteOptions{},
+		)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to clean up server (pvc): %w", err)
+	}
+
+	err = c.deleteRconSecret(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to clean up server (rcon secret): %w", err)
+	}
+
+	err = c.deletePluginLock(ctx, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to clean up server (plugin lock): %w", err)
+	}
+
 	return nil
 }
 
-func (c *Client) ListServers() ([]ServerInfo, error) {
+// ignoreAbsent drops the error deleting a server's optional resources gives when it
+// doesn't exist. Anything else, e.g. a Role that doesn't allow the delete, is returned,
+// as what is left behind keeps counting against the user's quota.
+func ignoreAbsent(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (c *Client) ListServers(ctx context.Context) ([]ServerInfo, error) {
 	servers, err := c.clientset.
 		AppsV1().
 		StatefulSets(c.namespace).
 		List(
-			context.TODO(),
+			ctx,
 			metav1.ListOptions{},
 		)
 	if err != nil {
@@ -336,7 +560,7 @@ func (c *Client) ListServers() ([]ServerInfo, error) {
 			CoreV1().
 			Services(c.namespace).
 			Get(
-				context.TODO(),
+				ctx,
 				sts.Name,
 				metav1.GetOptions{},
 			)
@@ -349,10 +573,11 @@ func (c *Client) ListServers() ([]ServerInfo, error) {
 		age := sts.CreationTimestamp.Time
 
 		serverInfo := ServerInfo{
-			Name:     sts.Name,
-			Status:   status,
-			NodePort: nodePort,
-			Age:      age,
+			Name:            sts.Name,
+			Status:          status,
+			NodePort:        nodePort,
+			BedrockNodePort: bedrockNodePort(svc),
+			Age:             age,
 		}
 
 		serversInfo = append(serversInfo, serverInfo)
@@ -361,7 +586,7 @@ func (c *Client) ListServers() ([]ServerInfo, error) {
 	return serversInfo, nil
 }
 
-func (c *Client) ScaleServer(serverName string, replicas int32) error {
+func (c *Client) ScaleServer(ctx context.Context, serverName string, replicas int32) error {
 	if replicas < 0 || replicas > 1 {
 		return fmt.Errorf("invalid number of replicas (%d) for server (%s), must be 0 or 1", replicas, serverName)
 	}
@@ -371,7 +596,7 @@ func (c *Client) ScaleServer(serverName string, replicas int32) error {
 		AppsV1().
 		StatefulSets(c.namespace).
 		Get(
-			context.TODO(),
+			ctx,
 			serverName,
 			metav1.GetOptions{},
 		)
@@ -379,15 +604,16 @@ func (c *Client) ScaleServer(serverName string, replicas int32) error {
 		return fmt.Errorf("failed to get server (statefulset): %w", err)
 	}
 
-	// Scale sts
+	// Scale sts, a start or stop by hand supersedes an idle shutdown
 	sts.Spec.Replicas = &replicas
+	delete(sts.Annotations, config.IdleStoppedAnnotation)
 
 	// Apply update
 	_, err = c.clientset.
 		AppsV1().
 		StatefulSets(c.namespace).
 		Update(
-			context.TODO(),
+			ctx,
 			sts,
 			metav1.UpdateOptions{},
 		)
@@ -398,37 +624,78 @@ func (c *Client) ScaleServer(serverName string, replicas int32) error {
 	return nil
 }
 
-func (c *Client) WaitForReady(serverName string) error {
+// SetServerType switches the server software. The pod template changes, so a running
+// server restarts to install it.
+func (c *Client) SetServerType(ctx context.Context, serverName string, serverType string) error {
+	return c.updateServerEnv(ctx, serverName, nil, map[string]string{serverTypeEnv: serverType})
+}
 
-	for i := 0; i < config.MaxAttempts; i++ {
-		pod, err := c.clientset.
-			CoreV1().
-			Pods(c.namespace).
-			Get(
-				context.TODO(),
-				serverName+"-0",
-				metav1.GetOptions{},
-			)
-		if err == nil {
-			for _, cond := range pod.Status.Conditions {
-				if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
-					return nil
-				}
-			}
+// SetModpack records the pack a server now runs, along with the Minecraft and loader
+// versions it needs. A running server restarts to install them.
+func (c *Client) SetModpack(ctx context.Context, serverName string, modpack string, version string, loaderVersion string) error {
+	return c.updateServerEnv(ctx, serverName, func(sts *appsv1.StatefulSet) {
+		setModpack(&sts.ObjectMeta, modpack)
+	}, map[string]string{"VERSION": version, loaderVersionEnv: loaderVersion})
+}
+
+func setModpack(meta *metav1.ObjectMeta, modpack string) {
+	if modpack == "" {
+		delete(meta.Annotations, config.ModpackAnnotation)
+		return
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[config.ModpackAnnotation] = modpack
+}
+
+// updateServerEnv sets env variables of the server container, removing those set to
+// an empty value, after applying edit to the StatefulSet if given
+func (c *Client) updateServerEnv(ctx context.Context, serverName string, edit func(*appsv1.StatefulSet), env map[string]string) error {
+	sts, err := c.getServerStatefulSet(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	if len(sts.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("server (%s) has no containers", serverName)
+	}
+
+	if edit != nil {
+		edit(sts)
+	}
+	container := &sts.Spec.Template.Spec.Containers[0]
+	container.Env = slices.DeleteFunc(container.Env, func(e corev1.EnvVar) bool {
+		_, ok := env[e.Name]
+		return ok
+	})
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if env[name] != "" {
+			container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: env[name]})
 		}
+	}
 
-		time.Sleep(config.PollInterval)
+	_, err = c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		Update(
+			ctx,
+			sts,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to update server (statefulset): %w", err)
 	}
 
-	return fmt.Errorf("timed out waiting for server (%s) to become ready", serverName)
+	return nil
 }
 
-func (c *Client) ServerExists(serverName string) (bool, error) {
+func (c *Client) ServerExists(ctx context.Context, serverName string) (bool, error) {
 	_, err := c.clientset.
 		AppsV1().
 		StatefulSets(c.namespace).
 		Get(
-			context.TODO(),
+			ctx,
 			serverName,
 			metav1.GetOptions{},
 		)
@@ -442,12 +709,12 @@ func (c *Client) ServerExists(serverName string) (bool, error) {
 	return true, nil
 }
 
-func (c *Client) GetNodePort(serverName string) (int32, error) {
+func (c *Client) GetNodePort(ctx context.Context, serverName string) (int32, error) {
 	svc, err := c.clientset.
 		CoreV1().
 		Services(c.namespace).
 		Get(
-			context.TODO(),
+			ctx,
 			serverName,
 			metav1.GetOptions{},
 		)
@@ -457,3 +724,30 @@ func (c *Client) GetNodePort(serverName string) (int32, error) {
 
 	return svc.Spec.Ports[0].NodePort, nil
 }
+
+// GetBedrockNodePort returns the UDP NodePort Bedrock players join on, 0 if the server
+// has none
+func (c *Client) GetBedrockNodePort(ctx context.Context, serverName string) (int32, error) {
+	svc, err := c.clientset.
+		CoreV1().
+		Services(c.namespace).
+		Get(
+			ctx,
+			serverName,
+			metav1.GetOptions{},
+		)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server while finding bedrock nodeport: %w", err)
+	}
+
+	return bedrockNodePort(svc), nil
+}
+
+func bedrockNodePort(svc *corev1.Service) int32 {
+	for _, port := range svc.Spec.Ports {
+		if port.Name == bedrockPortName {
+			return port.NodePort
+		}
+	}
+	return 0
+}
@@ -18,7 +18,7 @@ func TestServerExists_ReturnsFalse(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	exists, err := client.ServerExists("nonexistent")
+	exists, err := client.ServerExists(context.Background(), "nonexistent")
 	if err != nil {
 		t.Fatalf("ServerExists() error = %v", err)
 	}
@@ -35,17 +35,17 @@ func TestServerExists_ReturnsTrue(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
-	exists, err := client.ServerExists("testserver")
+	exists, err := client.ServerExists(context.Background(), "testserver")
 	if err != nil {
 		t.Fatalf("ServerExists() error = %v", err)
 	}
@@ -57,7 +57,7 @@ func TestServerExists_ReturnsTrue(t *testing.T) {
 func TestAllocateNodePort_ReturnsPortInRange(t *testing.T) {
 	client := GetTestClient(t)
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
@@ -76,18 +76,18 @@ func TestAllocateNodePort_SkipsOccupiedPorts(t *testing.T) {
 	client.namespace = config.NamespacePrefix + username
 
 	// Allocate first port and create a server on it
-	port1, err := client.AllocateNodePort()
+	port1, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() first call error = %v", err)
 	}
 
-	err = client.CreateServer("server1", username, port1)
+	err = client.CreateServer(context.Background(), "server1", username, port1, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
 	// Allocate second port — should be different
-	port2, err := client.AllocateNodePort()
+	port2, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() second call error = %v", err)
 	}
@@ -105,18 +105,18 @@ func TestCreateServer_Success(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
 	// Verify StatefulSet exists
-	exists, err := client.ServerExists("testserver")
+	exists, err := client.ServerExists(context.Background(), "testserver")
 	if err != nil {
 		t.Fatalf("ServerExists() error = %v", err)
 	}
@@ -146,22 +146,22 @@ func TestCreateServer_DuplicateNameFails(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("First CreateServer() error = %v", err)
 	}
 
-	port2, err := client.AllocateNodePort()
+	port2, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port2)
+	err = client.CreateServer(context.Background(), "testserver", username, port2, 0, DefaultServerSpec())
 	if err == nil {
 		t.Error("Second CreateServer() expected error for duplicate name, got nil")
 	}
@@ -175,23 +175,23 @@ func TestDeleteServer_Success(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
-	err = client.DeleteServer("testserver")
+	err = client.DeleteServer(context.Background(), "testserver")
 	if err != nil {
 		t.Fatalf("DeleteServer() error = %v", err)
 	}
 
 	// Verify server no longer exists
-	exists, err := client.ServerExists("testserver")
+	exists, err := client.ServerExists(context.Background(), "testserver")
 	if err != nil {
 		t.Fatalf("ServerExists() error = %v", err)
 	}
@@ -208,7 +208,7 @@ func TestDeleteServer_NonexistentFails(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	err := client.DeleteServer("nonexistent")
+	err := client.DeleteServer(context.Background(), "nonexistent")
 	if err == nil {
 		t.Error("DeleteServer() expected error for nonexistent server, got nil")
 	}
@@ -222,7 +222,7 @@ func TestListServers_Empty(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	servers, err := client.ListServers()
+	servers, err := client.ListServers(context.Background())
 	if err != nil {
 		t.Fatalf("ListServers() error = %v", err)
 	}
@@ -239,17 +239,17 @@ func TestListServers_ReturnsCreatedServer(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
-	servers, err := client.ListServers()
+	servers, err := client.ListServers(context.Background())
 	if err != nil {
 		t.Fatalf("ListServers() error = %v", err)
 	}
@@ -276,24 +276,24 @@ func TestScaleServer_StopAndStart(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
 	// Scale to 0 (stop)
-	err = client.ScaleServer("testserver", 0)
+	err = client.ScaleServer(context.Background(), "testserver", 0)
 	if err != nil {
 		t.Fatalf("ScaleServer(0) error = %v", err)
 	}
 
 	// Verify status is stopped
-	servers, err := client.ListServers()
+	servers, err := client.ListServers(context.Background())
 	if err != nil {
 		t.Fatalf("ListServers() error = %v", err)
 	}
@@ -305,13 +305,13 @@ func TestScaleServer_StopAndStart(t *testing.T) {
 	}
 
 	// Scale to 1 (start)
-	err = client.ScaleServer("testserver", 1)
+	err = client.ScaleServer(context.Background(), "testserver", 1)
 	if err != nil {
 		t.Fatalf("ScaleServer(1) error = %v", err)
 	}
 
 	// Verify status is running
-	servers, err = client.ListServers()
+	servers, err = client.ListServers(context.Background())
 	if err != nil {
 		t.Fatalf("ListServers() error = %v", err)
 	}
@@ -328,12 +328,12 @@ func TestScaleServer_InvalidReplicas(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	err := client.ScaleServer("testserver", 2)
+	err := client.ScaleServer(context.Background(), "testserver", 2)
 	if err == nil {
 		t.Error("ScaleServer(2) expected error, got nil")
 	}
 
-	err = client.ScaleServer("testserver", -1)
+	err = client.ScaleServer(context.Background(), "testserver", -1)
 	if err == nil {
 		t.Error("ScaleServer(-1) expected error, got nil")
 	}
@@ -347,7 +347,7 @@ func TestScaleServer_NonexistentFails(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	err := client.ScaleServer("nonexistent", 1)
+	err := client.ScaleServer(context.Background(), "nonexistent", 1)
 	if err == nil {
 		t.Error("ScaleServer() expected error for nonexistent server, got nil")
 	}
@@ -356,7 +356,7 @@ func TestScaleServer_NonexistentFails(t *testing.T) {
 func TestCheckNodeCapacity_PassesWhenEmpty(t *testing.T) {
 	client := GetTestClient(t)
 
-	err := client.CheckNodeCapacity()
+	err := client.CheckNodeCapacity(context.Background())
 	if err != nil {
 		t.Errorf("CheckNodeCapacity() error = %v, want nil when no servers running", err)
 	}
@@ -370,24 +370,24 @@ func TestListServers_ShowsStoppedServer(t *testing.T) {
 
 	client.namespace = config.NamespacePrefix + username
 
-	port, err := client.AllocateNodePort()
+	port, err := client.AllocateNodePort(context.Background())
 	if err != nil {
 		t.Fatalf("AllocateNodePort() error = %v", err)
 	}
 
-	err = client.CreateServer("testserver", username, port)
+	err = client.CreateServer(context.Background(), "testserver", username, port, 0, DefaultServerSpec())
 	if err != nil {
 		t.Fatalf("CreateServer() error = %v", err)
 	}
 
 	// Stop the server
-	err = client.ScaleServer("testserver", 0)
+	err = client.ScaleServer(context.Background(), "testserver", 0)
 	if err != nil {
 		t.Fatalf("ScaleServer(0) error = %v", err)
 	}
 
 	// Stopped server should still appear in list
-	servers, err := client.ListServers()
+	servers, err := client.ListServers(context.Background())
 	if err != nil {
 		t.Fatalf("ListServers() error = %v", err)
 	}
@@ -408,7 +408,10 @@ func TestWaitForReady_TimeoutOnNonexistent(t *testing.T) {
 	client.namespace = config.NamespacePrefix + username
 
 	// Should timeout since no server exists
-	err := client.WaitForReady("nonexistent")
+	ctx, cancel := context.WithTimeout(context.Background(), 3*config.PollInterval)
+	defer cancel()
+
+	err := client.WaitForReady(ctx, "nonexistent", nil)
 	if err == nil {
 		t.Error("WaitForReady() expected timeout error, got nil")
 	}
@@ -0,0 +1,377 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func TestCreateServer_BuildsServiceAndStatefulSet(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	ctx := context.Background()
+
+	spec := DefaultServerSpec()
+	spec.GameMode = "creative"
+	spec.MaxPlayers = 12
+	spec.Seed = "42"
+
+	if err := client.CreateServer(context.Background(), "testserver", fakeUsername, 30003, 0, spec); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	svc, err := clientset.CoreV1().Services(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeNodePort {
+		t.Errorf("Service type = %s, want NodePort", svc.Spec.Type)
+	}
+	if svc.Spec.Ports[0].NodePort != 30003 {
+		t.Errorf("Service NodePort = %d, want 30003", svc.Spec.Ports[0].NodePort)
+	}
+	if svc.Labels[config.CommonLabelKey] != config.CommonLabelValue {
+		t.Errorf("Service label %s = %q, want %q", config.CommonLabelKey, svc.Labels[config.CommonLabelKey], config.CommonLabelValue)
+	}
+	if svc.Spec.Selector["server"] != "testserver" || svc.Spec.Selector["user"] != fakeUsername {
+		t.Errorf("Service selector = %v, want server=testserver and user=%s", svc.Spec.Selector, fakeUsername)
+	}
+
+	sts, err := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 1 {
+		t.Errorf("StatefulSet replicas = %v, want 1", sts.Spec.Replicas)
+	}
+
+	container := sts.Spec.Template.Spec.Containers[0]
+	if container.Image != config.ServerImage {
+		t.Errorf("Container image = %q, want %q", container.Image, config.ServerImage)
+	}
+
+	wantEnv := map[string]string{
+		"EULA":        "TRUE",
+		"SERVER_TYPE": config.DefaultServerType,
+		"VERSION":     config.DefaultServerVersion,
+		"GAME_MODE":   "creative",
+		"MAX_PLAYERS": "12",
+		"SEED":        "42",
+		"PVP":         "true",
+		"JAVA_MEMORY": config.ServerJavaMemory,
+	}
+	for name, want := range wantEnv {
+		if got, _ := envValue(container, name); got != want {
+			t.Errorf("env %s = %q, want %q", name, got, want)
+		}
+	}
+
+	if memory := container.Resources.Limits.Memory().String(); memory != config.ServerMemoryLimit {
+		t.Errorf("Memory limit = %s, want %s", memory, config.ServerMemoryLimit)
+	}
+
+	claim := sts.Spec.VolumeClaimTemplates[0]
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName != config.ServerStorageClass {
+		t.Errorf("StorageClassName = %v, want %s", claim.Spec.StorageClassName, config.ServerStorageClass)
+	}
+}
+
+func TestCreateServer_OmitsEmptySeed(t *testing.T) {
+	client, clientset := newFakeClient(t)
+
+	if err := client.CreateServer(context.Background(), "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	sts, err := clientset.AppsV1().StatefulSets(client.namespace).Get(context.Background(), "testserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+
+	if _, ok := envValue(sts.Spec.Template.Spec.Containers[0], "SEED"); ok {
+		t.Error("SEED env is set for an empty seed, want it omitted")
+	}
+}
+
+func TestCreateServer_CleansUpServiceOnStatefulSetFailure(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	clientset.PrependReactor("create", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("exceeded quota")
+	})
+
+	err := client.CreateServer(context.Background(), "testserver", fakeUsername, 30000, 0, DefaultServerSpec())
+	if err == nil {
+		t.Fatal("CreateServer() expected error, got nil")
+	}
+
+	_, err = clientset.CoreV1().Services(client.namespace).Get(context.Background(), "testserver", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Service still exists after failed create (err = %v), want it cleaned up", err)
+	}
+}
+
+func TestCreateServer_ExistingServiceFails(t *testing.T) {
+	client, clientset := newFakeClient(t, fakeServerService(config.NamespacePrefix+fakeUsername, "testserver", 30000))
+
+	if err := client.CreateServer(context.Background(), "testserver", fakeUsername, 30001, 0, DefaultServerSpec()); err == nil {
+		t.Fatal("CreateServer() expected error for existing service, got nil")
+	}
+
+	list, err := clientset.AppsV1().StatefulSets(client.namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list statefulsets: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("Found %d statefulsets, want none after failed service create", len(list.Items))
+	}
+}
+
+func TestListServers_ReportsStatusAndPort(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	running := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "alpha", Namespace: namespace, CreationTimestamp: created},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(1))},
+	}
+	stopped := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "beta", Namespace: namespace, CreationTimestamp: created},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(0))},
+	}
+
+	client, _ := newFakeClient(t,
+		running, stopped,
+		fakeServerService(namespace, "alpha", 30001),
+		fakeServerService(namespace, "beta", 30002),
+	)
+
+	servers, err := client.ListServers(context.Background())
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("ListServers() returned %d servers, want 2", len(servers))
+	}
+
+	byName := map[string]ServerInfo{}
+	for _, s := range servers {
+		byName[s.Name] = s
+	}
+
+	if byName["alpha"].Status != "running" || byName["alpha"].NodePort != 30001 {
+		t.Errorf("alpha = %+v, want running on 30001", byName["alpha"])
+	}
+	if byName["beta"].Status != "stopped" || byName["beta"].NodePort != 30002 {
+		t.Errorf("beta = %+v, want stopped on 30002", byName["beta"])
+	}
+	if !byName["alpha"].Age.Equal(created.Time) {
+		t.Errorf("alpha Age = %v, want %v", byName["alpha"].Age, created.Time)
+	}
+}
+
+func TestListServers_OnlyOwnNamespace(t *testing.T) {
+	other := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "theirs", Namespace: config.NamespacePrefix + "bob"},
+	}
+	client, _ := newFakeClient(t, other)
+
+	servers, err := client.ListServers(context.Background())
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("ListServers() returned %d servers from another namespace, want 0", len(servers))
+	}
+}
+
+func TestListServers_MissingServiceFails(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: config.NamespacePrefix + fakeUsername},
+	}
+	client, _ := newFakeClient(t, sts)
+
+	if _, err := client.ListServers(context.Background()); err == nil {
+		t.Error("ListServers() expected error for statefulset without service, got nil")
+	}
+}
+
+func TestAllocateNodePort_FirstFreePort(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	port, err := client.AllocateNodePort(context.Background())
+	if err != nil {
+		t.Fatalf("AllocateNodePort() error = %v", err)
+	}
+	if port != config.McNodePortRangeMin {
+		t.Errorf("AllocateNodePort() = %d, want %d", port, config.McNodePortRangeMin)
+	}
+}
+
+func TestAllocateNodePort_SkipsPortsInOtherNamespaces(t *testing.T) {
+	client, _ := newFakeClient(t,
+		fakeServerService(config.NamespacePrefix+"bob", "one", config.McNodePortRangeMin),
+		fakeServerService(config.NamespacePrefix+"carol", "two", config.McNodePortRangeMin+1),
+		fakeServerService(config.NamespacePrefix+"carol", "three", config.McNodePortRangeMin+3),
+	)
+
+	port, err := client.AllocateNodePort(context.Background())
+	if err != nil {
+		t.Fatalf("AllocateNodePort() error = %v", err)
+	}
+	if port != config.McNodePortRangeMin+2 {
+		t.Errorf("AllocateNodePort() = %d, want %d", port, config.McNodePortRangeMin+2)
+	}
+}
+
+func TestAllocateNodePort_IgnoresUnlabelledServices(t *testing.T) {
+	svc := fakeServerService("default", "unrelated", config.McNodePortRangeMin)
+	svc.Labels = nil
+	client, _ := newFakeClient(t, svc)
+
+	port, err := client.AllocateNodePort(context.Background())
+	if err != nil {
+		t.Fatalf("AllocateNodePort() error = %v", err)
+	}
+	if port != config.McNodePortRangeMin {
+		t.Errorf("AllocateNodePort() = %d, want %d", port, config.McNodePortRangeMin)
+	}
+}
+
+func TestAllocateNodePort_RangeExhausted(t *testing.T) {
+	var objects []runtime.Object
+	for port := int32(config.McNodePortRangeMin); port <= config.McNodePortRangeMax; port++ {
+		objects = append(objects, fakeServerService(config.NamespacePrefix+"bob", fmt.Sprintf("s%d", port), port))
+	}
+	client, _ := newFakeClient(t, objects...)
+
+	if _, err := client.AllocateNodePort(context.Background()); err == nil {
+		t.Error("AllocateNodePort() expected error when range is full, got nil")
+	}
+}
+
+func TestAllocateBedrockNodePort_SeparateRange(t *testing.T) {
+	client, _ := newFakeClient(t,
+		fakeServerService(config.NamespacePrefix+"bob", "one", config.McNodePortRangeMin),
+		fakeServerService(config.NamespacePrefix+"bob", "two", config.BedrockNodePortRangeMin),
+	)
+
+	port, err := client.AllocateBedrockNodePort(context.Background())
+	if err != nil {
+		t.Fatalf("AllocateBedrockNodePort() error = %v", err)
+	}
+	if port != config.BedrockNodePortRangeMin+1 {
+		t.Errorf("AllocateBedrockNodePort() = %d, want %d", port, config.BedrockNodePortRangeMin+1)
+	}
+}
+
+func TestCreateServer_Bedrock(t *testing.T) {
+	ctx := context.Background()
+	client, clientset := newFakeClient(t)
+
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30001, 30100, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	svc, _ := clientset.CoreV1().Services(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[0].NodePort != 30001 {
+		t.Fatalf("ports = %+v, want the Java port first and a Bedrock port", svc.Spec.Ports)
+	}
+	if bedrock := svc.Spec.Ports[1]; bedrock.Protocol != corev1.ProtocolUDP || bedrock.Port != config.BedrockPort || bedrock.NodePort != 30100 {
+		t.Errorf("bedrock port = %+v, want UDP %d on nodeport 30100", bedrock, config.BedrockPort)
+	}
+
+	sts, _ := clientset.AppsV1().StatefulSets(client.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	container := sts.Spec.Template.Spec.Containers[0]
+	if !slices.Contains(container.Env, bedrockEnv) {
+		t.Error("BEDROCK not set for a server with a Bedrock port")
+	}
+	if container.ReadinessProbe.Exec == nil || container.ReadinessProbe.TCPSocket != nil {
+		t.Errorf("readiness probe = %+v, want the launcher to check both listeners", container.ReadinessProbe.ProbeHandler)
+	}
+
+	servers, err := client.ListServers(ctx)
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if servers[0].NodePort != 30001 || servers[0].BedrockNodePort != 30100 {
+		t.Errorf("ListServers() = %+v, want both nodeports", servers[0])
+	}
+}
+
+func TestCheckNodeCapacity_AllowsWithHeadroom(t *testing.T) {
+	client, _ := newFakeClient(t,
+		fakeMinecraftPod("mc-bob", "one-0", config.ServerMemoryRequest, corev1.PodRunning),
+		fakeMinecraftPod("mc-carol", "two-0", config.ServerMemoryRequest, corev1.PodRunning),
+	)
+
+	if err := client.CheckNodeCapacity(context.Background()); err != nil {
+		t.Errorf("CheckNodeCapacity() error = %v, want nil", err)
+	}
+}
+
+func TestCheckNodeCapacity_RejectsWhenFull(t *testing.T) {
+	// 14GiB total, 4GiB threshold: 6 servers requesting 2GiB leave only 2GiB free
+	var objects []runtime.Object
+	for i := 0; i < 6; i++ {
+		objects = append(objects, fakeMinecraftPod(fmt.Sprintf("mc-user%d", i), "server-0", config.ServerMemoryRequest, corev1.PodRunning))
+	}
+	client, _ := newFakeClient(t, objects...)
+
+	if err := client.CheckNodeCapacity(context.Background()); err == nil {
+		t.Error("CheckNodeCapacity() expected error when node is full, got nil")
+	}
+}
+
+func TestCheckNodeCapacity_IgnoresOtherPods(t *testing.T) {
+	big := fakeMinecraftPod("default", "database-0", "12Gi", corev1.PodRunning)
+	big.Labels = map[string]string{"app": "database"}
+	client, _ := newFakeClient(t, big)
+
+	if err := client.CheckNodeCapacity(context.Background()); err != nil {
+		t.Errorf("CheckNodeCapacity() error = %v, want non-minecraft pods ignored", err)
+	}
+}
+
+func TestCleanupServer_RemovesPartialServer(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	client, clientset := newFakeClient(t, fakeServerService(namespace, "testserver", 30000))
+
+	if err := client.CleanupServer(context.Background(), "testserver"); err != nil {
+		t.Fatalf("CleanupServer() error = %v", err)
+	}
+
+	_, err := clientset.CoreV1().Services(namespace).Get(context.Background(), "testserver", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Service still exists after cleanup (err = %v)", err)
+	}
+}
+
+func TestDeleteServer_ForbiddenCleanupReported(t *testing.T) {
+	ctx := context.Background()
+	namespace := config.NamespacePrefix + fakeUsername
+	worldPVC := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "mc-testserver-0", Namespace: namespace}}
+	client, clientset := newFakeClient(t, worldPVC)
+	if err := client.CreateServer(ctx, "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	// A Role that doesn't allow deleting backup schedules would leave the backups behind
+	clientset.PrependReactor("delete", "cronjobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", fmt.Errorf("not in the Role"))
+	})
+
+	err := client.DeleteServer(ctx, "testserver")
+	if err == nil || !apierrors.IsForbidden(err) {
+		t.Errorf("DeleteServer() error = %v, want the forbidden cleanup reported", err)
+	}
+}
@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// WaitForServerExit blocks until the server process has exited, meaning its container
+// stopped running or was restarted, or the pod is gone. Callers bound the wait with ctx.
+func (c *Client) WaitForServerExit(ctx context.Context, serverName string) error {
+	podName := serverName + "-0"
+
+	pod, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		Get(
+			ctx,
+			podName,
+			metav1.GetOptions{},
+		)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get server pod: %w", err)
+	}
+	if !containerRunning(pod) {
+		return nil
+	}
+	restarts := restartCount(pod)
+
+	podWatch, err := c.clientset.
+		CoreV1().
+		Pods(c.namespace).
+		Watch(
+			ctx,
+			metav1.ListOptions{
+				FieldSelector:   "metadata.name=" + podName,
+				ResourceVersion: pod.ResourceVersion,
+			},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to watch server pod: %w", err)
+	}
+	defer podWatch.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out waiting for server (%s) to exit", serverName)
+			}
+			return fmt.Errorf("stopped waiting for server (%s) to exit: %w", serverName, ctx.Err())
+
+		case ev, ok := <-podWatch.ResultChan():
+			if !ok {
+				return fmt.Errorf("lost watch on server (%s) before it exited", serverName)
+			}
+			if ev.Type == watch.Deleted {
+				return nil
+			}
+			pod, isPod := ev.Object.(*corev1.Pod)
+			if !isPod || pod.Name != podName {
+				continue
+			}
+			if !containerRunning(pod) || restartCount(pod) != restarts {
+				return nil
+			}
+		}
+	}
+}
+
+func restartCount(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+	}
+
+	return restarts
+}
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runningServerPod returns a server pod whose container is running
+func runningServerPod() *corev1.Pod {
+	pod := fakeMinecraftPod(config.NamespacePrefix+fakeUsername, "testserver-0", config.ServerMemoryRequest, corev1.PodRunning)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  config.CommonLabelValuePod,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+
+	return pod
+}
+
+func TestWaitForServerExit_NoPod(t *testing.T) {
+	client, _ := newFakeClient(t)
+
+	if err := client.WaitForServerExit(context.Background(), "testserver"); err != nil {
+		t.Errorf("WaitForServerExit() error = %v, want nil for a stopped server", err)
+	}
+}
+
+func TestWaitForServerExit_ReturnsWhenContainerRestarts(t *testing.T) {
+	namespace := config.NamespacePrefix + fakeUsername
+	pod := runningServerPod()
+	client, clientset := newFakeClient(t, pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitForServerExit(ctx, "testserver")
+	}()
+
+	waitForWatches(t, clientset, "pods")
+
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses[0].RestartCount = 1
+	if _, err := clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("WaitForServerExit() error = %v, want nil", err)
+	}
+}
+
+func TestWaitForServerExit_Timeout(t *testing.T) {
+	client, _ := newFakeClient(t, runningServerPod())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.WaitForServerExit(ctx, "testserver")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("WaitForServerExit() error = %v, want a timeout", err)
+	}
+}
+
+func TestCreateServer_GracefulShutdown(t *testing.T) {
+	client, clientset := newFakeClient(t)
+
+	if err := client.CreateServer(context.Background(), "testserver", fakeUsername, 30000, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	sts, err := clientset.AppsV1().StatefulSets(client.namespace).Get(context.Background(), "testserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+
+	podSpec := sts.Spec.Template.Spec
+	if podSpec.TerminationGracePeriodSeconds == nil || *podSpec.TerminationGracePeriodSeconds != config.ServerGracePeriod {
+		t.Errorf("TerminationGracePeriodSeconds = %v, want %d", podSpec.TerminationGracePeriodSeconds, config.ServerGracePeriod)
+	}
+
+	lifecycle := podSpec.Containers[0].Lifecycle
+	if lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil {
+		t.Fatal("server container has no preStop exec hook")
+	}
+	if cmd := lifecycle.PreStop.Exec.Command; cmd[len(cmd)-1] != "stop" {
+		t.Errorf("preStop command = %v, want the launcher stop command", cmd)
+	}
+}
@@ -2,13 +2,20 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/baighasan/kubecraft/internal/config"
 	authv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (c *Client) GenerateToken(username string) (string, error) {
+// ErrUnauthenticated is returned for tokens that don't belong to a kubecraft user
+var ErrUnauthenticated = errors.New("not a kubecraft user token")
+
+func (c *Client) GenerateToken(ctx context.Context, username string) (string, error) {
 	// Define token expiration (5 years in Canada)
 	expirationSeconds := int64(5 * 365 * 24 * 60 * 60) // 157,680,000 seconds
 
@@ -22,7 +29,7 @@ func (c *Client) GenerateToken(username string) (string, error) {
 		CoreV1().
 		ServiceAccounts(c.namespace).
 		CreateToken(
-			context.TODO(),
+			ctx,
 			username,
 			tokenRequest,
 			metav1.CreateOptions{},
@@ -35,3 +42,67 @@ func (c *Client) GenerateToken(username string) (string, error) {
 
 	return token, nil
 }
+
+// GenerateArchiveToken returns a short-lived token of the user's ServiceAccount for the
+// registration service's archive routes. Its audience keeps it from being used against
+// the API server, should it leak from the pod it is handed to.
+func (c *Client) GenerateArchiveToken(ctx context.Context, username string) (string, error) {
+	expirationSeconds := int64(config.ArchiveTokenExpiry)
+
+	tokenRequest := &authv1.TokenRequest{
+		Spec: authv1.TokenRequestSpec{
+			Audiences:         []string{config.ArchiveTokenAudience},
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	result, err := c.clientset.
+		CoreV1().
+		ServiceAccounts(c.namespace).
+		CreateToken(
+			ctx,
+			username,
+			tokenRequest,
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return "", fmt.Errorf("error generating archive token: %w", err)
+	}
+
+	return result.Status.Token, nil
+}
+
+// AuthenticateArchiveToken checks a token from GenerateArchiveToken with the API server
+// and returns the username it belongs to. Only tokens of a kubecraft user's own
+// ServiceAccount issued for the archive routes are accepted.
+func (c *Client) AuthenticateArchiveToken(ctx context.Context, token string) (string, error) {
+	review, err := c.clientset.
+		AuthenticationV1().
+		TokenReviews().
+		Create(
+			ctx,
+			&authv1.TokenReview{Spec: authv1.TokenReviewSpec{
+				Token:     token,
+				Audiences: []string{config.ArchiveTokenAudience},
+			}},
+			metav1.CreateOptions{},
+		)
+	if err != nil {
+		return "", fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated || !slices.Contains(review.Status.Audiences, config.ArchiveTokenAudience) {
+		return "", ErrUnauthenticated
+	}
+
+	// ServiceAccount usernames look like system:serviceaccount:<namespace>:<name>
+	account, ok := strings.CutPrefix(review.Status.User.Username, "system:serviceaccount:")
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	namespace, username, ok := strings.Cut(account, ":")
+	if !ok || namespace != config.NamespacePrefix+username {
+		return "", ErrUnauthenticated
+	}
+
+	return username, nil
+}
@@ -3,6 +3,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"strings"
@@ -17,12 +18,12 @@ func TestGenerateToken_Success(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace and ServiceAccount
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
@@ -31,7 +32,7 @@ func TestGenerateToken_Success(t *testing.T) {
 	WaitForServiceAccount(t, client, config.NamespacePrefix+username, username)
 
 	// Generate token
-	token, err := client.GenerateToken(username)
+	token, err := client.GenerateToken(context.Background(), username)
 	if err != nil {
 		t.Fatalf("GenerateToken() error = %v", err)
 	}
@@ -54,12 +55,12 @@ func TestGenerateToken_ValidJWT(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace and ServiceAccount
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
@@ -68,7 +69,7 @@ func TestGenerateToken_ValidJWT(t *testing.T) {
 	WaitForServiceAccount(t, client, config.NamespacePrefix+username, username)
 
 	// Generate token
-	token, err := client.GenerateToken(username)
+	token, err := client.GenerateToken(context.Background(), username)
 	if err != nil {
 		t.Fatalf("GenerateToken() error = %v", err)
 	}
@@ -122,13 +123,13 @@ func TestGenerateToken_NonexistentServiceAccount(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace but NOT ServiceAccount
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
 	// Try to generate token - should fail
-	token, err := client.GenerateToken(username)
+	token, err := client.GenerateToken(context.Background(), username)
 	if err == nil {
 		t.Fatal("GenerateToken() expected error for nonexistent ServiceAccount, got nil")
 	}
@@ -144,12 +145,12 @@ func TestGenerateToken_ExpirationCorrect(t *testing.T) {
 	defer CleanupNamespace(t, client, username)
 
 	// Create namespace and ServiceAccount
-	err := client.CreateNamespace(username)
+	err := client.CreateNamespace(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateNamespace() error = %v", err)
 	}
 
-	err = client.CreateServiceAccount(username)
+	err = client.CreateServiceAccount(context.Background(), username)
 	if err != nil {
 		t.Fatalf("CreateServiceAccount() error = %v", err)
 	}
@@ -158,7 +159,7 @@ func TestGenerateToken_ExpirationCorrect(t *testing.T) {
 	WaitForServiceAccount(t, client, config.NamespacePrefix+username, username)
 
 	// Generate token
-	token, err := client.GenerateToken(username)
+	token, err := client.GenerateToken(context.Background(), username)
 	if err != nil {
 		t.Fatalf("GenerateToken() error = %v", err)
 	}
@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// reviewTokens answers TokenReviews as if the API server authenticated username for
+// the given audiences
+func reviewTokens(t *testing.T, username string, authenticated bool, audiences ...string) *Client {
+	t.Helper()
+
+	client, clientset := newFakeClient(t)
+	clientset.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authv1.TokenReview).DeepCopy()
+		review.Status.Authenticated = authenticated
+		review.Status.User.Username = username
+		review.Status.Audiences = audiences
+		return true, review, nil
+	})
+
+	return client
+}
+
+func TestGenerateArchiveToken_AudienceAndExpiry(t *testing.T) {
+	client, clientset := newFakeClient(t)
+	var request *authv1.TokenRequest
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		request = action.(k8stesting.CreateAction).GetObject().(*authv1.TokenRequest)
+		return true, &authv1.TokenRequest{Status: authv1.TokenRequestStatus{Token: "archive-token"}}, nil
+	})
+
+	token, err := client.GenerateArchiveToken(context.Background(), fakeUsername)
+	if err != nil {
+		t.Fatalf("GenerateArchiveToken() error = %v", err)
+	}
+	if token != "archive-token" {
+		t.Errorf("token = %q, want archive-token", token)
+	}
+	if !slices.Equal(request.Spec.Audiences, []string{config.ArchiveTokenAudience}) {
+		t.Errorf("Audiences = %v, want only %s", request.Spec.Audiences, config.ArchiveTokenAudience)
+	}
+	if *request.Spec.ExpirationSeconds != config.ArchiveTokenExpiry {
+		t.Errorf("ExpirationSeconds = %d, want %d", *request.Spec.ExpirationSeconds, config.ArchiveTokenExpiry)
+	}
+}
+
+func TestAuthenticateArchiveToken_UserServiceAccount(t *testing.T) {
+	client := reviewTokens(t, "system:serviceaccount:mc-alice:alice", true, config.ArchiveTokenAudience)
+
+	username, err := client.AuthenticateArchiveToken(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("AuthenticateArchiveToken() error = %v", err)
+	}
+	if username != "alice" {
+		t.Errorf("username = %q, want alice", username)
+	}
+}
+
+func TestAuthenticateArchiveToken_RejectsOtherIdentities(t *testing.T) {
+	tests := []struct {
+		name          string
+		username      string
+		authenticated bool
+		audiences     []string
+	}{
+		{"unauthenticated", "system:serviceaccount:mc-alice:alice", false, []string{config.ArchiveTokenAudience}},
+		{"API server token", "system:serviceaccount:mc-alice:alice", true, []string{"https://kubernetes.default.svc"}},
+		{"other service account", "system:serviceaccount:kubecraft-system:registration-service", true, []string{config.ArchiveTokenAudience}},
+		{"account in another user's namespace", "system:serviceaccount:mc-bob:alice", true, []string{config.ArchiveTokenAudience}},
+		{"not a service account", "kubernetes-admin", true, []string{config.ArchiveTokenAudience}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := reviewTokens(t, tt.username, tt.authenticated, tt.audiences...)
+
+			_, err := client.AuthenticateArchiveToken(context.Background(), "token")
+			if !errors.Is(err, ErrUnauthenticated) {
+				t.Errorf("AuthenticateArchiveToken() error = %v, want ErrUnauthenticated", err)
+			}
+		})
+	}
+}
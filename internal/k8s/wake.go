@@ -0,0 +1,288 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// ServerState is what the wake proxy and idle shutdown need to know about a server in
+// any namespace
+type ServerState struct {
+	Namespace   string
+	Name        string
+	Username    string
+	NodePort    int32  // 0 for servers reached through the gateway only
+	ClusterIP   string // Of the Service, which the gateway connects to
+	Version     string
+	MaxPlayers  int
+	MOTD        string
+	Replicas    int32
+	Maintenance bool
+	IdleTimeout time.Duration
+	PodIP       string // Set once the server accepts players
+	Claimed     bool   // The NodePort routes to the wake proxy
+}
+
+// Ready reports whether the server accepts players
+func (s ServerState) Ready() bool {
+	return s.PodIP != ""
+}
+
+// Gateway reports whether the server has no NodePort and expects the PROXY protocol
+func (s ServerState) Gateway() bool {
+	return s.NodePort == 0
+}
+
+// ListServerStates returns the state of every server in the cluster. It needs an
+// unscoped client, as NewInClusterClient returns.
+func (c *Client) ListServerStates(ctx context.Context) ([]ServerState, error) {
+	statefulSets, err := c.clientset.
+		AppsV1().
+		StatefulSets(c.namespace).
+		List(
+			ctx,
+			metav1.ListOptions{
+				LabelSelector: config.CommonLabelKey + "=" + config.CommonLabelValuePod,
+			},
+		)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers (statefulsets): %w", err)
+	}
+
+	var states []ServerState
+	for _, sts := range statefulSets.Items {
+		if len(sts.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+
+		svc, err := c.clientset.
+			CoreV1().
+			Services(sts.Namespace).
+			Get(
+				ctx,
+				sts.Name,
+				metav1.GetOptions{},
+			)
+		if errors.IsNotFound(err) {
+			// Being created or deleted
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server (service): %w", err)
+		}
+		if len(svc.Spec.Ports) == 0 {
+			continue
+		}
+
+		spec := specFromEnv(sts.Spec.Template.Spec.Containers[0].Env)
+		state := ServerState{
+			Namespace:   sts.Namespace,
+			Name:        sts.Name,
+			Username:    sts.Labels["user"],
+			NodePort:    svc.Spec.Ports[0].NodePort,
+			ClusterIP:   svc.Spec.ClusterIP,
+			Version:     spec.Version,
+			MaxPlayers:  spec.MaxPlayers,
+			MOTD:        spec.MOTD,
+			Replicas:    ptr.Deref(sts.Spec.Replicas, 1),
+			Maintenance: spec.Maintenance,
+			IdleTimeout: idleTimeout(&sts),
+			Claimed:     len(svc.Spec.Selector) == 0,
+		}
+
+		if state.Replicas > 0 {
+			pod, err := c.clientset.
+				CoreV1().
+				Pods(sts.Namespace).
+				Get(
+					ctx,
+					sts.Name+"-0",
+					metav1.GetOptions{},
+				)
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get server (pod): %w", err)
+			}
+			if err == nil && pod.DeletionTimestamp == nil && isServerReady(pod) {
+				state.PodIP = pod.Status.PodIP
+			}
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// ClaimServerPort routes the server's NodePort to the wake proxy at proxyIP, listening
+// on a port equal to the NodePort. The Service loses its selector so that the
+// EndpointSlice pointing at the proxy is the only one.
+func (c *Client) ClaimServerPort(ctx context.Context, state ServerState, proxyIP string) error {
+	svc, err := c.clientset.
+		CoreV1().
+		Services(state.Namespace).
+		Get(
+			ctx,
+			state.Name,
+			metav1.GetOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to get server (service): %w", err)
+	}
+
+	slice := wakeEndpointSlice(svc, proxyIP, state.NodePort)
+	existing, err := c.clientset.
+		DiscoveryV1().
+		EndpointSlices(state.Namespace).
+		Get(
+			ctx,
+			slice.Name,
+			metav1.GetOptions{},
+		)
+	switch {
+	case errors.IsNotFound(err):
+		_, err = c.clientset.
+			DiscoveryV1().
+			EndpointSlices(state.Namespace).
+			Create(
+				ctx,
+				slice,
+				metav1.CreateOptions{},
+			)
+	case err == nil:
+		slice.ResourceVersion = existing.ResourceVersion
+		_, err = c.clientset.
+			DiscoveryV1().
+			EndpointSlices(state.Namespace).
+			Update(
+				ctx,
+				slice,
+				metav1.UpdateOptions{},
+			)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to route server to wake proxy (endpointslice): %w", err)
+	}
+
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	svc.Spec.Selector = nil
+	_, err = c.clientset.
+		CoreV1().
+		Services(state.Namespace).
+		Update(
+			ctx,
+			svc,
+			metav1.UpdateOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to route server to wake proxy (service): %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseServerPort routes the server's NodePort back to its pod, as CreateServer
+// set it up
+func (c *Client) ReleaseServerPort(ctx context.Context, state ServerState) error {
+	svc, err := c.clientset.
+		CoreV1().
+		Services(state.Namespace).
+		Get(
+			ctx,
+			state.Name,
+			metav1.GetOptions{},
+		)
+	if err != nil {
+		return fmt.Errorf("failed to get server (service): %w", err)
+	}
+
+	if len(svc.Spec.Selector) == 0 {
+		svc.Spec.Selector = map[string]string{
+			config.CommonLabelKey: config.CommonLabelValuePod,
+			"server":              state.Name,
+			"user":                state.Username,
+		}
+		_, err = c.clientset.
+			CoreV1().
+			Services(state.Namespace).
+			Update(
+				ctx,
+				svc,
+				metav1.UpdateOptions{},
+			)
+		if err != nil {
+			return fmt.Errorf("failed to route server to its pod (service): %w", err)
+		}
+	}
+
+	err = c.clientset.
+		DiscoveryV1().
+		EndpointSlices(state.Namespace).
+		Delete(
+			ctx,
+			state.Name+config.WakeEndpointSliceSuffix,
+			metav1.DeleteOptions{},
+		)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to route server to its pod (endpointslice): %w", err)
+	}
+
+	return nil
+}
+
+// WakeServer starts a stopped server in any namespace, if the node has room for it
+func (c *Client) WakeServer(ctx context.Context, namespace string, serverName string) error {
+	if err := c.CheckNodeCapacity(ctx); err != nil {
+		return err
+	}
+
+	scoped := NewClientWithInterface(c.clientset, namespace)
+	return scoped.ScaleServer(ctx, serverName, 1)
+}
+
+// wakeEndpointSlice points the server's Service at the wake proxy. It is owned by the
+// Service, so deleting the server removes it.
+func wakeEndpointSlice(svc *corev1.Service, proxyIP string, port int32) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.Name + config.WakeEndpointSliceSuffix,
+			Namespace: svc.Namespace,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: svc.Name,
+				discoveryv1.LabelManagedBy:   config.WakeProxyManagedBy,
+				config.CommonLabelKey:        config.CommonLabelValue,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Name:       svc.Name,
+					UID:        svc.UID,
+				},
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{proxyIP},
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+			},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{
+				Name:     ptr.To(config.CommonLabelValuePod),
+				Port:     ptr.To(port),
+				Protocol: ptr.To(corev1.ProtocolTCP),
+			},
+		},
+	}
+}
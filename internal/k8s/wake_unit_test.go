@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// wakeFixture creates a server as the user would and returns an unscoped client,
+// as the wake proxy has
+func wakeFixture(t *testing.T, replicas int32) (*Client, *Client) {
+	t.Helper()
+
+	ctx := context.Background()
+	user, clientset := newFakeClient(t)
+	if err := user.CreateServer(ctx, "testserver", fakeUsername, 30004, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if err := user.ScaleServer(ctx, "testserver", replicas); err != nil {
+		t.Fatalf("ScaleServer() error = %v", err)
+	}
+
+	return user, NewClientWithInterface(clientset, "")
+}
+
+func TestListServerStates(t *testing.T) {
+	ctx := context.Background()
+	user, proxy := wakeFixture(t, 1)
+
+	pod := fakeMinecraftPod(user.namespace, "testserver-0", "2Gi", corev1.PodRunning)
+	pod.Status.PodIP = "10.42.0.20"
+	if _, err := proxy.clientset.CoreV1().Pods(user.namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := proxy.ListServerStates(ctx)
+	if err != nil {
+		t.Fatalf("ListServerStates() error = %v", err)
+	}
+	if len(states) != 1 {
+		t.Fatalf("ListServerStates() = %+v, want 1 server", states)
+	}
+	state := states[0]
+	if state.Namespace != user.namespace || state.Username != fakeUsername || state.NodePort != 30004 {
+		t.Errorf("state = %+v, want testserver of %s on port 30004", state, fakeUsername)
+	}
+	if state.Version != config.DefaultServerVersion || state.MaxPlayers != config.DefaultMaxPlayers {
+		t.Errorf("state = %+v, want the server's version and max players", state)
+	}
+	if state.Ready() || state.Claimed {
+		t.Errorf("state = %+v, want a pod that is not ready and a port not claimed", state)
+	}
+
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	proxy.clientset.CoreV1().Pods(user.namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	states, _ = proxy.ListServerStates(ctx)
+	if states[0].PodIP != "10.42.0.20" {
+		t.Errorf("PodIP = %q, want the ready pod's IP", states[0].PodIP)
+	}
+}
+
+func TestListServerStates_Gateway(t *testing.T) {
+	ctx := context.Background()
+	user, clientset := newFakeClient(t)
+	if err := user.CreateServer(ctx, "testserver", fakeUsername, 0, 0, DefaultServerSpec()); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	svc, _ := clientset.CoreV1().Services(user.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("service type = %s, want ClusterIP without a nodeport", svc.Spec.Type)
+	}
+	sts, _ := clientset.AppsV1().StatefulSets(user.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if !slices.Contains(sts.Spec.Template.Spec.Containers[0].Env, proxyProtocolEnv) {
+		t.Error("PROXY_PROTOCOL not set for a server behind the gateway")
+	}
+
+	svc.Spec.ClusterIP = "10.43.0.50"
+	clientset.CoreV1().Services(user.namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	states, err := NewClientWithInterface(clientset, "").ListServerStates(ctx)
+	if err != nil {
+		t.Fatalf("ListServerStates() error = %v", err)
+	}
+	if len(states) != 1 || !states[0].Gateway() || states[0].ClusterIP != "10.43.0.50" {
+		t.Errorf("ListServerStates() = %+v, want the gateway server and its cluster IP", states)
+	}
+}
+
+func TestClaimServerPort_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	user, proxy := wakeFixture(t, 0)
+	states, _ := proxy.ListServerStates(ctx)
+
+	// Claiming twice, as a restarted proxy does, updates the slice
+	if err := proxy.ClaimServerPort(ctx, states[0], "10.42.0.8"); err != nil {
+		t.Fatalf("ClaimServerPort() error = %v", err)
+	}
+	if err := proxy.ClaimServerPort(ctx, states[0], "10.42.0.9"); err != nil {
+		t.Fatalf("ClaimServerPort() error = %v", err)
+	}
+
+	svc, _ := proxy.clientset.CoreV1().Services(user.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	if len(svc.Spec.Selector) != 0 {
+		t.Errorf("Selector = %v, want none while the proxy owns the port", svc.Spec.Selector)
+	}
+	slice, err := proxy.clientset.DiscoveryV1().EndpointSlices(user.namespace).Get(ctx, "testserver"+config.WakeEndpointSliceSuffix, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("EndpointSlice not created: %v", err)
+	}
+	if slice.Labels[discoveryv1.LabelServiceName] != "testserver" {
+		t.Errorf("slice labels = %v, want it attached to the server's service", slice.Labels)
+	}
+	if got := slice.Endpoints[0].Addresses[0]; got != "10.42.0.9" {
+		t.Errorf("endpoint = %s, want the latest proxy IP", got)
+	}
+	if *slice.Ports[0].Port != 30004 || *slice.Ports[0].Name != svc.Spec.Ports[0].Name {
+		t.Errorf("slice port = %s:%d, want the proxy port matching the service port name", *slice.Ports[0].Name, *slice.Ports[0].Port)
+	}
+
+	states, _ = proxy.ListServerStates(ctx)
+	if !states[0].Claimed {
+		t.Error("Claimed = false after ClaimServerPort")
+	}
+
+	if err := proxy.ReleaseServerPort(ctx, states[0]); err != nil {
+		t.Fatalf("ReleaseServerPort() error = %v", err)
+	}
+	svc, _ = proxy.clientset.CoreV1().Services(user.namespace).Get(ctx, "testserver", metav1.GetOptions{})
+	want := map[string]string{config.CommonLabelKey: config.CommonLabelValuePod, "server": "testserver", "user": fakeUsername}
+	if len(svc.Spec.Selector) != len(want) || svc.Spec.Selector["user"] != fakeUsername || svc.Spec.Selector["server"] != "testserver" {
+		t.Errorf("Selector = %v, want %v restored", svc.Spec.Selector, want)
+	}
+	slices, _ := proxy.clientset.DiscoveryV1().EndpointSlices(user.namespace).List(ctx, metav1.ListOptions{})
+	if len(slices.Items) != 0 {
+		t.Errorf("%d endpoint slices left after ReleaseServerPort, want none", len(slices.Items))
+	}
+}
+
+func TestWakeServer(t *testing.T) {
+	ctx := context.Background()
+	user, proxy := wakeFixture(t, 0)
+
+	if err := proxy.WakeServer(ctx, user.namespace, "testserver"); err != nil {
+		t.Fatalf("WakeServer() error = %v", err)
+	}
+
+	running, _ := user.IsServerRunning(ctx, "testserver")
+	if !running {
+		t.Error("server still stopped after WakeServer")
+	}
+}
+
+func TestWakeServer_NodeFull(t *testing.T) {
+	ctx := context.Background()
+	user, proxy := wakeFixture(t, 0)
+
+	// Other users' servers take up the node
+	pod := fakeMinecraftPod("mc-bob", "big-0", "12Gi", corev1.PodRunning)
+	proxy.clientset.CoreV1().Pods("mc-bob").Create(ctx, pod, metav1.CreateOptions{})
+
+	if err := proxy.WakeServer(ctx, user.namespace, "testserver"); err == nil {
+		t.Fatal("WakeServer() expected error on a full node, got nil")
+	}
+	running, _ := user.IsServerRunning(ctx, "testserver")
+	if running {
+		t.Error("server started on a full node")
+	}
+}
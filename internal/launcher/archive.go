@@ -0,0 +1,224 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+// versionHeader carries the Minecraft version of an archive being uploaded
+const versionHeader = "X-Minecraft-Version"
+
+// ArchiveObject is an archive as listed by the registration service
+type ArchiveObject struct {
+	Name             string    `json:"name"`
+	Size             int64     `json:"size"`
+	LastModified     time.Time `json:"lastModified"`
+	MinecraftVersion string    `json:"minecraftVersion,omitempty"`
+}
+
+// presignedRequest reads or writes one object of the archive bucket, signed by the
+// registration service
+type presignedRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// archiveError is the body of a failed archive request
+type archiveError struct {
+	Message string `json:"message"`
+}
+
+// ArchiveClient moves a server's world between its data directory and the archive
+// bucket. The registration service's archive routes only hand out presigned requests,
+// the world itself goes straight between the pod and the bucket.
+type ArchiveClient struct {
+	Service    string // Base URL of the cluster-internal archive routes
+	Server     string
+	Token      string // Short-lived archive token the CLI requested for the user
+	HTTPClient *http.Client
+}
+
+// List returns the server's archives
+func (c *ArchiveClient) List() ([]ArchiveObject, error) {
+	var archives []ArchiveObject
+	if err := c.call(http.MethodGet, "", nil, http.StatusOK, &archives); err != nil {
+		return nil, fmt.Errorf("listing archives: %w", err)
+	}
+
+	return archives, nil
+}
+
+// Push archives the world under dataDir as name and uploads it. The archive is written
+// to a temporary file first, as the bucket needs to know its size.
+func (c *ArchiveClient) Push(dataDir string, name string, version string, now time.Time) (*backup.Manifest, error) {
+	f, err := os.CreateTemp("", "archive-*"+backup.ArchiveExt)
+	if err != nil {
+		return nil, fmt.Errorf("creating archive file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(backup.TarWorld(dataDir, pw))
+	}()
+	manifest, err := backup.Write(f, pr, backup.Manifest{
+		Server:           c.Server,
+		MinecraftVersion: version,
+		CreatedAt:        now.UTC(),
+	})
+	pr.Close()
+	if err != nil {
+		return nil, fmt.Errorf("writing archive: %w", err)
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var upload presignedRequest
+	if err := c.call(http.MethodPost, name+"/upload", map[string]string{versionHeader: version}, http.StatusOK, &upload); err != nil {
+		return nil, fmt.Errorf("preparing upload: %w", err)
+	}
+	resp, err := c.send(upload, f, size)
+	if err != nil {
+		return nil, fmt.Errorf("uploading archive: %w", err)
+	}
+	resp.Body.Close()
+
+	// The registration service verifies the upload before pruning older archives
+	if err := c.call(http.MethodPost, name+"/complete", nil, http.StatusCreated, nil); err != nil {
+		return nil, fmt.Errorf("completing upload: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Pull downloads the archive name and swaps its world in for the one under dataDir.
+// The world is unpacked next to the current one and verified on the way, so a corrupt
+// archive leaves the current world in place. The server must not be running.
+func (c *ArchiveClient) Pull(dataDir string, name string) (*backup.Manifest, error) {
+	var download presignedRequest
+	if err := c.call(http.MethodGet, name, nil, http.StatusOK, &download); err != nil {
+		return nil, fmt.Errorf("preparing download: %w", err)
+	}
+	resp, err := c.send(download, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("downloading archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	target := filepath.Join(dataDir, restoreDir)
+	if err := os.RemoveAll(target); err != nil {
+		return nil, fmt.Errorf("clearing %s: %w", restoreDir, err)
+	}
+	defer os.RemoveAll(target)
+
+	pr, pw := io.Pipe()
+	type result struct {
+		manifest *backup.Manifest
+		err      error
+	}
+	extracted := make(chan result, 1)
+	go func() {
+		manifest, err := backup.ExtractWorld(resp.Body, pw)
+		pw.CloseWithError(err)
+		extracted <- result{manifest, err}
+	}()
+
+	err = backup.UntarWorld(pr, target)
+	if err != nil {
+		// Unblocks the extraction, nothing reads its output anymore
+		pr.CloseWithError(io.ErrClosedPipe)
+	} else {
+		// The tar reader may stop at the first end marker block
+		_, _ = io.Copy(io.Discard, pr)
+	}
+	res := <-extracted
+	if res.err != nil && !errors.Is(res.err, io.ErrClosedPipe) {
+		return nil, fmt.Errorf("invalid archive %s: %w", name, res.err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := swapWorld(dataDir, target); err != nil {
+		return nil, err
+	}
+
+	return res.manifest, nil
+}
+
+// call sends a request to the server's archive routes, or to the archive at path under
+// them, and decodes a wantStatus response into out if set
+func (c *ArchiveClient) call(method string, path string, header map[string]string, wantStatus int, out any) error {
+	url := strings.TrimSuffix(c.Service, "/") + "/archives/" + c.Server
+	if path != "" {
+		url += "/" + path
+	}
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach registration service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		var archiveErr archiveError
+		if err := json.NewDecoder(resp.Body).Decode(&archiveErr); err != nil || archiveErr.Message == "" {
+			return fmt.Errorf("registration service returned status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("%s", archiveErr.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a presigned request to the bucket with body of size bytes, if any
+func (c *ArchiveClient) send(presigned presignedRequest, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequest(presigned.Method, presigned.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for k, v := range presigned.Header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("archive storage returned status %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
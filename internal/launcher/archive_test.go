@@ -0,0 +1,158 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+// fakeArchiveService stands in for the registration service's archive routes of
+// myserver and for the bucket its presigned requests point at
+type fakeArchiveService struct {
+	objects   map[string][]byte
+	versions  map[string]string
+	completed []string
+}
+
+func newArchiveClient(t *testing.T, fake *fakeArchiveService) *ArchiveClient {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/bucket/"); ok {
+			switch r.Method {
+			case http.MethodPut:
+				data, _ := io.ReadAll(r.Body)
+				fake.objects[name] = data
+				fake.versions[name] = r.Header.Get("X-Amz-Meta-Minecraft-Version")
+			case http.MethodGet:
+				w.Write(fake.objects[name])
+			}
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer archive-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(archiveError{Message: "invalid token"})
+			return
+		}
+		path, _ := strings.CutPrefix(r.URL.Path, "/archives/myserver")
+		name, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		switch {
+		case name == "":
+			var objects []ArchiveObject
+			for name, data := range fake.objects {
+				objects = append(objects, ArchiveObject{Name: name, Size: int64(len(data)), MinecraftVersion: fake.versions[name]})
+			}
+			json.NewEncoder(w).Encode(objects)
+		case action == "upload":
+			json.NewEncoder(w).Encode(presignedRequest{
+				Method: http.MethodPut,
+				URL:    srv.URL + "/bucket/" + name,
+				Header: map[string]string{"X-Amz-Meta-Minecraft-Version": r.Header.Get(versionHeader)},
+			})
+		case action == "complete":
+			fake.completed = append(fake.completed, name)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			if _, ok := fake.objects[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(archiveError{Message: "archive " + name + " not found"})
+				return
+			}
+			json.NewEncoder(w).Encode(presignedRequest{Method: http.MethodGet, URL: srv.URL + "/bucket/" + name})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return &ArchiveClient{Service: srv.URL, Server: "myserver", Token: "archive-token", HTTPClient: srv.Client()}
+}
+
+func TestArchiveClient_PushThenPull(t *testing.T) {
+	fake := &fakeArchiveService{objects: map[string][]byte{}, versions: map[string]string{}}
+	client := newArchiveClient(t, fake)
+
+	dataDir := t.TempDir()
+	os.MkdirAll(filepath.Join(dataDir, "world"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("level"), 0644)
+
+	name := "myserver-20260301-040000.tar.zst"
+	m, err := client.Push(dataDir, name, "1.21.11", time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if m.Files != 1 || m.MinecraftVersion != "1.21.11" {
+		t.Errorf("manifest = %+v, want one file of 1.21.11", m)
+	}
+	if fake.versions[name] != "1.21.11" || len(fake.completed) != 1 {
+		t.Errorf("version = %q, completed = %v, want the upload recorded and completed", fake.versions[name], fake.completed)
+	}
+	if _, err := backup.Verify(bytes.NewReader(fake.objects[name])); err != nil {
+		t.Errorf("uploaded archive invalid: %v", err)
+	}
+
+	archives, err := client.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(archives) != 1 || archives[0].Name != name {
+		t.Errorf("List() = %+v, want the pushed archive", archives)
+	}
+
+	// Pulling replaces the world as it is now with the pushed one
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("changed"), 0644)
+	os.MkdirAll(filepath.Join(dataDir, "world_nether"), 0755)
+	if _, err := client.Pull(dataDir, name); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dataDir, "world", "level.dat")); string(data) != "level" {
+		t.Errorf("level.dat = %q, want the pushed world", data)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "world_nether")); !os.IsNotExist(err) {
+		t.Error("world_nether kept, want only the pushed world")
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, restoreDir)); !os.IsNotExist(err) {
+		t.Errorf("%s left behind", restoreDir)
+	}
+}
+
+func TestArchiveClient_PullCorruptKeepsWorld(t *testing.T) {
+	fake := &fakeArchiveService{
+		objects:  map[string][]byte{"myserver-20260301-040000.tar.zst": []byte("not an archive")},
+		versions: map[string]string{},
+	}
+	client := newArchiveClient(t, fake)
+
+	dataDir := t.TempDir()
+	os.MkdirAll(filepath.Join(dataDir, "world"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("level"), 0644)
+
+	if _, err := client.Pull(dataDir, "myserver-20260301-040000.tar.zst"); err == nil {
+		t.Fatal("Pull() expected error for a corrupt archive, got nil")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dataDir, "world", "level.dat")); string(data) != "level" {
+		t.Errorf("level.dat = %q, want the current world kept", data)
+	}
+
+	if _, err := client.Pull(dataDir, "myserver-20260302-040000.tar.zst"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Pull() error = %v, want not found", err)
+	}
+}
+
+func TestArchiveClient_RejectedToken(t *testing.T) {
+	client := newArchiveClient(t, &fakeArchiveService{})
+	client.Token = "expired"
+
+	if _, err := client.List(); err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Errorf("List() error = %v, want the service's message", err)
+	}
+}
@@ -0,0 +1,380 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+const (
+	DefaultBackupDir = "/backups"
+	TerminationLog   = "/dev/termination-log" // Read back by kubecraft server backup list
+	IncrementalDir   = "incremental"          // In the backup directory, the incremental repository
+	restoreDir       = ".restore"             // In the data directory, the world being restored
+	maxReportSize    = 4096                   // Kubernetes cuts termination messages off here
+)
+
+// BackupConfig is the scheduled backup job's configuration, read from the env variables
+// set by ScheduleBackups
+type BackupConfig struct {
+	DataDir     string
+	BackupDir   string
+	Server      string
+	Version     string // Used when the data directory has no installed version yet
+	Keep        int
+	Incremental bool   // Store only changed chunks instead of full archives
+	Report      string // Where the remaining backups are listed once done, empty for nowhere
+}
+
+// BackupConfigFromEnv builds a BackupConfig from env variables
+func BackupConfigFromEnv(getenv func(string) string) (*BackupConfig, error) {
+	cfg := &BackupConfig{
+		DataDir:     getenv("DATA_DIR"),
+		BackupDir:   getenv("BACKUP_DIR"),
+		Server:      getenv("SERVER_NAME"),
+		Version:     getenv("VERSION"),
+		Incremental: getenv("BACKUP_INCREMENTAL") == "true",
+		Report:      TerminationLog,
+	}
+
+	if cfg.DataDir == "" {
+		cfg.DataDir = DefaultDataDir
+	}
+	if cfg.BackupDir == "" {
+		cfg.BackupDir = DefaultBackupDir
+	}
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("SERVER_NAME is required")
+	}
+
+	keep, err := strconv.Atoi(getenv("BACKUP_KEEP"))
+	if err != nil || keep < 1 {
+		return nil, fmt.Errorf("BACKUP_KEEP must be a positive number")
+	}
+	cfg.Keep = keep
+
+	return cfg, nil
+}
+
+// Backup archives the world into the backup directory as a snapshot taken at now, or
+// adds it to the incremental repository there, prunes snapshots beyond cfg.Keep and
+// reports the ones left
+func Backup(cfg *BackupConfig, now time.Time) error {
+	// Left behind by a job that was killed mid-way
+	partials, _ := filepath.Glob(filepath.Join(cfg.BackupDir, cfg.Server+"-*.partial"))
+	for _, p := range partials {
+		os.Remove(p)
+	}
+
+	if cfg.Incremental {
+		if err := backupIncremental(cfg, now); err != nil {
+			return err
+		}
+	} else {
+		if err := backupArchive(cfg, now); err != nil {
+			return err
+		}
+	}
+
+	kept, err := pruneBackups(cfg)
+	if err != nil {
+		return fmt.Errorf("pruning old backups: %w", err)
+	}
+	fmt.Printf("Keeping %d backups\n", len(kept))
+
+	report, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("encoding backup list: %w", err)
+	}
+
+	return writeReport(cfg, string(report))
+}
+
+func backupArchive(cfg *BackupConfig, now time.Time) error {
+	name := backup.SnapshotName(cfg.Server, now)
+	fmt.Printf("Backing up %s to %s\n", cfg.Server, name)
+
+	m, err := writeSnapshot(cfg, filepath.Join(cfg.BackupDir, name), backup.Manifest{
+		Server:           cfg.Server,
+		MinecraftVersion: installedVersion(cfg),
+		CreatedAt:        now.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %d files (%d bytes)\n", m.Files, m.Size)
+
+	return nil
+}
+
+func backupIncremental(cfg *BackupConfig, now time.Time) error {
+	repo, err := backup.OpenRepository(filepath.Join(cfg.BackupDir, IncrementalDir))
+	if err != nil {
+		return err
+	}
+
+	name := backup.IndexName(cfg.Server, now)
+	fmt.Printf("Backing up %s to %s\n", cfg.Server, name)
+
+	idx, err := repo.Snapshot(cfg.DataDir, backup.Index{
+		Server:           cfg.Server,
+		MinecraftVersion: installedVersion(cfg),
+		CreatedAt:        now.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	fmt.Printf("Backed up %d files (%d bytes), %d bytes of new chunks\n", idx.Files, idx.Size, idx.Added)
+
+	return nil
+}
+
+// pruneBackups deletes all but the newest cfg.Keep backups, counting archives and
+// incremental snapshots together, and returns the ones left oldest first. Backups of
+// the other kind are left from before the schedule changed, and the report of the kept
+// ones has to fit the termination message.
+func pruneBackups(cfg *BackupConfig) ([]backup.Snapshot, error) {
+	archives, err := backup.ListSnapshots(cfg.BackupDir, cfg.Server)
+	if err != nil {
+		return nil, err
+	}
+	incremental, err := listIncremental(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(slices.Clone(archives), incremental...)
+	slices.SortFunc(all, func(a, b backup.Snapshot) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	kept := all[max(len(all)-cfg.Keep, 0):]
+
+	// Both lists are oldest first, so each kind keeps its newest ones
+	keepArchives, keepIncremental := 0, 0
+	for _, s := range kept {
+		if strings.HasSuffix(s.Name, backup.ArchiveExt) {
+			keepArchives++
+		} else {
+			keepIncremental++
+		}
+	}
+	if _, err := backup.Prune(cfg.BackupDir, cfg.Server, keepArchives); err != nil {
+		return nil, err
+	}
+	if incremental != nil {
+		repo, err := backup.OpenRepository(filepath.Join(cfg.BackupDir, IncrementalDir))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := repo.Prune(cfg.Server, keepIncremental); err != nil {
+			return nil, err
+		}
+	}
+
+	return kept, nil
+}
+
+// listIncremental returns the server's incremental snapshots, if it ever had any
+func listIncremental(cfg *BackupConfig) ([]backup.Snapshot, error) {
+	dir := filepath.Join(cfg.BackupDir, IncrementalDir)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	repo, err := backup.OpenRepository(dir)
+	if err != nil {
+		return nil, err
+	}
+	return repo.List(cfg.Server)
+}
+
+// VerifyBackup checks the named backup, or all of the server's backups if name is
+// empty, and reports the result
+func VerifyBackup(cfg *BackupConfig, name string) error {
+	names := []string{name}
+	if name == "" {
+		archives, err := backup.ListSnapshots(cfg.BackupDir, cfg.Server)
+		if err != nil {
+			return err
+		}
+		incremental, err := listIncremental(cfg)
+		if err != nil {
+			return err
+		}
+		names = nil
+		for _, s := range append(archives, incremental...) {
+			names = append(names, s.Name)
+		}
+		if len(names) == 0 {
+			return writeReport(cfg, "No backups to verify")
+		}
+	}
+
+	var results []string
+	var failed int
+	for _, name := range names {
+		result, err := verifyOne(cfg, name)
+		if err != nil {
+			failed++
+			result = fmt.Sprintf("%s: %v", name, err)
+		}
+		fmt.Println(result)
+		results = append(results, result)
+	}
+
+	report := strings.Join(results, "\n")
+	if err := writeReport(cfg, report); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d backups failed verification", failed, len(names))
+	}
+
+	return nil
+}
+
+func verifyOne(cfg *BackupConfig, name string) (string, error) {
+	if strings.HasSuffix(name, backup.ArchiveExt) && !strings.ContainsAny(name, `/\`) {
+		f, err := os.Open(filepath.Join(cfg.BackupDir, name))
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		m, err := backup.Verify(f)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: OK, %d files", name, m.Files), nil
+	}
+
+	repo, err := backup.OpenRepository(filepath.Join(cfg.BackupDir, IncrementalDir))
+	if err != nil {
+		return "", err
+	}
+	chunks, err := repo.Verify(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s: OK, %d chunks", name, chunks), nil
+}
+
+// RestoreBackup replaces the world directories with the ones of an incremental
+// snapshot. The world is rebuilt next to the current one and only swapped in once
+// complete. The server must not be running.
+func RestoreBackup(cfg *BackupConfig, name string) error {
+	repo, err := backup.OpenRepository(filepath.Join(cfg.BackupDir, IncrementalDir))
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(cfg.DataDir, restoreDir)
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("clearing %s: %w", restoreDir, err)
+	}
+	defer os.RemoveAll(target)
+
+	fmt.Printf("Restoring %s\n", name)
+	idx, err := repo.Restore(name, target)
+	if err != nil {
+		return err
+	}
+
+	if err := swapWorld(cfg.DataDir, target); err != nil {
+		return err
+	}
+
+	return writeReport(cfg, fmt.Sprintf("Restored %d files of Minecraft %s from %s", idx.Files, idx.MinecraftVersion, name))
+}
+
+// swapWorld replaces the world directories under dataDir with the ones in target
+func swapWorld(dataDir string, target string) error {
+	current, err := filepath.Glob(filepath.Join(dataDir, "world*"))
+	if err != nil {
+		return err
+	}
+	for _, p := range current {
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("removing current world: %w", err)
+		}
+	}
+	restored, err := os.ReadDir(target)
+	if err != nil {
+		return err
+	}
+	for _, entry := range restored {
+		if err := os.Rename(filepath.Join(target, entry.Name()), filepath.Join(dataDir, entry.Name())); err != nil {
+			return fmt.Errorf("moving restored world into place: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeReport writes report to cfg.Report, cutting a report too long for the
+// termination message off with a note. The full report is in the job's logs.
+func writeReport(cfg *BackupConfig, report string) error {
+	if cfg.Report == "" {
+		return nil
+	}
+	if len(report) > maxReportSize {
+		const note = "\n... (truncated, see the job's logs)"
+		report = strings.ToValidUTF8(report[:maxReportSize-len(note)], "") + note
+	}
+	if err := os.WriteFile(cfg.Report, []byte(report), 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
+
+// writeSnapshot writes the archive next to target and renames it once complete
+func writeSnapshot(cfg *BackupConfig, target string, m backup.Manifest) (*backup.Manifest, error) {
+	partial := target + ".partial"
+	f, err := os.Create(partial)
+	if err != nil {
+		return nil, fmt.Errorf("creating backup file: %w", err)
+	}
+	defer os.Remove(partial)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(backup.TarWorld(cfg.DataDir, pw))
+	}()
+
+	manifest, err := backup.Write(f, pr, m)
+	pr.Close()
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing backup: %w", err)
+	}
+
+	if err := os.Rename(partial, target); err != nil {
+		return nil, fmt.Errorf("writing backup: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// installedVersion is the version the server last ran, which may be newer than the one
+// the job was scheduled with
+func installedVersion(cfg *BackupConfig) string {
+	installed, err := os.ReadFile(filepath.Join(cfg.DataDir, StateDir, serverVersionFile))
+	if err == nil && len(strings.TrimSpace(string(installed))) > 0 {
+		return strings.TrimSpace(string(installed))
+	}
+
+	return cfg.Version
+}
@@ -0,0 +1,202 @@
+package launcher
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/backup"
+)
+
+func TestBackupConfigFromEnv(t *testing.T) {
+	cfg, err := BackupConfigFromEnv(envFunc(map[string]string{"SERVER_NAME": "myserver", "BACKUP_KEEP": "7"}))
+	if err != nil {
+		t.Fatalf("BackupConfigFromEnv() error = %v", err)
+	}
+	if cfg.DataDir != DefaultDataDir || cfg.BackupDir != DefaultBackupDir || cfg.Keep != 7 {
+		t.Errorf("BackupConfigFromEnv() = %+v, want default dirs keeping 7", cfg)
+	}
+
+	for _, keep := range []string{"", "0", "many"} {
+		_, err := BackupConfigFromEnv(envFunc(map[string]string{"SERVER_NAME": "myserver", "BACKUP_KEEP": keep}))
+		if err == nil {
+			t.Errorf("BackupConfigFromEnv() expected error for BACKUP_KEEP=%q, got nil", keep)
+		}
+	}
+}
+
+func TestBackup_WritesPrunesAndReports(t *testing.T) {
+	dataDir, backupDir := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(dataDir, "world"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("level"), 0644)
+	os.MkdirAll(filepath.Join(dataDir, StateDir), 0755)
+	os.WriteFile(filepath.Join(dataDir, StateDir, serverVersionFile), []byte("1.21.11\n"), 0644)
+
+	cfg := &BackupConfig{
+		DataDir:   dataDir,
+		BackupDir: backupDir,
+		Server:    "myserver",
+		Version:   "1.21.4",
+		Keep:      2,
+		Report:    filepath.Join(t.TempDir(), "termination-log"),
+	}
+
+	start := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	for day := range 3 {
+		if err := Backup(cfg, start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	snapshots, err := backup.ListSnapshots(backupDir, "myserver")
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].CreatedAt.Equal(start.AddDate(0, 0, 1)) {
+		t.Fatalf("snapshots = %+v, want the newest 2", snapshots)
+	}
+
+	f, _ := os.Open(filepath.Join(backupDir, snapshots[1].Name))
+	defer f.Close()
+	m, err := backup.Verify(f)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if m.MinecraftVersion != "1.21.11" {
+		t.Errorf("manifest version = %q, want the installed 1.21.11", m.MinecraftVersion)
+	}
+
+	var reported []backup.Snapshot
+	data, _ := os.ReadFile(cfg.Report)
+	if err := json.Unmarshal(data, &reported); err != nil {
+		t.Fatalf("report is not a snapshot list: %v", err)
+	}
+	if len(reported) != 2 || reported[1].Name != snapshots[1].Name {
+		t.Errorf("reported = %+v, want %+v", reported, snapshots)
+	}
+}
+
+func TestBackup_NoWorldLeavesNothing(t *testing.T) {
+	backupDir := t.TempDir()
+	cfg := &BackupConfig{DataDir: t.TempDir(), BackupDir: backupDir, Server: "myserver", Keep: 1}
+
+	if err := Backup(cfg, time.Now()); err == nil {
+		t.Fatal("Backup() expected error without a world, got nil")
+	}
+
+	entries, _ := os.ReadDir(backupDir)
+	if len(entries) != 0 {
+		t.Errorf("backup directory has %d entries after a failed backup, want 0", len(entries))
+	}
+}
+
+func TestBackup_IncrementalPrunesBothKinds(t *testing.T) {
+	dataDir, backupDir := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(dataDir, "world"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("level"), 0644)
+
+	cfg := &BackupConfig{
+		DataDir:   dataDir,
+		BackupDir: backupDir,
+		Server:    "myserver",
+		Version:   "1.21.11",
+		Keep:      2,
+		Report:    filepath.Join(t.TempDir(), "termination-log"),
+	}
+
+	// One full archive from before the schedule switched to incremental
+	start := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	if err := Backup(cfg, start); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	cfg.Incremental = true
+	for day := 1; day <= 3; day++ {
+		if err := Backup(cfg, start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	var reported []backup.Snapshot
+	data, _ := os.ReadFile(cfg.Report)
+	if err := json.Unmarshal(data, &reported); err != nil {
+		t.Fatalf("report is not a snapshot list: %v", err)
+	}
+	// The archive counts towards keep like the incremental snapshots
+	want := []string{
+		backup.IndexName("myserver", start.AddDate(0, 0, 2)),
+		backup.IndexName("myserver", start.AddDate(0, 0, 3)),
+	}
+	var got []string
+	for _, s := range reported {
+		got = append(got, s.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("reported = %v, want %v", got, want)
+	}
+	if archives, _ := backup.ListSnapshots(backupDir, "myserver"); len(archives) != 0 {
+		t.Errorf("archives = %v, want the old archive pruned", archives)
+	}
+	if reported[1].Added != 0 {
+		t.Errorf("unchanged world added %d bytes, want 0", reported[1].Added)
+	}
+}
+
+func TestWriteReport_TruncatesLongReports(t *testing.T) {
+	cfg := &BackupConfig{Report: filepath.Join(t.TempDir(), "termination-log")}
+
+	if err := writeReport(cfg, strings.Repeat("myserver-20260301-040000.tar.zst: ok\n", 200)); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	data, _ := os.ReadFile(cfg.Report)
+	if len(data) > maxReportSize || !strings.HasSuffix(string(data), "(truncated, see the job's logs)") {
+		t.Errorf("report is %d bytes ending %q, want it cut off at %d with a note", len(data), data[len(data)-40:], maxReportSize)
+	}
+}
+
+func TestVerifyAndRestoreBackup(t *testing.T) {
+	dataDir, backupDir := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(dataDir, "world"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("level"), 0644)
+
+	cfg := &BackupConfig{
+		DataDir:     dataDir,
+		BackupDir:   backupDir,
+		Server:      "myserver",
+		Keep:        2,
+		Incremental: true,
+		Report:      filepath.Join(t.TempDir(), "termination-log"),
+	}
+	now := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	if err := Backup(cfg, now); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if err := VerifyBackup(cfg, ""); err != nil {
+		t.Fatalf("VerifyBackup() error = %v", err)
+	}
+	report, _ := os.ReadFile(cfg.Report)
+	if !strings.Contains(string(report), backup.IndexName("myserver", now)+": OK") {
+		t.Errorf("report = %q, want the snapshot verified", report)
+	}
+
+	// The world moves on, with a new dimension the snapshot didn't have
+	os.WriteFile(filepath.Join(dataDir, "world", "level.dat"), []byte("changed"), 0644)
+	os.MkdirAll(filepath.Join(dataDir, "world_the_end"), 0755)
+
+	if err := RestoreBackup(cfg, backup.IndexName("myserver", now)); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	level, _ := os.ReadFile(filepath.Join(dataDir, "world", "level.dat"))
+	if string(level) != "level" {
+		t.Errorf("level.dat = %q after restore, want the snapshot's", level)
+	}
+	for _, gone := range []string{"world_the_end", restoreDir} {
+		if _, err := os.Stat(filepath.Join(dataDir, gone)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after restore", gone)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GeyserAPIURL = "https://download.geysermc.org/v2"
+	PluginsDir   = "plugins"
+)
+
+// bedrockPlugins let Bedrock Edition players join: Geyser translates their protocol on
+// the UDP port and Floodgate lets them in without a Java account. With Floodgate next to
+// it, Geyser uses it for authentication on its own and keeps its default port.
+var bedrockPlugins = []struct {
+	Project string
+	Jar     string
+}{
+	{Project: "geyser", Jar: "Geyser-Spigot.jar"},
+	{Project: "floodgate", Jar: "floodgate-spigot.jar"},
+}
+
+// GeyserClient resolves and downloads GeyserMC's plugins from its download API
+type GeyserClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewGeyserClient returns a client for the public GeyserMC download API
+func NewGeyserClient() *GeyserClient {
+	return &GeyserClient{
+		BaseURL:    GeyserAPIURL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+type geyserBuild struct {
+	Version   string `json:"version"`
+	Build     int    `json:"build"`
+	Downloads map[string]struct {
+		Name   string `json:"name"`
+		SHA256 string `json:"sha256"`
+	} `json:"downloads"`
+}
+
+// PluginDownload is the resolved jar of a plugin build
+type PluginDownload struct {
+	Build  int
+	URL    string
+	SHA256 string
+}
+
+// ResolveLatest finds the latest Spigot build of a GeyserMC project. Geyser follows the
+// newest Bedrock release, so it is kept up to date rather than pinned.
+func (g *GeyserClient) ResolveLatest(project string) (*PluginDownload, error) {
+	url := fmt.Sprintf("%s/projects/%s/versions/latest/builds/latest", g.BaseURL, project)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s builds: %w", project, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geyser api returned status %d for %s", resp.StatusCode, project)
+	}
+
+	var build geyserBuild
+	if err := json.NewDecoder(resp.Body).Decode(&build); err != nil {
+		return nil, fmt.Errorf("failed to parse %s build: %w", project, err)
+	}
+	dl, ok := build.Downloads["spigot"]
+	if !ok {
+		return nil, fmt.Errorf("no spigot download for %s build %d", project, build.Build)
+	}
+
+	return &PluginDownload{
+		Build:  build.Build,
+		URL:    fmt.Sprintf("%s/projects/%s/versions/%s/builds/%d/downloads/spigot", g.BaseURL, project, build.Version, build.Build),
+		SHA256: dl.SHA256,
+	}, nil
+}
+
+// Download fetches the plugin jar to dest, verifying its checksum before moving it into place
+func (g *GeyserClient) Download(project string, dl *PluginDownload, dest string) error {
+	return downloadJar(g.HTTPClient, project, dl.URL, sha256Checksum(dl.SHA256), dest)
+}
+
+// InstallBedrockPlugins puts the latest Geyser and Floodgate into the plugins directory.
+// A plugin that cannot be updated, e.g. with the download API down, keeps the jar it
+// has so the server still starts.
+func InstallBedrockPlugins(cfg *Config, geyser *GeyserClient) error {
+	if err := os.MkdirAll(cfg.path(PluginsDir), 0755); err != nil {
+		return fmt.Errorf("creating plugins directory: %w", err)
+	}
+
+	for _, plugin := range bedrockPlugins {
+		jar := cfg.path(PluginsDir, plugin.Jar)
+		buildFile := cfg.path(StateDir, plugin.Project+"-build")
+
+		installed, err := os.ReadFile(buildFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("reading installed %s build: %w", plugin.Project, err)
+		}
+		_, statErr := os.Stat(jar)
+
+		dl, err := geyser.ResolveLatest(plugin.Project)
+		if err != nil {
+			if statErr == nil {
+				fmt.Printf("Keeping installed %s, could not check for updates: %s\n", plugin.Project, err)
+				continue
+			}
+			return err
+		}
+		if statErr == nil && strings.TrimSpace(string(installed)) == strconv.Itoa(dl.Build) {
+			continue
+		}
+
+		fmt.Printf("Downloading %s build %d\n", plugin.Project, dl.Build)
+		if err := geyser.Download(plugin.Project, dl, jar); err != nil {
+			return err
+		}
+		if err := os.WriteFile(buildFile, []byte(strconv.Itoa(dl.Build)+"\n"), 0644); err != nil {
+			return fmt.Errorf("recording %s build: %w", plugin.Project, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFakeGeyserAPI serves the latest build of geyser and floodgate, counting downloads
+func newFakeGeyserAPI(t *testing.T, build int, downloads *int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for _, project := range []string{"geyser", "floodgate"} {
+		jar := "fake " + project + " jar"
+		sum := sha256.Sum256([]byte(jar))
+		mux.HandleFunc(fmt.Sprintf("/projects/%s/versions/latest/builds/latest", project), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"project_id": %q, "version": "2.9.0", "build": %d, "downloads": {"spigot": {"name": "x.jar", "sha256": %q}}}`,
+				project, build, hex.EncodeToString(sum[:]))
+		})
+		mux.HandleFunc(fmt.Sprintf("/projects/%s/versions/2.9.0/builds/%d/downloads/spigot", project, build), func(w http.ResponseWriter, r *http.Request) {
+			*downloads++
+			fmt.Fprint(w, jar)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInstallBedrockPlugins(t *testing.T) {
+	downloads := 0
+	server := newFakeGeyserAPI(t, 700, &downloads)
+	geyser := &GeyserClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	cfg := &Config{DataDir: t.TempDir()}
+	os.MkdirAll(cfg.path(StateDir), 0755)
+
+	if err := InstallBedrockPlugins(cfg, geyser); err != nil {
+		t.Fatalf("InstallBedrockPlugins() error = %v", err)
+	}
+	for _, plugin := range bedrockPlugins {
+		data, err := os.ReadFile(filepath.Join(cfg.DataDir, PluginsDir, plugin.Jar))
+		if err != nil || string(data) != "fake "+plugin.Project+" jar" {
+			t.Errorf("%s = %q (err %v), want the downloaded jar", plugin.Jar, data, err)
+		}
+	}
+
+	// The same builds are not downloaded again on the next start
+	if err := InstallBedrockPlugins(cfg, geyser); err != nil {
+		t.Fatalf("second InstallBedrockPlugins() error = %v", err)
+	}
+	if downloads != 2 {
+		t.Errorf("downloads = %d, want 2", downloads)
+	}
+}
+
+func TestInstallBedrockPlugins_KeepsJarWhenAPIDown(t *testing.T) {
+	downloads := 0
+	server := newFakeGeyserAPI(t, 700, &downloads)
+	cfg := &Config{DataDir: t.TempDir()}
+	os.MkdirAll(cfg.path(StateDir), 0755)
+	if err := InstallBedrockPlugins(cfg, &GeyserClient{BaseURL: server.URL, HTTPClient: server.Client()}); err != nil {
+		t.Fatalf("InstallBedrockPlugins() error = %v", err)
+	}
+
+	down := &GeyserClient{BaseURL: server.URL + "/missing", HTTPClient: server.Client()}
+	if err := InstallBedrockPlugins(cfg, down); err != nil {
+		t.Errorf("InstallBedrockPlugins() error = %v, want the installed jars kept", err)
+	}
+
+	if err := InstallBedrockPlugins(&Config{DataDir: t.TempDir()}, down); err == nil {
+		t.Error("InstallBedrockPlugins() expected error without jars or API, got nil")
+	}
+}
@@ -0,0 +1,47 @@
+package launcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var memoryPattern = regexp.MustCompile(`^[1-9][0-9]*[MG]$`)
+
+// aikarFlags are the G1 tuning flags recommended for Paper servers
+var aikarFlags = []string{
+	"-XX:+UseG1GC",
+	"-XX:+ParallelRefProcEnabled",
+	"-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions",
+	"-XX:+DisableExplicitGC",
+	"-XX:+AlwaysPreTouch",
+	"-XX:G1NewSizePercent=30",
+	"-XX:G1MaxNewSizePercent=40",
+	"-XX:G1HeapRegionSize=8M",
+	"-XX:G1ReservePercent=20",
+	"-XX:G1HeapWastePercent=5",
+	"-XX:G1MixedGCCountTarget=4",
+	"-XX:InitiatingHeapOccupancyPercent=15",
+	"-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5",
+	"-XX:SurvivorRatio=32",
+	"-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1",
+}
+
+// JVMArgs builds the java command line (without the java binary itself), with launch
+// starting the server, e.g. -jar server.jar
+func JVMArgs(memory string, extra string, launch ...string) ([]string, error) {
+	if !memoryPattern.MatchString(memory) {
+		return nil, fmt.Errorf("invalid JAVA_MEMORY %q, expected a size such as 768M or 3G", memory)
+	}
+
+	args := []string{"-Xms" + memory, "-Xmx" + memory}
+	args = append(args, aikarFlags...)
+	args = append(args, strings.Fields(extra)...)
+	args = append(args, launch...)
+	args = append(args, "--nogui")
+
+	return args, nil
+}
@@ -0,0 +1,48 @@
+package launcher
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestJVMArgs_Memory(t *testing.T) {
+	args, err := JVMArgs("3G", "", "-jar", ServerJar)
+	if err != nil {
+		t.Fatalf("JVMArgs() error = %v", err)
+	}
+
+	if args[0] != "-Xms3G" || args[1] != "-Xmx3G" {
+		t.Errorf("JVMArgs() heap flags = %v, want [-Xms3G -Xmx3G]", args[:2])
+	}
+
+	tail := args[len(args)-3:]
+	if !slices.Equal(tail, []string{"-jar", ServerJar, "--nogui"}) {
+		t.Errorf("JVMArgs() tail = %v, want [-jar %s --nogui]", tail, ServerJar)
+	}
+}
+
+func TestJVMArgs_ExtraOptions(t *testing.T) {
+	args, err := JVMArgs("768M", "-Dfoo=bar  -XX:+UseStringDeduplication", "-jar", ServerJar)
+	if err != nil {
+		t.Fatalf("JVMArgs() error = %v", err)
+	}
+
+	if !slices.Contains(args, "-Dfoo=bar") || !slices.Contains(args, "-XX:+UseStringDeduplication") {
+		t.Errorf("JVMArgs() = %v, missing extra options", args)
+	}
+	if slices.Index(args, "-Dfoo=bar") > slices.Index(args, "-jar") {
+		t.Error("JVMArgs() placed extra options after -jar")
+	}
+}
+
+func TestJVMArgs_InvalidMemory(t *testing.T) {
+	invalid := []string{"", "3", "3GB", "0G", "-3G", "3g"}
+
+	for _, memory := range invalid {
+		t.Run(memory, func(t *testing.T) {
+			if _, err := JVMArgs(memory, "", "-jar", ServerJar); err == nil {
+				t.Errorf("JVMArgs(%q) expected error, got nil", memory)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+const (
+	DefaultDataDir = "/data"
+	DefaultMemory  = "768M"
+	StateDir       = ".kubecraft" // Launcher bookkeeping, kept on the PVC next to the world
+	ServerJar      = "server.jar"
+
+	serverVersionFile = "server-version" // In StateDir, the version the server was installed for
+	serverTypeFile    = "server-type"    // In StateDir, the type the server was installed as
+	serverBuildFile   = "server-build"   // In StateDir, the build or loader version that was installed
+	serverLaunchFile  = "server-launch"  // In StateDir, the java arguments that start the installed server, one per line
+)
+
+// Config is the launcher configuration, read from the env variables set by CreateServer
+type Config struct {
+	DataDir    string
+	Type       string
+	Version    string
+	Memory     string
+	JVMOpts    string
+	EULA       bool
+	Properties map[string]string
+	// LoaderVersion pins the Fabric, Forge or NeoForge version, as modpacks do. Empty
+	// installs the newest stable one.
+	LoaderVersion string
+	// ProxyProtocol is set for servers behind the gateway, which sends the player's
+	// address in a PROXY protocol header
+	ProxyProtocol bool
+	// Bedrock installs Geyser and Floodgate so Bedrock Edition players can join
+	Bedrock bool
+	// PluginLock is the lockfile of the plugins to install, mounted from the server's
+	// ConfigMap
+	PluginLock string
+}
+
+// ConfigFromEnv builds a Config from env variables
+func ConfigFromEnv(getenv func(string) string) (*Config, error) {
+	cfg := &Config{
+		DataDir:    getenv("DATA_DIR"),
+		Type:       getenv("SERVER_TYPE"),
+		Version:    getenv("VERSION"),
+		Memory:     getenv("JAVA_MEMORY"),
+		JVMOpts:    getenv("JVM_OPTS"),
+		EULA:       strings.EqualFold(getenv("EULA"), "true"),
+		Properties: PropertiesFromEnv(getenv),
+
+		LoaderVersion: getenv("LOADER_VERSION"),
+		ProxyProtocol: getenv("PROXY_PROTOCOL") == "true",
+		Bedrock:       getenv("BEDROCK") == "true",
+		PluginLock:    getenv("PLUGIN_LOCK"),
+	}
+
+	if cfg.DataDir == "" {
+		cfg.DataDir = DefaultDataDir
+	}
+	if cfg.Memory == "" {
+		cfg.Memory = DefaultMemory
+	}
+	if cfg.PluginLock == "" {
+		cfg.PluginLock = DefaultPluginLock
+	}
+	if cfg.Type == "" {
+		cfg.Type = TypePaper
+	}
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("VERSION is required")
+	}
+	if !slices.Contains(ServerTypes, cfg.Type) {
+		return nil, fmt.Errorf("unknown SERVER_TYPE %q, expected one of: %s", cfg.Type, strings.Join(ServerTypes, ", "))
+	}
+	if (cfg.ProxyProtocol || cfg.Bedrock) && !PaperBased(cfg.Type) {
+		return nil, fmt.Errorf("PROXY_PROTOCOL and BEDROCK need a paper or purpur server, not %s", cfg.Type)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) path(elem ...string) string {
+	return filepath.Join(append([]string{c.DataDir}, elem...)...)
+}
+
+// Prepare gets the data directory ready to start the server: server of the configured
+// type, eula, properties and the Paper settings the gateway needs
+func Prepare(cfg *Config, installer *Installer) error {
+	if !cfg.EULA {
+		return fmt.Errorf("the Minecraft EULA must be accepted by setting EULA=TRUE")
+	}
+
+	if err := os.MkdirAll(cfg.path(StateDir), 0755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	if err := ensureServer(cfg, installer); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(cfg.path("eula.txt"), []byte("eula=true\n"), 0644); err != nil {
+		return fmt.Errorf("writing eula.txt: %w", err)
+	}
+
+	err := MergeProperties(cfg.path("server.properties"), cfg.path(StateDir, "properties.json"), cfg.Properties)
+	if err != nil {
+		return fmt.Errorf("merging server.properties: %w", err)
+	}
+
+	if cfg.ProxyProtocol {
+		if err := EnableProxyProtocol(cfg.path(PaperGlobalConfig)); err != nil {
+			return fmt.Errorf("enabling proxy protocol: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ensureServer installs the server when it is missing or was installed for another
+// version, type or pinned loader version. The version is recorded last, so an interrupted install is redone.
+func ensureServer(cfg *Config, installer *Installer) error {
+	installedVersion, err := readState(cfg, serverVersionFile)
+	if err != nil {
+		return fmt.Errorf("reading installed version: %w", err)
+	}
+	installedType, err := readState(cfg, serverTypeFile)
+	if err != nil {
+		return fmt.Errorf("reading installed type: %w", err)
+	}
+	// Servers installed before server types ran Paper
+	if installedType == "" {
+		installedType = TypePaper
+	}
+	installedBuild, err := readState(cfg, serverBuildFile)
+	if err != nil {
+		return fmt.Errorf("reading installed build: %w", err)
+	}
+	launch, err := launchArgs(cfg)
+	if err != nil {
+		return err
+	}
+
+	installed := fileExists(cfg.path(launchTarget(launch)))
+	pinned := cfg.LoaderVersion == "" || cfg.LoaderVersion == installedBuild
+	if installed && installedVersion == cfg.Version && installedType == cfg.Type && pinned {
+		return nil
+	}
+
+	// A jar without a version record predates the launcher, keep it rather than re-downloading
+	if installed && installedVersion == "" && cfg.Type == TypePaper {
+		return writeState(cfg, serverVersionFile, cfg.Version)
+	}
+
+	fmt.Printf("Downloading server (type: %s, version: %s)\n", cfg.Type, cfg.Version)
+	server, err := installer.install(cfg)
+	if err != nil {
+		return err
+	}
+	if server.Build != "" {
+		fmt.Printf("Download completed (type: %s, version: %s, build: %s)\n", cfg.Type, cfg.Version, server.Build)
+	} else {
+		fmt.Printf("Download completed (type: %s, version: %s)\n", cfg.Type, cfg.Version)
+	}
+
+	if err := writeState(cfg, serverLaunchFile, strings.Join(server.Launch, "\n")); err != nil {
+		return err
+	}
+	if err := writeState(cfg, serverTypeFile, cfg.Type); err != nil {
+		return err
+	}
+	if err := writeState(cfg, serverBuildFile, server.Build); err != nil {
+		return err
+	}
+
+	return writeState(cfg, serverVersionFile, cfg.Version)
+}
+
+// launchArgs are the java arguments that start the installed server, after the JVM
+// options. Servers installed before they were recorded run the Paper jar.
+func launchArgs(cfg *Config) ([]string, error) {
+	launch, err := readState(cfg, serverLaunchFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading server launch arguments: %w", err)
+	}
+	if launch == "" {
+		return jarLaunch(ServerJar), nil
+	}
+
+	return strings.Split(launch, "\n"), nil
+}
+
+// launchTarget is the file the launch arguments start, a jar or a java argument file
+func launchTarget(launch []string) string {
+	for i, arg := range launch {
+		if strings.HasPrefix(arg, "@") {
+			return strings.TrimPrefix(arg, "@")
+		}
+		if arg == "-jar" && i+1 < len(launch) {
+			return launch[i+1]
+		}
+	}
+
+	return ""
+}
+
+func readState(cfg *Config, name string) (string, error) {
+	data, err := os.ReadFile(cfg.path(StateDir, name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func writeState(cfg *Config, name string, value string) error {
+	return os.WriteFile(cfg.path(StateDir, name), []byte(value+"\n"), 0644)
+}
+
+// Exec replaces the launcher process with the Java server so it receives signals directly
+func Exec(cfg *Config) error {
+	java, err := exec.LookPath("java")
+	if err != nil {
+		return fmt.Errorf("java not found: %w", err)
+	}
+
+	launch, err := launchArgs(cfg)
+	if err != nil {
+		return err
+	}
+
+	args, err := JVMArgs(cfg.Memory, cfg.JVMOpts, launch...)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chdir(cfg.DataDir); err != nil {
+		return fmt.Errorf("changing to data directory: %w", err)
+	}
+
+	fmt.Println("Starting Minecraft server...")
+	fmt.Printf("Memory: %s\n", cfg.Memory)
+	fmt.Printf("Type: %s\n", cfg.Type)
+	fmt.Printf("Version: %s\n", cfg.Version)
+
+	return syscall.Exec(java, append([]string{"java"}, args...), os.Environ())
+}
@@ -0,0 +1,178 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConfigFromEnv_Defaults(t *testing.T) {
+	cfg, err := ConfigFromEnv(envFunc(map[string]string{"VERSION": "1.21.11", "EULA": "TRUE"}))
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+
+	if cfg.DataDir != DefaultDataDir {
+		t.Errorf("DataDir = %q, want %q", cfg.DataDir, DefaultDataDir)
+	}
+	if cfg.Memory != DefaultMemory {
+		t.Errorf("Memory = %q, want %q", cfg.Memory, DefaultMemory)
+	}
+	if !cfg.EULA {
+		t.Error("EULA = false, want true")
+	}
+}
+
+func TestConfigFromEnv_RequiresVersion(t *testing.T) {
+	if _, err := ConfigFromEnv(envFunc(map[string]string{})); err == nil {
+		t.Error("ConfigFromEnv() expected error without VERSION, got nil")
+	}
+}
+
+func TestPrepare_RequiresEULA(t *testing.T) {
+	cfg := &Config{DataDir: t.TempDir(), Version: "1.21.11"}
+
+	if err := Prepare(cfg, nil); err == nil {
+		t.Error("Prepare() expected error without EULA, got nil")
+	}
+}
+
+func TestPrepare_DownloadsJarAndWritesFiles(t *testing.T) {
+	server := newFakePaperAPI(t, stableBuilds)
+	paper := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	cfg := &Config{
+		DataDir:    t.TempDir(),
+		Type:       TypePaper,
+		Version:    "1.21.11",
+		EULA:       true,
+		Properties: map[string]string{"gamemode": "creative"},
+	}
+
+	if err := Prepare(cfg, &Installer{Paper: paper}); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	jar, err := os.ReadFile(filepath.Join(cfg.DataDir, ServerJar))
+	if err != nil || string(jar) != fakeJar {
+		t.Errorf("server.jar = %q (err %v), want %q", string(jar), err, fakeJar)
+	}
+
+	eula, err := os.ReadFile(filepath.Join(cfg.DataDir, "eula.txt"))
+	if err != nil || strings.TrimSpace(string(eula)) != "eula=true" {
+		t.Errorf("eula.txt = %q (err %v), want eula=true", string(eula), err)
+	}
+
+	props := readProperties(t, filepath.Join(cfg.DataDir, "server.properties"))
+	if props["gamemode"] != "creative" {
+		t.Errorf("gamemode = %q, want %q", props["gamemode"], "creative")
+	}
+}
+
+func TestPrepare_KeepsJarForSameVersion(t *testing.T) {
+	cfg := &Config{DataDir: t.TempDir(), Type: TypePaper, Version: "1.21.11", EULA: true}
+
+	// A nil Installer would panic if a download were attempted
+	os.MkdirAll(filepath.Join(cfg.DataDir, StateDir), 0755)
+	os.WriteFile(filepath.Join(cfg.DataDir, ServerJar), []byte("existing"), 0644)
+	os.WriteFile(filepath.Join(cfg.DataDir, StateDir, "server-version"), []byte("1.21.11\n"), 0644)
+
+	if err := Prepare(cfg, nil); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	jar, _ := os.ReadFile(filepath.Join(cfg.DataDir, ServerJar))
+	if string(jar) != "existing" {
+		t.Errorf("server.jar = %q, want existing jar to be kept", string(jar))
+	}
+}
+
+func TestPrepare_RedownloadsOnVersionChange(t *testing.T) {
+	server := newFakePaperAPI(t, stableBuilds)
+	paper := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	cfg := &Config{DataDir: t.TempDir(), Type: TypePaper, Version: "1.21.11", EULA: true}
+
+	os.MkdirAll(filepath.Join(cfg.DataDir, StateDir), 0755)
+	os.WriteFile(filepath.Join(cfg.DataDir, ServerJar), []byte("old"), 0644)
+	os.WriteFile(filepath.Join(cfg.DataDir, StateDir, "server-version"), []byte("1.21.4\n"), 0644)
+
+	if err := Prepare(cfg, &Installer{Paper: paper}); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	jar, _ := os.ReadFile(filepath.Join(cfg.DataDir, ServerJar))
+	if string(jar) != fakeJar {
+		t.Errorf("server.jar = %q, want re-downloaded jar", string(jar))
+	}
+}
+
+func TestPrepare_ReinstallsOnTypeChange(t *testing.T) {
+	installer := newFakeInstaller(t)
+	cfg := &Config{DataDir: t.TempDir(), Type: TypeFabric, Version: "1.21.11", EULA: true}
+
+	// A Paper server of the same version
+	os.MkdirAll(filepath.Join(cfg.DataDir, StateDir), 0755)
+	os.WriteFile(filepath.Join(cfg.DataDir, ServerJar), []byte("paper"), 0644)
+	os.WriteFile(filepath.Join(cfg.DataDir, StateDir, "server-version"), []byte("1.21.11\n"), 0644)
+
+	if err := Prepare(cfg, installer); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	launch, err := launchArgs(cfg)
+	if err != nil || !slices.Equal(launch, []string{"-jar", FabricServerJar}) {
+		t.Errorf("launchArgs() = %v (err %v), want [-jar %s]", launch, err, FabricServerJar)
+	}
+	installedType, _ := os.ReadFile(filepath.Join(cfg.DataDir, StateDir, "server-type"))
+	if strings.TrimSpace(string(installedType)) != TypeFabric {
+		t.Errorf("server-type = %q, want %q", string(installedType), TypeFabric)
+	}
+
+	// Installed, so a restart keeps it
+	if err := Prepare(cfg, nil); err != nil {
+		t.Fatalf("Prepare() after install error = %v", err)
+	}
+}
+
+func TestPrepare_ReinstallsOnLoaderPin(t *testing.T) {
+	installer := newFakeInstaller(t)
+	cfg := &Config{DataDir: t.TempDir(), Type: TypeFabric, Version: "1.21.11", EULA: true}
+	if err := Prepare(cfg, installer); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	// A modpack pins an older loader than the newest stable one installed
+	cfg.LoaderVersion = "0.16.9"
+	if err := Prepare(cfg, installer); err != nil {
+		t.Fatalf("Prepare() with a pinned loader error = %v", err)
+	}
+
+	launcher, _ := os.ReadFile(filepath.Join(cfg.DataDir, FabricServerJar))
+	if string(launcher) != "fake fabric launcher 0.16.9" {
+		t.Errorf("%s = %q, want the pinned loader", FabricServerJar, string(launcher))
+	}
+
+	// Installed, so a restart keeps it
+	if err := Prepare(cfg, nil); err != nil {
+		t.Fatalf("Prepare() after install error = %v", err)
+	}
+}
+
+func TestConfigFromEnv_ServerType(t *testing.T) {
+	cfg, err := ConfigFromEnv(envFunc(map[string]string{"VERSION": "1.21.11"}))
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	if cfg.Type != TypePaper {
+		t.Errorf("Type = %q, want %q by default", cfg.Type, TypePaper)
+	}
+
+	if _, err := ConfigFromEnv(envFunc(map[string]string{"VERSION": "1.21.11", "SERVER_TYPE": "spigot"})); err == nil {
+		t.Error("ConfigFromEnv() expected error for an unknown type, got nil")
+	}
+	if _, err := ConfigFromEnv(envFunc(map[string]string{"VERSION": "1.21.11", "SERVER_TYPE": "fabric", "BEDROCK": "true"})); err == nil {
+		t.Error("ConfigFromEnv() expected error for Bedrock on fabric, got nil")
+	}
+}
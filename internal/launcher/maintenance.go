@@ -0,0 +1,37 @@
+package launcher
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// MaintenanceAddr is the Minecraft port, which the readiness probe checks
+const MaintenanceAddr = ":25565"
+
+// Maintenance keeps the container running without starting the server, so the world
+// can be copied or replaced while nothing writes to it. Connections to addr are accepted
+// and closed right away: the pod has to pass its readiness probe, or the StatefulSet
+// would never roll it back to a normal pod. Maintenance returns once stop receives.
+func Maintenance(addr string, stop <-chan os.Signal) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	defer listener.Close()
+
+	fmt.Println("Maintenance mode, the server is not started")
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	<-stop
+	return nil
+}
@@ -0,0 +1,47 @@
+package launcher
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaintenance_AnswersProbeUntilStopped(t *testing.T) {
+	// Find a free port for the listener
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+
+	stop := make(chan os.Signal)
+	done := make(chan error)
+	go func() {
+		done <- Maintenance(addr, stop)
+	}()
+
+	// Like the readiness probe, a TCP connect must succeed
+	var conn net.Conn
+	for range 50 {
+		conn, err = net.Dial("tcp", addr)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("could not connect to maintenance listener: %v", err)
+	}
+	conn.Close()
+
+	stop <- os.Interrupt
+	if err := <-done; err != nil {
+		t.Fatalf("Maintenance() error = %v", err)
+	}
+
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("listener still open after Maintenance() returned")
+	}
+}
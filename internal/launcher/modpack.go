@@ -0,0 +1,166 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/modpack"
+)
+
+const (
+	ModpackFile = "modpack.mrpack" // In StateDir, uploaded by kubecraft server create --modpack and modpack update
+
+	modpackInstalledFile = "modpack.json" // In StateDir, the pack the mods were installed from
+)
+
+// InstallModpack installs the modpack uploaded to StateDir, if there is one. A new pack
+// has its server files downloaded, files the previous pack had and it dropped removed,
+// and its overrides written over the data directory. The same pack only gets files
+// back that went missing, so config edits survive restarts.
+func InstallModpack(cfg *Config, client *http.Client) error {
+	archive := cfg.path(StateDir, ModpackFile)
+	if !fileExists(archive) {
+		return nil
+	}
+
+	sum, err := fileSHA256(archive)
+	if err != nil {
+		return fmt.Errorf("reading modpack: %w", err)
+	}
+	installed, err := readInstalledModpack(cfg)
+	if err != nil {
+		return err
+	}
+
+	pack, err := modpack.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer pack.Close()
+
+	files := pack.ServerFiles()
+	for _, f := range files {
+		if reservedPath(f.Path) {
+			return fmt.Errorf("modpack file %s would overwrite launcher state", f.Path)
+		}
+	}
+
+	newPack := installed.SHA256 != sum
+	download := map[string]bool{}
+	if newPack {
+		fmt.Printf("Installing modpack %s\n", pack.Title())
+		changes := modpack.Diff(installed.Files, files)
+		for _, p := range changes.Removed {
+			if err := os.Remove(cfg.path(filepath.FromSlash(p))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("removing %s: %w", p, err)
+			}
+			fmt.Printf("Removed %s, the new pack dropped it\n", p)
+		}
+		for _, p := range append(changes.Added, changes.Updated...) {
+			download[p] = true
+		}
+	}
+
+	for _, f := range files {
+		dest := cfg.path(filepath.FromSlash(f.Path))
+		if !download[f.Path] && fileExists(dest) {
+			continue
+		}
+		if err := downloadModpackFile(client, f, dest); err != nil {
+			return err
+		}
+	}
+
+	if newPack {
+		overrides := 0
+		for name, f := range pack.Overrides() {
+			if reservedPath(name) {
+				continue
+			}
+			if err := modpack.Extract(f, cfg.path(filepath.FromSlash(name))); err != nil {
+				return fmt.Errorf("extracting override %s: %w", name, err)
+			}
+			overrides++
+		}
+		fmt.Printf("Modpack installed (%d files, %d overrides)\n", len(files), overrides)
+	}
+
+	return writeInstalledModpack(cfg, modpack.Installed{SHA256: sum, Title: pack.Title(), Files: files})
+}
+
+// reservedPath reports whether a pack path points into StateDir
+func reservedPath(p string) bool {
+	return p == StateDir || strings.HasPrefix(p, StateDir+"/")
+}
+
+// downloadModpackFile downloads f from the first of its mirrors that works, verifying
+// its SHA-512
+func downloadModpackFile(client *http.Client, f modpack.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	var err error
+	for _, url := range f.Downloads {
+		if !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		err = downloadJar(client, path.Base(f.Path), url, checksum{newHash: sha512.New, sum: f.SHA512()}, dest)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("downloading %s: %w", f.Path, err)
+}
+
+func readInstalledModpack(cfg *Config) (modpack.Installed, error) {
+	var installed modpack.Installed
+	data, err := os.ReadFile(cfg.path(StateDir, modpackInstalledFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return installed, nil
+	}
+	if err != nil {
+		return installed, fmt.Errorf("reading installed modpack: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &installed); err != nil {
+		return installed, fmt.Errorf("reading installed modpack: %w", err)
+	}
+
+	return installed, nil
+}
+
+func writeInstalledModpack(cfg *Config, installed modpack.Installed) error {
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cfg.path(StateDir, modpackInstalledFile), data, 0644)
+}
+
+func fileSHA256(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"archive/zip"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeMods are served by newFakeModrinth, by file name
+var fakeMods = map[string]string{
+	"lithium.jar":    "lithium 1",
+	"lithium-2.jar":  "lithium 2",
+	"fabric-api.jar": "fabric api",
+	"sodium.jar":     "sodium",
+}
+
+// newFakeModrinth serves fakeMods over TLS, as packs only list https downloads
+func newFakeModrinth(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := fakeMods[filepath.Base(r.URL.Path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// modFile is an index entry for a fakeMods download, saved at p
+func modFile(serverURL string, p string, name string, server string) map[string]any {
+	sum := sha512.Sum512([]byte(fakeMods[name]))
+	return map[string]any{
+		"path":      p,
+		"hashes":    map[string]string{"sha512": hex.EncodeToString(sum[:])},
+		"env":       map[string]string{"client": "required", "server": server},
+		"downloads": []string{serverURL + "/data/" + name},
+	}
+}
+
+// writeModpack uploads a pack to the data directory as the CLI does
+func writeModpack(t *testing.T, cfg *Config, files []map[string]any, entries map[string]string) {
+	t.Helper()
+
+	os.MkdirAll(cfg.path(StateDir), 0755)
+	file, err := os.Create(cfg.path(StateDir, ModpackFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+
+	w, _ := zw.Create("modrinth.index.json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"formatVersion": 1,
+		"game":          "minecraft",
+		"versionId":     fmt.Sprint(len(files)),
+		"name":          "Test Pack",
+		"files":         files,
+		"dependencies":  map[string]string{"minecraft": "1.21.1", "fabric-loader": "0.16.9"},
+	})
+	for name, content := range entries {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+}
+
+func readDataFile(t *testing.T, cfg *Config, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(cfg.path(filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestInstallModpack(t *testing.T) {
+	server := newFakeModrinth(t)
+	cfg := &Config{DataDir: t.TempDir()}
+
+	writeModpack(t, cfg, []map[string]any{
+		modFile(server.URL, "mods/lithium.jar", "lithium.jar", "required"),
+		modFile(server.URL, "mods/fabric-api.jar", "fabric-api.jar", "optional"),
+		modFile(server.URL, "mods/sodium.jar", "sodium.jar", "unsupported"),
+	}, map[string]string{
+		"overrides/config/lithium.properties":        "client",
+		"server-overrides/config/lithium.properties": "server",
+	})
+
+	if err := InstallModpack(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallModpack() error = %v", err)
+	}
+
+	if got := readDataFile(t, cfg, "mods/lithium.jar"); got != "lithium 1" {
+		t.Errorf("mods/lithium.jar = %q, want lithium 1", got)
+	}
+	if got := readDataFile(t, cfg, "mods/fabric-api.jar"); got != "fabric api" {
+		t.Errorf("mods/fabric-api.jar = %q, want fabric api", got)
+	}
+	if _, err := os.Stat(cfg.path("mods", "sodium.jar")); !os.IsNotExist(err) {
+		t.Error("client-only sodium.jar was installed on the server")
+	}
+	if got := readDataFile(t, cfg, "config/lithium.properties"); got != "server" {
+		t.Errorf("config/lithium.properties = %q, want the server override", got)
+	}
+}
+
+func TestInstallModpack_SamePackKeepsEdits(t *testing.T) {
+	server := newFakeModrinth(t)
+	cfg := &Config{DataDir: t.TempDir()}
+	writeModpack(t, cfg, []map[string]any{
+		modFile(server.URL, "mods/lithium.jar", "lithium.jar", "required"),
+	}, map[string]string{"overrides/config/lithium.properties": "pack"})
+
+	if err := InstallModpack(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallModpack() error = %v", err)
+	}
+
+	os.WriteFile(cfg.path("config", "lithium.properties"), []byte("edited"), 0644)
+	os.Remove(cfg.path("mods", "lithium.jar"))
+
+	if err := InstallModpack(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallModpack() on restart error = %v", err)
+	}
+	if got := readDataFile(t, cfg, "config/lithium.properties"); got != "edited" {
+		t.Errorf("config/lithium.properties = %q, want the edit kept", got)
+	}
+	if got := readDataFile(t, cfg, "mods/lithium.jar"); got != "lithium 1" {
+		t.Errorf("mods/lithium.jar = %q, want the missing mod downloaded again", got)
+	}
+}
+
+func TestInstallModpack_UpdateRemovesDroppedMods(t *testing.T) {
+	server := newFakeModrinth(t)
+	cfg := &Config{DataDir: t.TempDir()}
+	writeModpack(t, cfg, []map[string]any{
+		modFile(server.URL, "mods/lithium.jar", "lithium.jar", "required"),
+		modFile(server.URL, "mods/fabric-api.jar", "fabric-api.jar", "required"),
+	}, nil)
+	if err := InstallModpack(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallModpack() error = %v", err)
+	}
+
+	// A mod the players added themselves is not the pack's to remove
+	os.WriteFile(cfg.path("mods", "own.jar"), []byte("own"), 0644)
+
+	writeModpack(t, cfg, []map[string]any{
+		modFile(server.URL, "mods/lithium.jar", "lithium-2.jar", "required"),
+	}, nil)
+	if err := InstallModpack(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallModpack() update error = %v", err)
+	}
+
+	if got := readDataFile(t, cfg, "mods/lithium.jar"); got != "lithium 2" {
+		t.Errorf("mods/lithium.jar = %q, want the updated mod", got)
+	}
+	if _, err := os.Stat(cfg.path("mods", "fabric-api.jar")); !os.IsNotExist(err) {
+		t.Error("fabric-api.jar was kept after the pack dropped it")
+	}
+	if got := readDataFile(t, cfg, "mods/own.jar"); got != "own" {
+		t.Error("a mod outside the pack was removed")
+	}
+
+	installed, err := readInstalledModpack(cfg)
+	if err != nil || len(installed.Files) != 1 {
+		t.Errorf("installed modpack = %+v (err %v), want one file", installed, err)
+	}
+}
+
+func TestInstallModpack_ChecksumMismatch(t *testing.T) {
+	server := newFakeModrinth(t)
+	cfg := &Config{DataDir: t.TempDir()}
+
+	file := modFile(server.URL, "mods/lithium.jar", "lithium.jar", "required")
+	file["hashes"] = map[string]string{"sha512": strings.Repeat("0", 128)}
+	writeModpack(t, cfg, []map[string]any{file}, nil)
+
+	if err := InstallModpack(cfg, server.Client()); err == nil {
+		t.Fatal("InstallModpack() expected checksum error, got nil")
+	}
+	if _, err := os.Stat(cfg.path("mods", "lithium.jar")); !os.IsNotExist(err) {
+		t.Error("lithium.jar was installed despite the checksum mismatch")
+	}
+	if _, err := os.Stat(cfg.path(StateDir, "modpack.json")); !os.IsNotExist(err) {
+		t.Error("pack recorded as installed after a failed download")
+	}
+}
+
+func TestInstallModpack_KeepsOutOfState(t *testing.T) {
+	server := newFakeModrinth(t)
+	cfg := &Config{DataDir: t.TempDir()}
+	writeModpack(t, cfg, []map[string]any{
+		modFile(server.URL, ".kubecraft/server-launch", "lithium.jar", "required"),
+	}, nil)
+
+	if err := InstallModpack(cfg, server.Client()); err == nil {
+		t.Error("InstallModpack() expected error for a file in the launcher state, got nil")
+	}
+}
+
+func TestInstallModpack_NoPack(t *testing.T) {
+	if err := InstallModpack(&Config{DataDir: t.TempDir()}, nil); err != nil {
+		t.Errorf("InstallModpack() without a pack error = %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	PaperAPIURL = "https://fill.papermc.io/v3"
+	UserAgent   = "kubecraft/1.0.0 (baig.hasan@outlook.com)"
+)
+
+// PaperClient resolves and downloads Paper server jars from the Fill API
+type PaperClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewPaperClient returns a client for the public Fill API
+func NewPaperClient() *PaperClient {
+	return &PaperClient{
+		BaseURL:    PaperAPIURL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+type paperBuild struct {
+	ID        int    `json:"id"`
+	Channel   string `json:"channel"`
+	Downloads map[string]struct {
+		Name      string `json:"name"`
+		URL       string `json:"url"`
+		Checksums struct {
+			SHA256 string `json:"sha256"`
+		} `json:"checksums"`
+	} `json:"downloads"`
+}
+
+type paperError struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// PaperDownload is the resolved jar for a Minecraft version
+type PaperDownload struct {
+	Build  int
+	URL    string
+	SHA256 string
+}
+
+// ResolveStable finds the newest stable Paper build for a Minecraft version
+func (p *PaperClient) ResolveStable(version string) (*PaperDownload, error) {
+	url := fmt.Sprintf("%s/projects/paper/versions/%s/builds", p.BaseURL, version)
+	body, status, err := p.get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paper builds: %w", err)
+	}
+
+	if status != http.StatusOK {
+		var apiErr paperError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("paper api error: %s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("paper api returned status %d", status)
+	}
+
+	var builds []paperBuild
+	if err := json.Unmarshal(body, &builds); err != nil {
+		return nil, fmt.Errorf("failed to parse paper builds: %w", err)
+	}
+
+	// Builds are listed newest first
+	for _, build := range builds {
+		if build.Channel != "STABLE" {
+			continue
+		}
+		dl, ok := build.Downloads["server:default"]
+		if !ok || dl.URL == "" {
+			continue
+		}
+
+		return &PaperDownload{
+			Build:  build.ID,
+			URL:    dl.URL,
+			SHA256: dl.Checksums.SHA256,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no stable paper build for version %s", version)
+}
+
+// Download fetches the jar to dest, verifying its checksum before moving it into place
+func (p *PaperClient) Download(dl *PaperDownload, dest string) error {
+	return downloadJar(p.HTTPClient, "paper", dl.URL, sha256Checksum(dl.SHA256), dest)
+}
+
+// checksum is the digest a download must have, the zero value skips the check
+type checksum struct {
+	newHash func() hash.Hash
+	sum     string
+}
+
+func sha256Checksum(sum string) checksum {
+	return checksum{newHash: sha256.New, sum: sum}
+}
+
+// downloadJar fetches the jar of project at url to dest, verifying its checksum when
+// one is known before moving it into place
+func downloadJar(client *http.Client, project string, url string, want checksum, dest string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", project, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s download returned status %d", project, resp.StatusCode)
+	}
+
+	tmp := dest + ".part"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	var digest hash.Hash
+	out := io.Writer(file)
+	if want.newHash != nil && want.sum != "" {
+		digest = want.newHash()
+		out = io.MultiWriter(file, digest)
+	}
+	_, err = io.Copy(out, resp.Body)
+	closeErr := file.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s jar: %w", project, err)
+	}
+	if closeErr != nil {
+		os.Remove(tmp)
+		return closeErr
+	}
+
+	if digest != nil {
+		sum := hex.EncodeToString(digest.Sum(nil))
+		if !strings.EqualFold(sum, want.sum) {
+			os.Remove(tmp)
+			return fmt.Errorf("%s jar checksum mismatch: got %s, want %s", project, sum, want.sum)
+		}
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+func (p *PaperClient) get(url string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,121 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const fakeJar = "fake paper jar"
+
+// newFakePaperAPI serves a builds listing and a jar download
+func newFakePaperAPI(t *testing.T, buildsJSON func(serverURL string) string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/projects/paper/versions/1.21.11/builds", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != UserAgent {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), UserAgent)
+		}
+		fmt.Fprint(w, buildsJSON(server.URL))
+	})
+	mux.HandleFunc("/projects/paper/versions/9.9.9/builds", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"ok":false,"message":"Version not found"}`)
+	})
+	mux.HandleFunc("/download/paper.jar", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fakeJar)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func fakeJarSHA256() string {
+	sum := sha256.Sum256([]byte(fakeJar))
+	return hex.EncodeToString(sum[:])
+}
+
+func stableBuilds(serverURL string) string {
+	return fmt.Sprintf(`[
+		{"id": 12, "channel": "BETA", "downloads": {"server:default": {"url": "%[1]s/download/beta.jar"}}},
+		{"id": 11, "channel": "STABLE", "downloads": {"server:default": {"url": "%[1]s/download/paper.jar", "checksums": {"sha256": "%[2]s"}}}},
+		{"id": 10, "channel": "STABLE", "downloads": {"server:default": {"url": "%[1]s/download/old.jar"}}}
+	]`, serverURL, fakeJarSHA256())
+}
+
+func TestResolveStable_PicksNewestStable(t *testing.T) {
+	server := newFakePaperAPI(t, stableBuilds)
+	client := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	dl, err := client.ResolveStable("1.21.11")
+	if err != nil {
+		t.Fatalf("ResolveStable() error = %v", err)
+	}
+
+	if dl.Build != 11 {
+		t.Errorf("Build = %d, want 11", dl.Build)
+	}
+	if dl.URL != server.URL+"/download/paper.jar" {
+		t.Errorf("URL = %q, want %q", dl.URL, server.URL+"/download/paper.jar")
+	}
+}
+
+func TestResolveStable_NoStableBuild(t *testing.T) {
+	server := newFakePaperAPI(t, func(string) string {
+		return `[{"id": 1, "channel": "ALPHA", "downloads": {}}]`
+	})
+	client := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	if _, err := client.ResolveStable("1.21.11"); err == nil {
+		t.Error("ResolveStable() expected error without stable builds, got nil")
+	}
+}
+
+func TestResolveStable_APIError(t *testing.T) {
+	server := newFakePaperAPI(t, stableBuilds)
+	client := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	_, err := client.ResolveStable("9.9.9")
+	if err == nil {
+		t.Fatal("ResolveStable() expected error for unknown version, got nil")
+	}
+	if err.Error() != "paper api error: Version not found" {
+		t.Errorf("error = %q, want %q", err.Error(), "paper api error: Version not found")
+	}
+}
+
+func TestDownload_VerifiesChecksum(t *testing.T) {
+	server := newFakePaperAPI(t, stableBuilds)
+	client := &PaperClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	dest := filepath.Join(t.TempDir(), ServerJar)
+
+	dl := &PaperDownload{URL: server.URL + "/download/paper.jar", SHA256: "deadbeef"}
+	if err := client.Download(dl, dest); err == nil {
+		t.Fatal("Download() expected checksum error, got nil")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Download() left a jar behind after a checksum mismatch")
+	}
+
+	dl.SHA256 = fakeJarSHA256()
+	if err := client.Download(dl, dest); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read jar: %v", err)
+	}
+	if string(data) != fakeJar {
+		t.Errorf("jar content = %q, want %q", string(data), fakeJar)
+	}
+}
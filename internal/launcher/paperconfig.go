@@ -0,0 +1,72 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PaperGlobalConfig is Paper's global configuration, relative to the data directory
+const PaperGlobalConfig = "config/paper-global.yml"
+
+// EnableProxyProtocol sets proxies.proxy-protocol in Paper's global configuration so
+// the server reads the player's address from the gateway's PROXY protocol header.
+// Paper fills in the rest of a file created here on first start, and the comments and
+// other settings of an existing file are kept.
+func EnableProxyProtocol(path string) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", filepath.Base(path))
+	}
+
+	proxies := mappingValue(root, "proxies")
+	if proxies.Kind != yaml.MappingNode {
+		*proxies = yaml.Node{Kind: yaml.MappingNode}
+	}
+	value := mappingValue(proxies, "proxy-protocol")
+	if value.Kind == yaml.ScalarNode && value.Value == "true" {
+		return nil
+	}
+	*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// mappingValue returns the value node of key in a mapping, adding the key if missing
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
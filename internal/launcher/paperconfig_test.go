@@ -0,0 +1,52 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnableProxyProtocol_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PaperGlobalConfig)
+
+	if err := EnableProxyProtocol(path); err != nil {
+		t.Fatalf("EnableProxyProtocol() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "proxies:\n  proxy-protocol: true\n" {
+		t.Errorf("paper-global.yml = %q, want only proxy-protocol set", data)
+	}
+}
+
+func TestEnableProxyProtocol_KeepsSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paper-global.yml")
+	existing := `# This is the global configuration file for Paper.
+_version: 29
+proxies:
+  bungee-cord:
+    online-mode: true
+  proxy-protocol: false
+  velocity:
+    enabled: false
+unsupported-settings:
+  allow-headless-pistons: false
+`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnableProxyProtocol(path); err != nil {
+		t.Fatalf("EnableProxyProtocol() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := strings.Replace(existing, "proxy-protocol: false", "proxy-protocol: true", 1)
+	if string(data) != want {
+		t.Errorf("paper-global.yml =\n%s\nwant\n%s", data, want)
+	}
+}
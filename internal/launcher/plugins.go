@@ -0,0 +1,116 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/baighasan/kubecraft/internal/plugins"
+)
+
+const (
+	DefaultPluginLock = "/etc/kubecraft/plugins/" + plugins.LockFile
+
+	pluginsInstalledFile = "plugins.json" // In StateDir, the jars installed from the lockfile and their hashes
+)
+
+// InstallPlugins reconciles the plugins directory with the lockfile: plugins missing or
+// at another build are downloaded, jars of plugins removed from the lockfile deleted.
+// Jars put there by hand or by InstallBedrockPlugins are not touched. A missing lockfile
+// is an empty one, so removing the last plugin removes its jar.
+func InstallPlugins(cfg *Config, client *http.Client) error {
+	data, err := os.ReadFile(cfg.PluginLock)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading plugin lock: %w", err)
+	}
+	lock, err := plugins.ParseLock(data)
+	if err != nil {
+		return err
+	}
+
+	installed, err := readInstalledPlugins(cfg)
+	if err != nil {
+		return err
+	}
+	if len(lock.Plugins) == 0 && len(installed) == 0 {
+		return nil
+	}
+	if len(lock.Plugins) > 0 && !PaperBased(cfg.Type) {
+		fmt.Printf("Skipping %d plugins, %s servers do not load them\n", len(lock.Plugins), cfg.Type)
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.path(PluginsDir), 0755); err != nil {
+		return fmt.Errorf("creating plugins directory: %w", err)
+	}
+
+	next := map[string]string{}
+	for _, p := range lock.Plugins {
+		next[p.File] = p.Hash
+		jar := cfg.path(PluginsDir, p.File)
+		if installed[p.File] == p.Hash && fileExists(jar) {
+			continue
+		}
+
+		fmt.Printf("Installing plugin %s %s from %s\n", p.Slug, p.Version, p.Source)
+		if err := downloadJar(client, p.Slug, p.URL, pluginChecksum(p), jar); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range slices.Sorted(maps.Keys(installed)) {
+		if _, ok := next[file]; ok {
+			continue
+		}
+		err := os.Remove(cfg.path(PluginsDir, filepath.Base(file)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing plugin %s: %w", file, err)
+		}
+		fmt.Printf("Removed plugin %s, it is no longer in the lock\n", file)
+	}
+
+	return writeInstalledPlugins(cfg, next)
+}
+
+func pluginChecksum(p plugins.Plugin) checksum {
+	algorithm, sum := p.Checksum()
+	newHash := sha512.New
+	if algorithm == "sha256" {
+		newHash = sha256.New
+	}
+
+	return checksum{newHash: newHash, sum: sum}
+}
+
+func readInstalledPlugins(cfg *Config) (map[string]string, error) {
+	installed := map[string]string{}
+	data, err := os.ReadFile(cfg.path(StateDir, pluginsInstalledFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return installed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading installed plugins: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &installed); err != nil {
+		return nil, fmt.Errorf("reading installed plugins: %w", err)
+	}
+
+	return installed, nil
+}
+
+func writeInstalledPlugins(cfg *Config, installed map[string]string) error {
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cfg.path(StateDir, pluginsInstalledFile), data, 0644)
+}
@@ -0,0 +1,164 @@
+package launcher
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/plugins"
+)
+
+// fakePluginJars are served by newFakePluginCDN, by file name
+var fakePluginJars = map[string]string{
+	"EssentialsX-2.21.0.jar": "essentials 2.21.0",
+	"EssentialsX-2.21.1.jar": "essentials 2.21.1",
+	"ViaVersion-5.2.0.jar":   "viaversion",
+}
+
+func newFakePluginCDN(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := fakePluginJars[filepath.Base(r.URL.Path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// lockedPlugin pins a fakePluginJars jar, hashed as Modrinth (sha512) or Hangar (sha256) do
+func lockedPlugin(serverURL string, slug string, file string, algorithm string) plugins.Plugin {
+	hash := "sha512:"
+	if algorithm == "sha256" {
+		sum := sha256.Sum256([]byte(fakePluginJars[file]))
+		hash = "sha256:" + hex.EncodeToString(sum[:])
+	} else {
+		sum := sha512.Sum512([]byte(fakePluginJars[file]))
+		hash += hex.EncodeToString(sum[:])
+	}
+
+	return plugins.Plugin{Slug: slug, Source: "modrinth", Version: "1", File: file, URL: serverURL + "/" + file, Hash: hash}
+}
+
+func writePluginLock(t *testing.T, cfg *Config, locked ...plugins.Plugin) {
+	t.Helper()
+
+	data, err := (&plugins.Lock{Plugins: locked}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.PluginLock, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newPluginConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg := &Config{DataDir: t.TempDir(), Type: TypePaper, PluginLock: filepath.Join(t.TempDir(), plugins.LockFile)}
+	os.MkdirAll(cfg.path(StateDir), 0755)
+	return cfg
+}
+
+func TestInstallPlugins(t *testing.T) {
+	server := newFakePluginCDN(t)
+	cfg := newPluginConfig(t)
+
+	// Installed by hand, not the lock's to remove
+	os.MkdirAll(cfg.path(PluginsDir), 0755)
+	os.WriteFile(cfg.path(PluginsDir, "LuckPerms.jar"), []byte("luckperms"), 0644)
+
+	writePluginLock(t, cfg,
+		lockedPlugin(server.URL, "essentialsx", "EssentialsX-2.21.0.jar", "sha512"),
+		lockedPlugin(server.URL, "ViaVersion", "ViaVersion-5.2.0.jar", "sha256"),
+	)
+	if err := InstallPlugins(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallPlugins() error = %v", err)
+	}
+	for file, want := range map[string]string{"EssentialsX-2.21.0.jar": "essentials 2.21.0", "ViaVersion-5.2.0.jar": "viaversion"} {
+		if got := readDataFile(t, cfg, "plugins/"+file); got != want {
+			t.Errorf("plugins/%s = %q, want %q", file, got, want)
+		}
+	}
+
+	// Update one, remove the other
+	writePluginLock(t, cfg, lockedPlugin(server.URL, "essentialsx", "EssentialsX-2.21.1.jar", "sha512"))
+	if err := InstallPlugins(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallPlugins() after update error = %v", err)
+	}
+	for file, want := range map[string]string{"EssentialsX-2.21.1.jar": "essentials 2.21.1", "LuckPerms.jar": "luckperms"} {
+		if got := readDataFile(t, cfg, "plugins/"+file); got != want {
+			t.Errorf("plugins/%s = %q, want %q", file, got, want)
+		}
+	}
+	for _, file := range []string{"EssentialsX-2.21.0.jar", "ViaVersion-5.2.0.jar"} {
+		if _, err := os.Stat(cfg.path(PluginsDir, file)); !os.IsNotExist(err) {
+			t.Errorf("plugins/%s was kept after leaving the lock", file)
+		}
+	}
+
+	// Without a lockfile the remaining plugin goes too
+	os.Remove(cfg.PluginLock)
+	if err := InstallPlugins(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallPlugins() without a lock error = %v", err)
+	}
+	if _, err := os.Stat(cfg.path(PluginsDir, "EssentialsX-2.21.1.jar")); !os.IsNotExist(err) {
+		t.Error("plugin kept after the lockfile was removed")
+	}
+}
+
+func TestInstallPlugins_RestartSkipsDownloads(t *testing.T) {
+	server := newFakePluginCDN(t)
+	cfg := newPluginConfig(t)
+	writePluginLock(t, cfg, lockedPlugin(server.URL, "essentialsx", "EssentialsX-2.21.0.jar", "sha512"))
+	if err := InstallPlugins(cfg, server.Client()); err != nil {
+		t.Fatalf("InstallPlugins() error = %v", err)
+	}
+
+	// With the registry gone, an installed plugin still starts
+	server.Close()
+	if err := InstallPlugins(cfg, server.Client()); err != nil {
+		t.Errorf("InstallPlugins() on restart error = %v", err)
+	}
+}
+
+func TestInstallPlugins_ChecksumMismatch(t *testing.T) {
+	server := newFakePluginCDN(t)
+	cfg := newPluginConfig(t)
+
+	p := lockedPlugin(server.URL, "essentialsx", "EssentialsX-2.21.0.jar", "sha512")
+	p.File = "EssentialsX-2.21.1.jar"
+	p.URL = server.URL + "/EssentialsX-2.21.1.jar"
+	writePluginLock(t, cfg, p)
+
+	if err := InstallPlugins(cfg, server.Client()); err == nil {
+		t.Fatal("InstallPlugins() expected checksum error, got nil")
+	}
+	if _, err := os.Stat(cfg.path(PluginsDir, "EssentialsX-2.21.1.jar")); !os.IsNotExist(err) {
+		t.Error("plugin installed despite the checksum mismatch")
+	}
+}
+
+func TestInstallPlugins_NotPaper(t *testing.T) {
+	cfg := newPluginConfig(t)
+	cfg.Type = TypeFabric
+	writePluginLock(t, cfg, lockedPlugin("https://cdn.modrinth.com", "essentialsx", "EssentialsX-2.21.0.jar", "sha512"))
+
+	if err := InstallPlugins(cfg, nil); err != nil {
+		t.Errorf("InstallPlugins() on fabric error = %v", err)
+	}
+	if _, err := os.Stat(cfg.path(PluginsDir)); !os.IsNotExist(err) {
+		t.Error("plugins directory created on a fabric server")
+	}
+}
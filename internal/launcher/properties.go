@@ -0,0 +1,348 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envProperties maps the env variables set by CreateServer to server.properties keys
+var envProperties = map[string]string{
+	"GAME_MODE":     "gamemode",
+	"DIFFICULTY":    "difficulty",
+	"MAX_PLAYERS":   "max-players",
+	"MOTD":          "motd",
+	"SEED":          "level-seed",
+	"LEVEL_TYPE":    "level-type",
+	"PVP":           "pvp",
+	"HARDCORE":      "hardcore",
+	"RCON_PORT":     "rcon.port",
+	"RCON_PASSWORD": "rcon.password",
+}
+
+// PropertiesFromEnv returns the server.properties values provided through env variables.
+// Unset variables are left out so the server (or the user) keeps control of them.
+func PropertiesFromEnv(getenv func(string) string) map[string]string {
+	props := make(map[string]string)
+	for env, key := range envProperties {
+		value := getenv(env)
+		if value == "" {
+			continue
+		}
+
+		// Level types are namespaced world presets since 1.19
+		if key == "level-type" && !strings.Contains(value, ":") {
+			value = "minecraft:" + value
+		}
+
+		props[key] = value
+	}
+
+	// RCON is only switched on when a password is provided
+	if _, ok := props["rcon.password"]; ok {
+		props["enable-rcon"] = "true"
+	}
+
+	return props
+}
+
+// MergeProperties applies the env-provided properties to server.properties.
+//
+// A record of the values applied on the previous start is kept in stateFile so that
+// hand edits survive restarts: a key is only overwritten when its env value changed
+// since the last start, or when the file still holds the value we wrote last time.
+func MergeProperties(path string, stateFile string, props map[string]string) error {
+	lines, err := readLines(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	applied, err := loadApplied(stateFile)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", stateFile, err)
+	}
+
+	current := make(map[string]int) // key -> line index
+	for i, line := range lines {
+		if key, _, ok := parseLine(line); ok {
+			current[key] = i
+		}
+	}
+
+	for _, key := range sortedKeys(props) {
+		value := props[key]
+		line := key + "=" + escapeValue(value)
+
+		idx, exists := current[key]
+		if !exists {
+			lines = append(lines, line)
+			continue
+		}
+
+		_, fileValue, _ := parseLine(lines[idx])
+		last, appliedBefore := applied[key]
+		userEdited := appliedBefore && fileValue != last
+		envChanged := !appliedBefore || value != last
+		if userEdited && !envChanged {
+			continue
+		}
+
+		lines[idx] = line
+	}
+
+	if err := writeLines(path, lines); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := saveApplied(stateFile, props); err != nil {
+		return fmt.Errorf("writing %s: %w", stateFile, err)
+	}
+
+	return nil
+}
+
+// SetProperties writes props to server.properties over whatever the file holds, for
+// settings the CLI changes on a server rather than through env variables
+func SetProperties(path string, props map[string]string) error {
+	lines, err := readLines(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	for _, key := range sortedKeys(props) {
+		line := key + "=" + escapeValue(props[key])
+		idx := slices.IndexFunc(lines, func(l string) bool {
+			k, _, ok := parseLine(l)
+			return ok && k == key
+		})
+		if idx < 0 {
+			lines = append(lines, line)
+			continue
+		}
+		lines[idx] = line
+	}
+
+	if err := writeLines(path, lines); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseLine splits a key=value line the way java.util.Properties does
+func parseLine(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " \t\f")
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+		return "", "", false
+	}
+
+	// Find the first unescaped separator
+	sep := -1
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] == '\\' {
+			i++
+			continue
+		}
+		if trimmed[i] == '=' || trimmed[i] == ':' || trimmed[i] == ' ' || trimmed[i] == '\t' {
+			sep = i
+			break
+		}
+	}
+	if sep == -1 {
+		return unescape(trimmed), "", true
+	}
+
+	key := trimmed[:sep]
+	rest := strings.TrimLeft(trimmed[sep:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	return unescape(key), unescape(rest), true
+}
+
+// escapeValue escapes a value the way java.util.Properties#store does
+func escapeValue(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case r == ' ' && i == 0:
+			b.WriteString(`\ `)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < 0x20 || r > 0x7e:
+			if r > 0xffff {
+				// Encode as a UTF-16 surrogate pair
+				r -= 0x10000
+				fmt.Fprintf(&b, `\u%04X\u%04X`, 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+			} else {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var units []uint16
+	var b strings.Builder
+	flush := func() {
+		if len(units) > 0 {
+			b.WriteString(decodeUTF16(units))
+			units = units[:0]
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i == len(s)-1 {
+			flush()
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			flush()
+			b.WriteByte('\t')
+		case 'n':
+			flush()
+			b.WriteByte('\n')
+		case 'r':
+			flush()
+			b.WriteByte('\r')
+		case 'f':
+			flush()
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if code, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					units = append(units, uint16(code))
+					i += 4
+					continue
+				}
+			}
+			flush()
+			b.WriteByte('u')
+		default:
+			flush()
+			b.WriteByte(s[i])
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+func decodeUTF16(units []uint16) string {
+	var b strings.Builder
+	for i := 0; i < len(units); i++ {
+		u := rune(units[i])
+		if u >= 0xd800 && u < 0xdc00 && i+1 < len(units) {
+			low := rune(units[i+1])
+			if low >= 0xdc00 && low < 0xe000 {
+				b.WriteRune(0x10000 + (u-0xd800)<<10 + (low - 0xdc00))
+				i++
+				continue
+			}
+		}
+		b.WriteRune(u)
+	}
+
+	return b.String()
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{"# Kubecraft generated server.properties"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+func writeLines(path string, lines []string) error {
+	// Write to a temp file first so a crash never leaves a truncated server.properties
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func loadApplied(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]string{}
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func saveApplied(path string, props map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(props, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
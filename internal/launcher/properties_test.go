@@ -0,0 +1,285 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// envFunc returns a getenv function backed by a map
+func envFunc(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// readProperties parses a server.properties file into a map
+func readProperties(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	lines, err := readLines(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+
+	props := map[string]string{}
+	for _, line := range lines {
+		if key, value, ok := parseLine(line); ok {
+			props[key] = value
+		}
+	}
+
+	return props
+}
+
+func TestPropertiesFromEnv_MapsKnownVariables(t *testing.T) {
+	props := PropertiesFromEnv(envFunc(map[string]string{
+		"GAME_MODE":   "creative",
+		"DIFFICULTY":  "hard",
+		"MAX_PLAYERS": "10",
+		"MOTD":        "Build server",
+		"SEED":        "12345",
+		"LEVEL_TYPE":  "flat",
+		"PVP":         "false",
+		"HARDCORE":    "false",
+		"JAVA_MEMORY": "3G",
+	}))
+
+	want := map[string]string{
+		"gamemode":    "creative",
+		"difficulty":  "hard",
+		"max-players": "10",
+		"motd":        "Build server",
+		"level-seed":  "12345",
+		"level-type":  "minecraft:flat",
+		"pvp":         "false",
+		"hardcore":    "false",
+	}
+
+	if len(props) != len(want) {
+		t.Errorf("PropertiesFromEnv() returned %d properties, want %d: %v", len(props), len(want), props)
+	}
+	for key, value := range want {
+		if props[key] != value {
+			t.Errorf("props[%q] = %q, want %q", key, props[key], value)
+		}
+	}
+}
+
+func TestPropertiesFromEnv_SkipsUnset(t *testing.T) {
+	props := PropertiesFromEnv(envFunc(map[string]string{"GAME_MODE": "survival"}))
+
+	if len(props) != 1 {
+		t.Errorf("PropertiesFromEnv() returned %d properties, want 1: %v", len(props), props)
+	}
+	if _, ok := props["level-seed"]; ok {
+		t.Error("PropertiesFromEnv() set level-seed for an unset SEED")
+	}
+}
+
+func TestPropertiesFromEnv_NamespacedLevelTypeKept(t *testing.T) {
+	props := PropertiesFromEnv(envFunc(map[string]string{"LEVEL_TYPE": "minecraft:amplified"}))
+
+	if props["level-type"] != "minecraft:amplified" {
+		t.Errorf("level-type = %q, want %q", props["level-type"], "minecraft:amplified")
+	}
+}
+
+func TestPropertiesFromEnv_EnablesRcon(t *testing.T) {
+	props := PropertiesFromEnv(envFunc(map[string]string{"RCON_PASSWORD": "secret", "RCON_PORT": "25575"}))
+
+	if props["enable-rcon"] != "true" {
+		t.Errorf("enable-rcon = %q, want %q", props["enable-rcon"], "true")
+	}
+	if props["rcon.password"] != "secret" || props["rcon.port"] != "25575" {
+		t.Errorf("rcon.password = %q, rcon.port = %q, want secret and 25575", props["rcon.password"], props["rcon.port"])
+	}
+
+	props = PropertiesFromEnv(envFunc(map[string]string{"RCON_PORT": "25575"}))
+	if _, ok := props["enable-rcon"]; ok {
+		t.Error("PropertiesFromEnv() enabled RCON without a password")
+	}
+}
+
+func TestMergeProperties_CreatesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	state := filepath.Join(dir, StateDir, "properties.json")
+
+	err := MergeProperties(path, state, map[string]string{"gamemode": "creative", "motd": "Hello"})
+	if err != nil {
+		t.Fatalf("MergeProperties() error = %v", err)
+	}
+
+	props := readProperties(t, path)
+	if props["gamemode"] != "creative" || props["motd"] != "Hello" {
+		t.Errorf("server.properties = %v, want gamemode=creative and motd=Hello", props)
+	}
+}
+
+func TestMergeProperties_PreservesUnmanagedLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	state := filepath.Join(dir, StateDir, "properties.json")
+
+	original := "#Minecraft server properties\nview-distance=12\ngamemode=survival\nwhite-list=true\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write server.properties: %v", err)
+	}
+
+	err := MergeProperties(path, state, map[string]string{"gamemode": "creative"})
+	if err != nil {
+		t.Fatalf("MergeProperties() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read server.properties: %v", err)
+	}
+
+	want := "#Minecraft server properties\nview-distance=12\ngamemode=creative\nwhite-list=true\n"
+	if string(data) != want {
+		t.Errorf("server.properties = %q, want %q", string(data), want)
+	}
+}
+
+func TestMergeProperties_KeepsUserEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	state := filepath.Join(dir, StateDir, "properties.json")
+	props := map[string]string{"max-players": "5", "motd": "Hello"}
+
+	if err := MergeProperties(path, state, props); err != nil {
+		t.Fatalf("First MergeProperties() error = %v", err)
+	}
+
+	// User edits the file by hand while the server is running
+	data, _ := os.ReadFile(path)
+	edited := strings.Replace(string(data), "max-players=5", "max-players=8", 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit server.properties: %v", err)
+	}
+
+	// Restart with the same env
+	if err := MergeProperties(path, state, props); err != nil {
+		t.Fatalf("Second MergeProperties() error = %v", err)
+	}
+
+	got := readProperties(t, path)
+	if got["max-players"] != "8" {
+		t.Errorf("max-players = %q, want hand edited value %q", got["max-players"], "8")
+	}
+}
+
+func TestMergeProperties_EnvChangeOverridesUserEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	state := filepath.Join(dir, StateDir, "properties.json")
+
+	if err := MergeProperties(path, state, map[string]string{"gamemode": "survival"}); err != nil {
+		t.Fatalf("First MergeProperties() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	edited := strings.Replace(string(data), "gamemode=survival", "gamemode=adventure", 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit server.properties: %v", err)
+	}
+
+	// Env was changed on purpose, so it wins
+	if err := MergeProperties(path, state, map[string]string{"gamemode": "creative"}); err != nil {
+		t.Fatalf("Second MergeProperties() error = %v", err)
+	}
+
+	got := readProperties(t, path)
+	if got["gamemode"] != "creative" {
+		t.Errorf("gamemode = %q, want %q", got["gamemode"], "creative")
+	}
+}
+
+func TestSetProperties(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	state := filepath.Join(dir, StateDir, "properties.json")
+	os.WriteFile(path, []byte("# edited by hand\nresource-pack=\nmotd=Mine\n"), 0644)
+
+	err := SetProperties(path, map[string]string{
+		"resource-pack":         "https://example.com/pack.zip",
+		"resource-pack-sha1":    "3f786850e387550fdab836ed7e6dc881de23001b",
+		"require-resource-pack": "true",
+	})
+	if err != nil {
+		t.Fatalf("SetProperties() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# edited by hand\nresource-pack=https\\://example.com/pack.zip\nmotd=Mine\n") {
+		t.Errorf("server.properties = %q, want the value set in place and other lines kept", data)
+	}
+	props := readProperties(t, path)
+	if props["resource-pack"] != "https://example.com/pack.zip" || props["require-resource-pack"] != "true" || props["resource-pack-sha1"] == "" {
+		t.Errorf("server.properties = %v, want the resource pack set", props)
+	}
+
+	// Not managed through env variables, so a start keeps them
+	if err := MergeProperties(path, state, map[string]string{"motd": "Mine"}); err != nil {
+		t.Fatalf("MergeProperties() error = %v", err)
+	}
+	if props := readProperties(t, path); props["resource-pack"] != "https://example.com/pack.zip" {
+		t.Errorf("resource-pack = %q after a start, want it kept", props["resource-pack"])
+	}
+}
+
+func TestEscapeValue_RoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		"minecraft:normal",
+		"a=b",
+		" leading space",
+		`back\slash`,
+		"§aGreen MOTD",
+		"emoji 🎮",
+		"#not a comment",
+	}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			line := "motd=" + escapeValue(value)
+			key, got, ok := parseLine(line)
+			if !ok || key != "motd" {
+				t.Fatalf("parseLine(%q) = %q, %v, want key motd", line, key, ok)
+			}
+			if got != value {
+				t.Errorf("round trip of %q = %q", value, got)
+			}
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		key   string
+		value string
+		ok    bool
+	}{
+		{"gamemode=survival", "gamemode", "survival", true},
+		{"level-type=minecraft\\:normal", "level-type", "minecraft:normal", true},
+		{"motd = spaced", "motd", "spaced", true},
+		{"motd:colon", "motd", "colon", true},
+		{"empty=", "empty", "", true},
+		{"# comment", "", "", false},
+		{"! comment", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, value, ok := parseLine(tt.line)
+			if key != tt.key || value != tt.value || ok != tt.ok {
+				t.Errorf("parseLine(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.line, key, value, ok, tt.key, tt.value, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	JavaAddr     = "127.0.0.1:25565"
+	BedrockAddr  = "127.0.0.1:19132"
+	readyTimeout = 3 * time.Second // Below the probe's timeout
+)
+
+// RakNet's offline messages, which Bedrock clients use to list a server
+const (
+	raknetUnconnectedPing = 0x01
+	raknetUnconnectedPong = 0x1c
+)
+
+// raknetMagic marks RakNet's offline messages
+var raknetMagic = []byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// Ready checks that the server accepts players: Java Edition on its TCP port and, for
+// servers with Bedrock support, Geyser on its UDP port. In maintenance mode only the
+// TCP port is up.
+func Ready(getenv func(string) string) error {
+	conn, err := net.DialTimeout("tcp", JavaAddr, readyTimeout)
+	if err != nil {
+		return fmt.Errorf("java listener: %w", err)
+	}
+	conn.Close()
+
+	if getenv("BEDROCK") != "true" || getenv("MAINTENANCE") == "true" {
+		return nil
+	}
+	if err := PingBedrock(BedrockAddr, readyTimeout); err != nil {
+		return fmt.Errorf("bedrock listener: %w", err)
+	}
+
+	return nil
+}
+
+// PingBedrock sends the unconnected ping a Bedrock client lists servers with and waits
+// for the pong
+func PingBedrock(addr string, timeout time.Duration) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	ping := []byte{raknetUnconnectedPing}
+	ping = binary.BigEndian.AppendUint64(ping, uint64(time.Now().UnixMilli()))
+	ping = append(ping, raknetMagic...)
+	ping = binary.BigEndian.AppendUint64(ping, 0) // Client GUID
+	if _, err := conn.Write(ping); err != nil {
+		return err
+	}
+
+	// Pong: id, time, server GUID, magic, then the server's MOTD string
+	pong := make([]byte, 1500)
+	n, err := conn.Read(pong)
+	if err != nil {
+		return err
+	}
+	if n < 33 || pong[0] != raknetUnconnectedPong || !bytes.Equal(pong[17:33], raknetMagic) {
+		return fmt.Errorf("unexpected reply to ping")
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package launcher
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingBedrock(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Answers as Geyser does, echoing the ping time
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil || n != 33 || buf[0] != raknetUnconnectedPing || !bytes.Equal(buf[9:25], raknetMagic) {
+			return
+		}
+		pong := append([]byte{raknetUnconnectedPong}, buf[1:9]...)
+		pong = append(pong, 0, 0, 0, 0, 0, 0, 0, 1)
+		pong = append(pong, raknetMagic...)
+		motd := "MCPE;Kubecraft;844;1.21.111;0;5;1;Geyser;Survival;1;19132;19133;"
+		pong = append(pong, 0, byte(len(motd)))
+		pong = append(pong, motd...)
+		conn.WriteTo(pong, addr)
+	}()
+
+	if err := PingBedrock(conn.LocalAddr().String(), 5*time.Second); err != nil {
+		t.Errorf("PingBedrock() error = %v", err)
+	}
+}
+
+func TestPingBedrock_NoListener(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	if err := PingBedrock(addr, 200*time.Millisecond); err == nil {
+		t.Error("PingBedrock() expected error without a listener, got nil")
+	}
+}
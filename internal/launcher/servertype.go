@@ -0,0 +1,455 @@
+package launcher
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Server types the launcher installs, set with SERVER_TYPE
+const (
+	TypePaper    = "paper"
+	TypePurpur   = "purpur"
+	TypeVanilla  = "vanilla"
+	TypeFabric   = "fabric"
+	TypeForge    = "forge"
+	TypeNeoForge = "neoforge"
+)
+
+var ServerTypes = []string{TypePaper, TypePurpur, TypeVanilla, TypeFabric, TypeForge, TypeNeoForge}
+
+const (
+	PurpurAPIURL       = "https://api.purpurmc.org/v2"
+	MojangManifestURL  = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	FabricMetaURL      = "https://meta.fabricmc.net/v2"
+	ForgePromotionsURL = "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json"
+	ForgeMavenURL      = "https://maven.minecraftforge.net"
+	NeoForgeMavenURL   = "https://maven.neoforged.net/releases"
+
+	// FabricServerJar is Fabric's server launcher, written by its installer, which loads
+	// the mods and then the vanilla jar at ServerJar
+	FabricServerJar = "fabric-server-launch.jar"
+)
+
+// PaperBased reports whether the server type reads Paper's configuration and runs its
+// plugins, which the gateway's PROXY protocol and Geyser need
+func PaperBased(serverType string) bool {
+	return serverType == TypePaper || serverType == TypePurpur
+}
+
+// Installer downloads the server of every type, each from its own project's API
+type Installer struct {
+	Paper              *PaperClient
+	HTTPClient         *http.Client
+	PurpurURL          string
+	MojangURL          string
+	FabricURL          string
+	ForgePromotionsURL string
+	ForgeMavenURL      string
+	NeoForgeMavenURL   string
+	// RunInstaller runs a Fabric, Forge or NeoForge installer jar with args, installing
+	// the server into dir
+	RunInstaller func(dir string, jar string, args ...string) error
+}
+
+// NewInstaller returns an installer using the public APIs of every project
+func NewInstaller() *Installer {
+	return &Installer{
+		Paper:              NewPaperClient(),
+		HTTPClient:         &http.Client{Timeout: 5 * time.Minute},
+		PurpurURL:          PurpurAPIURL,
+		MojangURL:          MojangManifestURL,
+		FabricURL:          FabricMetaURL,
+		ForgePromotionsURL: ForgePromotionsURL,
+		ForgeMavenURL:      ForgeMavenURL,
+		NeoForgeMavenURL:   NeoForgeMavenURL,
+		RunInstaller:       runJavaInstaller,
+	}
+}
+
+// installedServer is a server the Installer put into the data directory
+type installedServer struct {
+	Build  string   // Build or loader version, empty for vanilla
+	Launch []string // Java arguments after the JVM options that start the server
+}
+
+// install downloads the server of cfg.Type for cfg.Version into the data directory
+func (i *Installer) install(cfg *Config) (*installedServer, error) {
+	switch cfg.Type {
+	case TypePaper:
+		return i.installPaper(cfg)
+	case TypePurpur:
+		return i.installPurpur(cfg)
+	case TypeVanilla:
+		return i.installVanilla(cfg)
+	case TypeFabric:
+		return i.installFabric(cfg)
+	case TypeForge:
+		return i.installForge(cfg)
+	case TypeNeoForge:
+		return i.installNeoForge(cfg)
+	}
+
+	return nil, fmt.Errorf("unknown server type %q", cfg.Type)
+}
+
+func jarLaunch(jar string) []string {
+	return []string{"-jar", jar}
+}
+
+func (i *Installer) installPaper(cfg *Config) (*installedServer, error) {
+	dl, err := i.Paper.ResolveStable(cfg.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.Paper.Download(dl, cfg.path(ServerJar)); err != nil {
+		return nil, err
+	}
+
+	return &installedServer{Build: fmt.Sprint(dl.Build), Launch: jarLaunch(ServerJar)}, nil
+}
+
+type purpurVersion struct {
+	Builds struct {
+		Latest string `json:"latest"`
+	} `json:"builds"`
+}
+
+type purpurBuild struct {
+	MD5 string `json:"md5"`
+}
+
+// installPurpur downloads the latest Purpur build, which only publishes an MD5 checksum
+func (i *Installer) installPurpur(cfg *Config) (*installedServer, error) {
+	var version purpurVersion
+	err := i.getJSON("purpur", fmt.Sprintf("%s/purpur/%s", i.PurpurURL, cfg.Version), &version)
+	if err != nil {
+		return nil, err
+	}
+	if version.Builds.Latest == "" {
+		return nil, fmt.Errorf("no purpur build for version %s", cfg.Version)
+	}
+
+	buildURL := fmt.Sprintf("%s/purpur/%s/%s", i.PurpurURL, cfg.Version, version.Builds.Latest)
+	var build purpurBuild
+	if err := i.getJSON("purpur", buildURL, &build); err != nil {
+		return nil, err
+	}
+
+	sum := checksum{newHash: md5.New, sum: build.MD5}
+	if err := downloadJar(i.HTTPClient, "purpur", buildURL+"/download", sum, cfg.path(ServerJar)); err != nil {
+		return nil, err
+	}
+
+	return &installedServer{Build: version.Builds.Latest, Launch: jarLaunch(ServerJar)}, nil
+}
+
+type mojangManifest struct {
+	Versions []mojangVersionRef `json:"versions"`
+}
+
+type mojangVersionRef struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type mojangVersion struct {
+	Downloads struct {
+		Server *struct {
+			SHA1 string `json:"sha1"`
+			URL  string `json:"url"`
+		} `json:"server"`
+	} `json:"downloads"`
+}
+
+// installVanilla downloads Mojang's server jar, found through the version manifest
+func (i *Installer) installVanilla(cfg *Config) (*installedServer, error) {
+	var manifest mojangManifest
+	if err := i.getJSON("minecraft", i.MojangURL, &manifest); err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(manifest.Versions, func(v mojangVersionRef) bool { return v.ID == cfg.Version })
+	if index < 0 {
+		return nil, fmt.Errorf("unknown minecraft version %s", cfg.Version)
+	}
+
+	var version mojangVersion
+	if err := i.getJSON("minecraft", manifest.Versions[index].URL, &version); err != nil {
+		return nil, err
+	}
+	server := version.Downloads.Server
+	if server == nil {
+		return nil, fmt.Errorf("minecraft %s has no server download", cfg.Version)
+	}
+
+	sum := checksum{newHash: sha1.New, sum: server.SHA1}
+	if err := downloadJar(i.HTTPClient, "minecraft", server.URL, sum, cfg.path(ServerJar)); err != nil {
+		return nil, err
+	}
+
+	return &installedServer{Launch: jarLaunch(ServerJar)}, nil
+}
+
+type fabricLoader struct {
+	Loader struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	} `json:"loader"`
+}
+
+type fabricInstaller struct {
+	URL     string `json:"url"` // Of the installer jar in Fabric's maven
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// installFabric runs Fabric's installer for the pinned loader, or else the newest stable
+// one, which writes the server launcher and downloads the loader's libraries. The
+// installer could download the vanilla jar itself, doing so here verifies its checksum
+// and replaces the jar of a server that ran another type.
+func (i *Installer) installFabric(cfg *Config) (*installedServer, error) {
+	if _, err := i.installVanilla(cfg); err != nil {
+		return nil, err
+	}
+
+	// Both listings are newest first
+	loaderVersion := cfg.LoaderVersion
+	if loaderVersion == "" {
+		var loaders []fabricLoader
+		if err := i.getJSON("fabric", fmt.Sprintf("%s/versions/loader/%s", i.FabricURL, cfg.Version), &loaders); err != nil {
+			return nil, err
+		}
+		loader := slices.IndexFunc(loaders, func(l fabricLoader) bool { return l.Loader.Stable })
+		if loader < 0 {
+			return nil, fmt.Errorf("no stable fabric loader for version %s", cfg.Version)
+		}
+		loaderVersion = loaders[loader].Loader.Version
+	}
+
+	var installers []fabricInstaller
+	if err := i.getJSON("fabric", i.FabricURL+"/versions/installer", &installers); err != nil {
+		return nil, err
+	}
+	installer := slices.IndexFunc(installers, func(in fabricInstaller) bool { return in.Stable })
+	if installer < 0 {
+		return nil, fmt.Errorf("no stable fabric installer")
+	}
+
+	// Left from an older loader, the installer has to write a new one
+	if err := os.Remove(cfg.path(FabricServerJar)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	args := []string{"server", "-dir", ".", "-mcversion", cfg.Version, "-loader", loaderVersion}
+	if err := i.runInstaller(cfg, "fabric", installers[installer].URL, args...); err != nil {
+		return nil, err
+	}
+	if !fileExists(cfg.path(FabricServerJar)) {
+		return nil, fmt.Errorf("fabric installer left no server to start")
+	}
+
+	return &installedServer{Build: loaderVersion, Launch: jarLaunch(FabricServerJar)}, nil
+}
+
+type forgePromotions struct {
+	Promos map[string]string `json:"promos"`
+}
+
+// installForge runs the installer of the pinned Forge release, or else the recommended
+// one, or the latest for versions without a recommendation
+func (i *Installer) installForge(cfg *Config) (*installedServer, error) {
+	forge := cfg.LoaderVersion
+	if forge == "" {
+		var promotions forgePromotions
+		if err := i.getJSON("forge", i.ForgePromotionsURL, &promotions); err != nil {
+			return nil, err
+		}
+
+		forge = promotions.Promos[cfg.Version+"-recommended"]
+		if forge == "" {
+			forge = promotions.Promos[cfg.Version+"-latest"]
+		}
+		if forge == "" {
+			return nil, fmt.Errorf("no forge release for version %s", cfg.Version)
+		}
+	}
+
+	full := cfg.Version + "-" + forge
+	url := fmt.Sprintf("%s/net/minecraftforge/forge/%[2]s/forge-%[2]s-installer.jar", i.ForgeMavenURL, full)
+	if err := i.runInstaller(cfg, "forge", url, "--installServer"); err != nil {
+		return nil, err
+	}
+
+	// Since 1.17 the installer writes an argument file, before that a runnable jar
+	argsFile := path.Join("libraries/net/minecraftforge/forge", full, "unix_args.txt")
+	if fileExists(cfg.path(argsFile)) {
+		return &installedServer{Build: forge, Launch: []string{"@" + argsFile}}, nil
+	}
+	for _, jar := range []string{"forge-" + full + ".jar", "forge-" + full + "-universal.jar"} {
+		if fileExists(cfg.path(jar)) {
+			return &installedServer{Build: forge, Launch: jarLaunch(jar)}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("forge installer left no server to start")
+}
+
+type mavenMetadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+// installNeoForge runs the installer of the pinned NeoForge version, or else the newest
+// release for the Minecraft version, or its newest beta when there is no release yet
+func (i *Installer) installNeoForge(cfg *Config) (*installedServer, error) {
+	neoForge := cfg.LoaderVersion
+	if neoForge == "" {
+		body, err := i.fetch("neoforge", i.NeoForgeMavenURL+"/net/neoforged/neoforge/maven-metadata.xml")
+		if err != nil {
+			return nil, err
+		}
+		var metadata mavenMetadata
+		if err := xml.Unmarshal(body, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse neoforge versions: %w", err)
+		}
+
+		neoForge = neoForgeVersion(metadata.Versions, cfg.Version)
+		if neoForge == "" {
+			return nil, fmt.Errorf("no neoforge release for version %s", cfg.Version)
+		}
+	}
+
+	url := fmt.Sprintf("%s/net/neoforged/neoforge/%[2]s/neoforge-%[2]s-installer.jar", i.NeoForgeMavenURL, neoForge)
+	if err := i.runInstaller(cfg, "neoforge", url, "--installServer"); err != nil {
+		return nil, err
+	}
+
+	argsFile := path.Join("libraries/net/neoforged/neoforge", neoForge, "unix_args.txt")
+	if !fileExists(cfg.path(argsFile)) {
+		return nil, fmt.Errorf("neoforge installer left no server to start")
+	}
+
+	return &installedServer{Build: neoForge, Launch: []string{"@" + argsFile}}, nil
+}
+
+// neoForgeVersion picks the NeoForge version for a Minecraft version from the maven
+// listing, oldest first. NeoForge numbers drop Minecraft's leading 1, e.g. 21.1.77 is
+// for 1.21.1 and 21.0.167 for 1.21.
+func neoForgeVersion(versions []string, minecraft string) string {
+	parts := strings.Split(strings.TrimPrefix(minecraft, "1."), ".")
+	if len(parts) == 1 {
+		parts = append(parts, "0")
+	}
+	prefix := parts[0] + "." + parts[1] + "."
+
+	var release, beta string
+	for _, v := range versions {
+		if !strings.HasPrefix(v, prefix) {
+			continue
+		}
+		if strings.Contains(v, "-") {
+			beta = v
+		} else {
+			release = v
+		}
+	}
+
+	if release != "" {
+		return release
+	}
+	return beta
+}
+
+// runInstaller downloads the installer jar at url in a maven repository into StateDir,
+// verifying it against the repository's checksum, and runs it with args
+func (i *Installer) runInstaller(cfg *Config, project string, url string, args ...string) error {
+	sum, err := i.mavenChecksum(project, url)
+	if err != nil {
+		return err
+	}
+	jar := cfg.path(StateDir, project+"-installer.jar")
+	if err := downloadJar(i.HTTPClient, project, url, sum, jar); err != nil {
+		return err
+	}
+	defer os.Remove(jar)
+
+	fmt.Printf("Running the %s installer\n", project)
+	if err := i.RunInstaller(cfg.DataDir, jar, args...); err != nil {
+		return fmt.Errorf("failed to run %s installer: %w", project, err)
+	}
+
+	return nil
+}
+
+// mavenChecksum fetches the SHA-1 a maven repository publishes next to the artifact at
+// url, which some repositories follow with the file name
+func (i *Installer) mavenChecksum(project string, url string) (checksum, error) {
+	body, err := i.fetch(project, url+".sha1")
+	if err != nil {
+		return checksum{}, fmt.Errorf("failed to fetch %s checksum: %w", path.Base(url), err)
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return checksum{}, fmt.Errorf("%s checksum of %s is empty", project, path.Base(url))
+	}
+
+	return checksum{newHash: sha1.New, sum: fields[0]}, nil
+}
+
+// runJavaInstaller runs an installer jar with args, which installs into the working directory
+func runJavaInstaller(dir string, jar string, args ...string) error {
+	cmd := exec.Command("java", append([]string{"-jar", jar}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func (i *Installer) getJSON(project string, url string, v any) error {
+	body, err := i.fetch(project, url)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse %s api response: %w", project, err)
+	}
+
+	return nil
+}
+
+func (i *Installer) fetch(project string, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := i.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s api: %w", project, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s api returned status %d", project, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,326 @@
+package launcher
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const fakeVanillaJar = "fake vanilla jar"
+
+// serveMaven serves the artifacts under pattern, named after their file, and the .sha1
+// files maven repositories publish next to them
+func serveMaven(mux *http.ServeMux, pattern string, project string) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		name, isSum := strings.CutSuffix(path.Base(r.URL.Path), ".sha1")
+		artifact := project + " installer " + name
+		if !isSum {
+			fmt.Fprint(w, artifact)
+			return
+		}
+		fmt.Fprintf(w, "%x  %s\n", sha1.Sum([]byte(artifact)), name)
+	})
+}
+
+// newFakeInstaller returns an installer backed by a fake of every project's API. Its
+// RunInstaller writes what the Fabric, Forge and NeoForge installers leave behind.
+func newFakeInstaller(t *testing.T) *Installer {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	md5Sum := md5.Sum([]byte("fake purpur jar"))
+	mux.HandleFunc("/purpur/purpur/1.21.11", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"project": "purpur", "version": "1.21.11", "builds": {"latest": "2500", "all": ["2499", "2500"]}}`)
+	})
+	mux.HandleFunc("/purpur/purpur/1.21.11/2500", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"build": "2500", "md5": "%s"}`, hex.EncodeToString(md5Sum[:]))
+	})
+	mux.HandleFunc("/purpur/purpur/1.21.11/2500/download", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "fake purpur jar")
+	})
+
+	sha1Sum := sha1.Sum([]byte(fakeVanillaJar))
+	mux.HandleFunc("/mojang/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"versions": [{"id": "1.21.11", "url": "%[1]s/mojang/1.21.11.json"}, {"id": "1.2.5", "url": "%[1]s/mojang/1.2.5.json"}]}`, server.URL)
+	})
+	mux.HandleFunc("/mojang/1.21.11.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"downloads": {"server": {"sha1": "%s", "url": "%s/mojang/server.jar"}}}`, hex.EncodeToString(sha1Sum[:]), server.URL)
+	})
+	mux.HandleFunc("/mojang/1.2.5.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"downloads": {"client": {}}}`)
+	})
+	mux.HandleFunc("/mojang/server.jar", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fakeVanillaJar)
+	})
+
+	mux.HandleFunc("/fabric/versions/loader/1.21.11", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"loader": {"version": "0.18.0-beta.1", "stable": false}}, {"loader": {"version": "0.17.3", "stable": true}}]`)
+	})
+	mux.HandleFunc("/fabric/versions/installer", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"url": "%[1]s/fabric-maven/net/fabricmc/fabric-installer/1.1.0/fabric-installer-1.1.0.jar", "version": "1.1.0", "stable": true},
+			{"url": "%[1]s/fabric-maven/net/fabricmc/fabric-installer/1.0.3/fabric-installer-1.0.3.jar", "version": "1.0.3", "stable": true}]`, server.URL)
+	})
+	serveMaven(mux, "/fabric-maven/net/fabricmc/fabric-installer/", "fabric")
+
+	mux.HandleFunc("/forge/promotions_slim.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"promos": {"1.21.11-latest": "61.0.3", "1.20.1-recommended": "47.4.0", "1.20.1-latest": "47.4.9", "1.16.5-recommended": "36.2.34"}}`)
+	})
+	serveMaven(mux, "/forge/maven/net/minecraftforge/forge/", "forge")
+
+	mux.HandleFunc("/neoforge/net/neoforged/neoforge/maven-metadata.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<metadata><versioning><versions>
+			<version>21.1.1-beta</version><version>21.1.77</version><version>21.1.90</version>
+			<version>21.11.0-beta</version><version>21.11.3-beta</version>
+		</versions></versioning></metadata>`)
+	})
+	serveMaven(mux, "/neoforge/net/neoforged/neoforge/", "neoforge")
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &Installer{
+		HTTPClient:         server.Client(),
+		PurpurURL:          server.URL + "/purpur",
+		MojangURL:          server.URL + "/mojang/manifest.json",
+		FabricURL:          server.URL + "/fabric",
+		ForgePromotionsURL: server.URL + "/forge/promotions_slim.json",
+		ForgeMavenURL:      server.URL + "/forge/maven",
+		NeoForgeMavenURL:   server.URL + "/neoforge",
+		RunInstaller:       fakeForgeInstaller,
+	}
+}
+
+// fakeForgeInstaller writes the argument file modern installers leave behind, the
+// runnable jar of installers for Minecraft 1.16, or Fabric's server launcher
+func fakeForgeInstaller(dir string, jar string, args ...string) error {
+	installer, err := os.ReadFile(jar)
+	if err != nil {
+		return err
+	}
+
+	var argsFile string
+	switch string(installer) {
+	case "fabric installer fabric-installer-1.1.0.jar":
+		loader := args[slices.Index(args, "-loader")+1]
+		return os.WriteFile(filepath.Join(dir, FabricServerJar), []byte("fake fabric launcher "+loader), 0644)
+	case "forge installer forge-1.20.1-47.4.0-installer.jar":
+		argsFile = "libraries/net/minecraftforge/forge/1.20.1-47.4.0/unix_args.txt"
+	case "forge installer forge-1.16.5-36.2.34-installer.jar":
+		return os.WriteFile(filepath.Join(dir, "forge-1.16.5-36.2.34.jar"), []byte("forge"), 0644)
+	case "neoforge installer neoforge-21.1.90-installer.jar":
+		argsFile = "libraries/net/neoforged/neoforge/21.1.90/unix_args.txt"
+	default:
+		return fmt.Errorf("unexpected installer %q", installer)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(argsFile)), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, argsFile), []byte("-cp libraries"), 0644)
+}
+
+func installFor(t *testing.T, installer *Installer, serverType string, version string) (*Config, *installedServer, error) {
+	t.Helper()
+
+	cfg := &Config{DataDir: t.TempDir(), Type: serverType, Version: version}
+	os.MkdirAll(cfg.path(StateDir), 0755)
+
+	server, err := installer.install(cfg)
+	return cfg, server, err
+}
+
+func TestInstall_Purpur(t *testing.T) {
+	cfg, server, err := installFor(t, newFakeInstaller(t), TypePurpur, "1.21.11")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if server.Build != "2500" || !slices.Equal(server.Launch, []string{"-jar", ServerJar}) {
+		t.Errorf("install() = %+v, want build 2500 launched with -jar %s", server, ServerJar)
+	}
+	jar, _ := os.ReadFile(cfg.path(ServerJar))
+	if string(jar) != "fake purpur jar" {
+		t.Errorf("server.jar = %q, want the purpur jar", string(jar))
+	}
+}
+
+func TestDownloadJar_MD5Mismatch(t *testing.T) {
+	installer := newFakeInstaller(t)
+	dest := filepath.Join(t.TempDir(), ServerJar)
+
+	url := installer.PurpurURL + "/purpur/1.21.11/2500/download"
+	if err := downloadJar(installer.HTTPClient, "purpur", url, checksum{newHash: md5.New, sum: "0123"}, dest); err == nil {
+		t.Fatal("downloadJar() expected checksum error, got nil")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("server.jar was written despite the checksum mismatch")
+	}
+}
+
+func TestInstall_Vanilla(t *testing.T) {
+	cfg, server, err := installFor(t, newFakeInstaller(t), TypeVanilla, "1.21.11")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if !slices.Equal(server.Launch, []string{"-jar", ServerJar}) {
+		t.Errorf("Launch = %v, want [-jar %s]", server.Launch, ServerJar)
+	}
+	jar, _ := os.ReadFile(cfg.path(ServerJar))
+	if string(jar) != fakeVanillaJar {
+		t.Errorf("server.jar = %q, want the vanilla jar", string(jar))
+	}
+}
+
+func TestInstall_VanillaUnknownVersion(t *testing.T) {
+	installer := newFakeInstaller(t)
+
+	if _, _, err := installFor(t, installer, TypeVanilla, "1.99"); err == nil {
+		t.Error("install() expected error for a version missing from the manifest, got nil")
+	}
+	if _, _, err := installFor(t, installer, TypeVanilla, "1.2.5"); err == nil {
+		t.Error("install() expected error for a version without a server download, got nil")
+	}
+}
+
+func TestInstall_Fabric(t *testing.T) {
+	cfg, server, err := installFor(t, newFakeInstaller(t), TypeFabric, "1.21.11")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if server.Build != "0.17.3" {
+		t.Errorf("Build = %q, want the newest stable loader 0.17.3", server.Build)
+	}
+	if !slices.Equal(server.Launch, []string{"-jar", FabricServerJar}) {
+		t.Errorf("Launch = %v, want [-jar %s]", server.Launch, FabricServerJar)
+	}
+
+	// The launcher loads the vanilla jar from server.jar
+	jar, _ := os.ReadFile(cfg.path(ServerJar))
+	if string(jar) != fakeVanillaJar {
+		t.Errorf("server.jar = %q, want the vanilla jar", string(jar))
+	}
+	launcher, _ := os.ReadFile(cfg.path(FabricServerJar))
+	if string(launcher) != "fake fabric launcher 0.17.3" {
+		t.Errorf("%s = %q, want the fabric launcher", FabricServerJar, string(launcher))
+	}
+}
+
+func TestInstall_Forge(t *testing.T) {
+	cfg, server, err := installFor(t, newFakeInstaller(t), TypeForge, "1.20.1")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if server.Build != "47.4.0" {
+		t.Errorf("Build = %q, want the recommended 47.4.0", server.Build)
+	}
+	want := []string{"@libraries/net/minecraftforge/forge/1.20.1-47.4.0/unix_args.txt"}
+	if !slices.Equal(server.Launch, want) {
+		t.Errorf("Launch = %v, want %v", server.Launch, want)
+	}
+	if _, err := os.Stat(cfg.path(StateDir, "forge-installer.jar")); !os.IsNotExist(err) {
+		t.Error("forge installer was left behind")
+	}
+}
+
+func TestInstall_ForgeRunnableJar(t *testing.T) {
+	_, server, err := installFor(t, newFakeInstaller(t), TypeForge, "1.16.5")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if !slices.Equal(server.Launch, []string{"-jar", "forge-1.16.5-36.2.34.jar"}) {
+		t.Errorf("Launch = %v, want the jar the installer wrote", server.Launch)
+	}
+}
+
+func TestInstall_ForgeLatestWithoutRecommendation(t *testing.T) {
+	installer := newFakeInstaller(t)
+	var ran string
+	installer.RunInstaller = func(dir string, jar string, args ...string) error {
+		data, _ := os.ReadFile(jar)
+		ran = string(data)
+		return nil
+	}
+
+	// The fake installer leaves nothing to start
+	if _, _, err := installFor(t, installer, TypeForge, "1.21.11"); err == nil {
+		t.Error("install() expected error when the installer leaves no server, got nil")
+	}
+	if ran != "forge installer forge-1.21.11-61.0.3-installer.jar" {
+		t.Errorf("ran %q, want the latest forge installer", ran)
+	}
+}
+
+func TestInstall_InstallerChecksumMismatch(t *testing.T) {
+	installer := newFakeInstaller(t)
+	maven := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha1") {
+			fmt.Fprintf(w, "%x", sha1.Sum([]byte("forge installer")))
+			return
+		}
+		fmt.Fprint(w, "tampered installer")
+	}))
+	defer maven.Close()
+	installer.ForgeMavenURL = maven.URL
+	ran := false
+	installer.RunInstaller = func(dir string, jar string, args ...string) error {
+		ran = true
+		return nil
+	}
+
+	if _, _, err := installFor(t, installer, TypeForge, "1.20.1"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("install() error = %v, want a checksum mismatch", err)
+	}
+	if ran {
+		t.Error("ran an installer that failed its checksum")
+	}
+}
+
+func TestInstall_ForgeUnknownVersion(t *testing.T) {
+	if _, _, err := installFor(t, newFakeInstaller(t), TypeForge, "1.99"); err == nil {
+		t.Error("install() expected error for a version without forge, got nil")
+	}
+}
+
+func TestInstall_NeoForge(t *testing.T) {
+	_, server, err := installFor(t, newFakeInstaller(t), TypeNeoForge, "1.21.1")
+	if err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	want := []string{"@libraries/net/neoforged/neoforge/21.1.90/unix_args.txt"}
+	if server.Build != "21.1.90" || !slices.Equal(server.Launch, want) {
+		t.Errorf("install() = %+v, want build 21.1.90 launched with %v", server, want)
+	}
+}
+
+func TestNeoForgeVersion(t *testing.T) {
+	versions := []string{"20.2.86", "21.0.167", "21.1.1-beta", "21.1.77", "21.1.90", "21.11.0-beta", "21.11.3-beta"}
+
+	tests := map[string]string{
+		"1.21.1":  "21.1.90",
+		"1.21":    "21.0.167",
+		"1.20.2":  "20.2.86",
+		"1.21.11": "21.11.3-beta", // Only betas so far
+		"1.21.4":  "",
+	}
+	for minecraft, want := range tests {
+		if got := neoForgeVersion(versions, minecraft); got != want {
+			t.Errorf("neoForgeVersion(%q) = %q, want %q", minecraft, got, want)
+		}
+	}
+}
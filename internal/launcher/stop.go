@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/rcon"
+)
+
+const (
+	StopTimeout   = 90 * time.Second // Must stay below the pod's termination grace period
+	rconTimeout   = 10 * time.Second
+	exitPollDelay = time.Second
+)
+
+// Stop saves the world and shuts the server down over RCON, then waits for it to exit.
+// It runs as the container's preStop hook so evictions and scale-downs don't lose chunks.
+func Stop(getenv func(string) string) error {
+	port, password := getenv("RCON_PORT"), getenv("RCON_PASSWORD")
+	if port == "" || password == "" {
+		return fmt.Errorf("RCON_PORT and RCON_PASSWORD are required")
+	}
+	addr := net.JoinHostPort("127.0.0.1", port)
+
+	client, err := rcon.Dial(addr, password, rconTimeout)
+	if err != nil {
+		// Nothing listening: the server already stopped or never finished starting
+		return nil
+	}
+	defer client.Close()
+
+	fmt.Println("Saving world before shutdown...")
+	if _, err := client.Execute("save-all flush"); err != nil {
+		return fmt.Errorf("saving world: %w", err)
+	}
+
+	// The server may close the connection before answering, whether it
+	// really stopped is checked by waiting for it to exit
+	_, _ = client.Execute("stop")
+
+	return waitForExit(addr, StopTimeout)
+}
+
+// waitForExit waits until nothing listens on addr any more
+func waitForExit(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, exitPollDelay)
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+		time.Sleep(exitPollDelay)
+	}
+
+	return fmt.Errorf("server still running after %s", timeout)
+}
@@ -0,0 +1,41 @@
+package launcher
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStop_RequiresRconEnv(t *testing.T) {
+	if err := Stop(envFunc(map[string]string{"RCON_PORT": "25575"})); err == nil {
+		t.Error("Stop() expected error without RCON_PASSWORD, got nil")
+	}
+}
+
+func TestStop_NothingListening(t *testing.T) {
+	// Grab a free port, then close it so nothing listens there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	env := envFunc(map[string]string{"RCON_PORT": strconv.Itoa(port), "RCON_PASSWORD": "secret"})
+	if err := Stop(env); err != nil {
+		t.Errorf("Stop() error = %v, want nil for a server that already exited", err)
+	}
+}
+
+func TestWaitForExit_TimesOutWhileListening(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	if err := waitForExit(listener.Addr().String(), 10*time.Millisecond); err == nil {
+		t.Error("waitForExit() expected error while the port is still open, got nil")
+	}
+}
@@ -0,0 +1,23 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/baighasan/kubecraft/internal/world"
+)
+
+// PruneWorld prunes the world in dataDir as opts selects and writes the report to out
+// as JSON, for kubecraft server world prune to read back
+func PruneWorld(dataDir string, opts world.PruneOptions, out io.Writer) error {
+	report, err := world.Prune(dataDir, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(out).Encode(report); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/world"
+)
+
+func TestPruneWorld_WritesReport(t *testing.T) {
+	var out bytes.Buffer
+	if err := PruneWorld(t.TempDir(), world.PruneOptions{InhabitedBelow: 6000, Apply: true}, &out); err != nil {
+		t.Fatalf("PruneWorld() error = %v", err)
+	}
+
+	var report world.PruneReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, out.String())
+	}
+	if !report.Applied || len(report.Dimensions) != 0 {
+		t.Errorf("report = %+v, want an applied prune of an empty world", report)
+	}
+}
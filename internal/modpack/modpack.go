@@ -0,0 +1,279 @@
+// Package modpack reads Modrinth modpacks (.mrpack), zip archives holding a
+// modrinth.index.json that lists the mods to download, and override folders copied
+// over the server directory.
+package modpack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	IndexFile          = "modrinth.index.json"
+	OverridesDir       = "overrides"        // Copied for clients and servers
+	ServerOverridesDir = "server-overrides" // Copied for servers only, after overrides
+)
+
+// loaders maps the dependency naming a mod loader to the server type that runs it
+var loaders = map[string]string{
+	"fabric-loader": "fabric",
+	"forge":         "forge",
+	"neoforge":      "neoforge",
+}
+
+// Index is a pack's modrinth.index.json
+type Index struct {
+	FormatVersion int               `json:"formatVersion"`
+	Game          string            `json:"game"`
+	VersionID     string            `json:"versionId"`
+	Name          string            `json:"name"`
+	Files         []File            `json:"files"`
+	Dependencies  map[string]string `json:"dependencies"`
+}
+
+// File is a file the pack downloads, to Path relative to the server directory
+type File struct {
+	Path      string            `json:"path"`
+	Hashes    map[string]string `json:"hashes"`
+	Env       *Env              `json:"env,omitempty"`
+	Downloads []string          `json:"downloads"`
+	FileSize  int64             `json:"fileSize"`
+}
+
+// Env says whether a file is required, optional or unsupported on each side
+type Env struct {
+	Client string `json:"client"`
+	Server string `json:"server"`
+}
+
+// SHA512 is the hex digest the download must have
+func (f File) SHA512() string {
+	return f.Hashes["sha512"]
+}
+
+// Pack is an opened .mrpack archive
+type Pack struct {
+	Index
+	Size int64 // Bytes of the archive
+
+	zip *zip.ReadCloser
+}
+
+// Open reads and checks the index of the .mrpack archive at p
+func Open(p string) (*Pack, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.OpenReader(p)
+	if errors.Is(err, zip.ErrInsecurePath) {
+		zr.Close()
+		return nil, fmt.Errorf("%s has entries outside the archive", p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a modpack archive: %w", p, err)
+	}
+
+	pack := &Pack{Size: info.Size(), zip: zr}
+	if err := pack.readIndex(); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("invalid modpack %s: %w", p, err)
+	}
+
+	return pack, nil
+}
+
+// Close releases the archive
+func (p *Pack) Close() error {
+	return p.zip.Close()
+}
+
+func (p *Pack) readIndex() error {
+	file, err := p.zip.Open(IndexFile)
+	if err != nil {
+		return fmt.Errorf("no %s", IndexFile)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&p.Index); err != nil {
+		return fmt.Errorf("reading %s: %w", IndexFile, err)
+	}
+
+	if p.FormatVersion != 1 {
+		return fmt.Errorf("unsupported format version %d", p.FormatVersion)
+	}
+	if p.Game != "minecraft" {
+		return fmt.Errorf("pack is for %q, not minecraft", p.Game)
+	}
+	if p.Dependencies["minecraft"] == "" {
+		return fmt.Errorf("pack does not name its minecraft version")
+	}
+	if _, _, err := p.Loader(); err != nil {
+		return err
+	}
+
+	for _, f := range p.Files {
+		if !validPath(f.Path) {
+			return fmt.Errorf("file %q is outside the server directory", f.Path)
+		}
+		if f.SHA512() == "" {
+			return fmt.Errorf("file %s has no sha512 hash", f.Path)
+		}
+		if !slices.ContainsFunc(f.Downloads, func(url string) bool { return strings.HasPrefix(url, "https://") }) {
+			return fmt.Errorf("file %s has no https download", f.Path)
+		}
+	}
+
+	return nil
+}
+
+// validPath reports whether p is a clean path inside the server directory
+func validPath(p string) bool {
+	return p != "" && fs.ValidPath(p) && p != "." && !strings.Contains(p, "\\")
+}
+
+// Minecraft is the Minecraft version the pack is made for
+func (p *Pack) Minecraft() string {
+	return p.Dependencies["minecraft"]
+}
+
+// Loader is the server type running the pack and the loader version it pins, vanilla
+// with no version for packs without mods (datapacks, resource packs)
+func (p *Pack) Loader() (serverType string, version string, err error) {
+	serverType = "vanilla"
+	found := false
+	for dependency, v := range p.Dependencies {
+		if dependency == "minecraft" {
+			continue
+		}
+		if dependency == "quilt-loader" {
+			return "", "", fmt.Errorf("quilt packs are not supported, only fabric, forge and neoforge")
+		}
+
+		loaderType, ok := loaders[dependency]
+		if !ok {
+			return "", "", fmt.Errorf("unknown dependency %q", dependency)
+		}
+		if found {
+			return "", "", fmt.Errorf("pack needs more than one mod loader")
+		}
+		serverType, version, found = loaderType, v, true
+	}
+
+	return serverType, version, nil
+}
+
+// ServerFiles are the files a server needs, those not marked unsupported on servers
+func (p *Pack) ServerFiles() []File {
+	var files []File
+	for _, f := range p.Files {
+		if f.Env == nil || f.Env.Server != "unsupported" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// Title names the pack and its version, e.g. "Fabulously Optimized 6.4.0"
+func (p *Pack) Title() string {
+	return strings.TrimSpace(p.Name + " " + p.VersionID)
+}
+
+// Overrides are the files of the pack's override folders, by their path in the server
+// directory. Files in server-overrides win over those in overrides.
+func (p *Pack) Overrides() map[string]*zip.File {
+	overrides := map[string]*zip.File{}
+	for _, dir := range []string{OverridesDir, ServerOverridesDir} {
+		for _, f := range p.zip.File {
+			name, ok := strings.CutPrefix(f.Name, dir+"/")
+			if !ok || f.FileInfo().IsDir() || !validPath(name) {
+				continue
+			}
+			overrides[name] = f
+		}
+	}
+
+	return overrides
+}
+
+// Extract writes a file of the archive to dest, creating its directory
+func Extract(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// Installed records the pack installed on a server, so the next one can remove the
+// files it dropped
+type Installed struct {
+	SHA256 string `json:"sha256"` // Of the .mrpack archive
+	Title  string `json:"title"`
+	Files  []File `json:"files"`
+}
+
+// Changes are the differences between the server files of two packs
+type Changes struct {
+	Added   []string
+	Removed []string
+	Updated []string // Same path, other contents
+}
+
+// Diff compares the files of an installed pack with those of its replacement
+func Diff(installed []File, next []File) Changes {
+	before := map[string]string{}
+	for _, f := range installed {
+		before[f.Path] = f.SHA512()
+	}
+
+	var changes Changes
+	after := map[string]bool{}
+	for _, f := range next {
+		after[f.Path] = true
+		sum, ok := before[f.Path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, f.Path)
+		case sum != f.SHA512():
+			changes.Updated = append(changes.Updated, f.Path)
+		}
+	}
+	for _, f := range installed {
+		if !after[f.Path] {
+			changes.Removed = append(changes.Removed, f.Path)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Updated)
+
+	return changes
+}
@@ -0,0 +1,193 @@
+package modpack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writePack writes a .mrpack with index and the given archive entries
+func writePack(t *testing.T, index map[string]any, entries map[string]string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "pack.mrpack")
+	file, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+
+	if index != nil {
+		w, _ := zw.Create(IndexFile)
+		json.NewEncoder(w).Encode(index)
+	}
+	for name, content := range entries {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	return p
+}
+
+func packFile(p string, server string) map[string]any {
+	return map[string]any{
+		"path":      p,
+		"hashes":    map[string]string{"sha1": "00", "sha512": "ab"},
+		"env":       map[string]string{"client": "required", "server": server},
+		"downloads": []string{"https://cdn.modrinth.com/data/x/" + filepath.Base(p)},
+		"fileSize":  2,
+	}
+}
+
+func fabricIndex(files ...map[string]any) map[string]any {
+	return map[string]any{
+		"formatVersion": 1,
+		"game":          "minecraft",
+		"versionId":     "1.2.0",
+		"name":          "Test Pack",
+		"files":         files,
+		"dependencies":  map[string]string{"minecraft": "1.21.1", "fabric-loader": "0.16.9"},
+	}
+}
+
+func TestOpen(t *testing.T) {
+	p := writePack(t, fabricIndex(
+		packFile("mods/lithium.jar", "required"),
+		packFile("mods/sodium.jar", "unsupported"),
+		packFile("mods/modmenu.jar", "optional"),
+	), nil)
+
+	pack, err := Open(p)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer pack.Close()
+
+	if pack.Minecraft() != "1.21.1" {
+		t.Errorf("Minecraft() = %q, want 1.21.1", pack.Minecraft())
+	}
+	serverType, loader, err := pack.Loader()
+	if err != nil || serverType != "fabric" || loader != "0.16.9" {
+		t.Errorf("Loader() = %q, %q, %v, want fabric 0.16.9", serverType, loader, err)
+	}
+	if pack.Title() != "Test Pack 1.2.0" {
+		t.Errorf("Title() = %q, want %q", pack.Title(), "Test Pack 1.2.0")
+	}
+
+	var paths []string
+	for _, f := range pack.ServerFiles() {
+		paths = append(paths, f.Path)
+	}
+	if !slices.Equal(paths, []string{"mods/lithium.jar", "mods/modmenu.jar"}) {
+		t.Errorf("ServerFiles() = %v, want the files servers support", paths)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	withDependencies := func(deps map[string]string) map[string]any {
+		index := fabricIndex()
+		index["dependencies"] = deps
+		return index
+	}
+	withFile := func(f map[string]any) map[string]any {
+		return fabricIndex(f)
+	}
+
+	noSHA512 := packFile("mods/a.jar", "required")
+	noSHA512["hashes"] = map[string]string{"sha1": "00"}
+	plainHTTP := packFile("mods/a.jar", "required")
+	plainHTTP["downloads"] = []string{"http://example.com/a.jar"}
+
+	tests := map[string]map[string]any{
+		"no index":         nil,
+		"quilt":            withDependencies(map[string]string{"minecraft": "1.21.1", "quilt-loader": "0.26.0"}),
+		"two loaders":      withDependencies(map[string]string{"minecraft": "1.21.1", "forge": "52.0.1", "neoforge": "21.1.77"}),
+		"no minecraft":     withDependencies(map[string]string{"fabric-loader": "0.16.9"}),
+		"path escapes":     withFile(packFile("../mods/a.jar", "required")),
+		"absolute path":    withFile(packFile("/etc/a.jar", "required")),
+		"no sha512":        withFile(noSHA512),
+		"no https mirror":  withFile(plainHTTP),
+		"unknown loader":   withDependencies(map[string]string{"minecraft": "1.21.1", "liteloader": "1.0"}),
+		"format version 2": func() map[string]any { index := fabricIndex(); index["formatVersion"] = 2; return index }(),
+	}
+	for name, index := range tests {
+		t.Run(name, func(t *testing.T) {
+			pack, err := Open(writePack(t, index, nil))
+			if err == nil {
+				pack.Close()
+				t.Error("Open() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoader_Vanilla(t *testing.T) {
+	index := fabricIndex()
+	index["dependencies"] = map[string]string{"minecraft": "1.21.1"}
+
+	pack, err := Open(writePack(t, index, nil))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer pack.Close()
+
+	if serverType, loader, _ := pack.Loader(); serverType != "vanilla" || loader != "" {
+		t.Errorf("Loader() = %q, %q, want vanilla without a loader version", serverType, loader)
+	}
+}
+
+func TestOverrides_ServerOverridesWin(t *testing.T) {
+	pack, err := Open(writePack(t, fabricIndex(), map[string]string{
+		"overrides/config/a.toml":        "client",
+		"overrides/config/b.toml":        "shared",
+		"server-overrides/config/a.toml": "server",
+		"client-overrides/options.txt":   "client only",
+	}))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer pack.Close()
+
+	overrides := pack.Overrides()
+	if len(overrides) != 2 {
+		t.Fatalf("Overrides() has %d files, want 2: %v", len(overrides), overrides)
+	}
+
+	dir := t.TempDir()
+	for name, f := range overrides {
+		if err := Extract(f, filepath.Join(dir, name)); err != nil {
+			t.Fatalf("Extract(%s) error = %v", name, err)
+		}
+	}
+	a, _ := os.ReadFile(filepath.Join(dir, "config", "a.toml"))
+	if string(a) != "server" {
+		t.Errorf("config/a.toml = %q, want the server override", string(a))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	file := func(p string, sum string) File {
+		return File{Path: p, Hashes: map[string]string{"sha512": sum}}
+	}
+	installed := []File{file("mods/a.jar", "1"), file("mods/b.jar", "1"), file("mods/c.jar", "1")}
+	next := []File{file("mods/a.jar", "1"), file("mods/c.jar", "2"), file("mods/d.jar", "1")}
+
+	changes := Diff(installed, next)
+	if !slices.Equal(changes.Added, []string{"mods/d.jar"}) {
+		t.Errorf("Added = %v, want [mods/d.jar]", changes.Added)
+	}
+	if !slices.Equal(changes.Removed, []string{"mods/b.jar"}) {
+		t.Errorf("Removed = %v, want [mods/b.jar]", changes.Removed)
+	}
+	if !slices.Equal(changes.Updated, []string{"mods/c.jar"}) {
+		t.Errorf("Updated = %v, want [mods/c.jar]", changes.Updated)
+	}
+}
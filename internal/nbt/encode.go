@@ -0,0 +1,145 @@
+package nbt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Write encodes root as an uncompressed NBT document named name. It accepts the
+// same Go types Read produces; compound keys are written in sorted order.
+func Write(w io.Writer, name string, root Compound) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.byte(TagCompound)
+	e.string(name)
+	if err := e.compound(root); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w *bufio.Writer
+}
+
+func (e *encoder) byte(b byte) {
+	e.w.WriteByte(b)
+}
+
+func (e *encoder) uint(v uint64, size int) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	e.w.Write(buf[8-size:])
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)), 2)
+	e.w.WriteString(s)
+}
+
+func (e *encoder) compound(c Compound) error {
+	for _, key := range slices.Sorted(maps.Keys(c)) {
+		tag, err := tagOf(c[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		e.byte(tag)
+		e.string(key)
+		if err := e.payload(c[key]); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	e.byte(TagEnd)
+	return nil
+}
+
+func (e *encoder) payload(v any) error {
+	switch v := v.(type) {
+	case int8:
+		e.byte(byte(v))
+	case int16:
+		e.uint(uint64(v), 2)
+	case int32:
+		e.uint(uint64(v), 4)
+	case int64:
+		e.uint(uint64(v), 8)
+	case float32:
+		e.uint(uint64(math.Float32bits(v)), 4)
+	case float64:
+		e.uint(math.Float64bits(v), 8)
+	case []byte:
+		e.uint(uint64(len(v)), 4)
+		e.w.Write(v)
+	case string:
+		e.string(v)
+	case List:
+		tag := TagEnd
+		if len(v) > 0 {
+			var err error
+			if tag, err = tagOf(v[0]); err != nil {
+				return err
+			}
+		}
+		e.byte(tag)
+		e.uint(uint64(len(v)), 4)
+		for i, elem := range v {
+			if t, _ := tagOf(elem); t != tag {
+				return fmt.Errorf("[%d]: list mixes tag types %d and %d", i, tag, t)
+			}
+			if err := e.payload(elem); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case Compound:
+		return e.compound(v)
+	case []int32:
+		e.uint(uint64(len(v)), 4)
+		for _, n := range v {
+			e.uint(uint64(n), 4)
+		}
+	case []int64:
+		e.uint(uint64(len(v)), 4)
+		for _, n := range v {
+			e.uint(uint64(n), 8)
+		}
+	default:
+		return fmt.Errorf("cannot encode %T", v)
+	}
+	return nil
+}
+
+func tagOf(v any) (byte, error) {
+	switch v.(type) {
+	case int8:
+		return TagByte, nil
+	case int16:
+		return TagShort, nil
+	case int32:
+		return TagInt, nil
+	case int64:
+		return TagLong, nil
+	case float32:
+		return TagFloat, nil
+	case float64:
+		return TagDouble, nil
+	case []byte:
+		return TagByteArray, nil
+	case string:
+		return TagString, nil
+	case List:
+		return TagList, nil
+	case Compound:
+		return TagCompound, nil
+	case []int32:
+		return TagIntArray, nil
+	case []int64:
+		return TagLongArray, nil
+	}
+	return 0, fmt.Errorf("cannot encode %T", v)
+}
@@ -0,0 +1,349 @@
+// Package nbt decodes Minecraft's Named Binary Tag format, used by level.dat,
+// player data and the chunks in region files.
+//
+// Tags decode to Go values: byte, short, int and long to int8, int16, int32 and
+// int64, float and double to float32 and float64, byte/int/long arrays to []byte,
+// []int32 and []int64, strings to string, lists to List and compounds to Compound.
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Tag types
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+const (
+	// maxDepth is the nesting limit Minecraft itself enforces
+	maxDepth = 512
+	// maxArrayBytes bounds a single array or string, so a corrupt length can't
+	// allocate the whole memory
+	maxArrayBytes = 64 << 20
+)
+
+// Compound is a decoded compound tag
+type Compound map[string]any
+
+// List is a decoded list tag
+type List []any
+
+// Compound returns the compound tag key, or nil
+func (c Compound) Compound(key string) Compound {
+	v, _ := c[key].(Compound)
+	return v
+}
+
+// List returns the list tag key, or nil
+func (c Compound) List(key string) List {
+	v, _ := c[key].(List)
+	return v
+}
+
+// String returns the string tag key, or ""
+func (c Compound) String(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Int returns the integer tag key widened to int64, and whether it is an integer
+func (c Compound) Int(key string) (int64, bool) {
+	switch v := c[key].(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Float returns the floating point tag key widened to float64, and whether it is one
+func (c Compound) Float(key string) (float64, bool) {
+	switch v := c[key].(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Bool returns the byte tag key as a boolean, as Minecraft stores flags
+func (c Compound) Bool(key string) bool {
+	v, _ := c.Int(key)
+	return v != 0
+}
+
+// Read decodes an uncompressed NBT document, whose root must be a compound, and
+// returns the root's name and value
+func Read(r io.Reader) (string, Compound, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	tag, err := d.byte()
+	if err != nil {
+		return "", nil, fmt.Errorf("reading root tag: %w", err)
+	}
+	if tag != TagCompound {
+		return "", nil, fmt.Errorf("root tag is type %d, not a compound", tag)
+	}
+	name, err := d.string()
+	if err != nil {
+		return "", nil, fmt.Errorf("reading root name: %w", err)
+	}
+	root, err := d.compound(1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, root, nil
+}
+
+// ReadCompressed decodes an NBT document that may be gzip or zlib compressed, as
+// level.dat and player data are, or not compressed at all
+func ReadCompressed(r io.Reader) (string, Compound, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading nbt: %w", err)
+	}
+
+	switch {
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading gzip nbt: %w", err)
+		}
+		defer zr.Close()
+		return Read(zr)
+	case magic[0] == 0x78:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading zlib nbt: %w", err)
+		}
+		defer zr.Close()
+		return Read(zr)
+	default:
+		return Read(br)
+	}
+}
+
+// Unmarshal decodes an uncompressed NBT document held in memory
+func Unmarshal(data []byte) (Compound, error) {
+	_, root, err := Read(bytes.NewReader(data))
+	return root, err
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return d.buf[:n], nil
+}
+
+func (d *decoder) byte() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) short() (int16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *decoder) int() (int32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (d *decoder) long() (int64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// length reads an array length and checks it against the size of its elements
+func (d *decoder) length(elemSize int) (int, error) {
+	n, err := d.int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || int64(n)*int64(elemSize) > maxArrayBytes {
+		return 0, fmt.Errorf("invalid length %d", n)
+	}
+	return int(n), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.short()
+	if err != nil {
+		return "", err
+	}
+	// Lengths are unsigned
+	buf := make([]byte, uint16(n))
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(buf), nil
+}
+
+func (d *decoder) compound(depth int) (Compound, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("nbt nested deeper than %d", maxDepth)
+	}
+
+	c := Compound{}
+	for {
+		tag, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if tag == TagEnd {
+			return c, nil
+		}
+
+		name, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.payload(tag, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		c[name] = v
+	}
+}
+
+func (d *decoder) list(depth int) (List, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("nbt nested deeper than %d", maxDepth)
+	}
+
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	// Every element takes at least a byte, which bounds the length
+	n, err := d.length(1)
+	if err != nil {
+		return nil, err
+	}
+	if tag == TagEnd && n > 0 {
+		return nil, fmt.Errorf("list of %d end tags", n)
+	}
+
+	l := make(List, 0, min(n, 1024))
+	for i := range n {
+		v, err := d.payload(tag, depth)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		l = append(l, v)
+	}
+	return l, nil
+}
+
+func (d *decoder) payload(tag byte, depth int) (any, error) {
+	switch tag {
+	case TagByte:
+		b, err := d.byte()
+		return int8(b), err
+	case TagShort:
+		return d.short()
+	case TagInt:
+		return d.int()
+	case TagLong:
+		return d.long()
+	case TagFloat:
+		v, err := d.int()
+		return math.Float32frombits(uint32(v)), err
+	case TagDouble:
+		v, err := d.long()
+		return math.Float64frombits(uint64(v)), err
+	case TagByteArray:
+		n, err := d.length(1)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(d.r, buf); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return buf, nil
+	case TagString:
+		return d.string()
+	case TagList:
+		return d.list(depth + 1)
+	case TagCompound:
+		return d.compound(depth + 1)
+	case TagIntArray:
+		n, err := d.length(4)
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int32, n)
+		for i := range a {
+			if a[i], err = d.int(); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case TagLongArray:
+		n, err := d.length(8)
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int64, n)
+		for i := range a {
+			if a[i], err = d.long(); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown tag type %d", tag)
+	}
+}
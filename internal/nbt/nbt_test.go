@@ -0,0 +1,113 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDocument() Compound {
+	return Compound{
+		"Data": Compound{
+			"LevelName":   "My World",
+			"DataVersion": int32(4671),
+			"RandomSeed":  int64(-42),
+			"hardcore":    int8(1),
+			"Difficulty":  int16(2),
+			"BorderSize":  float64(59999968),
+			"SpawnAngle":  float32(90),
+			"Version":     Compound{"Name": "1.21.11", "Snapshot": int8(0)},
+			"Pos":         List{float64(1), float64(64), float64(-3)},
+			"Empty":       List{},
+			"Bytes":       []byte{1, 2, 3},
+			"UUID":        []int32{1, -2, 3, -4},
+			"Heightmap":   []int64{1 << 40},
+		},
+	}
+}
+
+func TestRead_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "root", testDocument()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	name, root, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if name != "root" {
+		t.Errorf("root name = %q, want root", name)
+	}
+	if !reflect.DeepEqual(root, testDocument()) {
+		t.Errorf("Read() = %#v\nwant %#v", root, testDocument())
+	}
+
+	data := root.Compound("Data")
+	if v, ok := data.Int("DataVersion"); !ok || v != 4671 {
+		t.Errorf("Int(DataVersion) = %d, %t, want 4671", v, ok)
+	}
+	if !data.Bool("hardcore") {
+		t.Error("Bool(hardcore) = false, want true")
+	}
+	if data.Compound("Version").String("Name") != "1.21.11" {
+		t.Errorf("Version.Name = %q, want 1.21.11", data.Compound("Version").String("Name"))
+	}
+	if data.Compound("Missing") != nil || data.String("Missing") != "" {
+		t.Error("missing keys should read as zero values")
+	}
+}
+
+func TestReadCompressed_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := Write(zw, "", testDocument()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	zw.Close()
+
+	_, root, err := ReadCompressed(&buf)
+	if err != nil {
+		t.Fatalf("ReadCompressed() error = %v", err)
+	}
+	if root.Compound("Data").String("LevelName") != "My World" {
+		t.Errorf("LevelName = %q, want My World", root.Compound("Data").String("LevelName"))
+	}
+}
+
+func TestRead_RejectsCorruptData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "", testDocument()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	valid := buf.Bytes()
+
+	tests := map[string][]byte{
+		"truncated":         valid[:len(valid)/2],
+		"root not compound": {TagString, 0, 0, 0, 0},
+		"unknown tag":       {TagCompound, 0, 0, 99, 0, 1, 'x'},
+		"negative length":   {TagCompound, 0, 0, TagByteArray, 0, 1, 'x', 0xff, 0xff, 0xff, 0xff},
+		"huge length":       {TagCompound, 0, 0, TagLongArray, 0, 1, 'x', 0x7f, 0xff, 0xff, 0xff},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Read(bytes.NewReader(data)); err == nil {
+				t.Error("Read() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRead_DepthLimit(t *testing.T) {
+	// A compound holding a list of lists nested past the limit
+	data := []byte{TagCompound, 0, 0, TagList, 0, 1, 'x'}
+	data = append(data, bytes.Repeat([]byte{TagList, 0, 0, 0, 1}, maxDepth+1)...)
+
+	_, _, err := Read(bytes.NewReader(data))
+	if err == nil || !strings.Contains(err.Error(), "deeper") {
+		t.Errorf("Read() error = %v, want the depth limit", err)
+	}
+}
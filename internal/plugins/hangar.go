@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const HangarAPIURL = "https://hangar.papermc.io/api/v1"
+
+// hangarPlatform is the platform Paper and Purpur plugins are published for on Hangar
+const hangarPlatform = "PAPER"
+
+// Hangar resolves plugins on PaperMC's Hangar API
+type Hangar struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewHangar returns a client for the public Hangar API
+func NewHangar() *Hangar {
+	return &Hangar{
+		BaseURL:    HangarAPIURL,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (h *Hangar) Name() string {
+	return "hangar"
+}
+
+type hangarVersions struct {
+	Result []struct {
+		Name    string `json:"name"`
+		Channel struct {
+			Name string `json:"name"`
+		} `json:"channel"`
+		Downloads map[string]struct {
+			FileInfo *struct {
+				Name       string `json:"name"`
+				SHA256Hash string `json:"sha256Hash"`
+			} `json:"fileInfo"`
+			DownloadURL string `json:"downloadUrl"`
+			ExternalURL string `json:"externalUrl"`
+		} `json:"downloads"`
+	} `json:"result"`
+}
+
+// Resolve picks the newest version on the Release channel supporting the server's
+// version, the newest on any channel if none is a release. Versions only linking to an
+// external download are skipped, Hangar has no checksum for them.
+func (h *Hangar) Resolve(ctx context.Context, slug string, target Target) (*Plugin, error) {
+	// Hangar lists Paper plugins only, which Purpur runs too
+	if _, ok := modrinthLoaders[target.ServerType]; !ok {
+		return nil, fmt.Errorf("%s servers do not run plugins", target.ServerType)
+	}
+
+	query := url.Values{}
+	query.Set("platform", hangarPlatform)
+	query.Set("platformVersion", target.Version)
+	query.Set("limit", "25")
+	endpoint := fmt.Sprintf("%s/projects/%s/versions?%s", h.BaseURL, url.PathEscape(slug), query.Encode())
+
+	var versions hangarVersions
+	if err := getJSON(ctx, h.HTTPClient, endpoint, &versions); err != nil {
+		return nil, fmt.Errorf("%s: %w", slug, err)
+	}
+
+	// Versions are listed newest first
+	var picked *Plugin
+	for _, v := range versions.Result {
+		dl, ok := v.Downloads[hangarPlatform]
+		if !ok || dl.FileInfo == nil || dl.DownloadURL == "" {
+			continue
+		}
+
+		p := &Plugin{
+			Slug:    slug,
+			Source:  h.Name(),
+			Version: v.Name,
+			File:    dl.FileInfo.Name,
+			URL:     dl.DownloadURL,
+			Hash:    "sha256:" + dl.FileInfo.SHA256Hash,
+		}
+		if v.Channel.Name == "Release" {
+			return p, nil
+		}
+		if picked == nil {
+			picked = p
+		}
+	}
+	if picked == nil {
+		return nil, fmt.Errorf("%s has no hosted build for %s %s: %w", slug, target.ServerType, target.Version, ErrNotFound)
+	}
+
+	return picked, nil
+}
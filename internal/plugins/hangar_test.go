@@ -0,0 +1,76 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newFakeHangar(t *testing.T) *Hangar {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects/ViaVersion/versions", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("platform") != "PAPER" || r.URL.Query().Get("platformVersion") != "1.21.4" {
+			fmt.Fprint(w, `{"result": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"result": [
+			{"name": "5.3.0-SNAPSHOT", "channel": {"name": "Snapshot"}, "downloads": {"PAPER": {"fileInfo": {"name": "ViaVersion-5.3.0-SNAPSHOT.jar", "sha256Hash": "aa"}, "downloadUrl": "https://hangarcdn.papermc.io/snapshot.jar"}}},
+			{"name": "5.2.1", "channel": {"name": "Release"}, "downloads": {"PAPER": {"fileInfo": null, "externalUrl": "https://github.com/ViaVersion/ViaVersion/releases"}}},
+			{"name": "5.2.0", "channel": {"name": "Release"}, "downloads": {"PAPER": {"fileInfo": {"name": "ViaVersion-5.2.0.jar", "sha256Hash": "bb"}, "downloadUrl": "https://hangarcdn.papermc.io/release.jar"}}}
+		]}`)
+	})
+	mux.HandleFunc("/projects/Snapshots/versions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result": [
+			{"name": "1.0-beta", "channel": {"name": "Beta"}, "downloads": {"PAPER": {"fileInfo": {"name": "Snapshots-1.0-beta.jar", "sha256Hash": "cc"}, "downloadUrl": "https://hangarcdn.papermc.io/beta.jar"}}}
+		]}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &Hangar{BaseURL: server.URL, HTTPClient: server.Client()}
+}
+
+func TestHangar_Resolve(t *testing.T) {
+	hangar := newFakeHangar(t)
+	target := Target{ServerType: "purpur", Version: "1.21.4"}
+
+	// The newest release is only linked externally, so it cannot be verified
+	p, err := hangar.Resolve(context.Background(), "ViaVersion", target)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := Plugin{
+		Slug:    "ViaVersion",
+		Source:  "hangar",
+		Version: "5.2.0",
+		File:    "ViaVersion-5.2.0.jar",
+		URL:     "https://hangarcdn.papermc.io/release.jar",
+		Hash:    "sha256:bb",
+	}
+	if *p != want {
+		t.Errorf("Resolve() = %+v, want %+v", *p, want)
+	}
+
+	// Without a release the newest build on any channel
+	p, err = hangar.Resolve(context.Background(), "Snapshots", target)
+	if err != nil || p.Version != "1.0-beta" {
+		t.Errorf("Resolve(Snapshots) = %+v, %v, want 1.0-beta", p, err)
+	}
+}
+
+func TestHangar_ResolveNotFound(t *testing.T) {
+	hangar := newFakeHangar(t)
+
+	if _, err := hangar.Resolve(context.Background(), "ViaVersion", Target{ServerType: "paper", Version: "1.8.8"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() for an unsupported version error = %v, want ErrNotFound", err)
+	}
+	if _, err := hangar.Resolve(context.Background(), "ghost", Target{ServerType: "paper", Version: "1.21.4"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() for an unknown project error = %v, want ErrNotFound", err)
+	}
+}
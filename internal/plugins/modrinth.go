@@ -0,0 +1,132 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const ModrinthAPIURL = "https://api.modrinth.com/v2"
+
+// modrinthLoaders are the loaders whose plugins a server type runs, Purpur also runs
+// Paper plugins and both run Spigot and Bukkit ones
+var modrinthLoaders = map[string][]string{
+	"paper":  {"paper", "spigot", "bukkit"},
+	"purpur": {"purpur", "paper", "spigot", "bukkit"},
+}
+
+// Modrinth resolves plugins on the Modrinth API
+type Modrinth struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewModrinth returns a client for the public Modrinth API
+func NewModrinth() *Modrinth {
+	return &Modrinth{
+		BaseURL:    ModrinthAPIURL,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (m *Modrinth) Name() string {
+	return "modrinth"
+}
+
+type modrinthVersion struct {
+	VersionNumber string `json:"version_number"`
+	VersionType   string `json:"version_type"` // release, beta or alpha
+	Files         []struct {
+		URL      string            `json:"url"`
+		Filename string            `json:"filename"`
+		Primary  bool              `json:"primary"`
+		Hashes   map[string]string `json:"hashes"`
+	} `json:"files"`
+}
+
+// Resolve picks the newest release of the project for the server's loaders and version,
+// the newest beta or alpha if it has no release
+func (m *Modrinth) Resolve(ctx context.Context, slug string, target Target) (*Plugin, error) {
+	loaders, ok := modrinthLoaders[target.ServerType]
+	if !ok {
+		return nil, fmt.Errorf("%s servers do not run plugins", target.ServerType)
+	}
+	loadersJSON, _ := json.Marshal(loaders)
+	versionsJSON, _ := json.Marshal([]string{target.Version})
+
+	query := url.Values{}
+	query.Set("loaders", string(loadersJSON))
+	query.Set("game_versions", string(versionsJSON))
+	endpoint := fmt.Sprintf("%s/project/%s/version?%s", m.BaseURL, url.PathEscape(slug), query.Encode())
+
+	var versions []modrinthVersion
+	if err := getJSON(ctx, m.HTTPClient, endpoint, &versions); err != nil {
+		return nil, fmt.Errorf("%s: %w", slug, err)
+	}
+
+	// Versions are listed newest first
+	var picked *modrinthVersion
+	for i, v := range versions {
+		if v.VersionType == "release" {
+			picked = &versions[i]
+			break
+		}
+		if picked == nil {
+			picked = &versions[i]
+		}
+	}
+	if picked == nil {
+		return nil, fmt.Errorf("%s has no build for %s %s: %w", slug, target.ServerType, target.Version, ErrNotFound)
+	}
+
+	if len(picked.Files) == 0 {
+		return nil, fmt.Errorf("%s %s has no files", slug, picked.VersionNumber)
+	}
+	file := picked.Files[0]
+	for _, f := range picked.Files {
+		if f.Primary {
+			file = f
+			break
+		}
+	}
+
+	return &Plugin{
+		Slug:    slug,
+		Source:  m.Name(),
+		Version: picked.VersionNumber,
+		File:    file.Filename,
+		URL:     file.URL,
+		Hash:    "sha512:" + file.Hashes["sha512"],
+	}, nil
+}
+
+// getJSON decodes the response of a GET request into v, a 404 is ErrNotFound
+func getJSON(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newFakeModrinth(t *testing.T) *Modrinth {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/project/essentialsx/version", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("game_versions") != `["1.21.4"]` {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		if r.URL.Query().Get("loaders") != `["paper","spigot","bukkit"]` {
+			t.Errorf("loaders = %s, want paper, spigot and bukkit", r.URL.Query().Get("loaders"))
+		}
+		fmt.Fprintf(w, `[
+			{"version_number": "2.22.0-dev", "version_type": "beta", "files": [{"url": "https://cdn.modrinth.com/dev.jar", "filename": "EssentialsX-2.22.0-dev.jar", "primary": true, "hashes": {"sha512": "aa"}}]},
+			{"version_number": "2.21.0", "version_type": "release", "files": [
+				{"url": "https://cdn.modrinth.com/sources.jar", "filename": "EssentialsX-2.21.0-sources.jar", "primary": false, "hashes": {"sha512": "bb"}},
+				{"url": "https://cdn.modrinth.com/release.jar", "filename": "EssentialsX-2.21.0.jar", "primary": true, "hashes": {"sha512": "cc"}}
+			]}
+		]`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &Modrinth{BaseURL: server.URL, HTTPClient: server.Client()}
+}
+
+func TestModrinth_Resolve(t *testing.T) {
+	p, err := newFakeModrinth(t).Resolve(context.Background(), "essentialsx", Target{ServerType: "paper", Version: "1.21.4"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	want := Plugin{
+		Slug:    "essentialsx",
+		Source:  "modrinth",
+		Version: "2.21.0",
+		File:    "EssentialsX-2.21.0.jar",
+		URL:     "https://cdn.modrinth.com/release.jar",
+		Hash:    "sha512:cc",
+	}
+	if *p != want {
+		t.Errorf("Resolve() = %+v, want the primary file of the newest release %+v", *p, want)
+	}
+}
+
+func TestModrinth_ResolveNotFound(t *testing.T) {
+	modrinth := newFakeModrinth(t)
+
+	if _, err := modrinth.Resolve(context.Background(), "essentialsx", Target{ServerType: "paper", Version: "1.8.8"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() for an unsupported version error = %v, want ErrNotFound", err)
+	}
+	if _, err := modrinth.Resolve(context.Background(), "ghost", Target{ServerType: "paper", Version: "1.21.4"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() for an unknown project error = %v, want ErrNotFound", err)
+	}
+	if _, err := modrinth.Resolve(context.Background(), "essentialsx", Target{ServerType: "fabric", Version: "1.21.4"}); err == nil {
+		t.Error("Resolve() expected error for a fabric server, got nil")
+	}
+}
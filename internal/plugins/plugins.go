@@ -0,0 +1,194 @@
+// Package plugins resolves Paper plugins on Modrinth and Hangar and pins the builds in
+// a lockfile, which the launcher installs into the server's plugins directory.
+package plugins
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	LockFile  = "kubecraft-plugins.lock"
+	UserAgent = "kubecraft/1.0.0 (baig.hasan@outlook.com)"
+)
+
+// ErrNotFound is returned by a Source without a build of the plugin for the server
+var ErrNotFound = errors.New("plugin not found")
+
+// slugPattern matches the project slugs of both registries, and keeps them safe to put
+// in a URL path
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Plugin is a pinned plugin build
+type Plugin struct {
+	Slug    string `json:"slug"`
+	Source  string `json:"source"` // Name of the Source it was resolved on
+	Version string `json:"version"`
+	File    string `json:"file"` // Jar name in the plugins directory
+	URL     string `json:"url"`
+	Hash    string `json:"hash"` // <algorithm>:<hex digest>, sha512 or sha256
+}
+
+// Checksum splits Hash into its algorithm and hex digest
+func (p Plugin) Checksum() (algorithm string, sum string) {
+	algorithm, sum, _ = strings.Cut(p.Hash, ":")
+	return algorithm, sum
+}
+
+func (p Plugin) validate() error {
+	if !slugPattern.MatchString(p.Slug) {
+		return fmt.Errorf("invalid slug %q", p.Slug)
+	}
+	if strings.ContainsAny(p.File, `/\`) || !strings.HasSuffix(p.File, ".jar") || strings.HasPrefix(p.File, ".") {
+		return fmt.Errorf("%s: file %q is not a jar name", p.Slug, p.File)
+	}
+	if !strings.HasPrefix(p.URL, "https://") {
+		return fmt.Errorf("%s: download %q is not https", p.Slug, p.URL)
+	}
+
+	algorithm, sum := p.Checksum()
+	if algorithm != "sha512" && algorithm != "sha256" {
+		return fmt.Errorf("%s: unsupported hash %q", p.Slug, algorithm)
+	}
+	if _, err := hex.DecodeString(sum); err != nil || sum == "" {
+		return fmt.Errorf("%s: invalid %s digest", p.Slug, algorithm)
+	}
+
+	return nil
+}
+
+// Lock is the content of kubecraft-plugins.lock
+type Lock struct {
+	Plugins []Plugin `json:"plugins"`
+}
+
+// ParseLock reads and checks a lockfile, an empty one has no plugins
+func ParseLock(data []byte) (*Lock, error) {
+	lock := &Lock{}
+	if len(data) == 0 {
+		return lock, nil
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", LockFile, err)
+	}
+
+	files := map[string]bool{}
+	for i, p := range lock.Plugins {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", LockFile, err)
+		}
+		if slices.ContainsFunc(lock.Plugins[:i], func(other Plugin) bool { return other.Slug == p.Slug }) {
+			return nil, fmt.Errorf("invalid %s: %s is listed twice", LockFile, p.Slug)
+		}
+		if files[p.File] {
+			return nil, fmt.Errorf("invalid %s: two plugins install %s", LockFile, p.File)
+		}
+		files[p.File] = true
+	}
+
+	return lock, nil
+}
+
+// Marshal renders the lockfile, plugins sorted by slug so diffs stay small
+func (l *Lock) Marshal() ([]byte, error) {
+	slices.SortFunc(l.Plugins, func(a, b Plugin) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// Get returns the pinned build of slug, nil if the plugin is not in the lock
+func (l *Lock) Get(slug string) *Plugin {
+	for i := range l.Plugins {
+		if strings.EqualFold(l.Plugins[i].Slug, slug) {
+			return &l.Plugins[i]
+		}
+	}
+
+	return nil
+}
+
+// Set pins p, replacing the build of the same slug
+func (l *Lock) Set(p Plugin) {
+	if existing := l.Get(p.Slug); existing != nil {
+		*existing = p
+		return
+	}
+
+	l.Plugins = append(l.Plugins, p)
+}
+
+// Remove unpins slug, reporting whether it was in the lock
+func (l *Lock) Remove(slug string) bool {
+	n := len(l.Plugins)
+	l.Plugins = slices.DeleteFunc(l.Plugins, func(p Plugin) bool {
+		return strings.EqualFold(p.Slug, slug)
+	})
+
+	return len(l.Plugins) != n
+}
+
+// Target is the server a plugin build has to run on
+type Target struct {
+	ServerType string // paper or purpur
+	Version    string // Minecraft version
+}
+
+// Source is a plugin registry. The HTTP clients of Modrinth and Hangar implement it,
+// tests a fake one.
+type Source interface {
+	Name() string
+	// Resolve finds the newest build of slug for target, preferring releases. It returns
+	// an error wrapping ErrNotFound if the registry has no such build.
+	Resolve(ctx context.Context, slug string, target Target) (*Plugin, error)
+}
+
+// DefaultSources are the public registries, searched in this order
+func DefaultSources() []Source {
+	return []Source{NewModrinth(), NewHangar()}
+}
+
+// Resolve finds slug on the first source that has a build for target. A slug of the
+// form <source>:<slug>, e.g. hangar:ViaVersion, only searches that source.
+func Resolve(ctx context.Context, sources []Source, slug string, target Target) (*Plugin, error) {
+	if name, project, ok := strings.Cut(slug, ":"); ok {
+		i := slices.IndexFunc(sources, func(s Source) bool { return s.Name() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown plugin source %q", name)
+		}
+		sources, slug = sources[i:i+1], project
+	}
+	if !slugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("invalid plugin slug %q", slug)
+	}
+
+	var errs []error
+	for _, source := range sources {
+		p, err := source.Resolve(ctx, slug, target)
+		if err == nil {
+			if err := p.validate(); err != nil {
+				return nil, fmt.Errorf("%s: %w", source.Name(), err)
+			}
+			return p, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", source.Name(), err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+	}
+
+	return nil, errors.Join(errs...)
+}
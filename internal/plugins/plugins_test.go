@@ -0,0 +1,162 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var sha512Hash = "sha512:" + strings.Repeat("ab", 64)
+
+func testPlugin(slug string) Plugin {
+	return Plugin{
+		Slug:    slug,
+		Source:  "modrinth",
+		Version: "1.0.0",
+		File:    slug + "-1.0.0.jar",
+		URL:     "https://cdn.modrinth.com/data/" + slug + ".jar",
+		Hash:    sha512Hash,
+	}
+}
+
+// fakeSource is a registry holding builds by slug
+type fakeSource struct {
+	name    string
+	plugins map[string]Plugin
+	err     error
+}
+
+func (f *fakeSource) Name() string {
+	return f.name
+}
+
+func (f *fakeSource) Resolve(ctx context.Context, slug string, target Target) (*Plugin, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.plugins[slug]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", slug, ErrNotFound)
+	}
+	p.Source = f.name
+	return &p, nil
+}
+
+func TestLock_RoundTrip(t *testing.T) {
+	lock := &Lock{}
+	lock.Set(testPlugin("viaversion"))
+	lock.Set(testPlugin("essentialsx"))
+
+	updated := testPlugin("viaversion")
+	updated.Version = "2.0.0"
+	lock.Set(updated)
+
+	data, err := lock.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	parsed, err := ParseLock(data)
+	if err != nil {
+		t.Fatalf("ParseLock() error = %v", err)
+	}
+
+	if len(parsed.Plugins) != 2 || parsed.Plugins[0].Slug != "essentialsx" {
+		t.Fatalf("Plugins = %+v, want essentialsx and viaversion sorted", parsed.Plugins)
+	}
+	if got := parsed.Get("ViaVersion"); got == nil || got.Version != "2.0.0" {
+		t.Errorf("Get(ViaVersion) = %+v, want the updated build", got)
+	}
+
+	if !parsed.Remove("essentialsx") || parsed.Remove("essentialsx") {
+		t.Error("Remove() should report removing essentialsx once")
+	}
+	if len(parsed.Plugins) != 1 {
+		t.Errorf("Plugins = %+v after Remove(), want viaversion only", parsed.Plugins)
+	}
+}
+
+func TestParseLock_Empty(t *testing.T) {
+	lock, err := ParseLock(nil)
+	if err != nil || len(lock.Plugins) != 0 {
+		t.Errorf("ParseLock(nil) = %+v, %v, want an empty lock", lock, err)
+	}
+}
+
+func TestParseLock_Invalid(t *testing.T) {
+	with := func(edit func(p *Plugin)) Plugin {
+		p := testPlugin("essentialsx")
+		edit(&p)
+		return p
+	}
+
+	tests := map[string][]Plugin{
+		"file in a directory": {with(func(p *Plugin) { p.File = "../server.jar" })},
+		"not a jar":           {with(func(p *Plugin) { p.File = "essentials.sh" })},
+		"plain http":          {with(func(p *Plugin) { p.URL = "http://example.com/a.jar" })},
+		"md5 hash":            {with(func(p *Plugin) { p.Hash = "md5:00" })},
+		"hash not hex":        {with(func(p *Plugin) { p.Hash = "sha256:xyz" })},
+		"bad slug":            {with(func(p *Plugin) { p.Slug = "a/b" })},
+		"slug twice":          {testPlugin("essentialsx"), with(func(p *Plugin) { p.File = "other.jar" })},
+		"file twice":          {testPlugin("essentialsx"), with(func(p *Plugin) { p.Slug = "other" })},
+	}
+	for name, plugins := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, _ := (&Lock{Plugins: plugins}).Marshal()
+			if _, err := ParseLock(data); err == nil {
+				t.Error("ParseLock() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	modrinth := &fakeSource{name: "modrinth", plugins: map[string]Plugin{"essentialsx": testPlugin("essentialsx")}}
+	hangar := &fakeSource{name: "hangar", plugins: map[string]Plugin{
+		"essentialsx": testPlugin("essentialsx"),
+		"ViaVersion":  testPlugin("ViaVersion"),
+	}}
+	sources := []Source{modrinth, hangar}
+	target := Target{ServerType: "paper", Version: "1.21.4"}
+
+	tests := []struct {
+		slug       string
+		wantSource string
+	}{
+		{"essentialsx", "modrinth"},
+		{"ViaVersion", "hangar"}, // Not on the first source
+		{"hangar:essentialsx", "hangar"},
+	}
+	for _, tt := range tests {
+		p, err := Resolve(context.Background(), sources, tt.slug, target)
+		if err != nil {
+			t.Errorf("Resolve(%s) error = %v", tt.slug, err)
+			continue
+		}
+		if p.Source != tt.wantSource {
+			t.Errorf("Resolve(%s) Source = %s, want %s", tt.slug, p.Source, tt.wantSource)
+		}
+	}
+
+	if _, err := Resolve(context.Background(), sources, "modrinth:ViaVersion", target); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(modrinth:ViaVersion) error = %v, want ErrNotFound", err)
+	}
+	if _, err := Resolve(context.Background(), sources, "spigotmc:essentialsx", target); err == nil {
+		t.Error("Resolve() expected error for an unknown source, got nil")
+	}
+	if _, err := Resolve(context.Background(), sources, "../admin", target); err == nil {
+		t.Error("Resolve() expected error for an invalid slug, got nil")
+	}
+}
+
+func TestResolve_SourceFailureStopsSearch(t *testing.T) {
+	// A registry that is down must not make a same-named project elsewhere win
+	down := &fakeSource{name: "modrinth", err: errors.New("api returned status 503")}
+	hangar := &fakeSource{name: "hangar", plugins: map[string]Plugin{"essentialsx": testPlugin("essentialsx")}}
+
+	_, err := Resolve(context.Background(), []Source{down, hangar}, "essentialsx", Target{ServerType: "paper", Version: "1.21.4"})
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() error = %v, want the registry failure", err)
+	}
+}
@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// Connection states a handshake asks for
+const (
+	stateStatus   = 1
+	stateLogin    = 2
+	stateTransfer = 3 // Login after a transfer, 1.20.5+
+)
+
+// Packet ids, all of them 0 but the ping
+const (
+	packetHandshake  = 0x00
+	packetStatus     = 0x00 // Status request and response
+	packetPing       = 0x01 // Ping and pong
+	packetLoginStart = 0x00
+	packetDisconnect = 0x00 // During login
+)
+
+const (
+	// legacyPing starts the server list ping of clients before 1.7
+	legacyPing = 0xFE
+
+	// maxPacketSize bounds what a client may send before it logs in. Handshakes
+	// and login starts are well under 1KB.
+	maxPacketSize = 4096
+
+	// maxStatusSize bounds a server's status response, which carries its icon
+	maxStatusSize = 1 << 20
+
+	maxVarIntLen = 5
+)
+
+// handshake is the first packet of every connection
+type handshake struct {
+	Protocol  int32
+	Address   string
+	Port      uint16
+	NextState int32
+}
+
+// readVarInt reads a Minecraft VarInt, 7 bits at a time with the least significant first
+func readVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := range maxVarIntLen {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+
+	return 0, errors.New("varint is too long")
+}
+
+func appendVarInt(b []byte, value int32) []byte {
+	v := uint32(value)
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendVarInt(b, int32(len(s)))
+	return append(b, s...)
+}
+
+// readPacket reads a length-prefixed, uncompressed packet and returns its id and data
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	return readPacketMax(r, maxPacketSize)
+}
+
+func readPacketMax(r *bufio.Reader, maxSize int32) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxSize {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+
+	data := bytes.NewReader(packet)
+	id, err := readVarInt(data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return id, packet[len(packet)-data.Len():], nil
+}
+
+func writePacket(w io.Writer, id int32, data []byte) error {
+	packet := appendVarInt(nil, id)
+	packet = append(packet, data...)
+
+	_, err := w.Write(append(appendVarInt(nil, int32(len(packet))), packet...))
+	return err
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+
+	s := make([]byte, length)
+	r.Read(s)
+	return string(s), nil
+}
+
+func parseHandshake(data []byte) (handshake, error) {
+	var hs handshake
+	r := bytes.NewReader(data)
+
+	var err error
+	if hs.Protocol, err = readVarInt(r); err != nil {
+		return hs, fmt.Errorf("reading handshake: %w", err)
+	}
+	if hs.Address, err = readString(r); err != nil {
+		return hs, fmt.Errorf("reading handshake: %w", err)
+	}
+	if err = binary.Read(r, binary.BigEndian, &hs.Port); err != nil {
+		return hs, fmt.Errorf("reading handshake: %w", err)
+	}
+	if hs.NextState, err = readVarInt(r); err != nil {
+		return hs, fmt.Errorf("reading handshake: %w", err)
+	}
+
+	return hs, nil
+}
+
+// parseLoginStart returns the player name of a login start packet
+func parseLoginStart(data []byte) (string, error) {
+	name, err := readString(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("reading login start: %w", err)
+	}
+
+	return name, nil
+}
+
+// statusResponse is the server list entry, as the client shows it
+type statusResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int32  `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+	} `json:"players"`
+	Description textComponent `json:"description"`
+}
+
+type textComponent struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
+}
+
+// writeStatus answers a status request. The client's protocol is echoed so the entry
+// is not marked as an incompatible version.
+func writeStatus(w io.Writer, protocol int32, version string, maxPlayers int, motd string) error {
+	var status statusResponse
+	status.Version.Name = version
+	status.Version.Protocol = protocol
+	status.Players.Max = maxPlayers
+	status.Description = textComponent{Text: motd, Color: "gray"}
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return writePacket(w, packetStatus, appendString(nil, string(body)))
+}
+
+// writeDisconnect kicks a player who is logging in, with a reason shown on their screen
+func writeDisconnect(w io.Writer, reason string) error {
+	body, err := json.Marshal(textComponent{Text: reason})
+	if err != nil {
+		return err
+	}
+
+	return writePacket(w, packetDisconnect, appendString(nil, string(body)))
+}
+
+// PlayersOnline asks the server at addr for its player count with a server list ping,
+// as the multiplayer screen does. Servers behind the gateway expect the PROXY protocol,
+// for them proxyProtocol sends a LOCAL header first. The ping gives up at ctx's deadline.
+func PlayersOnline(ctx context.Context, addr string, proxyProtocol bool) (int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port in %s", addr)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if proxyProtocol {
+		if _, err := conn.Write(appendProxyHeader(nil, nil, nil)); err != nil {
+			return 0, err
+		}
+	}
+
+	// Protocol -1 asks for the status whatever the server's version
+	hs := appendVarInt(nil, -1)
+	hs = appendString(hs, host)
+	hs = binary.BigEndian.AppendUint16(hs, uint16(port))
+	hs = appendVarInt(hs, stateStatus)
+	if err := writePacket(conn, packetHandshake, hs); err != nil {
+		return 0, err
+	}
+	if err := writePacket(conn, packetStatus, nil); err != nil {
+		return 0, err
+	}
+
+	id, data, err := readPacketMax(bufio.NewReader(conn), maxStatusSize)
+	if err != nil {
+		return 0, fmt.Errorf("reading status: %w", err)
+	}
+	if id != packetStatus {
+		return 0, fmt.Errorf("expected a status response, got packet %#x", id)
+	}
+	body, err := readString(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("reading status: %w", err)
+	}
+
+	// Only the players are decoded, the description may be a string or a component
+	var status struct {
+		Players struct {
+			Online int `json:"online"`
+		} `json:"players"`
+	}
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		return 0, fmt.Errorf("reading status: %w", err)
+	}
+
+	return status.Players.Online, nil
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// handshakePacket builds the handshake a client sends to address:port
+func handshakePacket(protocol int32, address string, port uint16, nextState int32) []byte {
+	data := appendVarInt(nil, protocol)
+	data = appendString(data, address)
+	data = binary.BigEndian.AppendUint16(data, port)
+	data = appendVarInt(data, nextState)
+
+	var buf bytes.Buffer
+	writePacket(&buf, packetHandshake, data)
+	return buf.Bytes()
+}
+
+func TestVarInt(t *testing.T) {
+	tests := map[int32][]byte{
+		0:          {0x00},
+		1:          {0x01},
+		127:        {0x7f},
+		128:        {0x80, 0x01},
+		25565:      {0xdd, 0xc7, 0x01},
+		2147483647: {0xff, 0xff, 0xff, 0xff, 0x07},
+		-1:         {0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for value, encoded := range tests {
+		if got := appendVarInt(nil, value); !bytes.Equal(got, encoded) {
+			t.Errorf("appendVarInt(%d) = %x, want %x", value, got, encoded)
+		}
+		got, err := readVarInt(bytes.NewReader(encoded))
+		if err != nil || got != value {
+			t.Errorf("readVarInt(%x) = %d, %v, want %d", encoded, got, err, value)
+		}
+	}
+}
+
+func TestReadVarInt_TooLong(t *testing.T) {
+	if _, err := readVarInt(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01})); err == nil {
+		t.Error("readVarInt() expected error for 6 bytes, got nil")
+	}
+}
+
+func TestParseHandshake(t *testing.T) {
+	r := bufio.NewReader(bytes.NewReader(handshakePacket(774, "mc.example.com", 30001, stateLogin)))
+
+	id, data, err := readPacket(r)
+	if err != nil || id != packetHandshake {
+		t.Fatalf("readPacket() = %d, %v, want a handshake", id, err)
+	}
+	hs, err := parseHandshake(data)
+	if err != nil {
+		t.Fatalf("parseHandshake() error = %v", err)
+	}
+	want := handshake{Protocol: 774, Address: "mc.example.com", Port: 30001, NextState: stateLogin}
+	if hs != want {
+		t.Errorf("parseHandshake() = %+v, want %+v", hs, want)
+	}
+}
+
+func TestParseHandshake_Truncated(t *testing.T) {
+	packet := handshakePacket(774, "mc.example.com", 30001, stateLogin)
+	if _, err := parseHandshake(packet[2:10]); err == nil {
+		t.Error("parseHandshake() expected error for a truncated handshake, got nil")
+	}
+}
+
+func TestReadPacket_TooLarge(t *testing.T) {
+	packet := appendVarInt(nil, maxPacketSize+1)
+	if _, _, err := readPacket(bufio.NewReader(bytes.NewReader(packet))); err == nil {
+		t.Error("readPacket() expected error for an oversized packet, got nil")
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatus(&buf, 774, "1.21.11", 5, SleepingMOTD); err != nil {
+		t.Fatalf("writeStatus() error = %v", err)
+	}
+
+	id, data, err := readPacket(bufio.NewReader(&buf))
+	if err != nil || id != packetStatus {
+		t.Fatalf("readPacket() = %d, %v, want a status response", id, err)
+	}
+	body, err := readString(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readString() error = %v", err)
+	}
+
+	var status statusResponse
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatalf("status is not JSON: %v\n%s", err, body)
+	}
+	if status.Version.Protocol != 774 || status.Version.Name != "1.21.11" {
+		t.Errorf("version = %+v, want the client's protocol and the server version", status.Version)
+	}
+	if status.Players.Max != 5 || status.Description.Text != SleepingMOTD {
+		t.Errorf("status = %+v, want 5 max players and the sleeping MOTD", status)
+	}
+}
+
+func TestPlayersOnline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A server with an icon, larger than anything a client sends, and a plain string
+	// description as older servers send it
+	status := `{"version": {"name": "Paper 1.21.11", "protocol": 774}, "players": {"max": 5, "online": 3},` +
+		`"description": "A Kubecraft Server", "favicon": "data:image/png;base64,` + strings.Repeat("A", 8000) + `"}`
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if _, data, err := readPacket(r); err != nil {
+			return
+		} else if hs, err := parseHandshake(data); err != nil || hs.NextState != stateStatus {
+			return
+		}
+		if _, _, err := readPacket(r); err != nil {
+			return
+		}
+		writePacket(conn, packetStatus, appendString(nil, status))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	online, err := PlayersOnline(ctx, listener.Addr().String(), false)
+	if err != nil {
+		t.Fatalf("PlayersOnline() error = %v", err)
+	}
+	if online != 3 {
+		t.Errorf("PlayersOnline() = %d, want 3", online)
+	}
+}
+
+func TestPlayersOnline_NoServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if _, err := PlayersOnline(context.Background(), addr, false); err == nil {
+		t.Error("PlayersOnline() expected error without a server, got nil")
+	}
+}
+
+func TestPlayersOnline_ProxyProtocol(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A server behind the gateway drops connections without a PROXY header
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		header := make([]byte, len(proxySignature)+4)
+		if _, err := io.ReadFull(r, header); err != nil || string(header[:len(proxySignature)]) != proxySignature {
+			return
+		}
+		readPacket(r)
+		readPacket(r)
+		writePacket(conn, packetStatus, appendString(nil, `{"players": {"max": 5, "online": 1}}`))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	online, err := PlayersOnline(ctx, listener.Addr().String(), true)
+	if err != nil || online != 1 {
+		t.Errorf("PlayersOnline() = %d, %v, want 1", online, err)
+	}
+}
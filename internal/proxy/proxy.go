@@ -0,0 +1,462 @@
+// Package proxy is the wake-on-connect proxy. It answers on the NodePort of every
+// server that is not accepting players, shows it as sleeping in the server list and
+// starts it when a player joins. As the gateway it also routes <server>.<user>.<domain>
+// on a single port to the server the player asked for.
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+)
+
+// What the server list shows, and what players logging in are told
+const (
+	SleepingMOTD         = "Server is sleeping – join to wake"
+	StartingMOTD         = "Server is starting..."
+	MaintenanceMOTD      = "Server is under maintenance"
+	UnknownServerMOTD    = "No server at this address"
+	maintenanceMessage   = "The server is under maintenance, try again later"
+	noCapacityMessage    = "The server could not start, the node is out of memory. Try again later."
+	unknownServerMessage = "There is no server at this address, check it with kubecraft server list"
+)
+
+// Cluster is the part of the k8s client the proxy uses
+type Cluster interface {
+	ListServerStates(ctx context.Context) ([]k8s.ServerState, error)
+	ClaimServerPort(ctx context.Context, state k8s.ServerState, proxyIP string) error
+	ReleaseServerPort(ctx context.Context, state k8s.ServerState) error
+	WakeServer(ctx context.Context, namespace string, serverName string) error
+}
+
+// Proxy listens on a port equal to each server's NodePort and routes the NodePort to
+// itself while the server does not accept players
+type Proxy struct {
+	cluster Cluster
+	ip      string // Pod IP the NodePorts are routed to
+
+	// Overridden in tests
+	listen func(port int32) (net.Listener, error)
+	dial   func(ctx context.Context, addr string) (net.Conn, error)
+
+	mu      sync.Mutex
+	servers map[int32]*server          // By NodePort
+	routes  map[string]k8s.ServerState // Every server, by <server>.<user>
+}
+
+// server is a server the proxy listens for
+type server struct {
+	state    k8s.ServerState
+	claimed  bool // Routed to the proxy by this process
+	listener net.Listener
+}
+
+// New returns a proxy that routes NodePorts to ip, the proxy pod's IP
+func New(cluster Cluster, ip string) *Proxy {
+	var dialer net.Dialer
+	return &Proxy{
+		cluster: cluster,
+		ip:      ip,
+		listen: func(port int32) (net.Listener, error) {
+			return net.Listen("tcp", ":"+strconv.Itoa(int(port)))
+		},
+		dial: func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		},
+		servers: make(map[int32]*server),
+		routes:  make(map[string]k8s.ServerState),
+	}
+}
+
+// ServeGateway routes the connections accepted on listener by the address players
+// typed, <server>.<user>.<domain>, until the listener is closed
+func (p *Proxy) ServeGateway(listener net.Listener, domain string) {
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+	p.serve(listener, func(hs handshake) (k8s.ServerState, bool) {
+		host, ok := routeHost(hs.Address, suffix)
+		if !ok {
+			return k8s.ServerState{}, false
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		state, ok := p.routes[host]
+		return state, ok
+	})
+}
+
+// routeHost returns the <server>.<user> part of a handshake address under suffix
+func routeHost(address string, suffix string) (string, bool) {
+	// Forge clients append \x00FML\x00 or similar, and some a trailing dot
+	if i := strings.IndexByte(address, 0); i >= 0 {
+		address = address[:i]
+	}
+	host := strings.ToLower(strings.TrimSuffix(address, "."))
+
+	host, ok := strings.CutSuffix(host, suffix)
+	if !ok {
+		return "", false
+	}
+	server, user, ok := strings.Cut(host, ".")
+	if !ok || server == "" || user == "" || strings.Contains(user, ".") {
+		return "", false
+	}
+
+	return server + "." + user, true
+}
+
+func routeKey(state k8s.ServerState) string {
+	return state.Name + "." + state.Username
+}
+
+// Run keeps the servers' NodePorts routed until ctx is done, then hands every
+// NodePort back to its server so none is left pointing at a proxy that is gone
+func (p *Proxy) Run(ctx context.Context) error {
+	ticker := time.NewTicker(config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.reconcile(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("failed to reconcile servers: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.shutdown()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile claims the NodePort of every server not accepting players and releases
+// the NodePort of every server that does
+func (p *Proxy) reconcile(ctx context.Context) error {
+	states, err := p.cluster.ListServerStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Also replaces the replicas markWoken set with the real ones
+	routes := make(map[string]k8s.ServerState, len(states))
+	for _, state := range states {
+		routes[routeKey(state)] = state
+	}
+	p.mu.Lock()
+	p.routes = routes
+	p.mu.Unlock()
+
+	seen := make(map[int32]bool, len(states))
+	for _, state := range states {
+		// Servers behind the gateway have no NodePort to claim
+		if state.Gateway() {
+			continue
+		}
+
+		seen[state.NodePort] = true
+		srv := p.track(state)
+		if srv == nil {
+			continue
+		}
+
+		switch {
+		case state.Ready() && (srv.claimed || state.Claimed):
+			if err := p.cluster.ReleaseServerPort(ctx, state); err != nil {
+				fmt.Printf("failed to release %s/%s: %v\n", state.Namespace, state.Name, err)
+				continue
+			}
+			p.setClaimed(state.NodePort, false)
+			fmt.Printf("Server %s/%s is ready, routed port %d to it\n", state.Namespace, state.Name, state.NodePort)
+		case !state.Ready() && !srv.claimed:
+			if err := p.cluster.ClaimServerPort(ctx, state, p.ip); err != nil {
+				fmt.Printf("failed to claim %s/%s: %v\n", state.Namespace, state.Name, err)
+				continue
+			}
+			p.setClaimed(state.NodePort, true)
+			fmt.Printf("Server %s/%s is not accepting players, routed port %d to the proxy\n", state.Namespace, state.Name, state.NodePort)
+		}
+	}
+
+	// Stop listening for deleted servers
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for port, srv := range p.servers {
+		if !seen[port] {
+			srv.listener.Close()
+			delete(p.servers, port)
+		}
+	}
+
+	return nil
+}
+
+// track updates the known state of a server and listens on its NodePort. It returns
+// nil when the port cannot be listened on.
+func (p *Proxy) track(state k8s.ServerState) *server {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	srv, ok := p.servers[state.NodePort]
+	if ok && (srv.state.Namespace != state.Namespace || srv.state.Name != state.Name) {
+		// The port went to a server created since
+		srv.listener.Close()
+		ok = false
+	}
+	if !ok {
+		listener, err := p.listen(state.NodePort)
+		if err != nil {
+			fmt.Printf("failed to listen on port %d for %s/%s: %v\n", state.NodePort, state.Namespace, state.Name, err)
+			delete(p.servers, state.NodePort)
+			return nil
+		}
+		srv = &server{listener: listener}
+		p.servers[state.NodePort] = srv
+		port := state.NodePort
+		go p.serve(listener, func(handshake) (k8s.ServerState, bool) {
+			return p.lookup(port)
+		})
+	}
+
+	// Also replaces the replicas markWoken set with the real ones
+	srv.state = state
+	snapshot := *srv
+	return &snapshot
+}
+
+func (p *Proxy) setClaimed(port int32, claimed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if srv, ok := p.servers[port]; ok {
+		srv.claimed = claimed
+	}
+}
+
+// lookup returns the current state of the server on a NodePort
+func (p *Proxy) lookup(port int32) (k8s.ServerState, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	srv, ok := p.servers[port]
+	if !ok {
+		return k8s.ServerState{}, false
+	}
+	return srv.state, true
+}
+
+// shutdown stops listening and releases every claimed NodePort
+func (p *Proxy) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), config.RollbackTimeout)
+	defer cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for port, srv := range p.servers {
+		srv.listener.Close()
+		if srv.claimed {
+			if err := p.cluster.ReleaseServerPort(ctx, srv.state); err != nil {
+				fmt.Printf("failed to release %s/%s: %v\n", srv.state.Namespace, srv.state.Name, err)
+			}
+		}
+		delete(p.servers, port)
+	}
+}
+
+// routeFunc finds the server a client's handshake is for
+type routeFunc func(hs handshake) (k8s.ServerState, bool)
+
+func (p *Proxy) serve(listener net.Listener, route routeFunc) {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("failed to accept on %s: %v\n", listener.Addr(), err)
+			continue
+		}
+
+		go func() {
+			defer conn.Close()
+			if err := p.handle(context.Background(), conn, route); err != nil && !errors.Is(err, io.EOF) {
+				fmt.Printf("connection from %s on %s: %v\n", conn.RemoteAddr(), listener.Addr(), err)
+			}
+		}()
+	}
+}
+
+// handle serves one client connection to the server route finds
+func (p *Proxy) handle(ctx context.Context, conn net.Conn, route routeFunc) error {
+	conn.SetDeadline(time.Now().Add(config.WakeLoginTimeout))
+
+	// Keep what the client sent, to replay it to the server if it is ready
+	var received bytes.Buffer
+	r := bufio.NewReader(io.TeeReader(conn, &received))
+
+	first, err := r.Peek(1)
+	if err != nil {
+		return err
+	}
+	if first[0] == legacyPing {
+		return nil
+	}
+
+	id, data, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if id != packetHandshake {
+		return fmt.Errorf("expected a handshake, got packet %#x", id)
+	}
+	hs, err := parseHandshake(data)
+	if err != nil {
+		return err
+	}
+
+	state, ok := route(hs)
+	if !ok {
+		return p.unknown(conn, r, hs)
+	}
+	if state.Ready() {
+		conn.SetDeadline(time.Time{})
+		return p.pipe(ctx, conn, &received, state)
+	}
+
+	switch hs.NextState {
+	case stateStatus:
+		motd := SleepingMOTD
+		switch {
+		case state.Maintenance:
+			motd = MaintenanceMOTD
+		case state.Replicas > 0:
+			motd = StartingMOTD
+		}
+		return p.status(conn, r, hs, state.Version, state.MaxPlayers, motd)
+	case stateLogin, stateTransfer:
+		return p.login(ctx, conn, r, state)
+	default:
+		return fmt.Errorf("unknown handshake state %d", hs.NextState)
+	}
+}
+
+// status answers the server list ping for a server that is not accepting players
+func (p *Proxy) status(conn net.Conn, r *bufio.Reader, hs handshake, version string, maxPlayers int, motd string) error {
+	if _, _, err := readPacket(r); err != nil {
+		return err
+	}
+
+	if err := writeStatus(conn, hs.Protocol, version, maxPlayers, motd); err != nil {
+		return err
+	}
+
+	// Answer the ping so the client shows a latency
+	id, data, err := readPacket(r)
+	if err != nil || id != packetPing {
+		return err
+	}
+	return writePacket(conn, packetPing, data)
+}
+
+// unknown answers a client that asked for a server that does not exist
+func (p *Proxy) unknown(conn net.Conn, r *bufio.Reader, hs handshake) error {
+	if hs.NextState != stateStatus {
+		return writeDisconnect(conn, unknownServerMessage)
+	}
+
+	return p.status(conn, r, hs, "", 0, UnknownServerMOTD)
+}
+
+// login wakes a stopped server and kicks the player, who joins again once it is up
+func (p *Proxy) login(ctx context.Context, conn net.Conn, r *bufio.Reader, state k8s.ServerState) error {
+	player := "a player"
+	if id, data, err := readPacket(r); err == nil && id == packetLoginStart {
+		if name, err := parseLoginStart(data); err == nil {
+			player = name
+		}
+	}
+
+	if state.Maintenance {
+		return writeDisconnect(conn, maintenanceMessage)
+	}
+
+	if state.Replicas == 0 {
+		fmt.Printf("Waking server %s/%s for %s\n", state.Namespace, state.Name, player)
+		if err := p.cluster.WakeServer(ctx, state.Namespace, state.Name); err != nil {
+			fmt.Printf("failed to wake %s/%s: %v\n", state.Namespace, state.Name, err)
+			return writeDisconnect(conn, noCapacityMessage)
+		}
+		p.markWoken(state)
+	}
+
+	return writeDisconnect(conn, startingMessage())
+}
+
+// markWoken shows a server the proxy started as starting, until the next reconcile
+func (p *Proxy) markWoken(state k8s.ServerState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if srv, ok := p.servers[state.NodePort]; ok && !state.Gateway() {
+		srv.state.Replicas = 1
+	}
+	if route, ok := p.routes[routeKey(state)]; ok {
+		route.Replicas = 1
+		p.routes[routeKey(state)] = route
+	}
+}
+
+// pipe hands a connection to a ready server, replaying what the client already sent.
+// Servers behind the gateway are reached through their Service and told the player's
+// address with the PROXY protocol. The NodePort of the others may still route to the
+// proxy, so they are reached at their pod.
+func (p *Proxy) pipe(ctx context.Context, conn net.Conn, received *bytes.Buffer, state k8s.ServerState) error {
+	host := state.PodIP
+	var header []byte
+	if state.Gateway() {
+		host = state.ClusterIP
+		header = appendProxyHeader(nil, conn.RemoteAddr(), conn.LocalAddr())
+	}
+
+	backend, err := p.dial(ctx, net.JoinHostPort(host, strconv.Itoa(config.MinecraftPort)))
+	if err != nil {
+		return fmt.Errorf("connecting to %s/%s: %w", state.Namespace, state.Name, err)
+	}
+	defer backend.Close()
+
+	if _, err := backend.Write(append(header, received.Bytes()...)); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(conn, backend)
+		conn.Close()
+		close(done)
+	}()
+	io.Copy(backend, conn)
+
+	// Let the server finish answering a client that stopped sending
+	if tcp, ok := backend.(interface{ CloseWrite() error }); ok {
+		tcp.CloseWrite()
+	} else {
+		backend.Close()
+	}
+	<-done
+
+	return nil
+}
+
+func startingMessage() string {
+	return fmt.Sprintf("The server is starting, retry in ~%ds", int(config.WakeStartupEstimate.Seconds()))
+}
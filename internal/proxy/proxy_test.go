@@ -0,0 +1,490 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baighasan/kubecraft/internal/k8s"
+)
+
+// fakeCluster records what the proxy asks of the cluster
+type fakeCluster struct {
+	mu       sync.Mutex
+	states   []k8s.ServerState
+	claimed  []string
+	released []string
+	woken    []string
+	wakeErr  error
+}
+
+func (c *fakeCluster) ListServerStates(ctx context.Context) ([]k8s.ServerState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.states, nil
+}
+
+func (c *fakeCluster) ClaimServerPort(ctx context.Context, state k8s.ServerState, proxyIP string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.claimed = append(c.claimed, state.Name+"@"+proxyIP)
+	return nil
+}
+
+func (c *fakeCluster) ReleaseServerPort(ctx context.Context, state k8s.ServerState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.released = append(c.released, state.Name)
+	return nil
+}
+
+func (c *fakeCluster) WakeServer(ctx context.Context, namespace string, serverName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.wakeErr != nil {
+		return c.wakeErr
+	}
+	c.woken = append(c.woken, namespace+"/"+serverName)
+	return nil
+}
+
+// newTestProxy returns a proxy listening on loopback ports, with the address each
+// NodePort is served on
+func newTestProxy(t *testing.T, cluster *fakeCluster) (*Proxy, map[int32]string) {
+	t.Helper()
+
+	p := New(cluster, "10.42.0.9")
+	addrs := map[int32]string{}
+	p.listen = func(port int32) (net.Listener, error) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err == nil {
+			addrs[port] = listener.Addr().String()
+		}
+		return listener, err
+	}
+	t.Cleanup(p.shutdown)
+
+	if err := p.reconcile(context.Background()); err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+	return p, addrs
+}
+
+func stoppedServer(name string, nodePort int32) k8s.ServerState {
+	return k8s.ServerState{
+		Namespace:  "mc-alice",
+		Name:       name,
+		Username:   "alice",
+		NodePort:   nodePort,
+		Version:    "1.21.11",
+		MaxPlayers: 5,
+	}
+}
+
+// join connects to addr and sends a handshake for nextState
+func join(t *testing.T, addr string, nextState int32) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	return joinHost(t, addr, "localhost", nextState)
+}
+
+// joinHost is join with host as the address the player typed
+func joinHost(t *testing.T, addr string, host string, nextState int32) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write(handshakePacket(774, host, 30001, nextState)); err != nil {
+		t.Fatalf("failed to send handshake: %v", err)
+	}
+	return conn, bufio.NewReader(conn)
+}
+
+// ping asks for the server list entry and returns its description
+func ping(t *testing.T, addr string) string {
+	t.Helper()
+	return pingHost(t, addr, "localhost")
+}
+
+func pingHost(t *testing.T, addr string, host string) string {
+	t.Helper()
+
+	conn, r := joinHost(t, addr, host, stateStatus)
+	writePacket(conn, packetStatus, nil)
+
+	_, data, err := readPacket(r)
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	body, _ := readString(bytes.NewReader(data))
+	var status statusResponse
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatalf("status is not JSON: %v", err)
+	}
+	if status.Version.Protocol != 774 {
+		t.Errorf("protocol = %d, want the client's 774", status.Version.Protocol)
+	}
+
+	writePacket(conn, packetPing, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	id, data, err := readPacket(r)
+	if err != nil || id != packetPing || !bytes.Equal(data, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("pong = %d %x, %v, want the ping echoed", id, data, err)
+	}
+
+	return status.Description.Text
+}
+
+// login joins as Steve and returns the reason the player was kicked with
+func login(t *testing.T, addr string) string {
+	t.Helper()
+	return loginHost(t, addr, "localhost")
+}
+
+func loginHost(t *testing.T, addr string, host string) string {
+	t.Helper()
+
+	conn, r := joinHost(t, addr, host, stateLogin)
+	writePacket(conn, packetLoginStart, appendString(nil, "Steve"))
+
+	id, data, err := readPacket(r)
+	if err != nil || id != packetDisconnect {
+		t.Fatalf("readPacket() = %d, %v, want a disconnect", id, err)
+	}
+	body, _ := readString(bytes.NewReader(data))
+	var reason textComponent
+	if err := json.Unmarshal([]byte(body), &reason); err != nil {
+		t.Fatalf("reason is not JSON: %v", err)
+	}
+	return reason.Text
+}
+
+func TestReconcile_ClaimsServersNotAcceptingPlayers(t *testing.T) {
+	ready := stoppedServer("running", 30002)
+	ready.Replicas = 1
+	ready.PodIP = "10.42.0.20"
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001), ready}}
+
+	p, addrs := newTestProxy(t, cluster)
+
+	if len(cluster.claimed) != 1 || cluster.claimed[0] != "sleepy@10.42.0.9" {
+		t.Errorf("claimed = %v, want only the stopped server routed to the proxy", cluster.claimed)
+	}
+	if len(cluster.released) != 0 {
+		t.Errorf("released = %v, want a port never claimed left alone", cluster.released)
+	}
+	if len(addrs) != 2 {
+		t.Errorf("listening on %v, want both NodePorts", addrs)
+	}
+
+	// Claims are not repeated every poll
+	p.reconcile(context.Background())
+	if len(cluster.claimed) != 1 {
+		t.Errorf("claimed = %v after a second reconcile, want one claim", cluster.claimed)
+	}
+}
+
+func TestReconcile_ReleasesReadyServer(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001)}}
+	p, _ := newTestProxy(t, cluster)
+
+	cluster.states[0].Replicas = 1
+	cluster.states[0].PodIP = "10.42.0.20"
+	p.reconcile(context.Background())
+
+	if len(cluster.released) != 1 || cluster.released[0] != "sleepy" {
+		t.Errorf("released = %v, want the ready server routed back to its pod", cluster.released)
+	}
+}
+
+func TestReconcile_StopsListeningForDeletedServer(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001)}}
+	p, addrs := newTestProxy(t, cluster)
+
+	cluster.states = nil
+	p.reconcile(context.Background())
+
+	if _, err := net.Dial("tcp", addrs[30001]); err == nil {
+		t.Error("proxy still listens for a deleted server")
+	}
+}
+
+func TestShutdown_ReleasesClaimedPorts(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001)}}
+	p, _ := newTestProxy(t, cluster)
+
+	p.shutdown()
+
+	if len(cluster.released) != 1 {
+		t.Errorf("released = %v, want the claimed port handed back on shutdown", cluster.released)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	starting := stoppedServer("starting", 30002)
+	starting.Replicas = 1
+	maintenance := stoppedServer("upload", 30003)
+	maintenance.Replicas = 1
+	maintenance.Maintenance = true
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001), starting, maintenance}}
+	_, addrs := newTestProxy(t, cluster)
+
+	tests := map[int32]string{30001: SleepingMOTD, 30002: StartingMOTD, 30003: MaintenanceMOTD}
+	for port, want := range tests {
+		if got := ping(t, addrs[port]); got != want {
+			t.Errorf("MOTD on port %d = %q, want %q", port, got, want)
+		}
+	}
+	if len(cluster.woken) != 0 {
+		t.Errorf("woken = %v, a server list ping must not start a server", cluster.woken)
+	}
+}
+
+func TestLogin_WakesStoppedServer(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001)}}
+	_, addrs := newTestProxy(t, cluster)
+
+	if reason := login(t, addrs[30001]); !strings.Contains(reason, "starting, retry in ~60s") {
+		t.Errorf("kick reason = %q, want the player told to retry", reason)
+	}
+	if len(cluster.woken) != 1 || cluster.woken[0] != "mc-alice/sleepy" {
+		t.Errorf("woken = %v, want mc-alice/sleepy", cluster.woken)
+	}
+
+	// Players joining while it starts do not scale it again
+	if got := ping(t, addrs[30001]); got != StartingMOTD {
+		t.Errorf("MOTD after waking = %q, want %q", got, StartingMOTD)
+	}
+	login(t, addrs[30001])
+	if len(cluster.woken) != 1 {
+		t.Errorf("woken = %v, want a single wake", cluster.woken)
+	}
+}
+
+func TestLogin_NodeFull(t *testing.T) {
+	cluster := &fakeCluster{
+		states:  []k8s.ServerState{stoppedServer("sleepy", 30001)},
+		wakeErr: errors.New("not enough ram available to allocate to server"),
+	}
+	_, addrs := newTestProxy(t, cluster)
+
+	if reason := login(t, addrs[30001]); reason != noCapacityMessage {
+		t.Errorf("kick reason = %q, want %q", reason, noCapacityMessage)
+	}
+	if got := ping(t, addrs[30001]); got != SleepingMOTD {
+		t.Errorf("MOTD after a failed wake = %q, want %q", got, SleepingMOTD)
+	}
+}
+
+func TestLogin_Maintenance(t *testing.T) {
+	state := stoppedServer("upload", 30001)
+	state.Replicas = 1
+	state.Maintenance = true
+	cluster := &fakeCluster{states: []k8s.ServerState{state}}
+	_, addrs := newTestProxy(t, cluster)
+
+	if reason := login(t, addrs[30001]); reason != maintenanceMessage {
+		t.Errorf("kick reason = %q, want %q", reason, maintenanceMessage)
+	}
+	if len(cluster.woken) != 0 {
+		t.Errorf("woken = %v, want a server in maintenance left alone", cluster.woken)
+	}
+}
+
+func TestReadyServer_PipesTraffic(t *testing.T) {
+	backend, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	state := stoppedServer("running", 30001)
+	state.Replicas = 1
+	state.PodIP = "10.42.0.20"
+	cluster := &fakeCluster{states: []k8s.ServerState{state}}
+	p, addrs := newTestProxy(t, cluster)
+
+	var dialed string
+	p.dial = func(ctx context.Context, addr string) (net.Conn, error) {
+		dialed = addr
+		return net.Dial("tcp", backend.Addr().String())
+	}
+
+	// The server echoes everything back
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := backend.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(io.TeeReader(conn, conn))
+		received <- data
+	}()
+
+	conn, r := join(t, addrs[30001], stateLogin)
+	writePacket(conn, packetLoginStart, appendString(nil, "Steve"))
+	conn.(*net.TCPConn).CloseWrite()
+
+	echoed, _ := io.ReadAll(r)
+	want := append(handshakePacket(774, "localhost", 30001, stateLogin), 0x07, packetLoginStart, 0x05, 'S', 't', 'e', 'v', 'e')
+	if got := <-received; !bytes.Equal(got, want) {
+		t.Errorf("server received %x, want the handshake and login start %x", got, want)
+	}
+	if !bytes.Equal(echoed, want) {
+		t.Errorf("client received %x, want the server's reply %x", echoed, want)
+	}
+	if dialed != "10.42.0.20:25565" {
+		t.Errorf("dialed %s, want the server pod", dialed)
+	}
+}
+
+func TestLegacyPing_Closed(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{stoppedServer("sleepy", 30001)}}
+	_, addrs := newTestProxy(t, cluster)
+
+	conn, err := net.Dial("tcp", addrs[30001])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte{legacyPing, 0x01})
+
+	if data, err := io.ReadAll(conn); err != nil || len(data) != 0 {
+		t.Errorf("legacy ping got %x, %v, want the connection closed", data, err)
+	}
+}
+
+// startGateway serves the gateway for domain on a loopback port and returns its address
+func startGateway(t *testing.T, p *Proxy, domain string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go p.ServeGateway(listener, domain)
+
+	return listener.Addr().String()
+}
+
+func gatewayServer(name string, user string) k8s.ServerState {
+	state := stoppedServer(name, 0)
+	state.Namespace = "mc-" + user
+	state.Username = user
+	state.ClusterIP = "10.43.0.50"
+	return state
+}
+
+func TestRouteHost(t *testing.T) {
+	tests := map[string]string{
+		"survival.alice.mc.example.com":             "survival.alice",
+		"Survival.Alice.MC.example.com.":            "survival.alice",
+		"survival.alice.mc.example.com\x00FML3\x00": "survival.alice",
+		"alice.mc.example.com":                      "",
+		"a.survival.alice.mc.example.com":           "",
+		"survival.alice.example.org":                "",
+		".alice.mc.example.com":                     "",
+	}
+	for address, want := range tests {
+		got, ok := routeHost(address, ".mc.example.com")
+		if ok != (want != "") || got != want {
+			t.Errorf("routeHost(%q) = %q, %v, want %q", address, got, ok, want)
+		}
+	}
+}
+
+func TestGateway_RoutesByHostname(t *testing.T) {
+	cluster := &fakeCluster{states: []k8s.ServerState{gatewayServer("survival", "alice"), gatewayServer("survival", "bob")}}
+	p, addrs := newTestProxy(t, cluster)
+	addr := startGateway(t, p, "mc.example.com")
+
+	if len(addrs) != 0 || len(cluster.claimed) != 0 {
+		t.Errorf("listening on %v, claimed %v, want no NodePorts for gateway servers", addrs, cluster.claimed)
+	}
+	if got := pingHost(t, addr, "survival.bob.mc.example.com"); got != SleepingMOTD {
+		t.Errorf("MOTD = %q, want %q", got, SleepingMOTD)
+	}
+
+	if reason := loginHost(t, addr, "survival.bob.mc.example.com"); !strings.Contains(reason, "starting") {
+		t.Errorf("kick reason = %q, want the player told to retry", reason)
+	}
+	if len(cluster.woken) != 1 || cluster.woken[0] != "mc-bob/survival" {
+		t.Errorf("woken = %v, want only mc-bob/survival", cluster.woken)
+	}
+	if got := pingHost(t, addr, "survival.bob.mc.example.com"); got != StartingMOTD {
+		t.Errorf("MOTD after waking = %q, want %q", got, StartingMOTD)
+	}
+	if got := pingHost(t, addr, "survival.alice.mc.example.com"); got != SleepingMOTD {
+		t.Errorf("MOTD of another user's server = %q, want %q", got, SleepingMOTD)
+	}
+}
+
+func TestGateway_UnknownServer(t *testing.T) {
+	p, _ := newTestProxy(t, &fakeCluster{})
+	addr := startGateway(t, p, "mc.example.com")
+
+	if got := pingHost(t, addr, "ghost.alice.mc.example.com"); got != UnknownServerMOTD {
+		t.Errorf("MOTD = %q, want %q", got, UnknownServerMOTD)
+	}
+	if reason := loginHost(t, addr, "ghost.alice.mc.example.com"); reason != unknownServerMessage {
+		t.Errorf("kick reason = %q, want %q", reason, unknownServerMessage)
+	}
+}
+
+func TestGateway_PipesWithProxyHeader(t *testing.T) {
+	backend, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	state := gatewayServer("survival", "alice")
+	state.Replicas = 1
+	state.PodIP = "10.42.0.20"
+	p, _ := newTestProxy(t, &fakeCluster{states: []k8s.ServerState{state}})
+	addr := startGateway(t, p, "mc.example.com")
+
+	var dialed string
+	p.dial = func(ctx context.Context, addr string) (net.Conn, error) {
+		dialed = addr
+		return net.Dial("tcp", backend.Addr().String())
+	}
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := backend.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	conn, _ := joinHost(t, addr, "survival.alice.mc.example.com", stateStatus)
+	conn.(*net.TCPConn).CloseWrite()
+
+	got := <-received
+	header := appendProxyHeader(nil, conn.LocalAddr(), conn.RemoteAddr())
+	want := append(header, handshakePacket(774, "survival.alice.mc.example.com", 30001, stateStatus)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("server received %x, want the PROXY header and the handshake %x", got, want)
+	}
+	if dialed != "10.43.0.50:25565" {
+		t.Errorf("dialed %s, want the server's Service", dialed)
+	}
+}
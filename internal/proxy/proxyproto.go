@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// proxySignature starts every PROXY protocol v2 header
+const proxySignature = "\r\n\r\n\x00\r\nQUIT\n"
+
+// PROXY protocol v2 commands and address families
+const (
+	proxyCommandLocal = 0x20 // A connection of the proxy's own, e.g. a health check
+	proxyCommandProxy = 0x21 // A connection relayed for a client
+	proxyFamilyTCP4   = 0x11
+	proxyFamilyTCP6   = 0x21
+)
+
+// appendProxyHeader appends the PROXY protocol v2 header that tells a server the
+// connection was relayed from the client at src to dst. Without TCP addresses it is
+// a LOCAL header, and the server keeps the address the connection comes from.
+func appendProxyHeader(b []byte, src, dst net.Addr) []byte {
+	b = append(b, proxySignature...)
+
+	srcTCP, srcOK := src.(*net.TCPAddr)
+	dstTCP, dstOK := dst.(*net.TCPAddr)
+	if !srcOK || !dstOK {
+		return append(b, proxyCommandLocal, 0, 0, 0)
+	}
+
+	// Both addresses must be of one family, an IPv4 client of a dual-stack listener
+	// shows up as IPv4-mapped IPv6
+	srcIP, dstIP := srcTCP.IP.To4(), dstTCP.IP.To4()
+	family := byte(proxyFamilyTCP4)
+	if srcIP == nil || dstIP == nil {
+		srcIP, dstIP = srcTCP.IP.To16(), dstTCP.IP.To16()
+		family = proxyFamilyTCP6
+	}
+	if srcIP == nil || dstIP == nil {
+		return append(b, proxyCommandLocal, 0, 0, 0)
+	}
+
+	b = append(b, proxyCommandProxy, family)
+	b = binary.BigEndian.AppendUint16(b, uint16(2*len(srcIP)+4))
+	b = append(b, srcIP...)
+	b = append(b, dstIP...)
+	b = binary.BigEndian.AppendUint16(b, uint16(srcTCP.Port))
+	return binary.BigEndian.AppendUint16(b, uint16(dstTCP.Port))
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestAppendProxyHeader_TCP4(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.42.0.9"), Port: 25565}
+
+	got := appendProxyHeader(nil, src, dst)
+	want := []byte(proxySignature)
+	want = append(want, 0x21, 0x11, 0x00, 0x0c)
+	want = append(want, 203, 0, 113, 7, 10, 42, 0, 9)
+	want = append(want, 0xc8, 0x22, 0x63, 0xdd)
+	if !bytes.Equal(got, want) {
+		t.Errorf("appendProxyHeader() = %x, want %x", got, want)
+	}
+}
+
+func TestAppendProxyHeader_TCP6(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 51234}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.42.0.9"), Port: 25565}
+
+	got := appendProxyHeader(nil, src, dst)
+	if len(got) != len(proxySignature)+4+36 {
+		t.Fatalf("appendProxyHeader() = %x, want a 36 byte IPv6 address block", got)
+	}
+	if got[12] != proxyCommandProxy || got[13] != proxyFamilyTCP6 {
+		t.Errorf("command, family = %#x, %#x, want PROXY over TCP6", got[12], got[13])
+	}
+	if !net.IP(got[16:32]).Equal(src.IP) {
+		t.Errorf("source = %s, want %s", net.IP(got[16:32]), src.IP)
+	}
+}
+
+func TestAppendProxyHeader_Local(t *testing.T) {
+	got := appendProxyHeader(nil, nil, nil)
+	want := append([]byte(proxySignature), proxyCommandLocal, 0, 0, 0)
+	if !bytes.Equal(got, want) {
+		t.Errorf("appendProxyHeader() = %x, want a LOCAL header %x", got, want)
+	}
+}
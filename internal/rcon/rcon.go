@@ -0,0 +1,182 @@
+// Package rcon is a client for the Source RCON protocol as spoken by Minecraft servers.
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Packet types
+const (
+	typeResponse = 0
+	typeCommand  = 2
+	typeLogin    = 3
+
+	// Minecraft answers unknown types with "Unknown request", which marks the
+	// end of a (possibly fragmented) command response
+	typeEndMarker = 100
+)
+
+const (
+	headerSize    = 8    // id + type
+	maxPacketSize = 4110 // Largest packet the server sends (4096 byte payload)
+	maxCommandLen = 1446 // Longest command the server accepts
+)
+
+// ErrAuth is returned when the server rejects the password
+var ErrAuth = errors.New("rcon: authentication failed")
+
+// Client is an authenticated RCON connection. It is safe for concurrent use.
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+	mu      sync.Mutex
+	nextID  int32
+}
+
+// Dial connects to addr and logs in with password
+func Dial(addr string, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("rcon: connecting to %s: %w", addr, err)
+	}
+
+	c, err := NewClient(conn, password, timeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// NewClient logs in over an existing connection
+func NewClient(conn net.Conn, password string, timeout time.Duration) (*Client, error) {
+	c := &Client{conn: conn, timeout: timeout, nextID: 1}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.id()
+	if err := c.write(id, typeLogin, password); err != nil {
+		return nil, err
+	}
+
+	// The server sends an empty response before the auth result, skip it
+	for {
+		respID, respType, _, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if respType == typeResponse {
+			continue
+		}
+		if respID == -1 {
+			return nil, ErrAuth
+		}
+		if respID != id {
+			return nil, fmt.Errorf("rcon: unexpected login response id %d", respID)
+		}
+		return c, nil
+	}
+}
+
+// Execute runs a command and returns its output
+func (c *Client) Execute(command string) (string, error) {
+	if len(command) > maxCommandLen {
+		return "", fmt.Errorf("rcon: command is longer than %d bytes", maxCommandLen)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmdID := c.id()
+	if err := c.write(cmdID, typeCommand, command); err != nil {
+		return "", err
+	}
+
+	// Long outputs are split over several packets. The server answers in order,
+	// so everything before the reply to the marker belongs to the command.
+	endID := c.id()
+	if err := c.write(endID, typeEndMarker, ""); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for {
+		respID, _, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch respID {
+		case cmdID:
+			out.WriteString(body)
+		case endID:
+			return out.String(), nil
+		case -1:
+			return "", ErrAuth
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) id() int32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+func (c *Client) write(id int32, packetType int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(headerSize+len(body)+2))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("rcon: sending packet: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) read() (int32, int32, string, error) {
+	if c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: reading packet: %w", err)
+	}
+	if size < headerSize+2 || size > maxPacketSize {
+		return 0, 0, "", fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, packet); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: reading packet: %w", err)
+	}
+
+	id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+	packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+	body := string(bytes.TrimRight(packet[headerSize:], "\x00"))
+
+	return id, packetType, body, nil
+}
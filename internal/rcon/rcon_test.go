@@ -0,0 +1,162 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer answers RCON packets the way a Minecraft server does.
+// Commands are answered by handler; chunk splits responses into packets of that size.
+type fakeServer struct {
+	password string
+	handler  func(command string) string
+	chunk    int
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.LittleEndian, &size); err != nil {
+			return
+		}
+		packet := make([]byte, size)
+		if _, err := io.ReadFull(conn, packet); err != nil {
+			return
+		}
+		id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+		packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+		body := string(bytes.TrimRight(packet[8:], "\x00"))
+
+		switch packetType {
+		case typeLogin:
+			if body != s.password {
+				id = -1
+			}
+			sendPacket(conn, id, typeCommand, "")
+		case typeCommand:
+			out := s.handler(body)
+			for len(out) > s.chunk {
+				sendPacket(conn, id, typeResponse, out[:s.chunk])
+				out = out[s.chunk:]
+			}
+			sendPacket(conn, id, typeResponse, out)
+		default:
+			sendPacket(conn, id, typeResponse, fmt.Sprintf("Unknown request %x", packetType))
+		}
+	}
+}
+
+func sendPacket(w io.Writer, id int32, packetType int32, body string) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	w.Write(buf.Bytes())
+}
+
+// connect logs in to a fake server on a local port
+func connect(t *testing.T, server *fakeServer, password string) (*Client, error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			server.serve(conn)
+		}
+	}()
+
+	c, err := Dial(listener.Addr().String(), password, time.Second)
+	if err == nil {
+		t.Cleanup(func() { c.Close() })
+	}
+
+	return c, err
+}
+
+func TestExecute_ReturnsOutput(t *testing.T) {
+	server := &fakeServer{
+		password: "secret",
+		handler:  func(cmd string) string { return "ran " + cmd },
+		chunk:    4096,
+	}
+
+	c, err := connect(t, server, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	out, err := c.Execute("list")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if out != "ran list" {
+		t.Errorf("Execute() = %q, want %q", out, "ran list")
+	}
+
+	// The connection stays usable for further commands
+	out, err = c.Execute("time query daytime")
+	if err != nil || out != "ran time query daytime" {
+		t.Errorf("Execute() = %q, %v, want %q", out, err, "ran time query daytime")
+	}
+}
+
+func TestExecute_JoinsFragmentedOutput(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	server := &fakeServer{
+		password: "secret",
+		handler:  func(string) string { return long },
+		chunk:    30,
+	}
+
+	c, err := connect(t, server, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	out, err := c.Execute("help")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if out != long {
+		t.Errorf("Execute() returned %d bytes, want %d", len(out), len(long))
+	}
+}
+
+func TestNewClient_WrongPassword(t *testing.T) {
+	server := &fakeServer{password: "secret", handler: func(string) string { return "" }, chunk: 4096}
+
+	_, err := connect(t, server, "wrong")
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("NewClient() error = %v, want ErrAuth", err)
+	}
+}
+
+func TestExecute_CommandTooLong(t *testing.T) {
+	server := &fakeServer{password: "secret", handler: func(string) string { return "" }, chunk: 4096}
+
+	c, err := connect(t, server, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := c.Execute(strings.Repeat("x", maxCommandLen+1)); err == nil {
+		t.Error("Execute() expected error for an overlong command, got nil")
+	}
+}
@@ -1,6 +1,7 @@
 package registration
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -30,6 +31,9 @@ func NewRegistrationHandler(k8sClient *k8s.Client) http.HandlerFunc {
 			return
 		}
 
+		// Stop talking to the API server if the client goes away
+		ctx := r.Context()
+
 		// Parse the JSON request body
 		var req RegisterRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -44,7 +48,7 @@ func NewRegistrationHandler(k8sClient *k8s.Client) http.HandlerFunc {
 		}
 
 		// Check user limits
-		count, err := k8sClient.CountUserNamespaces()
+		count, err := k8sClient.CountUserNamespaces(ctx)
 		if err != nil {
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to check user count: %v", err))
 			return
@@ -55,7 +59,7 @@ func NewRegistrationHandler(k8sClient *k8s.Client) http.HandlerFunc {
 		}
 
 		// Check if username already taken
-		exists, err := k8sClient.NamespaceExists(req.Username)
+		exists, err := k8sClient.NamespaceExists(ctx, req.Username)
 		if err != nil {
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to check username exists: %v", err))
 			return
@@ -71,52 +75,54 @@ func NewRegistrationHandler(k8sClient *k8s.Client) http.HandlerFunc {
 		namespaceCreated := false
 		capacityCheckerUpdated := false
 
+		// Cleanup must still run if the request context was cancelled
 		cleanup := func() {
+			cleanupCtx := context.WithoutCancel(ctx)
 			if capacityCheckerUpdated {
-				k8sClient.RemoveUserFromCapacityChecker(req.Username)
+				k8sClient.RemoveUserFromCapacityChecker(cleanupCtx, req.Username)
 			}
 			if namespaceCreated {
-				k8sClient.DeleteNamespace(req.Username)
+				k8sClient.DeleteNamespace(cleanupCtx, req.Username)
 			}
 		}
 
 		// Create namespace
-		if err := k8sClient.CreateNamespace(req.Username); err != nil {
+		if err := k8sClient.CreateNamespace(ctx, req.Username); err != nil {
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create namespace: %v", err))
 			return
 		}
 		namespaceCreated = true
 
 		// Create ServiceAccount
-		if err := k8sClient.CreateServiceAccount(req.Username); err != nil {
+		if err := k8sClient.CreateServiceAccount(ctx, req.Username); err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create serviceaccount: %v", err))
 			return
 		}
 
 		// Create Role
-		if err := k8sClient.CreateRole(); err != nil {
+		if err := k8sClient.CreateRole(ctx); err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create role: %v", err))
 			return
 		}
 
 		// Create RoleBinding
-		if err := k8sClient.CreateRoleBinding(req.Username); err != nil {
+		if err := k8sClient.CreateRoleBinding(ctx, req.Username); err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create rolebinding: %v", err))
 			return
 		}
 
 		// Create ResourceQuota
-		if err := k8sClient.CreateResourceQuota(req.Username); err != nil {
+		if err := k8sClient.CreateResourceQuota(ctx, req.Username); err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create resourcequota: %v", err))
 			return
 		}
 
 		// Add user to capacity checker ClusterRoleBinding
-		if err := k8sClient.AddUserToCapacityChecker(req.Username); err != nil {
+		if err := k8sClient.AddUserToCapacityChecker(ctx, req.Username); err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to add user to capacity checker: %v", err))
 			return
@@ -124,7 +130,7 @@ func NewRegistrationHandler(k8sClient *k8s.Client) http.HandlerFunc {
 		capacityCheckerUpdated = true
 
 		// Generate token
-		token, err := k8sClient.GenerateToken(req.Username)
+		token, err := k8sClient.GenerateToken(ctx, req.Username)
 		if err != nil {
 			cleanup()
 			sendError(w, http.StatusInternalServerError, fmt.Sprintf("failed to generate token: %v", err))
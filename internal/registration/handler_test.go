@@ -149,7 +149,7 @@ func TestHandler_SuccessfulRegistration(t *testing.T) {
 	}
 
 	// Verify namespace was created
-	exists, err := client.NamespaceExists(username)
+	exists, err := client.NamespaceExists(context.Background(), username)
 	if err != nil {
 		t.Fatalf("Failed to check namespace existence: %v", err)
 	}
@@ -0,0 +1,203 @@
+package registration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/config"
+	"github.com/baighasan/kubecraft/internal/k8s"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const fakeToken = "fake-service-account-token"
+
+// newFakeHandler returns a registration handler backed by a fake clientset that already
+// contains the Helm-owned capacity checker binding
+func newFakeHandler(t *testing.T, objects ...runtime.Object) (http.HandlerFunc, *fake.Clientset) {
+	t.Helper()
+
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: config.CapacityCheckerBinding},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     config.CapacityCheckerClusterRole,
+		},
+	}
+
+	clientset := fake.NewClientset(append([]runtime.Object{crb}, objects...)...)
+
+	// The fake clientset has no token controller, so answer TokenRequests directly
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		return true, &authv1.TokenRequest{Status: authv1.TokenRequestStatus{Token: fakeToken}}, nil
+	})
+
+	return NewRegistrationHandler(k8s.NewClientWithInterface(clientset, "")), clientset
+}
+
+// postRegister sends a registration request and decodes the response
+func postRegister(t *testing.T, handler http.HandlerFunc, username string) (int, RegisterResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(RegisterRequest{Username: username})
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	var response RegisterResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	return w.Code, response
+}
+
+func userNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   config.NamespacePrefix + name,
+			Labels: map[string]string{config.CommonLabelKey: config.CommonLabelValue, "user": name},
+		},
+	}
+}
+
+func TestHandler_RegistersUserAgainstFakeCluster(t *testing.T) {
+	handler, clientset := newFakeHandler(t)
+	ctx := context.Background()
+
+	code, response := postRegister(t, handler, "alice")
+	if code != http.StatusCreated {
+		t.Fatalf("Registration status = %d, want %d (message: %s)", code, http.StatusCreated, response.Message)
+	}
+	if response.Status != "success" || response.Username != "alice" || response.Token != fakeToken {
+		t.Errorf("response = %+v, want success for alice with token", response)
+	}
+
+	nsName := config.NamespacePrefix + "alice"
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, nsName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Namespace not created: %v", err)
+	}
+	if _, err := clientset.CoreV1().ServiceAccounts(nsName).Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("ServiceAccount not created: %v", err)
+	}
+	if _, err := clientset.RbacV1().Roles(nsName).Get(ctx, config.UserRoleName, metav1.GetOptions{}); err != nil {
+		t.Errorf("Role not created: %v", err)
+	}
+	if _, err := clientset.RbacV1().RoleBindings(nsName).Get(ctx, "binding-alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("RoleBinding not created: %v", err)
+	}
+
+	quotas, err := clientset.CoreV1().ResourceQuotas(nsName).List(ctx, metav1.ListOptions{})
+	if err != nil || len(quotas.Items) != 1 {
+		t.Errorf("ResourceQuotas = %v (err %v), want 1", quotas, err)
+	}
+
+	crb, err := clientset.RbacV1().ClusterRoleBindings().Get(ctx, config.CapacityCheckerBinding, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get capacity checker binding: %v", err)
+	}
+	if len(crb.Subjects) != 1 || crb.Subjects[0].Name != "alice" || crb.Subjects[0].Namespace != nsName {
+		t.Errorf("Capacity checker subjects = %v, want alice in %s", crb.Subjects, nsName)
+	}
+}
+
+func TestHandler_RejectsTakenUsername(t *testing.T) {
+	handler, _ := newFakeHandler(t, userNamespace("alice"))
+
+	code, response := postRegister(t, handler, "alice")
+	if code != http.StatusConflict {
+		t.Errorf("Duplicate registration status = %d, want %d", code, http.StatusConflict)
+	}
+	if response.Status != "error" {
+		t.Errorf("response.Status = %q, want %q", response.Status, "error")
+	}
+}
+
+func TestHandler_MaxUsersReached(t *testing.T) {
+	var objects []runtime.Object
+	for i := 0; i < config.MaxUsers; i++ {
+		objects = append(objects, userNamespace(fmt.Sprintf("user%d", i)))
+	}
+	handler, clientset := newFakeHandler(t, objects...)
+
+	code, response := postRegister(t, handler, "latecomer")
+	if code != http.StatusInternalServerError {
+		t.Errorf("Registration status = %d, want %d", code, http.StatusInternalServerError)
+	}
+	if response.Status != "error" {
+		t.Errorf("response.Status = %q, want %q", response.Status, "error")
+	}
+
+	_, err := clientset.CoreV1().Namespaces().Get(context.Background(), config.NamespacePrefix+"latecomer", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Namespace created past the user limit (err = %v)", err)
+	}
+}
+
+func TestHandler_CleansUpWhenCapacityBindingMissing(t *testing.T) {
+	handler, clientset := newFakeHandler(t)
+	ctx := context.Background()
+
+	// Simulate the Helm chart not being installed
+	err := clientset.RbacV1().ClusterRoleBindings().Delete(ctx, config.CapacityCheckerBinding, metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("Failed to delete capacity checker binding: %v", err)
+	}
+
+	code, _ := postRegister(t, handler, "alice")
+	if code != http.StatusInternalServerError {
+		t.Errorf("Registration status = %d, want %d", code, http.StatusInternalServerError)
+	}
+
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, config.NamespacePrefix+"alice", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Namespace not cleaned up after failure (err = %v)", err)
+	}
+}
+
+func TestHandler_CleansUpWhenTokenFails(t *testing.T) {
+	handler, clientset := newFakeHandler(t)
+	ctx := context.Background()
+
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		return true, nil, fmt.Errorf("token controller unavailable")
+	})
+
+	code, _ := postRegister(t, handler, "alice")
+	if code != http.StatusInternalServerError {
+		t.Errorf("Registration status = %d, want %d", code, http.StatusInternalServerError)
+	}
+
+	_, err := clientset.CoreV1().Namespaces().Get(ctx, config.NamespacePrefix+"alice", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Namespace not cleaned up after token failure (err = %v)", err)
+	}
+
+	crb, err := clientset.RbacV1().ClusterRoleBindings().Get(ctx, config.CapacityCheckerBinding, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get capacity checker binding: %v", err)
+	}
+	if len(crb.Subjects) != 0 {
+		t.Errorf("Capacity checker subjects = %v, want user removed after failure", crb.Subjects)
+	}
+}
@@ -0,0 +1,100 @@
+package world
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Export describes a world written by ExportZip
+type Export struct {
+	Files   int
+	Size    int64    // Uncompressed bytes of world data
+	Skipped []string // Other worlds of the server, which a single-player save can't hold
+}
+
+// ExportZip repackages a tar of the server directories (world, world_nether, ...) as a
+// zip holding one single-player save in folder, with the nether and the end moved back
+// into its DIM-1 and DIM1 directories
+func ExportZip(worldTar io.Reader, w io.Writer, folder string) (*Export, error) {
+	zw := zip.NewWriter(w)
+	export := &Export{}
+
+	tr := tar.NewReader(worldTar)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading world data: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		rel, ok := singlePlayerPath(name)
+		if !ok {
+			dir, _, _ := strings.Cut(name, "/")
+			if !slices.Contains([]string{Overworld, Nether, End}, dir) && !slices.Contains(export.Skipped, dir) {
+				export.Skipped = append(export.Skipped, dir)
+			}
+			continue
+		}
+
+		fh := &zip.FileHeader{
+			Name:     path.Join(folder, rel),
+			Modified: hdr.ModTime,
+			Method:   zip.Deflate,
+		}
+		fh.SetMode(hdr.FileInfo().Mode())
+		if path.Ext(name) == ".mca" {
+			// Chunks are compressed already
+			fh.Method = zip.Store
+		}
+
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", fh.Name, err)
+		}
+		n, err := io.Copy(fw, tr)
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", fh.Name, err)
+		}
+		export.Files++
+		export.Size += n
+	}
+
+	if export.Files == 0 {
+		return nil, fmt.Errorf("no world files found")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("finishing zip: %w", err)
+	}
+
+	return export, nil
+}
+
+// singlePlayerPath maps a path in the server directories to its place in a
+// single-player save. Files of the nether and end worlds outside their dimension
+// directories, such as their own level.dat, have no place there.
+func singlePlayerPath(name string) (string, bool) {
+	if rel, ok := strings.CutPrefix(name, Overworld+"/"); ok {
+		// Held by the server, the game makes its own
+		return rel, rel != "session.lock"
+	}
+	if rel, ok := strings.CutPrefix(name, Nether+"/"); ok && strings.HasPrefix(rel, "DIM-1/") {
+		return rel, true
+	}
+	if rel, ok := strings.CutPrefix(name, End+"/"); ok && strings.HasPrefix(rel, "DIM1/") {
+		return rel, true
+	}
+
+	return "", false
+}
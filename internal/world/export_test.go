@@ -0,0 +1,107 @@
+package world
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// serverTar returns a tar of server directories holding files
+func serverTar(files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	return &buf
+}
+
+func TestExportZip_SinglePlayerLayout(t *testing.T) {
+	worldTar := serverTar(map[string]string{
+		"world/level.dat":                     "level",
+		"world/session.lock":                  "lock",
+		"world/region/r.0.0.mca":              "overworld",
+		"world_nether/level.dat":              "nether level",
+		"world_nether/DIM-1/region/r.0.0.mca": "nether",
+		"world_the_end/DIM1/region/r.0.0.mca": "end",
+		"world_creative/level.dat":            "other world",
+	})
+
+	var out bytes.Buffer
+	export, err := ExportZip(worldTar, &out, "myserver")
+	if err != nil {
+		t.Fatalf("ExportZip() error = %v", err)
+	}
+	if export.Files != 4 || !slices.Equal(export.Skipped, []string{"world_creative"}) {
+		t.Errorf("ExportZip() = %+v, want 4 files and world_creative skipped", export)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		got[f.Name] = string(data)
+	}
+
+	want := map[string]string{
+		"myserver/level.dat":              "level",
+		"myserver/region/r.0.0.mca":       "overworld",
+		"myserver/DIM-1/region/r.0.0.mca": "nether",
+		"myserver/DIM1/region/r.0.0.mca":  "end",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("zip files = %v, want %v", got, want)
+	}
+}
+
+func TestExportZip_RoundTripsThroughImport(t *testing.T) {
+	level := testLevel(t, "myserver", "1.21.11", 4671)
+	worldTar := serverTar(map[string]string{
+		"world/level.dat":                     string(level),
+		"world_nether/DIM-1/region/r.0.0.mca": "nether",
+		"world_the_end/DIM1/region/r.0.0.mca": "end",
+	})
+
+	p := filepath.Join(t.TempDir(), "world.zip")
+	var out bytes.Buffer
+	if _, err := ExportZip(worldTar, &out, "myserver"); err != nil {
+		t.Fatalf("ExportZip() error = %v", err)
+	}
+	if err := os.WriteFile(p, out.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+
+	im, err := OpenImport(p)
+	if err != nil {
+		t.Fatalf("OpenImport() error = %v", err)
+	}
+	defer im.Close()
+
+	want := map[string]string{
+		"world/level.dat":                     string(level),
+		"world_nether/DIM-1/region/r.0.0.mca": "nether",
+		"world_the_end/DIM1/region/r.0.0.mca": "end",
+	}
+	if got := tarContents(t, im); !maps.Equal(got, want) {
+		t.Errorf("imported files = %v, want the exported server layout", slices.Sorted(maps.Keys(got)))
+	}
+}
+
+func TestExportZip_NoWorld(t *testing.T) {
+	if _, err := ExportZip(serverTar(nil), io.Discard, "myserver"); err == nil {
+		t.Error("ExportZip() expected error for an empty world, got nil")
+	}
+}
@@ -0,0 +1,253 @@
+package world
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Server directories a world is split into, as Paper expects them under /data.
+// Single-player saves keep the nether and the end inside the world, in DIM-1 and
+// DIM1, Paper keeps each dimension in a world of its own.
+const (
+	Overworld = "world"
+	Nether    = "world_nether"
+	End       = "world_the_end"
+)
+
+// Import is a world found in a zip archive or directory, mapped onto the server layout
+type Import struct {
+	Level *Level
+	Files int
+	Size  int64 // Uncompressed bytes of world data
+
+	fsys   fs.FS
+	files  []importFile
+	closer io.Closer
+}
+
+type importFile struct {
+	source string
+	target string
+	mode   fs.FileMode
+	size   int64
+}
+
+// OpenImport finds the world in a zip archive or directory at p. The world is the
+// shallowest directory with a level.dat, in single-player layout (nether and end in
+// DIM-1 and DIM1) or server layout (next to <name>_nether and <name>_the_end).
+func OpenImport(p string) (*Import, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &Import{}
+	if info.IsDir() {
+		im.fsys = os.DirFS(p)
+	} else {
+		zr, err := zip.OpenReader(p)
+		if errors.Is(err, zip.ErrInsecurePath) {
+			zr.Close()
+			return nil, fmt.Errorf("%s has entries outside the archive", p)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a zip archive or directory: %w", p, err)
+		}
+		im.fsys, im.closer = zr, zr
+	}
+
+	if err := im.scan(); err != nil {
+		im.Close()
+		return nil, err
+	}
+
+	return im, nil
+}
+
+// Close releases the zip archive, if the world is in one
+func (im *Import) Close() error {
+	if im.closer == nil {
+		return nil
+	}
+	return im.closer.Close()
+}
+
+// scan locates the world root, reads its level.dat and maps every file onto the
+// server layout
+func (im *Import) scan() error {
+	var files []importFile
+	root := ""
+	err := fs.WalkDir(im.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skipped(d.Name()) {
+			if d.IsDir() && p != "." {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, importFile{source: p, mode: info.Mode().Perm(), size: info.Size()})
+
+		// Shallowest wins, a world may contain other level.dat files, e.g. in datapacks
+		if d.Name() == LevelFile && (root == "" || depth(p) < depth(root)) {
+			root = p
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading world: %w", err)
+	}
+	if root == "" {
+		return fmt.Errorf("no %s found, this does not look like a Minecraft world", LevelFile)
+	}
+
+	level, err := im.readLevel(root)
+	if err != nil {
+		return err
+	}
+	im.Level = level
+
+	mapping := newLayout(path.Dir(root))
+	for _, f := range files {
+		target, ok := mapping.target(f.source)
+		if !ok {
+			continue
+		}
+		f.target = target
+		if f.mode == 0 {
+			// Zip archives made on Windows carry no permissions
+			f.mode = 0644
+		}
+		im.files = append(im.files, f)
+		im.Files++
+		im.Size += f.size
+	}
+
+	return nil
+}
+
+func (im *Import) readLevel(p string) (*Level, error) {
+	f, err := im.fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadLevel(f)
+}
+
+// WriteTar writes the world as a plain tar of the server directories to w, in the
+// form the restore scripts unpack
+func (im *Import) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	for _, f := range im.files {
+		if err := im.addFile(tw, f); err != nil {
+			return fmt.Errorf("copying %s: %w", f.source, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+func (im *Import) addFile(tw *tar.Writer, f importFile) error {
+	src, err := im.fsys.Open(f.source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     f.target,
+		Mode:     int64(f.mode),
+		Size:     f.size,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The size in the header must be exact, even if the file changed since the scan
+	n, err := io.Copy(tw, io.LimitReader(src, f.size))
+	if err == nil && n != f.size {
+		err = fmt.Errorf("file shrank from %d to %d bytes", f.size, n)
+	}
+	return err
+}
+
+// layout maps paths in the imported world onto the server directories
+type layout struct {
+	root   string // Directory holding level.dat, "." for the top
+	nether string // Sibling dimension directories of a server layout
+	end    string
+}
+
+func newLayout(root string) layout {
+	l := layout{root: root}
+	if root != "." {
+		l.nether = root + "_nether"
+		l.end = root + "_the_end"
+	}
+	return l
+}
+
+func (l layout) target(p string) (string, bool) {
+	if l.nether != "" {
+		if rel, ok := within(p, l.nether); ok {
+			return path.Join(Nether, rel), true
+		}
+		if rel, ok := within(p, l.end); ok {
+			return path.Join(End, rel), true
+		}
+	}
+
+	rel, ok := within(p, l.root)
+	if !ok {
+		// Files next to the world, e.g. a server's plugins or a README
+		return "", false
+	}
+
+	switch {
+	case rel == "session.lock":
+		// Held by the game that saved the world, the server makes its own
+		return "", false
+	case strings.HasPrefix(rel, "DIM-1/"):
+		return path.Join(Nether, rel), true
+	case strings.HasPrefix(rel, "DIM1/"):
+		return path.Join(End, rel), true
+	default:
+		return path.Join(Overworld, rel), true
+	}
+}
+
+// within returns p relative to dir, if it is inside it
+func within(p string, dir string) (string, bool) {
+	if dir == "." {
+		return p, true
+	}
+	return strings.CutPrefix(p, dir+"/")
+}
+
+// skipped reports whether a file or directory is operating system clutter
+func skipped(name string) bool {
+	return name == "__MACOSX" || name == ".DS_Store" || name == "Thumbs.db" || strings.HasPrefix(name, "._")
+}
+
+func depth(p string) int {
+	return strings.Count(p, "/")
+}
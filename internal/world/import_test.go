@@ -0,0 +1,184 @@
+package world
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeZip writes files to a zip archive in a temp dir
+func writeZip(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "world.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		w.Write(files[name])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+
+	return p
+}
+
+// writeDir writes files to a temp dir
+func writeDir(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	return dir
+}
+
+// tarContents returns the files WriteTar produced, by name
+func tarContents(t *testing.T, im *Import) map[string]string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := im.WriteTar(&buf); err != nil {
+		t.Fatalf("WriteTar() error = %v", err)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+}
+
+func TestOpenImport_SinglePlayerZip(t *testing.T) {
+	level := testLevel(t, "My World", "1.21.4", 4189)
+	p := writeZip(t, map[string][]byte{
+		"My World/level.dat":              level,
+		"My World/session.lock":           []byte("lock"),
+		"My World/region/r.0.0.mca":       []byte("overworld"),
+		"My World/DIM-1/region/r.0.0.mca": []byte("nether"),
+		"My World/DIM1/region/r.0.0.mca":  []byte("end"),
+		"My World/datapacks/x/level.dat":  []byte("not the root"),
+		"__MACOSX/My World/._level.dat":   []byte("junk"),
+	})
+
+	im, err := OpenImport(p)
+	if err != nil {
+		t.Fatalf("OpenImport() error = %v", err)
+	}
+	defer im.Close()
+
+	if im.Level.Name != "My World" || im.Level.Version != "1.21.4" {
+		t.Errorf("Level = %+v, want My World from 1.21.4", im.Level)
+	}
+
+	want := map[string]string{
+		"world/level.dat":                     string(level),
+		"world/region/r.0.0.mca":              "overworld",
+		"world/datapacks/x/level.dat":         "not the root",
+		"world_nether/DIM-1/region/r.0.0.mca": "nether",
+		"world_the_end/DIM1/region/r.0.0.mca": "end",
+	}
+	got := tarContents(t, im)
+	if !maps.Equal(got, want) {
+		t.Errorf("WriteTar() files = %v, want %v", slices.Sorted(maps.Keys(got)), slices.Sorted(maps.Keys(want)))
+	}
+	if im.Files != len(want) {
+		t.Errorf("Files = %d, want %d", im.Files, len(want))
+	}
+}
+
+func TestOpenImport_LevelAtTop(t *testing.T) {
+	p := writeZip(t, map[string][]byte{
+		"level.dat":              testLevel(t, "Flat", "1.21.11", 4671),
+		"region/r.0.0.mca":       []byte("overworld"),
+		"DIM-1/region/r.0.0.mca": []byte("nether"),
+	})
+
+	im, err := OpenImport(p)
+	if err != nil {
+		t.Fatalf("OpenImport() error = %v", err)
+	}
+	defer im.Close()
+
+	got := slices.Sorted(maps.Keys(tarContents(t, im)))
+	want := []string{"world/level.dat", "world/region/r.0.0.mca", "world_nether/DIM-1/region/r.0.0.mca"}
+	if !slices.Equal(got, want) {
+		t.Errorf("WriteTar() files = %v, want %v", got, want)
+	}
+}
+
+func TestOpenImport_ServerLayoutDir(t *testing.T) {
+	dir := writeDir(t, map[string][]byte{
+		"server/survival/level.dat":                     testLevel(t, "survival", "1.21.11", 4671),
+		"server/survival/region/r.0.0.mca":              []byte("overworld"),
+		"server/survival_nether/DIM-1/region/r.0.0.mca": []byte("nether"),
+		"server/survival_the_end/DIM1/region/r.0.0.mca": []byte("end"),
+		"server/plugins/Essentials.jar":                 []byte("plugin"),
+	})
+
+	im, err := OpenImport(dir)
+	if err != nil {
+		t.Fatalf("OpenImport() error = %v", err)
+	}
+	defer im.Close()
+
+	got := slices.Sorted(maps.Keys(tarContents(t, im)))
+	want := []string{
+		"world/level.dat",
+		"world/region/r.0.0.mca",
+		"world_nether/DIM-1/region/r.0.0.mca",
+		"world_the_end/DIM1/region/r.0.0.mca",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("WriteTar() files = %v, want %v", got, want)
+	}
+}
+
+func TestOpenImport_NoLevelDat(t *testing.T) {
+	p := writeZip(t, map[string][]byte{"photos/cat.png": []byte("meow")})
+
+	if _, err := OpenImport(p); err == nil {
+		t.Error("OpenImport() expected error without a level.dat, got nil")
+	}
+}
+
+func TestOpenImport_NotAnArchive(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "world.zip")
+	os.WriteFile(p, []byte("not a zip"), 0644)
+
+	if _, err := OpenImport(p); err == nil {
+		t.Error("OpenImport() expected error for a non-zip file, got nil")
+	}
+}
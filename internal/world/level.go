@@ -0,0 +1,178 @@
+// Package world reads Minecraft worlds: their level.dat and the folder layouts
+// single-player saves and servers use.
+package world
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/baighasan/kubecraft/internal/nbt"
+)
+
+// LevelFile is the file every world has at its root
+const LevelFile = "level.dat"
+
+// Level is the part of a level.dat kubecraft looks at
+type Level struct {
+	Name        string
+	DataVersion int    // 0 for worlds saved before 1.9
+	Version     string // Minecraft version that last saved the world, empty before 1.9
+	Seed        int64
+	SpawnX      int
+	SpawnY      int
+	SpawnZ      int
+	GameTime    int64 // Ticks the world has run
+	DayTime     int64 // Ticks of the day-night cycle, only advancing while it is on
+	Difficulty  string
+	Hardcore    bool
+	DataPacks   []string // Enabled datapacks, in load order
+}
+
+// TicksPerDay is the length of a Minecraft day
+const TicksPerDay = 24000
+
+// Day returns the in-game day, as the client counts it in its statistics
+func (l *Level) Day() int64 {
+	return l.DayTime / TicksPerDay
+}
+
+// difficulties are the names of the difficulty ids level.dat stores
+var difficulties = []string{"peaceful", "easy", "normal", "hard"}
+
+// ReadLevel decodes a gzip-compressed level.dat
+func ReadLevel(r io.Reader) (*Level, error) {
+	_, root, err := nbt.ReadCompressed(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", LevelFile, err)
+	}
+
+	data := root.Compound("Data")
+	if data == nil {
+		return nil, fmt.Errorf("%s has no Data tag", LevelFile)
+	}
+
+	level := &Level{
+		Name:     data.String("LevelName"),
+		Version:  data.Compound("Version").String("Name"),
+		Hardcore: data.Bool("hardcore"),
+	}
+	dataVersion, _ := data.Int("DataVersion")
+	level.DataVersion = int(dataVersion)
+	level.GameTime, _ = data.Int("Time")
+	level.DayTime, _ = data.Int("DayTime")
+
+	// Moved into the world generation settings in 1.16
+	if seed, ok := data.Compound("WorldGenSettings").Int("seed"); ok {
+		level.Seed = seed
+	} else {
+		level.Seed, _ = data.Int("RandomSeed")
+	}
+
+	// Newer versions keep the spawn point in a compound of its own
+	if pos, ok := data.Compound("spawn")["pos"].([]int32); ok && len(pos) == 3 {
+		level.SpawnX, level.SpawnY, level.SpawnZ = int(pos[0]), int(pos[1]), int(pos[2])
+	} else {
+		x, _ := data.Int("SpawnX")
+		y, _ := data.Int("SpawnY")
+		z, _ := data.Int("SpawnZ")
+		level.SpawnX, level.SpawnY, level.SpawnZ = int(x), int(y), int(z)
+	}
+
+	if id, ok := data.Int("Difficulty"); ok && id >= 0 && int(id) < len(difficulties) {
+		level.Difficulty = difficulties[id]
+	} else {
+		level.Difficulty = data.Compound("difficulty_settings").String("difficulty")
+	}
+
+	for _, pack := range data.Compound("DataPacks").List("Enabled") {
+		if name, ok := pack.(string); ok {
+			level.DataPacks = append(level.DataPacks, name)
+		}
+	}
+
+	return level, nil
+}
+
+// Downgrade reports whether a server running serverVersion is older than the game
+// that last saved the world. Minecraft does not support loading such worlds and may
+// lose chunks or items doing so.
+func (l *Level) Downgrade(serverVersion string) bool {
+	if target, ok := dataVersions[serverVersion]; ok && l.DataVersion > 0 {
+		return l.DataVersion > target
+	}
+
+	return l.Version != "" && compareVersions(l.Version, serverVersion) > 0
+}
+
+// dataVersions maps releases Paper builds exist for to the data version their worlds
+// are saved with
+var dataVersions = map[string]int{
+	"1.16.5":  2586,
+	"1.17":    2724,
+	"1.17.1":  2730,
+	"1.18":    2860,
+	"1.18.1":  2865,
+	"1.18.2":  2975,
+	"1.19":    3105,
+	"1.19.1":  3117,
+	"1.19.2":  3120,
+	"1.19.3":  3218,
+	"1.19.4":  3337,
+	"1.20":    3463,
+	"1.20.1":  3465,
+	"1.20.2":  3578,
+	"1.20.3":  3698,
+	"1.20.4":  3700,
+	"1.20.5":  3837,
+	"1.20.6":  3839,
+	"1.21":    3953,
+	"1.21.1":  3955,
+	"1.21.2":  4080,
+	"1.21.3":  4082,
+	"1.21.4":  4189,
+	"1.21.5":  4325,
+	"1.21.6":  4435,
+	"1.21.7":  4438,
+	"1.21.8":  4440,
+	"1.21.9":  4554,
+	"1.21.10": 4556,
+	"1.21.11": 4671,
+}
+
+// compareVersions orders release versions such as 1.21 and 1.21.4. Versions that are
+// not releases, such as snapshots, compare equal to everything.
+func compareVersions(a, b string) int {
+	pa, okA := versionParts(a)
+	pb, okB := versionParts(b)
+	if !okA || !okB {
+		return 0
+	}
+
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) ([]int, bool) {
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
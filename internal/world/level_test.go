@@ -0,0 +1,145 @@
+package world
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/nbt"
+)
+
+// testLevel returns a gzipped level.dat saved by version with dataVersion
+func testLevel(t *testing.T, name string, version string, dataVersion int32) []byte {
+	t.Helper()
+
+	data := nbt.Compound{"LevelName": name}
+	if version != "" {
+		data["DataVersion"] = dataVersion
+		data["Version"] = nbt.Compound{"Name": version, "Id": dataVersion}
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := nbt.Write(zw, "", nbt.Compound{"Data": data}); err != nil {
+		t.Fatalf("nbt.Write() error = %v", err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestReadLevel(t *testing.T) {
+	level, err := ReadLevel(bytes.NewReader(testLevel(t, "Survival", "1.21.4", 4189)))
+	if err != nil {
+		t.Fatalf("ReadLevel() error = %v", err)
+	}
+
+	if level.Name != "Survival" || level.DataVersion != 4189 || level.Version != "1.21.4" {
+		t.Errorf("ReadLevel() = %+v, want Survival saved by 1.21.4 (4189)", level)
+	}
+}
+
+func TestReadLevel_Details(t *testing.T) {
+	tests := map[string]nbt.Compound{
+		"1.21.4": {
+			"WorldGenSettings": nbt.Compound{"seed": int64(-4172144997902289642)},
+			"SpawnX":           int32(16),
+			"SpawnY":           int32(70),
+			"SpawnZ":           int32(-32),
+			"Difficulty":       int8(2),
+		},
+		"newer layout": {
+			"WorldGenSettings":    nbt.Compound{"seed": int64(-4172144997902289642)},
+			"spawn":               nbt.Compound{"pos": []int32{16, 70, -32}, "dimension": "minecraft:overworld"},
+			"difficulty_settings": nbt.Compound{"difficulty": "normal", "locked": int8(0)},
+		},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			data["Time"] = int64(1_000_000)
+			data["DayTime"] = int64(3*TicksPerDay + 6000)
+			data["hardcore"] = int8(1)
+			data["DataPacks"] = nbt.Compound{
+				"Enabled":  nbt.List{"vanilla", "file/terralith.zip"},
+				"Disabled": nbt.List{"minecraft_improvements"},
+			}
+
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if err := nbt.Write(zw, "", nbt.Compound{"Data": data}); err != nil {
+				t.Fatalf("nbt.Write() error = %v", err)
+			}
+			zw.Close()
+
+			level, err := ReadLevel(&buf)
+			if err != nil {
+				t.Fatalf("ReadLevel() error = %v", err)
+			}
+
+			want := &Level{
+				Seed:       -4172144997902289642,
+				SpawnX:     16,
+				SpawnY:     70,
+				SpawnZ:     -32,
+				GameTime:   1_000_000,
+				DayTime:    3*TicksPerDay + 6000,
+				Difficulty: "normal",
+				Hardcore:   true,
+				DataPacks:  []string{"vanilla", "file/terralith.zip"},
+			}
+			if !reflect.DeepEqual(level, want) {
+				t.Errorf("ReadLevel() = %+v\nwant %+v", level, want)
+			}
+			if level.Day() != 3 {
+				t.Errorf("Day() = %d, want 3", level.Day())
+			}
+		})
+	}
+}
+
+func TestReadLevel_OldSeed(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	nbt.Write(zw, "", nbt.Compound{"Data": nbt.Compound{"RandomSeed": int64(42)}})
+	zw.Close()
+
+	level, err := ReadLevel(&buf)
+	if err != nil {
+		t.Fatalf("ReadLevel() error = %v", err)
+	}
+	if level.Seed != 42 {
+		t.Errorf("Seed = %d, want 42", level.Seed)
+	}
+}
+
+func TestReadLevel_NotALevel(t *testing.T) {
+	if _, err := ReadLevel(bytes.NewReader([]byte("not nbt at all"))); err == nil {
+		t.Error("ReadLevel() expected error, got nil")
+	}
+}
+
+func TestLevel_Downgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  Level
+		server string
+		want   bool
+	}{
+		{"same version", Level{DataVersion: 4671, Version: "1.21.11"}, "1.21.11", false},
+		{"upgrade", Level{DataVersion: 3465, Version: "1.20.1"}, "1.21.11", false},
+		{"newer patch", Level{DataVersion: 4189, Version: "1.21.4"}, "1.21.1", true},
+		{"newer major", Level{DataVersion: 3953, Version: "1.21"}, "1.20.6", true},
+		{"unknown server version falls back to names", Level{DataVersion: 9999, Version: "1.22.1"}, "1.22", true},
+		{"pre-1.9 world", Level{}, "1.21.11", false},
+		{"snapshot", Level{Version: "25w02a"}, "1.30", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.level.Downgrade(tt.server); got != tt.want {
+				t.Errorf("Downgrade(%s) = %t, want %t", tt.server, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package world
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// DatapacksDir is where the server loads datapacks from, relative to /data
+const DatapacksDir = Overworld + "/datapacks"
+
+// PackMeta is the file at the root of every datapack and resource pack
+const PackMeta = "pack.mcmeta"
+
+// Pack is a datapack or resource pack in a zip archive
+type Pack struct {
+	Description string
+	MinFormat   int // Pack formats the pack declares it works with, major versions only
+	MaxFormat   int
+	Worldgen    bool // Changes world generation or dimensions, which only load on a start
+}
+
+// packMeta is pack.mcmeta. Formats are a number before 1.21.9 and may be a
+// [major, minor] pair since, supported_formats also a range or an object.
+type packMeta struct {
+	Pack *struct {
+		PackFormat       json.RawMessage `json:"pack_format"`
+		SupportedFormats json.RawMessage `json:"supported_formats"`
+		MinFormat        json.RawMessage `json:"min_format"`
+		MaxFormat        json.RawMessage `json:"max_format"`
+		Description      json.RawMessage `json:"description"`
+	} `json:"pack"`
+}
+
+// OpenPack reads the pack.mcmeta of the pack in the zip archive at p
+func OpenPack(p string) (*Pack, error) {
+	zr, err := zip.OpenReader(p)
+	if errors.Is(err, zip.ErrInsecurePath) {
+		zr.Close()
+		return nil, fmt.Errorf("%s has entries outside the archive", p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a zip archive: %w", p, err)
+	}
+	defer zr.Close()
+
+	return readPack(zr)
+}
+
+func readPack(fsys fs.FS) (*Pack, error) {
+	f, err := fsys.Open(PackMeta)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no %s at the root of the pack", PackMeta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", PackMeta, err)
+	}
+	var meta packMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PackMeta, err)
+	}
+	if meta.Pack == nil {
+		return nil, fmt.Errorf("invalid %s: no pack section", PackMeta)
+	}
+
+	pack := &Pack{Description: packDescription(meta.Pack.Description)}
+	switch {
+	case meta.Pack.MinFormat != nil || meta.Pack.MaxFormat != nil:
+		pack.MinFormat, err = parseFormat(meta.Pack.MinFormat)
+		if err == nil {
+			pack.MaxFormat, err = parseFormat(meta.Pack.MaxFormat)
+		}
+	case meta.Pack.SupportedFormats != nil:
+		pack.MinFormat, pack.MaxFormat, err = parseFormatRange(meta.Pack.SupportedFormats)
+	default:
+		pack.MinFormat, err = parseFormat(meta.Pack.PackFormat)
+		pack.MaxFormat = pack.MinFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PackMeta, err)
+	}
+	if pack.MinFormat <= 0 || pack.MaxFormat < pack.MinFormat {
+		return nil, fmt.Errorf("invalid %s: pack format %d-%d", PackMeta, pack.MinFormat, pack.MaxFormat)
+	}
+
+	pack.Worldgen, err = hasWorldgen(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return pack, nil
+}
+
+// Supports reports whether the pack declares it works with a pack format
+func (p *Pack) Supports(format int) bool {
+	return format >= p.MinFormat && format <= p.MaxFormat
+}
+
+// parseFormat reads a pack format, 48 or [88, 0]
+func parseFormat(raw json.RawMessage) (int, error) {
+	if raw == nil {
+		return 0, fmt.Errorf("no pack format")
+	}
+
+	var n float64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return int(n), nil
+	}
+	var version []int
+	if err := json.Unmarshal(raw, &version); err == nil && len(version) > 0 {
+		return version[0], nil
+	}
+
+	return 0, fmt.Errorf("invalid pack format %s", raw)
+}
+
+// parseFormatRange reads supported_formats: 48, [48, 57] or
+// {"min_inclusive": 48, "max_inclusive": 57}
+func parseFormatRange(raw json.RawMessage) (int, int, error) {
+	var bounds []int
+	if err := json.Unmarshal(raw, &bounds); err == nil && len(bounds) == 2 {
+		return bounds[0], bounds[1], nil
+	}
+	var object struct {
+		Min *int `json:"min_inclusive"`
+		Max *int `json:"max_inclusive"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil && object.Min != nil && object.Max != nil {
+		return *object.Min, *object.Max, nil
+	}
+
+	format, err := parseFormat(raw)
+	return format, format, err
+}
+
+// packDescription renders a description given as text or as a text component
+func packDescription(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var component struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &component); err == nil {
+		return component.Text
+	}
+	var components []json.RawMessage
+	if err := json.Unmarshal(raw, &components); err == nil {
+		var b strings.Builder
+		for _, c := range components {
+			b.WriteString(packDescription(c))
+		}
+		return b.String()
+	}
+
+	return ""
+}
+
+// hasWorldgen reports whether the pack has files under data/<namespace>/worldgen,
+// dimension or dimension_type
+func hasWorldgen(fsys fs.FS) (bool, error) {
+	namespaces, err := fs.ReadDir(fsys, "data")
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, ns := range namespaces {
+		for _, dir := range []string{"worldgen", "dimension", "dimension_type"} {
+			if _, err := fs.Stat(fsys, "data/"+ns.Name()+"/"+dir); err == nil {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// DatapackFormat returns the datapack format a release loads, false for versions it is
+// not known for
+func DatapackFormat(version string) (int, bool) {
+	format, ok := datapackFormats[version]
+	return format, ok
+}
+
+// datapackFormats maps releases Paper builds exist for to their datapack format
+var datapackFormats = map[string]int{
+	"1.16.5":  6,
+	"1.17":    7,
+	"1.17.1":  7,
+	"1.18":    8,
+	"1.18.1":  8,
+	"1.18.2":  9,
+	"1.19":    10,
+	"1.19.1":  10,
+	"1.19.2":  10,
+	"1.19.3":  10,
+	"1.19.4":  12,
+	"1.20":    15,
+	"1.20.1":  15,
+	"1.20.2":  18,
+	"1.20.3":  26,
+	"1.20.4":  26,
+	"1.20.5":  41,
+	"1.20.6":  41,
+	"1.21":    48,
+	"1.21.1":  48,
+	"1.21.2":  57,
+	"1.21.3":  57,
+	"1.21.4":  61,
+	"1.21.5":  71,
+	"1.21.6":  80,
+	"1.21.7":  81,
+	"1.21.8":  81,
+	"1.21.9":  88,
+	"1.21.10": 88,
+	"1.21.11": 94,
+}
@@ -0,0 +1,108 @@
+package world
+
+import "testing"
+
+func TestOpenPack(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     string
+		files    []string
+		want     Pack
+		supports int
+	}{
+		{
+			name:     "pack_format",
+			meta:     `{"pack": {"pack_format": 48, "description": "Terralith"}}`,
+			want:     Pack{Description: "Terralith", MinFormat: 48, MaxFormat: 48},
+			supports: 48,
+		},
+		{
+			name:     "supported_formats range",
+			meta:     `{"pack": {"pack_format": 48, "supported_formats": [48, 61], "description": {"text": "Tweaks"}}}`,
+			want:     Pack{Description: "Tweaks", MinFormat: 48, MaxFormat: 61},
+			supports: 57,
+		},
+		{
+			name:     "supported_formats object",
+			meta:     `{"pack": {"pack_format": 26, "supported_formats": {"min_inclusive": 18, "max_inclusive": 26}, "description": ["A", {"text": "B"}]}}`,
+			want:     Pack{Description: "AB", MinFormat: 18, MaxFormat: 26},
+			supports: 18,
+		},
+		{
+			name:     "min and max format",
+			meta:     `{"pack": {"min_format": [88, 0], "max_format": 94.1, "description": ""}}`,
+			files:    []string{"data/terralith/worldgen/biome/desert.json"},
+			want:     Pack{MinFormat: 88, MaxFormat: 94, Worldgen: true},
+			supports: 94,
+		},
+		{
+			name:     "dimension",
+			meta:     `{"pack": {"pack_format": 61}}`,
+			files:    []string{"data/mypack/function/tick.mcfunction", "data/mypack/dimension/mine.json"},
+			want:     Pack{MinFormat: 61, MaxFormat: 61, Worldgen: true},
+			supports: 61,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{PackMeta: []byte(tt.meta)}
+			for _, name := range tt.files {
+				files[name] = []byte("{}")
+			}
+
+			pack, err := OpenPack(writeZip(t, files))
+			if err != nil {
+				t.Fatalf("OpenPack() error = %v", err)
+			}
+			if *pack != tt.want {
+				t.Errorf("OpenPack() = %+v, want %+v", *pack, tt.want)
+			}
+			if !pack.Supports(tt.supports) || pack.Supports(tt.want.MaxFormat+1) {
+				t.Errorf("Supports() wrong for %d-%d", pack.MinFormat, pack.MaxFormat)
+			}
+		})
+	}
+}
+
+func TestOpenPack_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string][]byte
+	}{
+		{name: "no pack.mcmeta", files: map[string][]byte{"data/mypack/function/tick.mcfunction": nil}},
+		{name: "nested pack.mcmeta", files: map[string][]byte{"mypack/pack.mcmeta": []byte(`{"pack": {"pack_format": 48}}`)}},
+		{name: "not json", files: map[string][]byte{PackMeta: []byte("pack_format=48")}},
+		{name: "no pack section", files: map[string][]byte{PackMeta: []byte(`{"filter": {}}`)}},
+		{name: "no format", files: map[string][]byte{PackMeta: []byte(`{"pack": {"description": "x"}}`)}},
+		{name: "inverted range", files: map[string][]byte{PackMeta: []byte(`{"pack": {"supported_formats": [61, 48]}}`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenPack(writeZip(t, tt.files)); err == nil {
+				t.Error("OpenPack() expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := OpenPack(writeDir(t, map[string][]byte{PackMeta: []byte(`{"pack": {"pack_format": 48}}`)})); err == nil {
+		t.Error("OpenPack() expected error for a directory, got nil")
+	}
+}
+
+func TestDatapackFormat(t *testing.T) {
+	if format, ok := DatapackFormat("1.21.4"); !ok || format != 61 {
+		t.Errorf("DatapackFormat(1.21.4) = %d, %v, want 61", format, ok)
+	}
+	if _, ok := DatapackFormat("1.8.9"); ok {
+		t.Error("DatapackFormat(1.8.9) known, want unknown")
+	}
+
+	// Every release a world can be checked against has a datapack format
+	for version := range dataVersions {
+		if _, ok := DatapackFormat(version); !ok {
+			t.Errorf("DatapackFormat(%s) unknown", version)
+		}
+	}
+}
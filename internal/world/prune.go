@@ -0,0 +1,279 @@
+package world
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// chunkWidth is the width of a chunk in blocks
+const chunkWidth = 16
+
+// dimension is where a server keeps a dimension's region files, relative to its
+// data directory
+type dimension struct {
+	name      string
+	dir       string
+	fromSpawn bool // The keep radius is measured from the world spawn instead of 0,0
+}
+
+var dimensions = []dimension{
+	{"overworld", Overworld, true},
+	{"nether", filepath.Join(Nether, "DIM-1"), false},
+	{"end", filepath.Join(End, "DIM1"), false},
+}
+
+// regionDir holds a dimension's chunks. The entities and points of interest in them
+// are kept in files of the same names in directories of their own.
+const regionDir = "region"
+
+var chunkDirs = []string{regionDir, "entities", "poi"}
+
+// PruneOptions selects the chunks Prune removes
+type PruneOptions struct {
+	InhabitedBelow int64 // Ticks players spent in a chunk, below which it is removed
+	KeepRadius     int   // Blocks around the spawn within which chunks are always kept
+	Apply          bool  // Rewrite the region files, otherwise only report
+}
+
+// DimensionReport is what Prune found in a dimension
+type DimensionReport struct {
+	Name       string `json:"name"`
+	Dir        string `json:"dir"`
+	Size       int64  `json:"size"`       // Bytes of the dimension's region, entities and poi files
+	Chunks     int    `json:"chunks"`     // Chunks in the dimension's region files
+	Prunable   int    `json:"prunable"`   // Chunks matching the prune options
+	Freed      int64  `json:"freed"`      // Bytes freed by removing the prunable chunks
+	Unreadable int    `json:"unreadable"` // Chunks that couldn't be decoded and are always kept
+}
+
+// PruneReport is what Prune found, or removed when Applied
+type PruneReport struct {
+	Dimensions []DimensionReport `json:"dimensions"`
+	Damaged    []string          `json:"damaged,omitempty"` // Files that aren't valid region files and were left alone
+	Applied    bool              `json:"applied"`
+}
+
+// Prune finds the chunks of the server world in dataDir that players spent less than
+// opts.InhabitedBelow ticks in and that lie outside opts.KeepRadius, so the game
+// generates them anew the next time they load. With opts.Apply it removes them,
+// rewriting each region file without gaps. The server must not be running.
+func Prune(dataDir string, opts PruneOptions) (*PruneReport, error) {
+	spawnX, spawnZ := 0, 0
+	if f, err := os.Open(filepath.Join(dataDir, Overworld, LevelFile)); err == nil {
+		level, err := ReadLevel(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		spawnX, spawnZ = level.SpawnX, level.SpawnZ
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	report := &PruneReport{Applied: opts.Apply}
+	for _, dim := range dimensions {
+		dir := filepath.Join(dataDir, dim.dir)
+		if _, err := os.Stat(filepath.Join(dir, regionDir)); os.IsNotExist(err) {
+			continue // Dimension not generated yet
+		}
+
+		centerX, centerZ := 0, 0
+		if dim.fromSpawn {
+			centerX, centerZ = spawnX, spawnZ
+		}
+		pruner := &dimensionPruner{
+			dir:     dir,
+			opts:    opts,
+			centerX: centerX,
+			centerZ: centerZ,
+			report:  DimensionReport{Name: dim.name, Dir: dim.dir},
+		}
+		if err := pruner.run(); err != nil {
+			return nil, fmt.Errorf("pruning %s: %w", dim.name, err)
+		}
+		report.Dimensions = append(report.Dimensions, pruner.report)
+		report.Damaged = append(report.Damaged, pruner.damaged...)
+	}
+
+	return report, nil
+}
+
+// dimensionPruner prunes the region files of one dimension
+type dimensionPruner struct {
+	dir              string
+	opts             PruneOptions
+	centerX, centerZ int
+	report           DimensionReport
+	damaged          []string
+}
+
+func (p *dimensionPruner) run() error {
+	for _, sub := range chunkDirs {
+		entries, err := os.ReadDir(filepath.Join(p.dir, sub))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry.Name(), ".mca") && !strings.HasSuffix(entry.Name(), ".mcc") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			p.report.Size += info.Size()
+		}
+	}
+
+	names, err := filepath.Glob(filepath.Join(p.dir, regionDir, "r.*.mca"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range names {
+		name := filepath.Base(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		region, err := ParseRegion(name, data)
+		if err != nil {
+			p.damaged = append(p.damaged, p.rel(path))
+			continue
+		}
+
+		var prunable []int
+		for i := range regionChunks {
+			if !region.Has(i) {
+				continue
+			}
+			p.report.Chunks++
+			if p.keep(region, i) {
+				continue
+			}
+			prunable = append(prunable, i)
+		}
+		p.report.Prunable += len(prunable)
+
+		// The entities and points of interest of a removed chunk go with it, or the
+		// game would place them in the regenerated chunk
+		for _, sub := range chunkDirs {
+			if err := p.prune(filepath.Join(p.dir, sub, name), prunable); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// keep reports whether the chunk at index i of region stays: it lies within the keep
+// radius, players spent enough time in it, or it can't be read
+func (p *dimensionPruner) keep(region *Region, i int) bool {
+	x, z := region.ChunkPos(i)
+	dx := float64(x*chunkWidth + chunkWidth/2 - p.centerX)
+	dz := float64(z*chunkWidth + chunkWidth/2 - p.centerZ)
+	if math.Hypot(dx, dz) <= float64(p.opts.KeepRadius) {
+		return true
+	}
+
+	chunk, err := region.Chunk(i)
+	if err != nil {
+		p.report.Unreadable++
+		return true
+	}
+	// Chunks kept their data in a Level compound before 1.18
+	if level := chunk.Compound("Level"); level != nil {
+		chunk = level
+	}
+	inhabited, _ := chunk.Int("InhabitedTime")
+	return inhabited >= p.opts.InhabitedBelow
+}
+
+// prune removes the chunks at indexes from the region file at path, if there is one,
+// and adds the bytes that frees to the report
+func (p *dimensionPruner) prune(path string, indexes []int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	region, err := ParseRegion(filepath.Base(path), data)
+	if err != nil {
+		p.damaged = append(p.damaged, p.rel(path))
+		return nil
+	}
+
+	var external []string
+	for _, i := range indexes {
+		if region.External(i) {
+			external = append(external, filepath.Join(filepath.Dir(path), region.ExternalName(i)))
+		}
+		region.Remove(i)
+	}
+	pruned := region.Bytes()
+
+	p.report.Freed += int64(len(data) - len(pruned))
+	var existing []string
+	for _, name := range external {
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		p.report.Freed += info.Size()
+		existing = append(existing, name)
+	}
+
+	if !p.opts.Apply {
+		return nil
+	}
+	if len(pruned) == 0 {
+		err = os.Remove(path)
+	} else if !bytes.Equal(pruned, data) {
+		err = writeFileAtomic(path, pruned)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only once the region file no longer points at them, so a failed write leaves
+	// every chunk readable
+	for _, name := range existing {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rel returns path as the report shows it, relative to the data directory
+func (p *dimensionPruner) rel(path string) string {
+	rel, err := filepath.Rel(p.dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.Join(p.report.Dir, rel)
+}
+
+// writeFileAtomic replaces the file at path with data, so a failed write leaves the
+// old file in place
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
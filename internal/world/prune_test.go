@@ -0,0 +1,156 @@
+package world
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestWorld writes a server world to a temp directory: an overworld with a
+// level.dat and two region files with entities, and a nether with one region file
+func writeTestWorld(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"world/level.dat": testLevel(t, "world", "1.21.11", 4671),
+		// Chunk 0 is at the spawn, chunk 20 is 328 blocks away
+		"world/region/r.0.0.mca":   testRegion(t, map[int]int64{0: 0, 20: 100, 21: 100_000}),
+		"world/entities/r.0.0.mca": testRegion(t, map[int]int64{20: 0, 21: 0}),
+		// A region that's entirely unvisited, chunks more than 512 blocks away
+		"world/region/r.1.0.mca":                testRegion(t, map[int]int64{0: 0, 1: 0}),
+		"world/poi/r.1.0.mca":                   testRegion(t, map[int]int64{1: 0}),
+		"world_nether/DIM-1/region/r.-1.-1.mca": testRegion(t, map[int]int64{1023: 0}),
+		"world_nether/DIM-1/region/r.5.5.mca":   []byte("damaged"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPrune_DryRun(t *testing.T) {
+	dir := writeTestWorld(t)
+	before, _ := os.ReadFile(filepath.Join(dir, "world/region/r.0.0.mca"))
+
+	report, err := Prune(dir, PruneOptions{InhabitedBelow: 1000, KeepRadius: 100})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if report.Applied || len(report.Dimensions) != 2 {
+		t.Fatalf("Prune() = %+v, want a dry run of the overworld and nether", report)
+	}
+	overworld := report.Dimensions[0]
+	if overworld.Name != "overworld" || overworld.Chunks != 5 || overworld.Prunable != 3 {
+		t.Errorf("overworld = %+v, want 3 of 5 chunks prunable", overworld)
+	}
+	if overworld.Freed <= 0 || overworld.Freed >= overworld.Size {
+		t.Errorf("overworld frees %d of %d bytes, want part of it", overworld.Freed, overworld.Size)
+	}
+	nether := report.Dimensions[1]
+	if nether.Name != "nether" || nether.Chunks != 1 || nether.Prunable != 0 {
+		t.Errorf("nether = %+v, want its one chunk kept within the radius of 0,0", nether)
+	}
+	if len(report.Damaged) != 1 || report.Damaged[0] != "world_nether/DIM-1/region/r.5.5.mca" {
+		t.Errorf("Damaged = %v, want the nether's r.5.5.mca", report.Damaged)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(dir, "world/region/r.0.0.mca"))
+	if string(after) != string(before) {
+		t.Error("dry run changed the region file")
+	}
+}
+
+func TestPrune_Apply(t *testing.T) {
+	dir := writeTestWorld(t)
+
+	report, err := Prune(dir, PruneOptions{InhabitedBelow: 1000, KeepRadius: 100, Apply: true})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if !report.Applied {
+		t.Error("Applied = false, want true")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "world/region/r.0.0.mca"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	region, err := ParseRegion("r.0.0.mca", data)
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+	if region.Len() != 2 || region.Has(20) {
+		t.Errorf("region keeps %d chunks, want the spawn chunk and the inhabited one", region.Len())
+	}
+
+	data, _ = os.ReadFile(filepath.Join(dir, "world/entities/r.0.0.mca"))
+	entities, err := ParseRegion("r.0.0.mca", data)
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+	if entities.Has(20) || !entities.Has(21) {
+		t.Error("entities of the pruned chunk kept, or of the kept chunk removed")
+	}
+
+	for _, name := range []string{"world/region/r.1.0.mca", "world/poi/r.1.0.mca"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists, want it removed with its last chunk", name)
+		}
+	}
+
+	// A second run finds nothing left to prune
+	again, err := Prune(dir, PruneOptions{InhabitedBelow: 1000, KeepRadius: 100})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if again.Dimensions[0].Prunable != 0 || again.Dimensions[0].Size != report.Dimensions[0].Size-report.Dimensions[0].Freed {
+		t.Errorf("second run = %+v, want nothing prunable and the freed bytes gone", again.Dimensions[0])
+	}
+}
+
+func TestDimensionPruner_KeepsExternalChunksUntilRegionWritten(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "r.0.0.mca")
+	data := testRegion(t, map[int]int64{0: 0, 1: 0})
+	// The first chunk is stored in c.0.0.mcc next to the region file
+	data[2*sectorSize+4] = compressionZlib | compressionExternal
+	os.WriteFile(path, data, 0644)
+	external := filepath.Join(dir, "c.0.0.mcc")
+	os.WriteFile(external, []byte("chunk"), 0644)
+
+	// The region file can't be replaced while its temp file's name is taken
+	os.Mkdir(path+".tmp", 0755)
+	pruner := &dimensionPruner{dir: dir, opts: PruneOptions{Apply: true}}
+	if err := pruner.prune(path, []int{0}); err == nil {
+		t.Fatal("prune() expected error for a failed write, got nil")
+	}
+	if _, err := os.Stat(external); err != nil {
+		t.Errorf("%s removed although the region file still points at it", external)
+	}
+
+	os.Remove(path + ".tmp")
+	if err := pruner.prune(path, []int{0}); err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	if _, err := os.Stat(external); !os.IsNotExist(err) {
+		t.Errorf("%s kept, want it removed with its chunk", external)
+	}
+}
+
+func TestPrune_NoWorld(t *testing.T) {
+	report, err := Prune(t.TempDir(), PruneOptions{InhabitedBelow: 1000})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(report.Dimensions) != 0 {
+		t.Errorf("Dimensions = %v, want none", report.Dimensions)
+	}
+}
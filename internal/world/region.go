@@ -0,0 +1,191 @@
+package world
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/baighasan/kubecraft/internal/nbt"
+)
+
+// Region files (.mca) hold 32x32 chunks. They start with a header of 1024 chunk
+// locations and 1024 timestamps; each location is a 3-byte offset and a 1-byte
+// length in 4KiB sectors. A chunk's sectors start with its 4-byte length and a
+// compression byte.
+const (
+	sectorSize       = 4096
+	RegionWidth      = 32
+	regionChunks     = RegionWidth * RegionWidth
+	regionHeaderSize = 2 * sectorSize
+)
+
+// Chunk compression schemes
+const (
+	compressionGzip = 1
+	compressionZlib = 2
+	compressionNone = 3
+	compressionLZ4  = 4
+	// Set on the compression byte when the chunk was too large for the region file
+	// and is stored in c.<x>.<z>.mcc next to it
+	compressionExternal = 0x80
+)
+
+// Region is a region file with its chunks kept as stored, so writing it back does
+// not recompress anything
+type Region struct {
+	X, Z       int // Region coordinates, from the file name
+	chunks     [regionChunks][]byte
+	timestamps [regionChunks]uint32
+}
+
+// ParseRegionName returns the region coordinates of a file named r.<x>.<z>.mca
+func ParseRegionName(name string) (x, z int, ok bool) {
+	_, err := fmt.Sscanf(filepath.Base(name), "r.%d.%d.mca", &x, &z)
+	return x, z, err == nil
+}
+
+// ParseRegion splits a region file named r.<x>.<z>.mca into its chunks
+func ParseRegion(name string, data []byte) (*Region, error) {
+	x, z, ok := ParseRegionName(name)
+	if !ok {
+		return nil, fmt.Errorf("%s is not named like a region file", name)
+	}
+	r := &Region{X: x, Z: z}
+	if len(data) == 0 {
+		// The server creates empty files for regions it hasn't saved chunks to yet
+		return r, nil
+	}
+	if len(data) < regionHeaderSize {
+		return nil, fmt.Errorf("region file is %d bytes, shorter than its header", len(data))
+	}
+
+	for i := range regionChunks {
+		location := binary.BigEndian.Uint32(data[i*4:])
+		r.timestamps[i] = binary.BigEndian.Uint32(data[sectorSize+i*4:])
+
+		start, count := int(location>>8)*sectorSize, int(location&0xff)*sectorSize
+		if count == 0 {
+			continue // Chunk not generated
+		}
+		if start < regionHeaderSize || start+count > len(data) {
+			return nil, fmt.Errorf("chunk %d lies outside the file", i)
+		}
+
+		length := int(binary.BigEndian.Uint32(data[start:]))
+		if length < 1 || 4+length > count {
+			return nil, fmt.Errorf("chunk %d has invalid length %d", i, length)
+		}
+		r.chunks[i] = data[start : start+4+length]
+	}
+
+	return r, nil
+}
+
+// ChunkPos returns the chunk coordinates of the chunk at index i
+func (r *Region) ChunkPos(i int) (x, z int) {
+	return r.X*RegionWidth + i%RegionWidth, r.Z*RegionWidth + i/RegionWidth
+}
+
+// Has reports whether the chunk at index i exists
+func (r *Region) Has(i int) bool {
+	return r.chunks[i] != nil
+}
+
+// Len returns the number of chunks in the region
+func (r *Region) Len() int {
+	n := 0
+	for _, c := range r.chunks {
+		if c != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// External reports whether the chunk at index i is stored in a .mcc file of its own
+func (r *Region) External(i int) bool {
+	return r.chunks[i] != nil && r.chunks[i][4]&compressionExternal != 0
+}
+
+// ExternalName returns the name of the file an external chunk at index i is stored in
+func (r *Region) ExternalName(i int) string {
+	x, z := r.ChunkPos(i)
+	return fmt.Sprintf("c.%d.%d.mcc", x, z)
+}
+
+// Chunk decodes the NBT of the chunk at index i. External chunks and chunks
+// compressed with LZ4 can't be read from the region file alone.
+func (r *Region) Chunk(i int) (nbt.Compound, error) {
+	c := r.chunks[i]
+	if c == nil {
+		return nil, fmt.Errorf("chunk %d does not exist", i)
+	}
+	if r.External(i) {
+		return nil, fmt.Errorf("chunk %d is stored in %s", i, r.ExternalName(i))
+	}
+
+	payload := bytes.NewReader(c[5:])
+	var data io.Reader
+	switch c[4] {
+	case compressionGzip:
+		zr, err := gzip.NewReader(payload)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		data = zr
+	case compressionZlib:
+		zr, err := zlib.NewReader(payload)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		data = zr
+	case compressionNone:
+		data = payload
+	case compressionLZ4:
+		return nil, fmt.Errorf("chunk %d is compressed with lz4, which is not supported", i)
+	default:
+		return nil, fmt.Errorf("chunk %d has unknown compression %d", i, c[4])
+	}
+
+	_, root, err := nbt.Read(data)
+	return root, err
+}
+
+// Remove deletes the chunk at index i, the game generates it anew when it is next
+// loaded
+func (r *Region) Remove(i int) {
+	r.chunks[i] = nil
+	r.timestamps[i] = 0
+}
+
+// Bytes writes the region file with its chunks packed one after the other, leaving
+// out the sectors removed or outgrown chunks occupied. A region without chunks
+// is empty.
+func (r *Region) Bytes() []byte {
+	if r.Len() == 0 {
+		return nil
+	}
+
+	out := make([]byte, regionHeaderSize)
+	sector := regionHeaderSize / sectorSize
+	for i, c := range r.chunks {
+		if c == nil {
+			continue
+		}
+		count := (len(c) + sectorSize - 1) / sectorSize
+		binary.BigEndian.PutUint32(out[i*4:], uint32(sector)<<8|uint32(count))
+		binary.BigEndian.PutUint32(out[sectorSize+i*4:], r.timestamps[i])
+
+		out = append(out, c...)
+		out = append(out, make([]byte, count*sectorSize-len(c))...)
+		sector += count
+	}
+
+	return out
+}
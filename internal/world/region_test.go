@@ -0,0 +1,164 @@
+package world
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	"github.com/baighasan/kubecraft/internal/nbt"
+)
+
+// testRegion returns a region file with a zlib-compressed chunk at each index of
+// inhabited, which has the chunk's InhabitedTime. Each chunk is padded to span
+// several sectors, with a free sector after it.
+func testRegion(t *testing.T, inhabited map[int]int64) []byte {
+	t.Helper()
+
+	data := make([]byte, regionHeaderSize)
+	sector := regionHeaderSize / sectorSize
+	for i := range regionChunks {
+		time, ok := inhabited[i]
+		if !ok {
+			continue
+		}
+
+		var payload bytes.Buffer
+		zw := zlib.NewWriter(&payload)
+		chunk := nbt.Compound{
+			"DataVersion":   int32(4671),
+			"InhabitedTime": time,
+			"Status":        "minecraft:full",
+		}
+		if err := nbt.Write(zw, "", chunk); err != nil {
+			t.Fatalf("nbt.Write() error = %v", err)
+		}
+		zw.Close()
+
+		stored := binary.BigEndian.AppendUint32(nil, uint32(payload.Len()+1))
+		stored = append(stored, compressionZlib)
+		stored = append(stored, payload.Bytes()...)
+		count := len(stored)/sectorSize + 1
+
+		binary.BigEndian.PutUint32(data[i*4:], uint32(sector)<<8|uint32(count))
+		binary.BigEndian.PutUint32(data[sectorSize+i*4:], uint32(1700000000+i))
+		data = append(data, stored...)
+		data = append(data, make([]byte, (count+1)*sectorSize-len(stored))...)
+		sector += count + 1
+	}
+
+	return data
+}
+
+func TestParseRegion(t *testing.T) {
+	region, err := ParseRegion("r.-1.2.mca", testRegion(t, map[int]int64{0: 100, 33: 5000}))
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+
+	if region.Len() != 2 || !region.Has(0) || !region.Has(33) {
+		t.Errorf("ParseRegion() found %d chunks, want chunks 0 and 33", region.Len())
+	}
+	if x, z := region.ChunkPos(33); x != -31 || z != 65 {
+		t.Errorf("ChunkPos(33) = %d, %d, want -31, 65", x, z)
+	}
+
+	chunk, err := region.Chunk(33)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if inhabited, _ := chunk.Int("InhabitedTime"); inhabited != 5000 {
+		t.Errorf("InhabitedTime = %d, want 5000", inhabited)
+	}
+}
+
+func TestParseRegion_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		data []byte
+	}{
+		"not a region name": {"level.dat", testRegion(t, nil)},
+		"short header":      {"r.0.0.mca", make([]byte, 100)},
+		"chunk past the end": {"r.0.0.mca", func() []byte {
+			data := make([]byte, regionHeaderSize)
+			binary.BigEndian.PutUint32(data, 2<<8|1)
+			return data
+		}()},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRegion(tt.name, tt.data); err == nil {
+				t.Error("ParseRegion() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseRegion_Empty(t *testing.T) {
+	region, err := ParseRegion("r.0.0.mca", nil)
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+	if region.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", region.Len())
+	}
+}
+
+func TestRegion_Chunk_Unreadable(t *testing.T) {
+	data := testRegion(t, map[int]int64{0: 1, 1: 1})
+	// Mark the first chunk as external and the second as lz4-compressed
+	data[2*sectorSize+4] = compressionZlib | compressionExternal
+	data[4*sectorSize+4] = compressionLZ4
+
+	region, err := ParseRegion("r.0.0.mca", data)
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+	if !region.External(0) || region.ExternalName(0) != "c.0.0.mcc" {
+		t.Errorf("External(0) = %t, %s, want stored in c.0.0.mcc", region.External(0), region.ExternalName(0))
+	}
+	for _, i := range []int{0, 1} {
+		if _, err := region.Chunk(i); err == nil {
+			t.Errorf("Chunk(%d) expected error, got nil", i)
+		}
+	}
+}
+
+func TestRegion_Bytes_Compacts(t *testing.T) {
+	data := testRegion(t, map[int]int64{0: 1, 5: 2, 1023: 3})
+	region, err := ParseRegion("r.0.0.mca", data)
+	if err != nil {
+		t.Fatalf("ParseRegion() error = %v", err)
+	}
+
+	region.Remove(5)
+	compact := region.Bytes()
+	if len(compact) != regionHeaderSize+2*sectorSize {
+		t.Errorf("Bytes() is %d bytes, want header and two sectors", len(compact))
+	}
+
+	reread, err := ParseRegion("r.0.0.mca", compact)
+	if err != nil {
+		t.Fatalf("ParseRegion() of rewritten region error = %v", err)
+	}
+	if reread.Len() != 2 || reread.Has(5) {
+		t.Fatalf("rewritten region has %d chunks, want 0 and 1023", reread.Len())
+	}
+	chunk, err := reread.Chunk(1023)
+	if err != nil {
+		t.Fatalf("Chunk() error = %v", err)
+	}
+	if inhabited, _ := chunk.Int("InhabitedTime"); inhabited != 3 {
+		t.Errorf("InhabitedTime = %d, want 3", inhabited)
+	}
+	if ts := binary.BigEndian.Uint32(compact[sectorSize+1023*4:]); ts != 1700000000+1023 {
+		t.Errorf("timestamp = %d, want it kept", ts)
+	}
+
+	region.Remove(0)
+	region.Remove(1023)
+	if len(region.Bytes()) != 0 {
+		t.Errorf("Bytes() of region without chunks is %d bytes, want empty", len(region.Bytes()))
+	}
+}
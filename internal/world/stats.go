@@ -0,0 +1,101 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// StatsDir holds a stats file per player, named <uuid>.json, in the overworld
+const StatsDir = "stats"
+
+// UserCacheFile maps the UUIDs of players who joined to their names, at the root of
+// the server's data directory
+const UserCacheFile = "usercache.json"
+
+// Common statistics, as Stats.Get takes them
+const (
+	StatPlayTime = "custom/play_time"
+	StatDeaths   = "custom/deaths"
+	StatWalked   = "custom/walk_one_cm"
+
+	// What play_time was called before 1.17, though it always counted ticks
+	statPlayOneMinute = "custom/play_one_minute"
+)
+
+// Stats are a player's statistics by category (custom, mined, killed...) and name,
+// both without the minecraft: namespace
+type Stats map[string]map[string]int64
+
+// ReadStats decodes a player's stats file
+func ReadStats(r io.Reader) (Stats, error) {
+	var file struct {
+		Stats map[string]map[string]int64 `json:"stats"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("reading stats: %w", err)
+	}
+
+	stats := Stats{}
+	for category, values := range file.Stats {
+		category = trimNamespace(category)
+		if stats[category] == nil {
+			stats[category] = map[string]int64{}
+		}
+		for name, value := range values {
+			stats[category][trimNamespace(name)] += value
+		}
+	}
+
+	return stats, nil
+}
+
+// Get returns a statistic named <category>/<name>, e.g. mined/diamond_ore, or only
+// <name> for one of the custom category. Namespaces are optional.
+func (s Stats) Get(stat string) int64 {
+	category, name, ok := strings.Cut(stat, "/")
+	if !ok {
+		category, name = "custom", stat
+	}
+
+	return s[trimNamespace(category)][trimNamespace(name)]
+}
+
+// PlayTime returns the ticks the player spent on the server, also from stats saved
+// before 1.17
+func (s Stats) PlayTime() int64 {
+	if ticks := s.Get(StatPlayTime); ticks > 0 {
+		return ticks
+	}
+	return s.Get(statPlayOneMinute)
+}
+
+// ParseStatsName returns the player UUID of a stats file path, and whether it is one
+func ParseStatsName(name string) (string, bool) {
+	uuid, ok := strings.CutSuffix(path.Base(name), ".json")
+	return uuid, ok && len(uuid) == 36
+}
+
+// ReadUserCache decodes usercache.json into player names by UUID
+func ReadUserCache(r io.Reader) (map[string]string, error) {
+	var entries []struct {
+		Name string `json:"name"`
+		UUID string `json:"uuid"`
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", UserCacheFile, err)
+	}
+
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[entry.UUID] = entry.Name
+	}
+
+	return names, nil
+}
+
+func trimNamespace(name string) string {
+	return strings.TrimPrefix(name, "minecraft:")
+}
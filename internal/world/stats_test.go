@@ -0,0 +1,87 @@
+package world
+
+import (
+	"strings"
+	"testing"
+)
+
+const testStats = `{
+	"stats": {
+		"minecraft:custom": {
+			"minecraft:play_time": 72000,
+			"minecraft:deaths": 3,
+			"minecraft:walk_one_cm": 123456,
+			"minecraft:jump": 512
+		},
+		"minecraft:mined": {"minecraft:diamond_ore": 9}
+	},
+	"DataVersion": 4671
+}`
+
+func TestReadStats(t *testing.T) {
+	stats, err := ReadStats(strings.NewReader(testStats))
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+
+	tests := map[string]int64{
+		StatPlayTime:                            72000,
+		StatDeaths:                              3,
+		StatWalked:                              123456,
+		"jump":                                  512,
+		"minecraft:jump":                        512,
+		"mined/diamond_ore":                     9,
+		"minecraft:mined/minecraft:diamond_ore": 9,
+		"killed/zombie":                         0,
+	}
+	for stat, want := range tests {
+		if got := stats.Get(stat); got != want {
+			t.Errorf("Get(%s) = %d, want %d", stat, got, want)
+		}
+	}
+	if stats.PlayTime() != 72000 {
+		t.Errorf("PlayTime() = %d, want 72000", stats.PlayTime())
+	}
+}
+
+func TestStats_PlayTime_BeforeRename(t *testing.T) {
+	stats, err := ReadStats(strings.NewReader(`{"stats": {"minecraft:custom": {"minecraft:play_one_minute": 2400}}}`))
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if stats.PlayTime() != 2400 {
+		t.Errorf("PlayTime() = %d, want 2400", stats.PlayTime())
+	}
+}
+
+func TestReadStats_Invalid(t *testing.T) {
+	if _, err := ReadStats(strings.NewReader("not json")); err == nil {
+		t.Error("ReadStats() expected error, got nil")
+	}
+}
+
+func TestParseStatsName(t *testing.T) {
+	tests := map[string]bool{
+		"world/stats/069a79f4-44e9-4726-a5be-fca90e38aaf5.json": true,
+		"069a79f4-44e9-4726-a5be-fca90e38aaf5.json":             true,
+		"world/stats/notes.json":                                false,
+		"world/stats/069a79f4-44e9-4726-a5be-fca90e38aaf5.dat":  false,
+	}
+	for name, want := range tests {
+		if _, ok := ParseStatsName(name); ok != want {
+			t.Errorf("ParseStatsName(%s) = %t, want %t", name, ok, want)
+		}
+	}
+}
+
+func TestReadUserCache(t *testing.T) {
+	names, err := ReadUserCache(strings.NewReader(`[
+		{"name": "Notch", "uuid": "069a79f4-44e9-4726-a5be-fca90e38aaf5", "expiresOn": "2026-11-01 12:00:00 +0000"}
+	]`))
+	if err != nil {
+		t.Fatalf("ReadUserCache() error = %v", err)
+	}
+	if names["069a79f4-44e9-4726-a5be-fca90e38aaf5"] != "Notch" {
+		t.Errorf("ReadUserCache() = %v, want Notch", names)
+	}
+}
@@ -28,6 +28,22 @@ resource "oci_core_security_list" "kc_security_list" {
       max = var.node_port_max
     }
   }
+  ingress_security_rules {
+    protocol = "6"
+    source   = "0.0.0.0/0"
+    tcp_options {
+      min = 25565
+      max = 25565
+    }
+  }
+  ingress_security_rules {
+    protocol = "17"
+    source   = "0.0.0.0/0"
+    udp_options {
+      min = var.bedrock_port_min
+      max = var.bedrock_port_max
+    }
+  }
 
   # One egress_security_rules block for outbound
   egress_security_rules {
@@ -54,6 +54,18 @@ variable "node_port_max" {
   default     = 30099
 }
 
+variable "bedrock_port_min" {
+  description = "Start of UDP NodePort range for Bedrock players (Geyser)"
+  type        = number
+  default     = 30100
+}
+
+variable "bedrock_port_max" {
+  description = "End of UDP NodePort range for Bedrock players (Geyser)"
+  type        = number
+  default     = 30115
+}
+
 variable "ad_number" {
   description = "Availability domain number (1-3)"
   type        = number